@@ -12,6 +12,7 @@ import CategoryManagement from './admin/CategoryManagement';
 import UserManagement from './admin/UserManagement';
 import OrderManagement from './admin/OrderManagement';
 import TransactionManagement from './admin/TransactionManagement'
+import PayLink from './pages/PayLink';
 import { BrowserRouter as Router, Routes, Route, Navigate } from "react-router-dom";
 
 //customer part
@@ -53,6 +54,7 @@ const App: React.FC = () => {
           <Route path="/" element={<Home />} />
           <Route path="/signup" element={<Signup />} />
           <Route path="/login" element={<Login />} />
+          <Route path="/pay/:token" element={<PayLink />} />
           
           {/* Protected routes - require login */}
           <Route 
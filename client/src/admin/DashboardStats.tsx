@@ -3,13 +3,13 @@ import { Link } from 'react-router-dom';
 import { useBooks } from '../hooks/useBooks';
 import { useCategories } from '../hooks/useCategories';
 import { useOrders } from '../hooks/useOrder';
-import {useTransactions} from '../hooks/useTransactions'
+import {useTransactionTotals} from '../hooks/useTransactions'
 
 const DashboardStats: React.FC = () => {
   const { data: books, isLoading: booksLoading } = useBooks();
   const { data: categories, isLoading: categoriesLoading } = useCategories();
   const { data: orders, isLoading: ordersLoading } = useOrders();
-  const { data: transactions, isLoading: transactionsLoading } = useTransactions();
+  const { data: transactionTotals, isLoading: transactionsLoading } = useTransactionTotals();
 
   if (booksLoading || categoriesLoading || ordersLoading || transactionsLoading) {
     return (
@@ -35,13 +35,11 @@ const DashboardStats: React.FC = () => {
     ?.reduce((sum, order) => sum + order.total_price, 0) || 0;
 
     //Add transaction statistics
-    const totalTransactions = transactions?.length || 0;
-    const pendingTransactions = transactions?.filter(t => t.status === 'PENDING').length || 0;
-    const successfulTransactions = transactions?.filter(t => t.status === 'SUCCESS').length || 0;
+    const totalTransactions = transactionTotals?.count || 0;
+    const pendingTransactions = transactionTotals?.by_status.PENDING?.count || 0;
+    const successfulTransactions = transactionTotals?.by_status.SUCCESS?.count || 0;
 
-    const transactionRevenue = transactions
-  ?.filter(t => t.status === 'SUCCESS')
-  ?.reduce((sum, t) => sum + t.amount, 0) || 0;
+    const transactionRevenue = transactionTotals?.by_status.SUCCESS?.amount || 0;
 
   // Calculate percentage changes (mock data for demonstration)
   const getRandomChange = () => (Math.random() * 20 + 5).toFixed(1);
@@ -0,0 +1,87 @@
+import React, { useState } from 'react';
+import { useStartExport, useExportJob } from '../hooks/useExports';
+import { downloadExport, type ExportFormat, type ExportKind } from '../api/exportApi';
+
+interface ExportPanelProps {
+  kind: ExportKind;
+  // The list's current filters, as query parameters
+  filters: Record<string, string | number | undefined>;
+}
+
+// Exports what the list is showing. Large exports run in the background and
+// offer a download once the file is ready.
+const ExportPanel: React.FC<ExportPanelProps> = ({ kind, filters }) => {
+  const [format, setFormat] = useState<ExportFormat>('csv');
+  const [calendar, setCalendar] = useState<'ad' | 'bs'>('ad');
+  const [jobId, setJobId] = useState('');
+  const [message, setMessage] = useState('');
+  const startExport = useStartExport();
+  const { data: job } = useExportJob(jobId);
+
+  const handleExport = () => {
+    setMessage('');
+    startExport.mutate(
+      { kind, filters, options: { format, calendar } },
+      {
+        onSuccess: (result) => {
+          if (!result.file) {
+            setJobId(result.job.id);
+          }
+        },
+        onError: (err) => setMessage(err.message),
+      }
+    );
+  };
+
+  const handleDownload = async () => {
+    if (!job) return;
+    try {
+      await downloadExport(job);
+    } catch (err) {
+      setMessage(err instanceof Error ? err.message : 'Download failed');
+    }
+  };
+
+  return (
+    <div className="flex flex-wrap items-center gap-3">
+      <select
+        aria-label="Export format"
+        value={format}
+        onChange={(e) => setFormat(e.target.value as ExportFormat)}
+        className="px-3 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-indigo-500"
+      >
+        <option value="csv">CSV</option>
+        <option value="xlsx">Excel (XLSX)</option>
+      </select>
+      <select
+        aria-label="Export calendar"
+        value={calendar}
+        onChange={(e) => setCalendar(e.target.value as 'ad' | 'bs')}
+        className="px-3 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-indigo-500"
+      >
+        <option value="ad">AD dates</option>
+        <option value="bs">BS dates</option>
+      </select>
+      <button
+        onClick={handleExport}
+        disabled={startExport.isPending}
+        className="px-4 py-2 bg-indigo-600 text-white rounded-lg hover:bg-indigo-700 disabled:opacity-50 transition-colors"
+      >
+        {startExport.isPending ? 'Exporting...' : 'Export'}
+      </button>
+
+      {job && (job.status === 'PENDING' || job.status === 'RUNNING') && (
+        <span className="text-sm text-gray-600">Large export queued, preparing the file...</span>
+      )}
+      {job?.status === 'DONE' && (
+        <button onClick={handleDownload} className="text-sm text-indigo-600 hover:text-indigo-800 underline">
+          Download {job.file_name} ({job.row_count} rows)
+        </button>
+      )}
+      {job?.status === 'FAILED' && <span className="text-sm text-red-600">Export failed: {job.error}</span>}
+      {message && <span className="text-sm text-red-600">{message}</span>}
+    </div>
+  );
+};
+
+export default ExportPanel;
@@ -4,6 +4,8 @@ import { useOrders } from '../hooks/useOrder';
 import { useUpdateOrderStatus } from '../hooks/useOrder';
 import { useDeleteOrder } from '../hooks/useOrder';
 import { type  Order, type OrderStatus } from '../api/orderApi';
+import OrderPaymentLinks from './OrderPaymentLinks';
+import ExportPanel from './ExportPanel';
 
 const OrderManagement: React.FC = () => {
   const { data: orders, isLoading, error } = useOrders();
@@ -45,6 +47,7 @@ const OrderManagement: React.FC = () => {
     const matchesStatus = statusFilter === 'ALL' || order.status === statusFilter;
     const matchesSearch = searchTerm === '' || 
       order.id.toLowerCase().includes(searchTerm.toLowerCase()) ||
+      order.ref?.toLowerCase().includes(searchTerm.toLowerCase()) ||
       order.user?.name?.toLowerCase().includes(searchTerm.toLowerCase()) ||
       order.user_id.toLowerCase().includes(searchTerm.toLowerCase());
     return matchesStatus && matchesSearch;
@@ -109,7 +112,7 @@ const OrderManagement: React.FC = () => {
             <input
               type="text"
               id="search"
-              placeholder="Search by order ID or customer..."
+              placeholder="Search by order ref, ID or customer..."
               value={searchTerm}
               onChange={(e) => setSearchTerm(e.target.value)}
               className="mt-1 block w-full border border-gray-300 rounded-md shadow-sm py-2 px-3 focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm"
@@ -135,6 +138,11 @@ const OrderManagement: React.FC = () => {
             </select>
           </div>
         </div>
+
+        {/* Export the orders with the selected status */}
+        <div className="mt-4 pt-4 border-t border-gray-100">
+          <ExportPanel kind="orders" filters={{ status: statusFilter === 'ALL' ? undefined : statusFilter }} />
+        </div>
       </div>
 
       {/* Orders Table */}
@@ -182,7 +190,7 @@ const OrderManagement: React.FC = () => {
                   <tr key={order.id} className="hover:bg-gray-50">
                     <td className="px-6 py-4 whitespace-nowrap">
                       <div className="text-sm font-medium text-gray-900">
-                        {order.id.slice(0, 8)}...
+                        {order.ref ?? `${order.id.slice(0, 8)}...`}
                       </div>
                       <div className="text-sm text-gray-500">
                         {order.items.length} item{order.items.length !== 1 ? 's' : ''}
@@ -261,7 +269,7 @@ const OrderManagement: React.FC = () => {
           <div className="relative top-20 mx-auto p-5 border w-full max-w-2xl shadow-lg rounded-md bg-white">
             <div className="flex justify-between items-center pb-3">
               <h3 className="text-lg font-medium text-gray-900">
-                Order Details - #{selectedOrder.id.slice(0, 8)}...
+                Order Details - {selectedOrder.ref ?? `#${selectedOrder.id.slice(0, 8)}...`}
               </h3>
               <button
                 onClick={() => setSelectedOrder(null)}
@@ -331,6 +339,8 @@ const OrderManagement: React.FC = () => {
                 </div>
               </div>
 
+              <OrderPaymentLinks orderId={selectedOrder.id} canCreate={selectedOrder.status === 'PENDING'} />
+
               {/* Actions */}
               <div className="flex justify-end space-x-3 pt-4 border-t">
                 <button
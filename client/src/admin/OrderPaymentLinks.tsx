@@ -0,0 +1,106 @@
+import React, { useState } from "react";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import {
+  createPaymentLink,
+  getPaymentLinks,
+  revokePaymentLink,
+  type PaymentLink,
+  type PaymentLinkStatus,
+} from "../api/paymentLinkApi";
+
+const statusColors: Record<PaymentLinkStatus, string> = {
+  ACTIVE: "bg-blue-100 text-blue-800",
+  PAID: "bg-green-100 text-green-800",
+  REVOKED: "bg-gray-100 text-gray-800",
+  EXPIRED: "bg-yellow-100 text-yellow-800",
+};
+
+// Payment links for one order, shown in the order details
+const OrderPaymentLinks: React.FC<{ orderId: string; canCreate: boolean }> = ({ orderId, canCreate }) => {
+  const queryClient = useQueryClient();
+  const [hours, setHours] = useState(72);
+  const [copied, setCopied] = useState<string | null>(null);
+
+  const { data: links, isLoading } = useQuery<PaymentLink[], Error>({
+    queryKey: ["payment-links", orderId],
+    queryFn: () => getPaymentLinks(orderId),
+  });
+
+  const refresh = () => queryClient.invalidateQueries({ queryKey: ["payment-links", orderId] });
+  const createMutation = useMutation({
+    mutationFn: () => createPaymentLink(orderId, { expires_in_hours: hours }),
+    onSuccess: refresh,
+  });
+  const revokeMutation = useMutation({ mutationFn: revokePaymentLink, onSuccess: refresh });
+
+  const copy = async (link: PaymentLink) => {
+    if (!link.url) return;
+    await navigator.clipboard.writeText(link.url);
+    setCopied(link.id);
+    setTimeout(() => setCopied(null), 2000);
+  };
+
+  return (
+    <div>
+      <h4 className="font-medium text-gray-900">Payment Links</h4>
+      {canCreate && (
+        <div className="mt-2 flex items-center space-x-2 text-sm">
+          <span className="text-gray-500">Valid for</span>
+          <input
+            type="number"
+            min={1}
+            max={720}
+            value={hours}
+            onChange={(e) => setHours(Number(e.target.value))}
+            className="w-20 px-2 py-1 border border-gray-300 rounded-md"
+          />
+          <span className="text-gray-500">hours</span>
+          <button
+            onClick={() => createMutation.mutate()}
+            disabled={createMutation.isPending}
+            className="px-3 py-1 text-white bg-blue-600 rounded-md hover:bg-blue-700 disabled:opacity-50"
+          >
+            Create link
+          </button>
+        </div>
+      )}
+      {createMutation.error && <p className="mt-1 text-sm text-red-600">Could not create the link</p>}
+
+      <div className="mt-2 space-y-2">
+        {isLoading && <p className="text-sm text-gray-500">Loading…</p>}
+        {links?.length === 0 && <p className="text-sm text-gray-500">No payment links yet</p>}
+        {links?.map((link) => (
+          <div key={link.id} className="flex justify-between items-center p-3 bg-gray-50 rounded text-sm">
+            <div>
+              <span className={`inline-flex px-2 py-1 text-xs font-semibold rounded-full ${statusColors[link.status]}`}>
+                {link.status}
+              </span>
+              <span className="ml-2 text-gray-500">
+                {link.status === "PAID" && link.paid_at
+                  ? `Paid ${new Date(link.paid_at).toLocaleString()}`
+                  : `Expires ${new Date(link.expires_at).toLocaleString()}`}
+              </span>
+            </div>
+            <div className="space-x-2">
+              {link.status === "ACTIVE" && (
+                <>
+                  <button onClick={() => copy(link)} className="text-blue-600 hover:text-blue-900">
+                    {copied === link.id ? "Copied" : "Copy URL"}
+                  </button>
+                  <button
+                    onClick={() => revokeMutation.mutate(link.id)}
+                    className="text-red-600 hover:text-red-900"
+                  >
+                    Revoke
+                  </button>
+                </>
+              )}
+            </div>
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+};
+
+export default OrderPaymentLinks;
@@ -1,22 +1,32 @@
 import React, { useState, useEffect } from 'react';
-import { useTransactions, useUpdateTransactionStatus, useDeleteTransaction } from '../hooks/useTransactions';
-import { type TransactionStatus, type PaymentMethod, type Transaction } from '../api/transactionApi';
+import { useTransactions, useTransaction, useUpdateTransactionStatus, useDeleteTransaction, useAdminStatusFeed } from '../hooks/useTransactions';
+import { type TransactionStatus, type PaymentMethod, type TransactionSummary, type TransactionSort } from '../api/transactionApi';
+import ExportPanel from './ExportPanel';
 
 const TransactionManagement: React.FC = () => {
-  const { data: transactions, isLoading, error, refetch } = useTransactions();
-  const updateStatusMutation = useUpdateTransactionStatus();
-  const deleteMutation = useDeleteTransaction();
-  
   const [filterStatus, setFilterStatus] = useState<TransactionStatus | 'ALL'>('ALL');
   const [filterPaymentMethod, setFilterPaymentMethod] = useState<PaymentMethod | 'ALL'>('ALL');
+  const [sort, setSort] = useState<TransactionSort>('-created_at');
   const [searchTerm, setSearchTerm] = useState('');
-  const [selectedTransaction, setSelectedTransaction] = useState<Transaction | null>(null);
+  const [selectedTransaction, setSelectedTransaction] = useState<TransactionSummary | null>(null);
   const [showDetailsModal, setShowDetailsModal] = useState(false);
   const [showUpdateModal, setShowUpdateModal] = useState(false);
   const [newStatus, setNewStatus] = useState<TransactionStatus>('PENDING');
   const [failureReason, setFailureReason] = useState('');
   const [notification, setNotification] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
 
+  // Status, method and sort are applied by the server; search only narrows the loaded pages
+  const { data, isLoading, error, refetch, fetchNextPage, hasNextPage, isFetchingNextPage } = useTransactions({
+    status: filterStatus === 'ALL' ? undefined : [filterStatus],
+    payment_method: filterPaymentMethod === 'ALL' ? undefined : [filterPaymentMethod],
+    sort,
+  });
+  const transactions = data?.pages.flatMap(page => page.items) ?? [];
+  const totals = data?.pages[0]?.totals;
+  const updateStatusMutation = useUpdateTransactionStatus();
+  const deleteMutation = useDeleteTransaction();
+  useAdminStatusFeed();
+
   // Show notification and auto-hide
   useEffect(() => {
     if (notification) {
@@ -25,18 +35,21 @@ const TransactionManagement: React.FC = () => {
     }
   }, [notification]);
 
-  // Filter transactions based on selected filters
-  const filteredTransactions = transactions?.filter(transaction => {
-    const matchesStatus = filterStatus === 'ALL' || transaction.status === filterStatus;
-    const matchesPaymentMethod = filterPaymentMethod === 'ALL' || transaction.payment_method === filterPaymentMethod;
-    const matchesSearch = searchTerm === '' || 
-      transaction.transaction_id?.toLowerCase().includes(searchTerm.toLowerCase()) ||
-      transaction.order_id.toLowerCase().includes(searchTerm.toLowerCase()) ||
-      transaction.user_id.toLowerCase().includes(searchTerm.toLowerCase()) ||
-      transaction.product_name.toLowerCase().includes(searchTerm.toLowerCase());
-    
-    return matchesStatus && matchesPaymentMethod && matchesSearch;
-  }) || [];
+  // Full transaction (order, gateway response) for the details modal
+  const { data: selectedDetails } = useTransaction(showDetailsModal && selectedTransaction ? selectedTransaction.id : '');
+
+  // Search the loaded transactions
+  const filteredTransactions = transactions.filter(transaction => {
+    const term = searchTerm.toLowerCase();
+    return searchTerm === '' ||
+      transaction.ref?.toLowerCase().includes(term) ||
+      transaction.transaction_id?.toLowerCase().includes(term) ||
+      transaction.order_ref?.toLowerCase().includes(term) ||
+      transaction.order_id.toLowerCase().includes(term) ||
+      transaction.user_id.toLowerCase().includes(term) ||
+      transaction.user_name?.toLowerCase().includes(term) ||
+      transaction.product_name.toLowerCase().includes(term);
+  });
 
   const statusColors: Record<TransactionStatus, string> = {
     PENDING: 'bg-yellow-100 text-yellow-800 border-yellow-200',
@@ -96,12 +109,12 @@ const TransactionManagement: React.FC = () => {
     }
   };
 
-  const openDetailsModal = (transaction: Transaction) => {
+  const openDetailsModal = (transaction: TransactionSummary) => {
     setSelectedTransaction(transaction);
     setShowDetailsModal(true);
   };
 
-  const openUpdateModal = (transaction: Transaction) => {
+  const openUpdateModal = (transaction: TransactionSummary) => {
     setSelectedTransaction(transaction);
     setNewStatus(transaction.status);
     setFailureReason(transaction.failure_reason || '');
@@ -130,10 +143,11 @@ const TransactionManagement: React.FC = () => {
     }
   };
 
-  // Safe status counter function
+  // Counts cover every transaction with the current method filter, not just the loaded pages
   const getStatusCount = (status: TransactionStatus | 'ALL'): number => {
-    if (status === 'ALL') return transactions?.length || 0;
-    return transactions?.filter(t => t.status === status).length || 0;
+    const byStatus = totals?.by_status ?? {};
+    if (status === 'ALL') return Object.values(byStatus).reduce((sum, s) => sum + (s?.count ?? 0), 0);
+    return byStatus[status]?.count ?? 0;
   };
 
   const statusOptions: (TransactionStatus | 'ALL')[] = ['ALL', 'PENDING', 'SUCCESS', 'FAILED', 'CANCELLED'];
@@ -213,8 +227,8 @@ const TransactionManagement: React.FC = () => {
                   <p className="text-sm font-medium text-gray-600">{statusDisplayNames[status]} Transactions</p>
                   <p className="text-2xl font-bold mt-1">{count}</p>
                   <p className="text-xs text-gray-500 mt-1">
-                    {transactions && transactions.length > 0 
-                      ? `${Math.round((count / transactions.length) * 100)}% of total`
+                    {getStatusCount('ALL') > 0
+                      ? `${Math.round((count / getStatusCount('ALL')) * 100)}% of total`
                       : '0% of total'
                     }
                   </p>
@@ -230,7 +244,7 @@ const TransactionManagement: React.FC = () => {
 
       {/* Filters and Search */}
       <div className="bg-white rounded-xl shadow-sm border border-gray-100 p-6 mb-6">
-        <div className="grid grid-cols-1 md:grid-cols-4 gap-4">
+        <div className="grid grid-cols-1 md:grid-cols-5 gap-4">
           {/* Search */}
           <div>
             <label htmlFor="search-input" className="block text-sm font-medium text-gray-700 mb-2">
@@ -239,7 +253,7 @@ const TransactionManagement: React.FC = () => {
             <input
               id="search-input"
               type="text"
-              placeholder="Search by TXN ID, Order ID, User ID..."
+              placeholder="Search by ref, TXN ID, order, user..."
               value={searchTerm}
               onChange={(e) => setSearchTerm(e.target.value)}
               className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-indigo-500"
@@ -283,12 +297,31 @@ const TransactionManagement: React.FC = () => {
             </select>
           </div>
 
+          {/* Sort */}
+          <div>
+            <label htmlFor="sort-select" className="block text-sm font-medium text-gray-700 mb-2">
+              Sort
+            </label>
+            <select
+              id="sort-select"
+              value={sort}
+              onChange={(e) => setSort(e.target.value as TransactionSort)}
+              className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-indigo-500"
+            >
+              <option value="-created_at">Newest first</option>
+              <option value="created_at">Oldest first</option>
+              <option value="-amount">Largest amount</option>
+              <option value="amount">Smallest amount</option>
+            </select>
+          </div>
+
           {/* Actions */}
           <div className="flex items-end space-x-3">
             <button
               onClick={() => {
                 setFilterStatus('ALL');
                 setFilterPaymentMethod('ALL');
+                setSort('-created_at');
                 setSearchTerm('');
               }}
               className="w-1/2 px-4 py-2 bg-gray-200 text-gray-700 rounded-lg hover:bg-gray-300 transition-colors"
@@ -303,6 +336,18 @@ const TransactionManagement: React.FC = () => {
             </button>
           </div>
         </div>
+
+        {/* Export what the filters above select */}
+        <div className="mt-4 pt-4 border-t border-gray-100">
+          <ExportPanel
+            kind="transactions"
+            filters={{
+              status: filterStatus === 'ALL' ? undefined : filterStatus,
+              payment_method: filterPaymentMethod === 'ALL' ? undefined : filterPaymentMethod,
+              sort,
+            }}
+          />
+        </div>
       </div>
 
       {/* Transactions Table */}
@@ -358,7 +403,7 @@ const TransactionManagement: React.FC = () => {
                     <td className="px-6 py-4 whitespace-nowrap">
                       <div>
                         <p className="text-sm font-medium text-gray-900">
-                          {transaction.transaction_id || 'N/A'}
+                          {transaction.ref ?? (transaction.transaction_id || 'N/A')}
                         </p>
                         <p className="text-sm text-gray-500">${transaction.amount.toFixed(2)}</p>
                       </div>
@@ -366,10 +411,10 @@ const TransactionManagement: React.FC = () => {
                     <td className="px-6 py-4 whitespace-nowrap">
                       <div>
                         <p className="text-sm font-medium text-gray-900">
-                          Order: {transaction.order_id.slice(0, 8)}...
+                          Order: {transaction.order_ref ?? `${transaction.order_id.slice(0, 8)}...`}
                         </p>
                         <p className="text-sm text-gray-500">
-                          User: {transaction.user_id.slice(0, 8)}...
+                          User: {transaction.user_name || `${transaction.user_id.slice(0, 8)}...`}
                         </p>
                       </div>
                     </td>
@@ -431,6 +476,17 @@ const TransactionManagement: React.FC = () => {
             </tbody>
           </table>
         </div>
+        {hasNextPage && (
+          <div className="p-4 border-t border-gray-100 text-center">
+            <button
+              onClick={() => fetchNextPage()}
+              disabled={isFetchingNextPage}
+              className="px-4 py-2 bg-indigo-600 text-white rounded-lg hover:bg-indigo-700 transition-colors disabled:opacity-50"
+            >
+              {isFetchingNextPage ? 'Loading...' : `Load more (${transactions.length} of ${totals?.count ?? 0})`}
+            </button>
+          </div>
+        )}
       </div>
 
       {/* Transaction Details Modal */}
@@ -534,11 +590,11 @@ const TransactionManagement: React.FC = () => {
                   </dl>
                 </div>
 
-                {selectedTransaction.esewa_response && (
+                {selectedDetails?.esewa_response && (
                   <div className="md:col-span-2">
                     <h4 className="font-medium text-gray-900 mb-3">eSewa Response</h4>
                     <pre className="text-xs bg-gray-50 p-3 rounded-lg border border-gray-200 overflow-x-auto max-h-40">
-                      {JSON.stringify(selectedTransaction.esewa_response, null, 2)}
+                      {JSON.stringify(selectedDetails.esewa_response, null, 2)}
                     </pre>
                   </div>
                 )}
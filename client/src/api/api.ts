@@ -10,12 +10,23 @@ const api = axios.create({
   },
 });
 
+// Stable per-browser ID the server's fraud checks use for device velocity
+const getDeviceId = () => {
+  let deviceId = localStorage.getItem("deviceId");
+  if (!deviceId) {
+    deviceId = crypto.randomUUID();
+    localStorage.setItem("deviceId", deviceId);
+  }
+  return deviceId;
+};
+
 // Add token automatically if present
 api.interceptors.request.use((config) => {
   const token = localStorage.getItem("token");
   if (token) {
     config.headers.Authorization = `Bearer ${token}`;
   }
+  config.headers["X-Device-ID"] = getDeviceId();
   return config;
 });
 
@@ -7,6 +7,7 @@ export interface Book {
   price: number;
   stock: number;
   description: string;
+  product_type: "BOOK" | "GIFT_CARD";
   category_id: string;
   category?: any;
   created_at?: string;
@@ -19,6 +20,7 @@ export interface BookRequest {
   price: number;
   stock: number;
   description: string;
+  product_type?: "BOOK" | "GIFT_CARD";
   category_id: string;
 }
 
@@ -0,0 +1,96 @@
+import api from "./api";
+
+export type ExportKind = "transactions" | "orders";
+export type ExportFormat = "csv" | "xlsx";
+export type ExportStatus = "PENDING" | "RUNNING" | "DONE" | "FAILED" | "EXPIRED";
+
+export interface ExportJob {
+  id: string;
+  kind: ExportKind;
+  format: ExportFormat;
+  status: ExportStatus;
+  row_count: number;
+  file_name?: string;
+  file_size: number;
+  error?: string;
+  download_url?: string;
+  expires_at?: string;
+  created_at: string;
+}
+
+export interface ExportOptions {
+  format: ExportFormat;
+  columns?: string[]; // default every column
+  tz?: string; // IANA zone, default Asia/Kathmandu
+  calendar?: "ad" | "bs";
+  async?: boolean;
+}
+
+// Either the file itself, or the background job when the export is too large to stream
+export type ExportResult = { file: true } | { file: false; job: ExportJob };
+
+// Filename from a Content-Disposition header, if there is one
+const dispositionFilename = (header?: string) => {
+  const match = header?.match(/filename="?([^";]+)"?/);
+  return match?.[1];
+};
+
+// Hand a downloaded blob to the browser as a file
+const saveBlob = (blob: Blob, filename: string) => {
+  const url = URL.createObjectURL(blob);
+  const link = document.createElement("a");
+  link.href = url;
+  link.download = filename;
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  URL.revokeObjectURL(url);
+};
+
+// Start an export (Admin only). filters are the list filters, as query parameters.
+export const startExport = async (
+  kind: ExportKind,
+  filters: Record<string, string | number | undefined>,
+  options: ExportOptions
+): Promise<ExportResult> => {
+  let res;
+  try {
+    res = await api.get(`/${kind}/export`, {
+      params: { ...filters, ...options, columns: options.columns?.join(",") || undefined },
+      responseType: "blob",
+    });
+  } catch (error) {
+    // Errors arrive as a blob too; surface the server's message
+    const data = (error as any)?.response?.data;
+    if (data instanceof Blob) {
+      const body = JSON.parse(await data.text());
+      throw new Error(body.error ?? "Export failed");
+    }
+    throw error;
+  }
+
+  if (res.status === 202) {
+    const body = JSON.parse(await (res.data as Blob).text());
+    return { file: false, job: body.data as ExportJob };
+  }
+  saveBlob(res.data as Blob, dispositionFilename(res.headers["content-disposition"]) ?? `${kind}.${options.format}`);
+  return { file: true };
+};
+
+// Background export job (Admin only)
+export const getExportJob = async (id: string): Promise<ExportJob> => {
+  const res = await api.get(`/exports/${id}`);
+  return res.data.data as ExportJob;
+};
+
+// Recent background export jobs (Admin only)
+export const getExportJobs = async (kind?: ExportKind): Promise<ExportJob[]> => {
+  const res = await api.get("/exports", { params: kind ? { kind } : {} });
+  return (res.data.data ?? []) as ExportJob[];
+};
+
+// Download a finished background export (Admin only)
+export const downloadExport = async (job: ExportJob): Promise<void> => {
+  const res = await api.get(`/exports/${job.id}/download`, { responseType: "blob" });
+  saveBlob(res.data as Blob, job.file_name ?? `${job.kind}.${job.format}`);
+};
@@ -7,27 +7,52 @@ export interface OrderItem {
   book: any; // You might want to define a proper Book interface
   quantity: number;
   price: number;
+  tax_exempt: boolean;
+  tax_amount: number;
+  discount_amount: number;
+}
+
+export interface OrderDiscount {
+  id: string;
+  type: string;
+  coupon_id?: string;
+  promotion_id?: string;
+  code?: string;
+  description: string;
+  amount: number;
 }
 
 export type OrderStatus = "PENDING" | "PAID" | "CANCELLED" | "SHIPPED" | "DELIVERED";
 
 export interface Order {
   id: string;
+  ref?: string; // e.g. BK-2082-000457; missing on orders placed before references
   user_id: string;
   user?: any; // You might want to define a proper User interface
   status: OrderStatus;
+  sub_total: number;
+  discount_total: number;
+  taxable_amount: number;
+  exempt_amount: number;
+  tax_amount: number;
+  service_charge: number;
+  delivery_charge: number;
   total_price: number;
+  delivery_district?: string;
   items: OrderItem[];
+  discounts?: OrderDiscount[];
   created_at: string;
   updated_at: string;
 }
 
 export interface CreateOrderRequest {
+  user_id?: string; // Admins placing an order for a customer
   items: {
     book_id: string;
     quantity: number;
     price: number;
   }[];
+  delivery_district?: string;
 }
 
 export interface UpdateOrderStatusRequest {
@@ -49,6 +74,20 @@ export const createOrder = async (orderData: CreateOrderRequest): Promise<Order>
   return res.data as Order;
 };
 
+// Quote Order (prices a cart with promotions without placing it)
+export const quoteOrder = async (orderData: CreateOrderRequest): Promise<Order> => {
+  const res = await api.post("/orders/quote", orderData);
+
+  if (res.status >= 400) {
+    throw new Error(`Failed to quote order: ${res.statusText}`);
+  }
+
+  if (res.data?.data?.order) {
+    return res.data.data.order as Order;
+  }
+  return res.data as Order;
+};
+
 // Get All Orders
 export const getAllOrders = async (): Promise<Order[]> => {
   const res = await api.get("/orders/");
@@ -103,6 +142,51 @@ export const updateOrderStatus = async (
   return res.data as Order;
 };
 
+// Apply Coupon
+export const applyCoupon = async (id: string, code: string): Promise<Order> => {
+  const res = await api.post(`/orders/${id}/coupon`, { code });
+
+  if (res.status >= 400) {
+    throw new Error(`Failed to apply coupon: ${res.statusText}`);
+  }
+
+  if (res.data?.data?.order) {
+    return res.data.data.order as Order;
+  }
+  return res.data as Order;
+};
+
+// Remove Coupon
+export const removeCoupon = async (id: string): Promise<Order> => {
+  const res = await api.delete(`/orders/${id}/coupon`);
+
+  if (res.status >= 400) {
+    throw new Error(`Failed to remove coupon: ${res.statusText}`);
+  }
+
+  if (res.data?.data?.order) {
+    return res.data.data.order as Order;
+  }
+  return res.data as Order;
+};
+
+export interface PaymentMethodOption {
+  payment_method: string;
+  available: boolean;
+  reasons?: string[];
+}
+
+// Get the payment methods that can pay for an order
+export const getPaymentMethods = async (id: string): Promise<PaymentMethodOption[]> => {
+  const res = await api.get(`/orders/${id}/payment-methods`);
+
+  if (res.status >= 400) {
+    throw new Error(`Failed to fetch payment methods: ${res.statusText}`);
+  }
+
+  return res.data?.data?.payment_methods ?? [];
+};
+
 // Delete Order
 export const deleteOrder = async (id: string): Promise<boolean> => {
   const res = await api.delete(`/orders/${id}`);
@@ -117,8 +201,12 @@ export const deleteOrder = async (id: string): Promise<boolean> => {
 
 export default {
   createOrder,
+  quoteOrder,
   getAllOrders,
   getOrderById,
   updateOrderStatus,
+  applyCoupon,
+  removeCoupon,
+  getPaymentMethods,
   deleteOrder,
 };
\ No newline at end of file
@@ -0,0 +1,84 @@
+import api from "./api";
+import type { EsewaResponseData } from "./transactionApi";
+
+export type PaymentLinkStatus = "ACTIVE" | "PAID" | "REVOKED" | "EXPIRED";
+
+export interface PaymentLink {
+  id: string;
+  order_id: string;
+  user_id: string;
+  amount: number;
+  note?: string;
+  expires_at: string;
+  status: PaymentLinkStatus;
+  transaction_id?: string;
+  paid_at?: string;
+  revoked_at?: string;
+  url?: string;
+  created_at: string;
+}
+
+export interface PaymentLinkRequest {
+  expires_in_hours?: number;
+  note?: string;
+}
+
+// What the customer sees when opening a link
+export interface PaymentLinkView {
+  status: PaymentLinkStatus;
+  expires_at: string;
+  order_id: string;
+  order_ref?: string;
+  items: { title: string; quantity: number; price: number }[];
+  sub_total: number;
+  discount_total: number;
+  tax_amount: number;
+  service_charge: number;
+  delivery_charge: number;
+  amount: number;
+  transaction_status?: string;
+}
+
+export interface PaymentLinkPayment {
+  transaction_id: string;
+  status: string;
+  amount?: number;
+  payment_url?: string;
+  failure_reason?: string;
+}
+
+// Create a link for an order (Admin only)
+export const createPaymentLink = async (orderId: string, data: PaymentLinkRequest = {}): Promise<PaymentLink> => {
+  const res = await api.post(`/orders/${orderId}/payment-links`, data);
+  return res.data.data as PaymentLink;
+};
+
+// List links, optionally for one order (Admin only)
+export const getPaymentLinks = async (orderId?: string): Promise<PaymentLink[]> => {
+  const res = await api.get("/payment-links", { params: orderId ? { order_id: orderId } : {} });
+  return (res.data.data ?? []) as PaymentLink[];
+};
+
+// Revoke an active link (Admin only)
+export const revokePaymentLink = async (id: string): Promise<PaymentLink> => {
+  const res = await api.post(`/payment-links/${id}/revoke`);
+  return res.data.data as PaymentLink;
+};
+
+// Public: the order behind a link
+export const openPaymentLink = async (token: string): Promise<PaymentLinkView> => {
+  const res = await api.get(`/pay/${token}`);
+  return res.data.data as PaymentLinkView;
+};
+
+// Public: start paying a link with eSewa
+export const startPaymentLinkEsewa = async (token: string): Promise<PaymentLinkPayment> => {
+  const res = await api.post(`/pay/${token}/esewa`);
+  return res.data.data as PaymentLinkPayment;
+};
+
+// Public: confirm the eSewa payment after the redirect back
+export const verifyPaymentLinkEsewa = async (token: string, esewaResponse: EsewaResponseData): Promise<PaymentLinkPayment> => {
+  const res = await api.post(`/pay/${token}/esewa/verify`, esewaResponse);
+  return res.data.data as PaymentLinkPayment;
+};
@@ -0,0 +1,85 @@
+import api from "./api";
+
+const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || "http://localhost:8080/api";
+
+// Body of every live status event
+export interface StatusUpdate {
+  event: "snapshot" | "order.created" | "order.cancelled" | "payment.succeeded" | "payment.failed";
+  order_id: string;
+  order_status: string;
+  transaction_id?: string;
+  transaction_status?: string;
+  payment_method?: string;
+  amount: number;
+  failure_reason?: string;
+  at: string;
+}
+
+const STATUS_EVENTS = ["snapshot", "order.created", "order.cancelled", "payment.succeeded", "payment.failed"];
+
+// How long to wait before reconnecting a dropped stream
+const RECONNECT_DELAY = 3000;
+
+// A one-minute ticket that opens a stream; only valid for streams
+const getStreamTicket = async (): Promise<string> => {
+  const res = await api.post("/streams/ticket");
+  return res.data.data.ticket as string;
+};
+
+// Opens a Server-Sent Events stream. EventSource cannot send headers, so the
+// stream is opened with a short-lived ticket in the query rather than the
+// login token. Tickets expire, so a dropped stream is reopened with a fresh
+// one, resuming from the last event ID; onReset is called when the server
+// could not replay everything that was missed. Returns a function that
+// closes the stream.
+export const openStatusStream = (
+  path: string,
+  onUpdate: (update: StatusUpdate) => void,
+  onReset?: () => void
+): (() => void) => {
+  let source: EventSource | null = null;
+  let retry: ReturnType<typeof setTimeout> | undefined;
+  let lastEventId = "";
+  let closed = false;
+
+  const connect = async () => {
+    let ticket: string;
+    try {
+      ticket = await getStreamTicket();
+    } catch (error) {
+      console.error("Could not get a stream ticket:", error);
+      retry = setTimeout(connect, RECONNECT_DELAY);
+      return;
+    }
+    if (closed) return;
+
+    const params = new URLSearchParams({ ticket });
+    if (lastEventId) params.set("last_event_id", lastEventId);
+    source = new EventSource(`${API_BASE_URL}${path}?${params}`);
+
+    STATUS_EVENTS.forEach((name) => {
+      source?.addEventListener(name, (e) => {
+        const message = e as MessageEvent;
+        if (message.lastEventId) lastEventId = message.lastEventId;
+        try {
+          onUpdate(JSON.parse(message.data) as StatusUpdate);
+        } catch (error) {
+          console.error("Bad status event:", error);
+        }
+      });
+    });
+    source.addEventListener("reset", () => onReset?.());
+    // The browser would retry with the same, soon expired, ticket
+    source.onerror = () => {
+      source?.close();
+      if (!closed) retry = setTimeout(connect, RECONNECT_DELAY);
+    };
+  };
+  connect();
+
+  return () => {
+    closed = true;
+    clearTimeout(retry);
+    source?.close();
+  };
+};
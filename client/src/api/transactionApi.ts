@@ -1,10 +1,18 @@
 import api from "./api";
 
 export type TransactionStatus = "PENDING" | "SUCCESS" | "FAILED" | "CANCELLED";
-export type PaymentMethod = "ESEWA" | "CASH" | "CARD";
+export type PaymentMethod = "ESEWA" | "CONNECTIPS" | "BANK_TRANSFER" | "CASH" | "CARD" | "STORE_CREDIT" | "GIFT_CARD";
+
+export interface Tender {
+  payment_method: PaymentMethod;
+  amount: number;
+  gift_card_code?: string; // GIFT_CARD tenders
+  gift_card_id?: string;
+}
 
 export interface Transaction {
   id: string;
+  ref?: string; // e.g. TX-2082-000123
   order_id: string;
   order: any;
   user_id: string;
@@ -12,6 +20,9 @@ export interface Transaction {
   payment_method: PaymentMethod;
   transaction_id: string;
   amount: number;
+  tax_amount: number;
+  service_charge: number;
+  delivery_charge: number;
   status: TransactionStatus;
   payment_url: string;
   merchant_code: string;
@@ -19,6 +30,13 @@ export interface Transaction {
   product_name: string;
   esewa_response: any;
   failure_reason: string;
+  needs_review: boolean;
+  review_reason?: string;
+  paid_at?: string;
+  gateway_fee: number;
+  net_amount: number;
+  fee_source?: string;
+  tenders: Tender[];
   created_at: string;
   updated_at: string;
 }
@@ -27,6 +45,7 @@ export interface CreateTransactionRequest {
   order_id: string;
   payment_method: PaymentMethod;
   amount: number;
+  tenders?: Tender[];
 }
 
 export interface TransactionUpdateRequest {
@@ -62,6 +81,7 @@ export interface EsewaResponseData {
   transaction_code: string;
   status: string;
   total_amount: string;
+  transaction_uuid?: string;
   product_code: string;
   ref_id: string;
   message: string;
@@ -69,6 +89,33 @@ export interface EsewaResponseData {
   signature?: string;
 }
 
+export type PaymentProofStatus = "PENDING" | "APPROVED" | "REJECTED";
+
+export interface PaymentProof {
+  id: string;
+  transaction_id: string;
+  user_id: string;
+  file_name: string;
+  content_type: string;
+  size: number;
+  bank_name: string;
+  deposit_reference: string;
+  deposited_at?: string;
+  status: PaymentProofStatus;
+  rejection_reason?: string;
+  reviewed_by?: string;
+  reviewed_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface PaymentProofUpload {
+  file: File;
+  bank_name?: string;
+  deposit_reference?: string;
+  deposited_at?: string; // YYYY-MM-DD
+}
+
 // Create Transaction
 export const createTransaction = async (transactionData: CreateTransactionRequest): Promise<Transaction> => {
   try {
@@ -88,29 +135,71 @@ export const createTransaction = async (transactionData: CreateTransactionReques
   }
 };
 
-// Get All Transactions (Admin only)
-export const getAllTransactions = async (): Promise<Transaction[]> => {
+// Lightweight row of the admin transaction list; open the transaction for the order and gateway data
+export interface TransactionSummary {
+  id: string;
+  ref?: string;
+  order_id: string;
+  order_ref?: string;
+  user_id: string;
+  user_name: string;
+  user_email: string;
+  payment_method: PaymentMethod;
+  transaction_id: string;
+  amount: number;
+  status: TransactionStatus;
+  product_name: string;
+  failure_reason: string;
+  needs_review: boolean;
+  paid_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TransactionTotals {
+  count: number;
+  amount: number;
+  by_status: Partial<Record<TransactionStatus, { count: number; amount: number }>>; // Ignores the status filter
+}
+
+export interface TransactionPage {
+  items: TransactionSummary[];
+  next_cursor?: string;
+  totals: TransactionTotals;
+}
+
+export type TransactionSort = "created_at" | "-created_at" | "amount" | "-amount";
+
+export interface TransactionListParams {
+  status?: TransactionStatus[];
+  payment_method?: PaymentMethod[];
+  user_id?: string;
+  order_id?: string;
+  from?: string; // YYYY-MM-DD
+  to?: string;
+  min_amount?: number;
+  max_amount?: number;
+  sort?: TransactionSort;
+  limit?: number;
+  cursor?: string;
+}
+
+// List Transactions (Admin only), a page at a time
+export const listTransactions = async (params: TransactionListParams = {}): Promise<TransactionPage> => {
   try {
-    const res = await api.get("/transactions");
+    const res = await api.get("/transactions", {
+      params: {
+        ...params,
+        status: params.status?.join(",") || undefined,
+        payment_method: params.payment_method?.join(",") || undefined,
+      },
+    });
 
     if (res.status >= 400) {
       throw new Error(`Failed to fetch transactions: ${res.status} ${res.statusText}`);
     }
 
-    if (res.data?.data?.transactions) {
-      return res.data.data.transactions as Transaction[];
-    }
-    
-    if (Array.isArray(res.data)) {
-      return res.data as Transaction[];
-    }
-    
-    if (res.data?.data && Array.isArray(res.data.data)) {
-      return res.data.data as Transaction[];
-    }
-    
-    console.warn('Unexpected API response structure:', res.data);
-    return [];
+    return res.data.data as TransactionPage;
   } catch (error) {
     console.error('Error fetching transactions:', error);
     throw new Error(`Network error: ${error instanceof Error ? error.message : 'Unknown error'}`);
@@ -258,9 +347,49 @@ export const deleteTransaction = async (id: string): Promise<boolean> => {
   }
 };
 
+// Upload a bank transfer deposit slip
+export const uploadPaymentProof = async (transactionId: string, upload: PaymentProofUpload): Promise<PaymentProof> => {
+  try {
+    const form = new FormData();
+    form.append("file", upload.file);
+    if (upload.bank_name) form.append("bank_name", upload.bank_name);
+    if (upload.deposit_reference) form.append("deposit_reference", upload.deposit_reference);
+    if (upload.deposited_at) form.append("deposited_at", upload.deposited_at);
+
+    const res = await api.post(`/transactions/${transactionId}/payment-proofs`, form, {
+      headers: { "Content-Type": "multipart/form-data" },
+    });
+
+    if (res.status >= 400) {
+      throw new Error(`Failed to upload deposit slip: ${res.status} ${res.statusText}`);
+    }
+
+    return res.data.data as PaymentProof;
+  } catch (error) {
+    console.error('Error uploading deposit slip:', error);
+    throw new Error(`Network error: ${error instanceof Error ? error.message : 'Unknown error'}`);
+  }
+};
+
+// Get a transaction's deposit slips
+export const getPaymentProofs = async (transactionId: string): Promise<PaymentProof[]> => {
+  try {
+    const res = await api.get(`/transactions/${transactionId}/payment-proofs`);
+
+    if (res.status >= 400) {
+      throw new Error(`Failed to fetch deposit slips: ${res.status} ${res.statusText}`);
+    }
+
+    return (res.data?.data ?? []) as PaymentProof[];
+  } catch (error) {
+    console.error('Error fetching deposit slips:', error);
+    throw new Error(`Network error: ${error instanceof Error ? error.message : 'Unknown error'}`);
+  }
+};
+
 export default {
   createTransaction,
-  getAllTransactions,
+  listTransactions,
   getTransactionById,
   getUserTransactions,
   getTransactionByOrderId,
@@ -268,4 +397,6 @@ export default {
   initiateEsewaPayment,
   verifyEsewaPayment,
   deleteTransaction,
+  uploadPaymentProof,
+  getPaymentProofs,
 };
\ No newline at end of file
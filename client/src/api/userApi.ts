@@ -29,6 +29,8 @@ export interface User {
   name: string;
   email: string;
   role: string;
+  account_type: "INDIVIDUAL" | "INSTITUTIONAL";
+  verified: boolean;
   created_at: string;
   updated_at: string;
 }
@@ -0,0 +1,35 @@
+import { useMutation, useQuery } from "@tanstack/react-query";
+import {
+  startExport,
+  getExportJob,
+  type ExportJob,
+  type ExportKind,
+  type ExportOptions,
+  type ExportResult,
+} from "../api/exportApi";
+
+interface StartExportVariables {
+  kind: ExportKind;
+  filters: Record<string, string | number | undefined>;
+  options: ExportOptions;
+}
+
+// Start an export; small ones download straight away, large ones return a job
+export const useStartExport = () => {
+  return useMutation<ExportResult, Error, StartExportVariables>({
+    mutationFn: ({ kind, filters, options }) => startExport(kind, filters, options),
+  });
+};
+
+// A background export job, polled until it finishes
+export const useExportJob = (id: string) => {
+  return useQuery<ExportJob, Error>({
+    queryKey: ["exports", id],
+    queryFn: () => getExportJob(id),
+    enabled: !!id,
+    refetchInterval: (query) => {
+      const status = query.state.data?.status;
+      return status === "PENDING" || status === "RUNNING" ? 3000 : false;
+    },
+  });
+};
@@ -1,7 +1,9 @@
-import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { useEffect } from "react";
+import { keepPreviousData, useInfiniteQuery, useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { openStatusStream } from "../api/streamApi";
 import { 
   createTransaction, 
-  getAllTransactions, 
+  listTransactions, 
   getTransactionById, 
   getUserTransactions, 
   getTransactionByOrderId, 
@@ -13,20 +15,35 @@ import {
   type TransactionUpdateRequest,
   type EsewaPaymentRequest,
   type EsewaResponseData,
-  type Transaction 
+  type Transaction,
+  type TransactionListParams,
+  type TransactionPage
 } from "../api/transactionApi";
 
-// All transactions (Admin only)
-export const useTransactions = () => {
-  return useQuery<Transaction[], Error>({
-    queryKey: ["transactions"],
-    queryFn: getAllTransactions,
+// Transaction list (Admin only), loaded a page at a time with fetchNextPage
+export const useTransactions = (params: Omit<TransactionListParams, "cursor"> = {}) => {
+  return useInfiniteQuery({
+    queryKey: ["transactions", "list", params],
+    queryFn: ({ pageParam }) => listTransactions({ ...params, cursor: pageParam }),
+    initialPageParam: undefined as string | undefined,
+    getNextPageParam: (lastPage: TransactionPage) => lastPage.next_cursor,
+    placeholderData: keepPreviousData, // Keep the table up while a new filter loads
     retry: 3,
     retryDelay: attemptIndex => Math.min(1000 * 2 ** attemptIndex, 30000),
     staleTime: 5 * 60 * 1000, // 5 minutes
   });
 };
 
+// Counts and sums over every transaction (Admin only)
+export const useTransactionTotals = () => {
+  return useQuery({
+    queryKey: ["transactions", "totals"],
+    queryFn: () => listTransactions({ limit: 1 }),
+    select: (page: TransactionPage) => page.totals,
+    staleTime: 5 * 60 * 1000,
+  });
+};
+
 // Single transaction
 export const useTransaction = (id: string) => {
   return useQuery<Transaction, Error>({
@@ -61,6 +78,39 @@ export const useTransactionByOrderId = (orderId: string) => {
   });
 };
 
+// Keeps a transaction's cached status live, e.g. while waiting on eSewa
+export const useTransactionStream = (id: string) => {
+  const queryClient = useQueryClient();
+
+  useEffect(() => {
+    if (!id) return;
+    return openStatusStream(`/transactions/${id}/stream`, (update) => {
+      queryClient.setQueryData(["transaction", id], (old: Transaction | undefined) =>
+        old && update.transaction_status
+          ? { ...old, status: update.transaction_status as Transaction["status"], failure_reason: update.failure_reason ?? old.failure_reason }
+          : old
+      );
+      if (update.event !== "snapshot") {
+        queryClient.invalidateQueries({ queryKey: ["transaction", id] });
+        queryClient.invalidateQueries({ queryKey: ["transactions", "user"] });
+      }
+    });
+  }, [id, queryClient]);
+};
+
+// Refreshes the admin lists as orders and payments come in (Admin only)
+export const useAdminStatusFeed = () => {
+  const queryClient = useQueryClient();
+
+  useEffect(() => {
+    const refresh = () => {
+      queryClient.invalidateQueries({ queryKey: ["transactions"] });
+      queryClient.invalidateQueries({ queryKey: ["orders"] });
+    };
+    return openStatusStream("/streams/admin", refresh, refresh);
+  }, [queryClient]);
+};
+
 // Create transaction
 export const useCreateTransaction = () => {
   const queryClient = useQueryClient();
@@ -89,13 +139,6 @@ export const useUpdateTransactionStatus = () => {
         updatedTransaction
       );
       
-      // Update the transaction in the transactions list
-      queryClient.setQueryData(
-        ["transactions"],
-        (old: Transaction[] | undefined) => 
-          old?.map(t => t.id === variables.id ? updatedTransaction : t)
-      );
-      
       // Invalidate queries to refetch if needed
       queryClient.invalidateQueries({ queryKey: ["transactions"] });
       queryClient.invalidateQueries({ queryKey: ["transactions", "user"] });
@@ -142,13 +185,6 @@ export const useDeleteTransaction = () => {
   return useMutation<boolean, Error, string>({
     mutationFn: deleteTransaction,
     onSuccess: (_, transactionId) => {
-      // Remove the transaction from the cache
-      queryClient.setQueryData(
-        ["transactions"],
-        (old: Transaction[] | undefined) => 
-          old?.filter(t => t.id !== transactionId)
-      );
-      
       // Invalidate queries
       queryClient.invalidateQueries({ queryKey: ["transactions"] });
       queryClient.removeQueries({ queryKey: ["transaction", transactionId] });
@@ -200,30 +236,17 @@ export const useEsewaPaymentFlow = () => {
 
 // Helper hook for transaction statistics
 export const useTransactionStats = () => {
-  const { data: transactions } = useTransactions();
-  
-  if (!transactions) {
-    return {
-      total: 0,
-      pending: 0,
-      success: 0,
-      failed: 0,
-      cancelled: 0,
-      totalAmount: 0,
-      successAmount: 0,
-    };
-  }
-
-  const total = transactions.length;
-  const pending = transactions.filter(t => t.status === 'PENDING').length;
-  const success = transactions.filter(t => t.status === 'SUCCESS').length;
-  const failed = transactions.filter(t => t.status === 'FAILED').length;
-  const cancelled = transactions.filter(t => t.status === 'CANCELLED').length;
-  
-  const totalAmount = transactions.reduce((sum, t) => sum + t.amount, 0);
-  const successAmount = transactions
-    .filter(t => t.status === 'SUCCESS')
-    .reduce((sum, t) => sum + t.amount, 0);
+  const { data: totals } = useTransactionTotals();
+  const byStatus = totals?.by_status ?? {};
+
+  const total = totals?.count ?? 0;
+  const pending = byStatus.PENDING?.count ?? 0;
+  const success = byStatus.SUCCESS?.count ?? 0;
+  const failed = byStatus.FAILED?.count ?? 0;
+  const cancelled = byStatus.CANCELLED?.count ?? 0;
+
+  const totalAmount = totals?.amount ?? 0;
+  const successAmount = byStatus.SUCCESS?.amount ?? 0;
 
   return {
     total,
@@ -235,4 +258,4 @@ export const useTransactionStats = () => {
     successAmount,
     successRate: total > 0 ? (success / total) * 100 : 0,
   };
-};
\ No newline at end of file
+};
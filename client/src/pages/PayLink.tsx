@@ -0,0 +1,146 @@
+import React, { useEffect, useState } from "react";
+import { useParams, useSearchParams } from "react-router-dom";
+import {
+  openPaymentLink,
+  startPaymentLinkEsewa,
+  verifyPaymentLinkEsewa,
+  type PaymentLinkView,
+} from "../api/paymentLinkApi";
+import type { EsewaResponseData } from "../api/transactionApi";
+
+const statusMessages: Record<string, string> = {
+  PAID: "This order has been paid. Thank you!",
+  REVOKED: "This payment link has been withdrawn. Please contact the store for a new one.",
+  EXPIRED: "This payment link has expired. Please contact the store for a new one.",
+};
+
+const errorMessage = (err: unknown) =>
+  (err as any)?.response?.data?.error || (err as any)?.response?.data?.message || "Something went wrong";
+
+// Public page a customer opens from a payment link. No login is needed.
+const PayLink: React.FC = () => {
+  const { token = "" } = useParams();
+  const [searchParams] = useSearchParams();
+  const [view, setView] = useState<PaymentLinkView | null>(null);
+  const [error, setError] = useState<string | null>(null);
+  const [busy, setBusy] = useState(false);
+
+  const load = async () => {
+    try {
+      setView(await openPaymentLink(token));
+    } catch (err) {
+      setError(errorMessage(err));
+    }
+  };
+
+  useEffect(() => {
+    const finish = async () => {
+      // eSewa sends the customer back with its response as base64 JSON in "data"
+      const data = searchParams.get("data");
+      if (searchParams.get("result") === "success" && data) {
+        try {
+          const response = JSON.parse(atob(data)) as EsewaResponseData;
+          const result = await verifyPaymentLinkEsewa(token, response);
+          if (result.status === "PENDING") {
+            setError("eSewa has not confirmed the payment yet. Check back in a few minutes.");
+          } else if (result.status !== "SUCCESS") {
+            setError(result.failure_reason || "The payment was not completed");
+          }
+        } catch (err) {
+          setError(errorMessage(err));
+        }
+      } else if (searchParams.get("result") === "failure") {
+        setError("The payment was not completed. You can try again.");
+      }
+      await load();
+    };
+    finish();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [token]);
+
+  const payWithEsewa = async () => {
+    setBusy(true);
+    setError(null);
+    try {
+      const payment = await startPaymentLinkEsewa(token);
+      if (payment.payment_url) {
+        window.location.href = payment.payment_url;
+        return;
+      }
+      setError("eSewa did not return a payment page");
+    } catch (err) {
+      setError(errorMessage(err));
+    }
+    setBusy(false);
+  };
+
+  if (!view) {
+    return (
+      <div className="max-w-lg mx-auto mt-20 p-6 bg-white rounded-lg shadow text-center">
+        {error ? <p className="text-red-600">{error}</p> : <p className="text-gray-500">Loading…</p>}
+      </div>
+    );
+  }
+
+  return (
+    <div className="max-w-lg mx-auto mt-20 p-6 bg-white rounded-lg shadow space-y-4">
+      <h1 className="text-xl font-semibold text-gray-900">Pay for your order</h1>
+      <p className="text-sm text-gray-500">Order {view.order_ref ?? `#${view.order_id.slice(0, 8)}`}</p>
+
+      <div className="space-y-2">
+        {view.items.map((item, i) => (
+          <div key={i} className="flex justify-between text-sm">
+            <span>
+              {item.title} × {item.quantity}
+            </span>
+            <span>Rs. {(item.price * item.quantity).toFixed(2)}</span>
+          </div>
+        ))}
+      </div>
+
+      <div className="border-t pt-3 space-y-1 text-sm">
+        {view.discount_total > 0 && (
+          <div className="flex justify-between text-green-700">
+            <span>Discounts</span>
+            <span>- Rs. {view.discount_total.toFixed(2)}</span>
+          </div>
+        )}
+        <div className="flex justify-between">
+          <span>VAT</span>
+          <span>Rs. {view.tax_amount.toFixed(2)}</span>
+        </div>
+        {view.delivery_charge > 0 && (
+          <div className="flex justify-between">
+            <span>Delivery</span>
+            <span>Rs. {view.delivery_charge.toFixed(2)}</span>
+          </div>
+        )}
+        <div className="flex justify-between font-semibold text-base">
+          <span>Total</span>
+          <span>Rs. {view.amount.toFixed(2)}</span>
+        </div>
+      </div>
+
+      {error && <p className="text-sm text-red-600">{error}</p>}
+
+      {view.status === "ACTIVE" ? (
+        <>
+          <button
+            onClick={payWithEsewa}
+            disabled={busy}
+            className="w-full py-2 text-white bg-green-600 rounded-md hover:bg-green-700 disabled:opacity-50"
+          >
+            {busy ? "Redirecting…" : "Pay with eSewa"}
+          </button>
+          <p className="text-xs text-gray-400 text-center">
+            Link valid until {new Date(view.expires_at).toLocaleString()}
+          </p>
+        </>
+      ) : (
+        <p className="text-center font-medium text-gray-700">{statusMessages[view.status]}</p>
+      )}
+    </div>
+  );
+};
+
+export default PayLink;
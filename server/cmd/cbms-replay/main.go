@@ -0,0 +1,76 @@
+// Command cbms-replay requeues failed CBMS sync jobs and syncs everything due.
+//
+//	go run ./cmd/cbms-replay            # replay all FAILED jobs
+//	go run ./cmd/cbms-replay -job <id>  # replay a single job
+//	go run ./cmd/cbms-replay -list      # only list FAILED jobs
+package main
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	jobFlag := flag.String("job", "", "replay only this sync job ID")
+	listOnly := flag.Bool("list", false, "list failed jobs without replaying")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := config.LoadConfig()
+	syncService := services.NewCBMSSyncService(
+		repositories.NewCBMSSyncRepository(cfg.DB),
+		repositories.NewInvoiceRepository(cfg.DB, cfg.CBMS.Enabled),
+		cfg.CBMS,
+		cfg.Seller,
+	)
+	ctx := context.Background()
+
+	if *listOnly {
+		jobs, err := syncService.GetJobs(ctx, models.CBMSSyncStatusFailed)
+		if err != nil {
+			log.Fatalf("failed to list jobs: %v", err)
+		}
+		for _, job := range jobs {
+			fmt.Printf("%s  %-11s %-20s attempts=%d  %s\n", job.ID, job.DocumentType, job.DocumentNumber, job.Attempts, job.LastError)
+		}
+		return
+	}
+
+	var jobID *uuid.UUID
+	if *jobFlag != "" {
+		id, err := uuid.Parse(*jobFlag)
+		if err != nil {
+			log.Fatalf("invalid job ID: %v", err)
+		}
+		jobID = &id
+	}
+
+	requeued, err := syncService.Replay(ctx, jobID)
+	if err != nil {
+		log.Fatalf("failed to requeue jobs: %v", err)
+	}
+	log.Printf("Requeued %d job(s)", requeued)
+
+	synced, err := syncService.SyncDue(ctx)
+	if err != nil {
+		log.Fatalf("sync stopped: %v", err)
+	}
+	log.Printf("Synced %d job(s)", synced)
+
+	failed, err := syncService.GetJobs(ctx, models.CBMSSyncStatusFailed)
+	if err == nil && len(failed) > 0 {
+		log.Printf("%d job(s) still FAILED; run with -list for details", len(failed))
+	}
+}
@@ -0,0 +1,82 @@
+// Command cbms-stub is a local stand-in for the IRD CBMS API, for testing the
+// bill sync without the real endpoint. Point CBMS_URL at it:
+//
+//	go run ./cmd/cbms-stub -addr :9090 -fail 2
+//	CBMS_ENABLED=true CBMS_URL=http://localhost:9090 go run ./cmd/server
+package main
+
+import (
+	"bookstore/pkg/cbms"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	failFirst := flag.Int("fail", 0, "answer the first N attempts per document with code 103")
+	username := flag.String("username", "", "expected username (empty accepts any)")
+	flag.Parse()
+
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	received := map[string]bool{}
+
+	handle := func(kind string, number func([]byte) (string, string, error)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			var raw json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+				fmt.Fprint(w, cbms.CodeInvalidModel)
+				return
+			}
+			user, key, err := number(raw)
+			if err != nil || key == "" {
+				fmt.Fprint(w, cbms.CodeInvalidModel)
+				return
+			}
+			if *username != "" && user != *username {
+				fmt.Fprint(w, cbms.CodeInvalidCredential)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			key = kind + ":" + key
+			attempts[key]++
+			switch {
+			case attempts[key] <= *failFirst:
+				log.Printf("%s attempt %d -> 103 (simulated failure)", key, attempts[key])
+				fmt.Fprint(w, cbms.CodeUnknownException)
+			case received[key]:
+				log.Printf("%s -> 101 (already exists)", key)
+				fmt.Fprint(w, cbms.CodeAlreadyExists)
+			default:
+				received[key] = true
+				log.Printf("%s -> 200 %s", key, raw)
+				fmt.Fprint(w, cbms.CodeSuccess)
+			}
+		}
+	}
+
+	http.HandleFunc("/api/bill", handle("bill", func(raw []byte) (string, string, error) {
+		var bill cbms.Bill
+		err := json.Unmarshal(raw, &bill)
+		return bill.Username, bill.InvoiceNumber, err
+	}))
+	http.HandleFunc("/api/billreturn", handle("return", func(raw []byte) (string, string, error) {
+		var ret cbms.BillReturn
+		err := json.Unmarshal(raw, &ret)
+		return ret.Username, ret.CreditNoteNumber, err
+	}))
+
+	log.Printf("CBMS stub listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,43 @@
+// Command esewa-mock is a local stand-in for the eSewa ePay v2 gateway, for
+// trying the payment flow without eSewa's UAT. Point the server at it:
+//
+//	go run ./cmd/esewa-mock -addr :9095
+//	ESEWA_FORM_URL=http://localhost:9095/api/epay/main/v2/form \
+//	ESEWA_STATUS_URL=http://localhost:9095/api/epay/transaction/status/ go run ./cmd/server
+//
+// Without -scenario every payment shows a page to pick how it ends. Set one
+// payment's outcome ahead of time with:
+//
+//	curl -X POST localhost:9095/mock/scenarios -d '{"transaction_uuid":"...","scenario":"amount_mismatch"}'
+package main
+
+import (
+	"bookstore/pkg/esewa"
+	"bookstore/pkg/esewa/esewamock"
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", ":9095", "listen address")
+	secret := flag.String("secret", esewa.UATSecretKey, "merchant secret key used to check and sign signatures")
+	var names []string
+	for _, scenario := range esewamock.Scenarios {
+		names = append(names, string(scenario))
+	}
+	scenario := flag.String("scenario", "", "default scenario: "+strings.Join(names, ", ")+" (empty asks each time)")
+	delay := flag.Duration("delay", esewamock.New("").Delay, "how long a delayed payment stays pending before it completes")
+	flag.Parse()
+	if *scenario != "" && !esewamock.Scenario(*scenario).Valid() {
+		log.Fatalf("unknown scenario %q", *scenario)
+	}
+
+	mock := esewamock.New(*secret)
+	mock.Default = esewamock.Scenario(*scenario)
+	mock.Delay = *delay
+
+	log.Printf("eSewa mock listening on %s (form %s, status %s)", *addr, esewa.FormPath, esewa.StatusPath)
+	log.Fatal(http.ListenAndServe(*addr, mock))
+}
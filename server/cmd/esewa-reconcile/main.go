@@ -0,0 +1,84 @@
+// Command esewa-reconcile imports an eSewa settlement statement CSV and
+// prints the reconciliation report.
+//
+//	go run ./cmd/esewa-reconcile -file statement.csv      # import and summarise
+//	go run ./cmd/esewa-reconcile -file statement.csv -all # also list matched rows
+package main
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	fileFlag := flag.String("file", "", "settlement statement CSV to import")
+	all := flag.Bool("all", false, "list matched and skipped rows too")
+	flag.Parse()
+
+	if *fileFlag == "" {
+		log.Fatal("usage: esewa-reconcile -file statement.csv")
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	file, err := os.Open(*fileFlag)
+	if err != nil {
+		log.Fatalf("failed to open statement: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.LoadConfig()
+	transactionRepo := repositories.NewTransactionRepository(cfg.DB, cfg.Refs.Transaction)
+	ledgerService := services.NewLedgerService(
+		repositories.NewLedgerRepository(cfg.DB),
+		transactionRepo,
+		repositories.NewRefundRepository(cfg.DB),
+	)
+	feeService := services.NewFeeService(
+		repositories.NewFeeScheduleRepository(cfg.DB),
+		transactionRepo,
+		ledgerService,
+	)
+	settlementService := services.NewSettlementService(
+		repositories.NewSettlementRepository(cfg.DB),
+		transactionRepo,
+		feeService,
+	)
+
+	settlement, err := settlementService.ImportEsewaStatement(context.Background(), filepath.Base(*fileFlag), file, nil)
+	if err != nil {
+		log.Fatalf("import failed: %v", err)
+	}
+
+	fmt.Printf("Import %s: %d row(s), gross %.2f, fees %.2f\n",
+		settlement.ID, settlement.RowCount, settlement.GrossAmount, settlement.FeeTotal)
+	fmt.Printf("  matched             %d\n", settlement.Matched)
+	fmt.Printf("  missing locally     %d\n", settlement.MissingLocally)
+	fmt.Printf("  missing at gateway  %d\n", settlement.MissingAtGateway)
+	fmt.Printf("  amount mismatches   %d\n", settlement.AmountMismatches)
+	fmt.Printf("  duplicates          %d\n", settlement.Duplicates)
+	fmt.Printf("  skipped             %d\n", settlement.Skipped)
+
+	for _, item := range settlement.Items {
+		if !*all && (item.Status == models.SettlementMatched || item.Status == models.SettlementSkipped) {
+			continue
+		}
+		transactionID := "-"
+		if item.TransactionID != nil {
+			transactionID = item.TransactionID.String()
+		}
+		fmt.Printf("%-18s line=%-4d ref=%-20s txn=%-36s esewa=%10.2f local=%10.2f  %s\n",
+			item.Status, item.Line, item.RefID, transactionID, item.GatewayAmount, item.LocalAmount, item.Note)
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"bookstore/config"
+	"bookstore/internal/events"
 	"bookstore/internal/handlers"
+	"bookstore/internal/models"
 	"bookstore/internal/repositories"
 	"bookstore/internal/routes"
 	"bookstore/internal/services"
+	"bookstore/pkg/filestore"
+	"bookstore/pkg/middleware"
+	"context"
 	"log"
 	"os"
 	"time"
@@ -31,19 +36,67 @@ func main() {
 	cfg := config.LoadConfig()
 	db := cfg.DB
 
+	fileStore, err := filestore.NewLocal(cfg.Uploads.Dir)
+	if err != nil {
+		log.Fatalf("failed to open upload directory: %v", err)
+	}
+
 	// Repositories
 	userRepo := repositories.NewUserRepository(db)
 	categoryRepo := repositories.NewCategoryRepository(db)
 	bookRepo := repositories.NewBookRepository(db)
-	orderRepo := repositories.NewOrderRepository(db)
-	transactionRepo := repositories.NewTransactionRepository(db)
+	orderRepo := repositories.NewOrderRepository(db, cfg.Refs.Order)
+	transactionRepo := repositories.NewTransactionRepository(db, cfg.Refs.Transaction)
+	invoiceRepo := repositories.NewInvoiceRepository(db, cfg.CBMS.Enabled)
+	cbmsSyncRepo := repositories.NewCBMSSyncRepository(db)
+	couponRepo := repositories.NewCouponRepository(db)
+	promotionRepo := repositories.NewPromotionRepository(db)
+	walletRepo := repositories.NewWalletRepository(db)
+	refundRepo := repositories.NewRefundRepository(db)
+	giftCardRepo := repositories.NewGiftCardRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	settlementRepo := repositories.NewSettlementRepository(db)
+	feeScheduleRepo := repositories.NewFeeScheduleRepository(db)
+	paymentRuleRepo := repositories.NewPaymentRuleRepository(db)
+	codRepo := repositories.NewCODRepository(db)
+	paymentProofRepo := repositories.NewPaymentProofRepository(db)
+	disputeRepo := repositories.NewDisputeRepository(db)
+	riskRepo := repositories.NewRiskRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	paymentLinkRepo := repositories.NewPaymentLinkRepository(db)
+	exportRepo := repositories.NewExportRepository(db)
 
 	// Services
+	bus := events.NewBus()
+	statusFeed := events.NewBroadcaster(cfg.Streams.History)
 	authService := services.NewAuthService(userRepo)
 	categoryService := services.NewCategoryService(categoryRepo)
 	bookService := services.NewBookService(bookRepo)
-	orderService := services.NewOrderService(orderRepo)
-	transactionService := services.NewTransactionService(transactionRepo, orderRepo)
+	taxCalculator := services.NewTaxCalculator(cfg.Tax)
+	promotionEngine := services.NewPromotionEngine(promotionRepo, cfg.Tax)
+	orderService := services.NewOrderService(orderRepo, bookRepo, promotionEngine, taxCalculator, bus, cfg.Orders)
+	cbmsSyncService := services.NewCBMSSyncService(cbmsSyncRepo, invoiceRepo, cfg.CBMS, cfg.Seller)
+	invoiceService := services.NewInvoiceService(invoiceRepo, transactionRepo, cfg.Seller)
+	ledgerService := services.NewLedgerService(ledgerRepo, transactionRepo, refundRepo)
+	feeService := services.NewFeeService(feeScheduleRepo, transactionRepo, ledgerService)
+	paymentRuleService := services.NewPaymentRuleService(paymentRuleRepo, orderRepo)
+	giftCardService := services.NewGiftCardService(giftCardRepo, ledgerService, cfg.GiftCard)
+	riskService := services.NewRiskService(riskRepo, cfg.Risk)
+	transactionService := services.NewTransactionService(transactionRepo, orderService, giftCardService, paymentRuleService, riskService, bus, cfg.Esewa)
+	codService := services.NewCODService(codRepo, transactionRepo, orderService, transactionService)
+	paymentProofService := services.NewPaymentProofService(paymentProofRepo, transactionRepo, transactionService, fileStore, cfg.Uploads.MaxSize)
+	reportService := services.NewReportService(invoiceRepo, settlementRepo)
+	couponService := services.NewCouponService(couponRepo, orderRepo, transactionRepo, promotionEngine, taxCalculator)
+	promotionService := services.NewPromotionService(promotionRepo)
+	walletService := services.NewWalletService(walletRepo, ledgerService, cfg.Wallet)
+	refundService := services.NewRefundService(refundRepo, transactionRepo, ledgerService, cfg.Wallet)
+	settlementService := services.NewSettlementService(settlementRepo, transactionRepo, feeService)
+	webhookService := services.NewWebhookService(webhookRepo, cfg.Webhooks)
+	outboxRelay := services.NewOutboxRelay(outboxRepo, cfg.Outbox)
+	paymentLinkService := services.NewPaymentLinkService(paymentLinkRepo, orderService, transactionService, cfg.PayLinks)
+	exportService := services.NewExportService(exportRepo, transactionRepo, orderRepo, fileStore, cfg.Exports)
+	disputeService := services.NewDisputeService(disputeRepo, transactionRepo, refundRepo, ledgerService, fileStore, cfg.Uploads.MaxSize, cfg.Disputes)
 
 	// Handlers
 	authHandler := handlers.NewAuthHandler(authService)
@@ -51,16 +104,68 @@ func main() {
 	bookHandler := handlers.NewBookHandler(bookService)
 	orderHandler := handlers.NewOrderHandler(orderService)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService, orderService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	cbmsHandler := handlers.NewCBMSHandler(cbmsSyncService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+	walletHandler := handlers.NewWalletHandler(walletService)
+	refundHandler := handlers.NewRefundHandler(refundService, transactionService)
+	giftCardHandler := handlers.NewGiftCardHandler(giftCardService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService, feeService)
+	settlementHandler := handlers.NewSettlementHandler(settlementService)
+	feeScheduleHandler := handlers.NewFeeScheduleHandler(feeService)
+	paymentRuleHandler := handlers.NewPaymentRuleHandler(paymentRuleService)
+	codHandler := handlers.NewCODHandler(codService)
+	paymentProofHandler := handlers.NewPaymentProofHandler(paymentProofService, cfg.Uploads.MaxSize)
+	disputeHandler := handlers.NewDisputeHandler(disputeService, cfg.Uploads.MaxSize)
+	riskHandler := handlers.NewRiskHandler(riskService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	outboxHandler := handlers.NewOutboxHandler(outboxRelay)
+	streamHandler := handlers.NewStreamHandler(statusFeed, transactionService, orderService)
+	paymentLinkHandler := handlers.NewPaymentLinkHandler(paymentLinkService)
+	exportHandler := handlers.NewExportHandler(exportService)
+
+	// Event bus subscribers
+	services.SubscribeStatusFeed(bus, statusFeed)
+	services.SubscribePaymentLinks(bus, paymentLinkService)
+
+	// Outbox subscribers
+	services.SubscribePaymentOutbox(outboxRelay, transactionService, invoiceService, giftCardService, ledgerService, feeService)
+	services.SubscribePaymentEffects(outboxRelay, walletService, giftCardService, transactionService)
+	for _, eventType := range models.WebhookEvents {
+		outboxRelay.Subscribe(eventType, "webhooks", webhookService.Publish)
+	}
+
+	// Background workers
+	if cfg.CBMS.Enabled {
+		go cbmsSyncService.Run(context.Background())
+	}
+	go walletService.Run(context.Background())
+	go disputeService.Run(context.Background())
+	go outboxRelay.Run(context.Background())
+	go webhookService.Run(context.Background())
+	go exportService.Run(context.Background())
+	go transactionService.Run(context.Background())
+	go orderService.Run(context.Background())
 
 	// Gin router
-	router := gin.Default()
+	// gin.Default's logger would write stream tickets from the query to the log
+	router := gin.New()
+	router.Use(middleware.Logger(), gin.Recovery())
+
+	// The fraud checks key on the client IP, so forwarded addresses are only
+	// believed from configured proxies
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("❌ Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Proper CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{frontendURL},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Device-ID", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -68,7 +173,7 @@ func main() {
 	router.RedirectTrailingSlash = false
 
 	// Routes
-	routes.SetupRoutes(router, authHandler, categoryHandler, bookHandler, orderHandler, transactionHandler)
+	routes.SetupRoutes(router, authHandler, categoryHandler, bookHandler, orderHandler, transactionHandler, invoiceHandler, reportHandler, cbmsHandler, couponHandler, promotionHandler, walletHandler, refundHandler, giftCardHandler, ledgerHandler, settlementHandler, feeScheduleHandler, paymentRuleHandler, codHandler, paymentProofHandler, disputeHandler, riskHandler, webhookHandler, outboxHandler, streamHandler, paymentLinkHandler, exportHandler)
 
 	// Start server
 	log.Println("🚀 Server running at http://localhost:8080")
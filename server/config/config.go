@@ -1,8 +1,14 @@
 package config
 
 import (
+	"bookstore/pkg/esewa"
+	"bookstore/pkg/refno"
 	"log"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -11,6 +17,168 @@ import (
 type Config struct {
 	DB        *gorm.DB
 	JWTSecret string
+	Tax       TaxConfig
+	Seller    SellerConfig
+	CBMS      CBMSConfig
+	Wallet    WalletConfig
+	GiftCard  GiftCardConfig
+	Uploads   UploadConfig
+	Disputes  DisputeConfig
+	Risk      RiskConfig
+	Webhooks  WebhookConfig
+	Outbox    OutboxConfig
+	Streams   StreamConfig
+	PayLinks  PaymentLinkConfig
+	Esewa     EsewaConfig
+	Refs      RefConfig
+	Exports   ExportConfig
+	Orders    OrderConfig
+
+	// TrustedProxies are the proxy addresses or CIDRs whose X-Forwarded-For
+	// is believed for the client IP; empty trusts none
+	TrustedProxies []string
+}
+
+// TaxConfig holds VAT and order charge settings
+type TaxConfig struct {
+	VATRate           float64  // e.g. 0.13 for 13% VAT
+	PricesIncludeVAT  bool     // true if book prices already include VAT
+	ExemptCategories  []string // category names or IDs that are VAT-exempt
+	ServiceChargeRate float64  // e.g. 0.02 for 2% service charge
+	DeliveryCharge    float64  // flat delivery charge per order
+}
+
+// SellerConfig identifies the business on tax invoices
+type SellerConfig struct {
+	Name    string
+	PAN     string // PAN/VAT registration number
+	Address string
+}
+
+// CBMSConfig configures bill sync to the IRD Central Billing Monitoring System
+type CBMSConfig struct {
+	Enabled      bool
+	URL          string
+	Username     string
+	Password     string
+	PollInterval time.Duration // how often the sync worker looks for due jobs
+	MaxAttempts  int           // attempts before a job is marked FAILED
+}
+
+// WalletConfig configures customer store credit
+type WalletConfig struct {
+	CreditValidity time.Duration // how long refunded credit stays usable, 0 = no expiry
+	ExpiryInterval time.Duration // how often lapsed credit is written off
+}
+
+// GiftCardConfig configures gift card validity and code lookup protection
+type GiftCardConfig struct {
+	Validity          time.Duration // lifetime of purchased cards, 0 = no expiry
+	MaxLookupFailures int           // wrong codes allowed per window before lockout
+	LookupWindow      time.Duration
+	LookupLockout     time.Duration
+}
+
+// UploadConfig configures where customer uploads such as deposit slips are kept
+type UploadConfig struct {
+	Dir     string // root directory of the local file store
+	MaxSize int64  // largest accepted upload in bytes
+}
+
+// DisputeConfig configures chargeback evidence deadline reminders
+type DisputeConfig struct {
+	ReminderWindow time.Duration // how long before the evidence deadline admins are alerted
+	CheckInterval  time.Duration // how often deadlines are checked
+}
+
+// RiskConfig sets the fraud check thresholds. Each signal that fires adds its
+// weight to a score; the score decides whether a payment is allowed, flagged
+// for review or blocked.
+type RiskConfig struct {
+	Enabled     bool
+	ReviewScore int // score at which a payment is flagged for manual review
+	BlockScore  int // score at which a payment is refused
+
+	VelocityWindow    time.Duration // window the attempt limits below apply to
+	MaxUserAttempts   int
+	MaxIPAttempts     int
+	MaxDeviceAttempts int
+
+	FailureWindow time.Duration
+	MaxFailures   int // failed transactions in FailureWindow before they count against the user
+
+	AmountMultiple float64 // an order this many times the user's average is unusual
+	MinHistory     int     // successful payments needed before amounts are compared
+
+	NewAccountAge   time.Duration // accounts younger than this are new
+	HighValueAmount float64       // order total that is high value for a new account
+}
+
+// WebhookConfig configures outbound webhook delivery
+type WebhookConfig struct {
+	PollInterval time.Duration // how often the delivery worker looks for due deliveries
+	Timeout      time.Duration // per request
+	RetryBase    time.Duration // delay after the first failure, doubled on each further one
+	MaxBackoff   time.Duration
+	MaxAttempts  int // failed attempts before a delivery is dead-lettered
+}
+
+// OutboxConfig configures the relay that hands outbox events to subscribers
+type OutboxConfig struct {
+	PollInterval time.Duration // how often the relay looks for due events
+	MaxAttempts  int           // failed rounds before an event is marked FAILED
+}
+
+// StreamConfig configures the live status streams
+type StreamConfig struct {
+	History int // recent events kept for clients resuming with Last-Event-ID
+}
+
+// PaymentLinkConfig configures shareable payment links
+type PaymentLinkConfig struct {
+	Secret  string        // signs link tokens; required, and never the JWT secret
+	TTL     time.Duration // lifetime when the admin does not give one
+	BaseURL string        // client page the token is appended to
+}
+
+// EsewaConfig holds the eSewa ePay v2 merchant settings. The defaults are
+// eSewa's public UAT merchant; point the URLs at cmd/esewa-mock to work offline.
+// Any other URL requires ESEWA_SECRET_KEY and ESEWA_PRODUCT_CODE.
+type EsewaConfig struct {
+	FormURL     string
+	StatusURL   string
+	SecretKey   string
+	ProductCode string
+	// Pending payments started within StatusCheckWindow are checked against
+	// the status API every StatusCheckInterval
+	StatusCheckInterval time.Duration
+	StatusCheckWindow   time.Duration
+}
+
+// RefConfig sets how order and transaction references are numbered; see
+// package refno for the pattern placeholders
+type RefConfig struct {
+	Order       refno.Scheme
+	Transaction refno.Scheme
+}
+
+// ExportConfig configures transaction and order exports. Exports with more
+// rows than SyncMaxRows run as background jobs; their files are kept in the
+// upload store for Retention.
+type ExportConfig struct {
+	SyncMaxRows  int
+	PollInterval time.Duration // how often the export worker looks for queued jobs
+	JobTimeout   time.Duration // a job running longer than this is assumed lost and restarted
+	Retention    time.Duration
+}
+
+// OrderConfig configures how long unpaid orders are kept. A PENDING order with
+// no payment in progress and no usable payment link is cancelled once it is
+// older than PendingTTL, which also releases the coupon it holds; 0 keeps
+// unpaid orders forever.
+type OrderConfig struct {
+	PendingTTL     time.Duration
+	ExpiryInterval time.Duration // how often abandoned orders are looked for
 }
 
 func LoadConfig() *Config {
@@ -40,6 +208,172 @@ func LoadConfig() *Config {
 	return &Config{
 		DB:        db,
 		JWTSecret: jwtSecret,
+		Tax:       loadTaxConfig(),
+		Seller: SellerConfig{
+			Name:    getEnv("SELLER_NAME", "Bookstore"),
+			PAN:     getEnv("SELLER_PAN", ""),
+			Address: getEnv("SELLER_ADDRESS", "Kathmandu, Nepal"),
+		},
+		CBMS: CBMSConfig{
+			Enabled:      getEnvBool("CBMS_ENABLED", false),
+			URL:          getEnv("CBMS_URL", "https://cbapi.ird.gov.np"),
+			Username:     getEnv("CBMS_USERNAME", ""),
+			Password:     getEnv("CBMS_PASSWORD", ""),
+			PollInterval: getEnvDuration("CBMS_POLL_INTERVAL", 30*time.Second),
+			MaxAttempts:  int(getEnvFloat("CBMS_MAX_ATTEMPTS", 10)),
+		},
+		Wallet: WalletConfig{
+			CreditValidity: getEnvDuration("STORE_CREDIT_VALIDITY", 365*24*time.Hour),
+			ExpiryInterval: getEnvDuration("STORE_CREDIT_EXPIRY_INTERVAL", time.Hour),
+		},
+		GiftCard: GiftCardConfig{
+			Validity:          getEnvDuration("GIFT_CARD_VALIDITY", 365*24*time.Hour),
+			MaxLookupFailures: int(getEnvFloat("GIFT_CARD_MAX_LOOKUP_FAILURES", 5)),
+			LookupWindow:      getEnvDuration("GIFT_CARD_LOOKUP_WINDOW", 15*time.Minute),
+			LookupLockout:     getEnvDuration("GIFT_CARD_LOOKUP_LOCKOUT", 30*time.Minute),
+		},
+		Uploads: UploadConfig{
+			Dir:     getEnv("UPLOAD_DIR", "uploads"),
+			MaxSize: int64(getEnvFloat("UPLOAD_MAX_SIZE", 5<<20)),
+		},
+		Risk: loadRiskConfig(),
+		Disputes: DisputeConfig{
+			ReminderWindow: getEnvDuration("DISPUTE_REMINDER_WINDOW", 72*time.Hour),
+			CheckInterval:  getEnvDuration("DISPUTE_CHECK_INTERVAL", time.Hour),
+		},
+		Webhooks: WebhookConfig{
+			PollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 10*time.Second),
+			Timeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			RetryBase:    getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+			MaxBackoff:   getEnvDuration("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
+			MaxAttempts:  int(getEnvFloat("WEBHOOK_MAX_ATTEMPTS", 10)),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", 2*time.Second),
+			MaxAttempts:  int(getEnvFloat("OUTBOX_MAX_ATTEMPTS", 20)),
+		},
+		Streams: StreamConfig{
+			History: int(getEnvFloat("STREAM_HISTORY", 1000)),
+		},
+		PayLinks: loadPaymentLinkConfig(jwtSecret),
+		Esewa:    loadEsewaConfig(),
+		Refs:     loadRefConfig(),
+		Exports: ExportConfig{
+			SyncMaxRows:  int(getEnvFloat("EXPORT_SYNC_MAX_ROWS", 10000)),
+			PollInterval: getEnvDuration("EXPORT_POLL_INTERVAL", 5*time.Second),
+			JobTimeout:   getEnvDuration("EXPORT_JOB_TIMEOUT", time.Hour),
+			Retention:    getEnvDuration("EXPORT_RETENTION", 24*time.Hour),
+		},
+		Orders: OrderConfig{
+			PendingTTL:     getEnvDuration("ORDER_PENDING_TTL", 2*time.Hour),
+			ExpiryInterval: getEnvDuration("ORDER_EXPIRY_INTERVAL", 10*time.Minute),
+		},
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", nil),
+	}
+}
+
+// loadPaymentLinkConfig insists on a link secret of its own: link tokens are
+// handed to people outside the store, and a leaked key must not also sign logins
+func loadPaymentLinkConfig(jwtSecret string) PaymentLinkConfig {
+	links := PaymentLinkConfig{
+		Secret:  getEnv("PAYMENT_LINK_SECRET", ""),
+		TTL:     getEnvDuration("PAYMENT_LINK_TTL", 72*time.Hour),
+		BaseURL: getEnv("PAYMENT_LINK_BASE_URL", "http://localhost:5173/pay/"),
+	}
+	if links.Secret == "" {
+		log.Fatal("PAYMENT_LINK_SECRET must be set")
+	}
+	if links.Secret == jwtSecret {
+		log.Fatal("PAYMENT_LINK_SECRET must not be the same as JWT_SECRET")
+	}
+	return links
+}
+
+// loadEsewaConfig falls back to the UAT merchant only while both URLs point at
+// eSewa's UAT or a local mock; a live gateway needs real credentials
+func loadEsewaConfig() EsewaConfig {
+	cfg := EsewaConfig{
+		FormURL:     getEnv("ESEWA_FORM_URL", esewa.UATFormURL),
+		StatusURL:   getEnv("ESEWA_STATUS_URL", esewa.UATStatusURL),
+		SecretKey:   getEnv("ESEWA_SECRET_KEY", ""),
+		ProductCode: getEnv("ESEWA_PRODUCT_CODE", ""),
+
+		StatusCheckInterval: getEnvDuration("ESEWA_STATUS_CHECK_INTERVAL", time.Minute),
+		StatusCheckWindow:   getEnvDuration("ESEWA_STATUS_CHECK_WINDOW", 24*time.Hour),
+	}
+	sandbox := isEsewaTestURL(cfg.FormURL, esewa.UATFormURL) && isEsewaTestURL(cfg.StatusURL, esewa.UATStatusURL)
+	if cfg.SecretKey == "" || cfg.ProductCode == "" {
+		if !sandbox {
+			log.Fatal("ESEWA_SECRET_KEY and ESEWA_PRODUCT_CODE must be set when eSewa URLs are not the UAT or a local mock")
+		}
+		if cfg.SecretKey == "" {
+			cfg.SecretKey = esewa.UATSecretKey
+		}
+		if cfg.ProductCode == "" {
+			cfg.ProductCode = esewa.UATProductCode
+		}
+	}
+	return cfg
+}
+
+// isEsewaTestURL reports whether rawURL is eSewa's UAT endpoint or served from
+// this machine, as cmd/esewa-mock is
+func isEsewaTestURL(rawURL, uatURL string) bool {
+	if rawURL == uatURL {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+func loadRefConfig() RefConfig {
+	pattern := getEnv("REF_PATTERN", refno.DefaultPattern)
+	width := int(getEnvFloat("REF_SEQ_WIDTH", 6))
+	refs := RefConfig{
+		Order:       refno.Scheme{Prefix: getEnv("ORDER_REF_PREFIX", "BK"), Pattern: pattern, Width: width},
+		Transaction: refno.Scheme{Prefix: getEnv("TRANSACTION_REF_PREFIX", "TX"), Pattern: pattern, Width: width},
+	}
+	if err := refs.Order.Validate(); err != nil {
+		log.Fatal("Invalid order reference format: ", err)
+	}
+	if err := refs.Transaction.Validate(); err != nil {
+		log.Fatal("Invalid transaction reference format: ", err)
+	}
+	return refs
+}
+
+func loadTaxConfig() TaxConfig {
+	return TaxConfig{
+		VATRate:           getEnvFloat("VAT_RATE", 0.13),
+		PricesIncludeVAT:  getEnvBool("PRICES_INCLUDE_VAT", false),
+		ExemptCategories:  getEnvList("VAT_EXEMPT_CATEGORIES", []string{"Books"}),
+		ServiceChargeRate: getEnvFloat("SERVICE_CHARGE_RATE", 0),
+		DeliveryCharge:    getEnvFloat("DELIVERY_CHARGE", 0),
+	}
+}
+
+func loadRiskConfig() RiskConfig {
+	return RiskConfig{
+		Enabled:           getEnvBool("RISK_ENABLED", true),
+		ReviewScore:       int(getEnvFloat("RISK_REVIEW_SCORE", 40)),
+		BlockScore:        int(getEnvFloat("RISK_BLOCK_SCORE", 80)),
+		VelocityWindow:    getEnvDuration("RISK_VELOCITY_WINDOW", time.Hour),
+		MaxUserAttempts:   int(getEnvFloat("RISK_MAX_USER_ATTEMPTS", 10)),
+		MaxIPAttempts:     int(getEnvFloat("RISK_MAX_IP_ATTEMPTS", 30)),
+		MaxDeviceAttempts: int(getEnvFloat("RISK_MAX_DEVICE_ATTEMPTS", 10)),
+		FailureWindow:     getEnvDuration("RISK_FAILURE_WINDOW", 24*time.Hour),
+		MaxFailures:       int(getEnvFloat("RISK_MAX_FAILURES", 3)),
+		AmountMultiple:    getEnvFloat("RISK_AMOUNT_MULTIPLE", 5),
+		MinHistory:        int(getEnvFloat("RISK_MIN_HISTORY", 3)),
+		NewAccountAge:     getEnvDuration("RISK_NEW_ACCOUNT_AGE", 72*time.Hour),
+		HighValueAmount:   getEnvFloat("RISK_HIGH_VALUE_AMOUNT", 20000),
 	}
 }
 
@@ -49,3 +383,57 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %v", key, value, fallback)
+		return fallback
+	}
+	return f
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %v", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default %v", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// getEnvList reads a comma-separated list, e.g. "Books,Magazines"
+func getEnvList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
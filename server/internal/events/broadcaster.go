@@ -0,0 +1,137 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Message is one update sent to live listeners, such as a Server-Sent Events
+// stream. IDs increase across the process's lifetime and are seeded from the
+// clock, so they keep increasing after a restart.
+type Message struct {
+	ID     uint64
+	Event  string
+	Data   json.RawMessage
+	topics []string
+}
+
+// Broadcaster fans messages out to listeners by topic and keeps the most
+// recent ones so a listener that reconnects can catch up from its last ID.
+type Broadcaster struct {
+	mu        sync.Mutex
+	lastID    uint64
+	history   []Message
+	size      int
+	listeners map[*Listener]struct{}
+}
+
+// Listener receives the messages for its topics on C. C is closed when the
+// listener falls too far behind or is closed, after which it should
+// reconnect with the last ID it saw.
+type Listener struct {
+	C <-chan Message
+
+	// Backlog holds the messages since the requested ID, oldest first
+	Backlog []Message
+	// Missed is set when messages after the requested ID are no longer kept
+	Missed bool
+
+	ch          chan Message
+	topics      map[string]bool
+	broadcaster *Broadcaster
+}
+
+const listenerBuffer = 64
+
+func NewBroadcaster(history int) *Broadcaster {
+	return &Broadcaster{
+		lastID:    uint64(time.Now().UnixMicro()),
+		size:      history,
+		listeners: map[*Listener]struct{}{},
+	}
+}
+
+// Publish sends data, encoded as JSON, to every listener of any of the topics
+func (b *Broadcaster) Publish(event string, data interface{}, topics ...string) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	msg := Message{ID: b.lastID, Event: event, Data: body, topics: topics}
+	b.history = append(b.history, msg)
+	if len(b.history) > b.size {
+		b.history = b.history[len(b.history)-b.size:]
+	}
+
+	for listener := range b.listeners {
+		if !listener.wants(msg) {
+			continue
+		}
+		select {
+		case listener.ch <- msg:
+		default:
+			// Too slow to keep up; it can catch up from history when it reconnects
+			b.remove(listener)
+		}
+	}
+	return nil
+}
+
+// Listen registers a listener for the topics. A non-zero lastID fills the
+// backlog with the kept messages after it.
+func (b *Broadcaster) Listen(lastID uint64, topics ...string) *Listener {
+	ch := make(chan Message, listenerBuffer)
+	listener := &Listener{C: ch, ch: ch, topics: map[string]bool{}, broadcaster: b}
+	for _, topic := range topics {
+		listener.topics[topic] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastID > 0 {
+		// An ID from the future came from before a clock change; nothing can be replayed reliably
+		oldest := b.lastID + 1
+		if len(b.history) > 0 {
+			oldest = b.history[0].ID
+		}
+		listener.Missed = lastID > b.lastID || lastID < oldest-1
+		for _, msg := range b.history {
+			if msg.ID > lastID && listener.wants(msg) {
+				listener.Backlog = append(listener.Backlog, msg)
+			}
+		}
+	}
+	b.listeners[listener] = struct{}{}
+	return listener
+}
+
+// Close stops the listener. It is safe to call more than once.
+func (l *Listener) Close() {
+	l.broadcaster.mu.Lock()
+	defer l.broadcaster.mu.Unlock()
+	l.broadcaster.remove(l)
+}
+
+func (l *Listener) wants(msg Message) bool {
+	for _, topic := range msg.topics {
+		if l.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// remove must be called with b.mu held
+func (b *Broadcaster) remove(listener *Listener) {
+	if _, ok := b.listeners[listener]; ok {
+		delete(b.listeners, listener)
+		close(listener.ch)
+	}
+}
@@ -0,0 +1,126 @@
+// Package events is an in-process bus for domain events. Services publish
+// what happened and other services subscribe to react, so payment code does
+// not have to know about invoices, ledgers or whatever is added next.
+//
+// Events published here are lost if the process stops before an async
+// subscriber runs. Work that must survive a crash belongs on the outbox.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// Event is anything published on the bus. Events are struct values whose
+// EventName works on the zero value.
+type Event interface {
+	EventName() string
+}
+
+// Handler reacts to one event type
+type Handler[E Event] func(ctx context.Context, event E) error
+
+type subscription struct {
+	name   string
+	async  bool
+	handle func(ctx context.Context, event Event) error
+}
+
+// Bus delivers events to subscribers in the order they subscribed
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]subscription
+	pending     sync.WaitGroup
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: map[string][]subscription{}}
+}
+
+// Subscribe runs handler inside Publish, before Publish returns. The name
+// identifies the subscriber in logs.
+func Subscribe[E Event](b *Bus, name string, handler Handler[E]) {
+	subscribe(b, name, false, handler)
+}
+
+// SubscribeAsync runs handler in its own goroutine after Publish returns.
+// The handler gets a context that is not cancelled with the publisher's.
+func SubscribeAsync[E Event](b *Bus, name string, handler Handler[E]) {
+	subscribe(b, name, true, handler)
+}
+
+func subscribe[E Event](b *Bus, name string, async bool, handler Handler[E]) {
+	var zero E
+	eventName := zero.EventName()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventName] = append(b.subscribers[eventName], subscription{
+		name:  name,
+		async: async,
+		handle: func(ctx context.Context, event Event) error {
+			typed, ok := event.(E)
+			if !ok {
+				return fmt.Errorf("got %T for %s", event, eventName)
+			}
+			return handler(ctx, typed)
+		},
+	})
+}
+
+// Publish hands event to its subscribers. Sync subscribers run in order and
+// a failure is logged without stopping the rest; the returned error says how
+// many failed. Async subscribers are started after the sync ones.
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	subscribers := b.subscribers[event.EventName()]
+	b.mu.RUnlock()
+
+	failed := 0
+	for _, sub := range subscribers {
+		if sub.async {
+			continue
+		}
+		if err := run(ctx, sub, event); err != nil {
+			failed++
+		}
+	}
+
+	for _, sub := range subscribers {
+		if !sub.async {
+			continue
+		}
+		b.pending.Add(1)
+		go func(sub subscription) {
+			defer b.pending.Done()
+			run(context.WithoutCancel(ctx), sub, event)
+		}(sub)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of the %s subscribers failed", failed, event.EventName())
+	}
+	return nil
+}
+
+// Wait blocks until every async subscriber started so far has finished
+func (b *Bus) Wait() {
+	b.pending.Wait()
+}
+
+// run calls one subscriber, logging its error or panic
+func run(ctx context.Context, sub subscription, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			log.Printf("events: %s subscriber %s panicked: %v\n%s", event.EventName(), sub.name, r, debug.Stack())
+		}
+	}()
+	if err = sub.handle(ctx, event); err != nil {
+		log.Printf("events: %s subscriber %s failed: %v", event.EventName(), sub.name, err)
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package events
+
+import "bookstore/internal/models"
+
+// OrderCreated is published after a new order is saved
+type OrderCreated struct {
+	Order *models.Order
+}
+
+func (OrderCreated) EventName() string { return "order.created" }
+
+// OrderCancelled is published after an order moves to CANCELLED
+type OrderCancelled struct {
+	Order *models.Order
+}
+
+func (OrderCancelled) EventName() string { return "order.cancelled" }
+
+// PaymentSucceeded is published after a transaction moves to SUCCESS and
+// its order to PAID. Transaction.Order is loaded.
+type PaymentSucceeded struct {
+	Transaction *models.Transaction
+}
+
+func (PaymentSucceeded) EventName() string { return "payment.succeeded" }
+
+// PaymentFailed is published after a transaction moves to FAILED or
+// CANCELLED. Previous is the status it moved from.
+type PaymentFailed struct {
+	Transaction *models.Transaction
+	Previous    string
+}
+
+func (PaymentFailed) EventName() string { return "payment.failed" }
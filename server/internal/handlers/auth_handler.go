@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bookstore/internal/models"
 	"bookstore/internal/services"
 	"bookstore/pkg/utils"
 	"net/http"
@@ -81,3 +82,20 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, gin.H{"user": user})
 }
+
+// UpdateUserAccount sets a user's account type and verification (admin only)
+func (h *AuthHandler) UpdateUserAccount(c *gin.Context) {
+	var req models.UpdateUserAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.authService.UpdateUserAccount(c.Param("id"), req.AccountType, req.Verified)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"user": user})
+}
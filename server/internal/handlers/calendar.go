@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/pkg/bs"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarParam reads ?calendar=ad|bs, defaulting to ad
+func calendarParam(c *gin.Context) (string, error) {
+	calendar := c.DefaultQuery("calendar", models.CalendarAD)
+	if calendar != models.CalendarAD && calendar != models.CalendarBS {
+		return "", errors.New("calendar must be ad or bs")
+	}
+	return calendar, nil
+}
+
+// bsDateTime formats t as a BS date with Nepal local time, or "" if out of range
+func bsDateTime(t time.Time) string {
+	d, err := bs.FromAD(t)
+	if err != nil {
+		return ""
+	}
+	return d.String() + t.In(bs.Location()).Format(" 15:04:05")
+}
+
+func withBSOrderDates(orders []models.Order) {
+	for i := range orders {
+		orders[i].CreatedAtBS = bsDateTime(orders[i].CreatedAt)
+	}
+}
+
+func withBSTransactionDates(transactions []models.Transaction) {
+	for i := range transactions {
+		transactions[i].CreatedAtBS = bsDateTime(transactions[i].CreatedAt)
+	}
+}
+
+func withBSInvoiceDates(invoices []models.Invoice) {
+	for i := range invoices {
+		invoices[i].IssuedAtBS = bsDateTime(invoices[i].IssuedAt)
+	}
+}
+
+// dateRangeParams reads ?fiscal_year=2082/83 or ?from=&to= (inclusive dates in the
+// requested calendar) and returns the half-open AD range. Defaults to the current fiscal year.
+func dateRangeParams(c *gin.Context, calendar string) (time.Time, time.Time, error) {
+	if fy := c.Query("fiscal_year"); fy != "" {
+		return bs.FiscalYearBounds(fy)
+	}
+
+	fromStr, toStr := c.Query("from"), c.Query("to")
+	if fromStr == "" && toStr == "" {
+		fy, err := bs.FiscalYearOf(time.Now())
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return bs.FiscalYearBounds(fy)
+	}
+	if fromStr == "" || toStr == "" {
+		return time.Time{}, time.Time{}, errors.New("from and to must be given together")
+	}
+
+	from, err := parseCalendarDate(fromStr, calendar)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseCalendarDate(toStr, calendar)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// optionalDateRange reads ?from= and ?to=, inclusive dates in the requested
+// calendar, as a half-open AD range. Either end may be left open.
+func optionalDateRange(c *gin.Context, calendar string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if s := c.Query("from"); s != "" {
+		t, err := parseCalendarDate(s, calendar)
+		if err != nil {
+			return nil, nil, err
+		}
+		from = &t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := parseCalendarDate(s, calendar)
+		if err != nil {
+			return nil, nil, err
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+	return from, to, nil
+}
+
+// parseCalendarDate parses YYYY-MM-DD in the given calendar to midnight NPT
+func parseCalendarDate(s, calendar string) (time.Time, error) {
+	if calendar == models.CalendarBS {
+		d, err := bs.Parse(s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return d.ToAD()
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, bs.Location())
+	if err != nil {
+		return time.Time{}, errors.New("invalid date " + s + ": expected YYYY-MM-DD")
+	}
+	return t, nil
+}
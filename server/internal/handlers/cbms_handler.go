@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CBMSHandler struct {
+	cbmsSyncService services.CBMSSyncService
+}
+
+func NewCBMSHandler(cbmsSyncService services.CBMSSyncService) *CBMSHandler {
+	return &CBMSHandler{cbmsSyncService: cbmsSyncService}
+}
+
+// GetSyncJobs lists CBMS sync jobs (admin only)
+// @Summary List CBMS sync jobs
+// @Tags cbms
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "PENDING, SYNCED or FAILED"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CBMSSyncJob}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /cbms/sync-jobs [get]
+func (h *CBMSHandler) GetSyncJobs(c *gin.Context) {
+	jobs, err := h.cbmsSyncService.GetJobs(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, jobs)
+}
+
+// GetInvoiceSyncStatus lists the CBMS sync jobs of an invoice and its credit note (admin only)
+// @Summary Get invoice CBMS sync status
+// @Tags cbms
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CBMSSyncJob}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /invoices/{id}/cbms [get]
+func (h *CBMSHandler) GetInvoiceSyncStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invoice ID")
+		return
+	}
+
+	jobs, err := h.cbmsSyncService.GetInvoiceJobs(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, jobs)
+}
+
+// RetrySyncJob requeues a single unsynced job (admin only)
+// @Summary Retry a CBMS sync job
+// @Tags cbms
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Sync job ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /cbms/sync-jobs/{id}/retry [post]
+func (h *CBMSHandler) RetrySyncJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sync job ID")
+		return
+	}
+
+	requeued, err := h.cbmsSyncService.Replay(c.Request.Context(), &id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if requeued == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Sync job not found or already synced")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Sync job requeued"})
+}
+
+// ReplayFailedSyncJobs requeues every FAILED job (admin only)
+// @Summary Replay failed CBMS sync jobs
+// @Tags cbms
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /cbms/sync-jobs/replay [post]
+func (h *CBMSHandler) ReplayFailedSyncJobs(c *gin.Context) {
+	requeued, err := h.cbmsSyncService.Replay(c.Request.Context(), nil)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"requeued": requeued})
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CODHandler struct {
+	codService services.CODService
+}
+
+func NewCODHandler(codService services.CODService) *CODHandler {
+	return &CODHandler{codService: codService}
+}
+
+// optionalAgentID reads ?agent_id=, nil when absent
+func optionalAgentID(c *gin.Context) (*uuid.UUID, error) {
+	s := c.Query("agent_id")
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, errors.New("invalid agent_id")
+	}
+	return &id, nil
+}
+
+// codErrorStatus maps delivery state errors to 409 and the rest to 400
+func codErrorStatus(err error) int {
+	if errors.Is(err, repositories.ErrCODClosed) || errors.Is(err, repositories.ErrCODOverCollection) ||
+		errors.Is(err, repositories.ErrCODTransactionClosed) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// CreateAgent adds a delivery agent (admin only)
+// @Summary Create delivery agent
+// @Tags cod
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param agent body models.DeliveryAgentRequest true "Delivery agent"
+// @Success 201 {object} utils.SuccessResponse{data=models.DeliveryAgent}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /cod/agents [post]
+func (h *CODHandler) CreateAgent(c *gin.Context) {
+	var req models.DeliveryAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	agent, err := h.codService.CreateAgent(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, agent)
+}
+
+// GetAgents lists delivery agents (admin only)
+// @Summary List delivery agents
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.DeliveryAgent}
+// @Router /cod/agents [get]
+func (h *CODHandler) GetAgents(c *gin.Context) {
+	agents, err := h.codService.GetAgents(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, agents)
+}
+
+// UpdateAgent edits a delivery agent (admin only)
+// @Summary Update delivery agent
+// @Tags cod
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Agent ID"
+// @Param agent body models.DeliveryAgentRequest true "Delivery agent"
+// @Success 200 {object} utils.SuccessResponse{data=models.DeliveryAgent}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /cod/agents/{id} [put]
+func (h *CODHandler) UpdateAgent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid agent ID")
+		return
+	}
+
+	var req models.DeliveryAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	agent, err := h.codService.UpdateAgent(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Delivery agent not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, agent)
+}
+
+// GetAwaitingAssignment lists pending CASH transactions with no delivery agent (admin only)
+// @Summary COD orders awaiting assignment
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Transaction}
+// @Router /cod/awaiting-assignment [get]
+func (h *CODHandler) GetAwaitingAssignment(c *gin.Context) {
+	transactions, err := h.codService.GetAwaitingAssignment(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, transactions)
+}
+
+// AssignDelivery gives a CASH order to a delivery agent, or reassigns an open delivery (admin only)
+// @Summary Assign COD delivery
+// @Tags cod
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param assignment body models.AssignCODRequest true "Transaction and agent"
+// @Success 200 {object} utils.SuccessResponse{data=models.CODDelivery}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /cod/deliveries [post]
+func (h *CODHandler) AssignDelivery(c *gin.Context) {
+	var req models.AssignCODRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	delivery, err := h.codService.Assign(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, codErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, delivery)
+}
+
+// GetDeliveries lists COD deliveries (admin only)
+// @Summary List COD deliveries
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "ASSIGNED, PARTIALLY_COLLECTED, COLLECTED or REFUSED"
+// @Param agent_id query string false "Delivery agent ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CODDelivery}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /cod/deliveries [get]
+func (h *CODHandler) GetDeliveries(c *gin.Context) {
+	agentID, err := optionalAgentID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deliveries, err := h.codService.GetDeliveries(c.Request.Context(), c.Query("status"), agentID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, deliveries)
+}
+
+// GetDelivery returns a COD delivery with its collections (admin only)
+// @Summary Get COD delivery
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.CODDelivery}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /cod/deliveries/{id} [get]
+func (h *CODHandler) GetDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.codService.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Delivery not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, delivery)
+}
+
+// RecordCollection records cash collected on delivery, in full or in part (admin only)
+// @Summary Record COD collection
+// @Tags cod
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Param collection body models.CODCollectionRequest true "Collected cash"
+// @Success 201 {object} utils.SuccessResponse{data=models.CODDelivery}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /cod/deliveries/{id}/collections [post]
+func (h *CODHandler) RecordCollection(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	var req models.CODCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	delivery, err := h.codService.RecordCollection(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, codErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, delivery)
+}
+
+// RefuseDelivery records that the customer refused a COD delivery (admin only)
+// @Summary Refuse COD delivery
+// @Tags cod
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Param refusal body models.RefuseCODRequest true "Reason"
+// @Success 200 {object} utils.SuccessResponse{data=models.CODDelivery}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /cod/deliveries/{id}/refuse [post]
+func (h *CODHandler) RefuseDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	var req models.RefuseCODRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	delivery, err := h.codService.Refuse(c.Request.Context(), id, req.Reason)
+	if err != nil {
+		utils.ErrorResponse(c, codErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, delivery)
+}
+
+// RecordRemittance reconciles an agent's cash hand-over for a day (admin only)
+// @Summary Record COD remittance
+// @Tags cod
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs; applies to date"
+// @Param remittance body models.CODRemittanceRequest true "Remitted cash"
+// @Success 201 {object} utils.SuccessResponse{data=models.CODRemittance}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /cod/remittances [post]
+func (h *CODHandler) RecordRemittance(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.CODRemittanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	date, err := parseCalendarDate(req.Date, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	remittance, err := h.codService.RecordRemittance(c.Request.Context(), req.AgentID, date, req.Amount, req.Note, &adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if calendar == models.CalendarBS {
+		remittance.RemittanceDateBS = bsDateTime(remittance.RemittanceDate)
+	}
+	utils.SuccessResponse(c, http.StatusCreated, remittance)
+}
+
+// GetRemittances lists COD remittances (admin only)
+// @Summary List COD remittances
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Param agent_id query string false "Delivery agent ID"
+// @Param calendar query string false "ad (default) or bs; applies to from/to and returned dates"
+// @Param fiscal_year query string false "Fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date YYYY-MM-DD (inclusive)"
+// @Param to query string false "End date YYYY-MM-DD (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CODRemittance}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /cod/remittances [get]
+func (h *CODHandler) GetRemittances(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	agentID, err := optionalAgentID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	remittances, err := h.codService.GetRemittances(c.Request.Context(), agentID, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if calendar == models.CalendarBS {
+		for i := range remittances {
+			remittances[i].RemittanceDateBS = bsDateTime(remittances[i].RemittanceDate)
+		}
+	}
+	utils.SuccessResponse(c, http.StatusOK, remittances)
+}
+
+// GetRemittance returns a COD remittance with the collections it covered (admin only)
+// @Summary Get COD remittance
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Remittance ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.CODRemittance}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /cod/remittances/{id} [get]
+func (h *CODHandler) GetRemittance(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid remittance ID")
+		return
+	}
+
+	remittance, err := h.codService.GetRemittance(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Remittance not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, remittance)
+}
+
+// GetDiscrepancyReport lists short or over remittances and cash not remitted on time (admin only)
+// @Summary COD remittance discrepancy report
+// @Tags cod
+// @Produce json
+// @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs; applies to from/to and row dates"
+// @Param fiscal_year query string false "Fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date YYYY-MM-DD (inclusive)"
+// @Param to query string false "End date YYYY-MM-DD (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CODDiscrepancy}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /cod/discrepancies [get]
+func (h *CODHandler) GetDiscrepancyReport(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.codService.DiscrepancyReport(c.Request.Context(), from, to, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"calendar": calendar,
+		"rows":     report,
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID reads the authenticated user's ID set by AuthMiddleware
+func currentUserID(c *gin.Context) (uuid.UUID, error) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, errors.New("user not authenticated")
+	}
+	userIDStr, ok := userID.(string)
+	if !ok {
+		return uuid.Nil, errors.New("invalid user ID format")
+	}
+	return uuid.Parse(userIDStr)
+}
+
+// isAdmin reports whether the authenticated user has the admin role
+func isAdmin(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	return role == "admin"
+}
+
+// canAccess reports whether the current user is an admin or the owner
+func canAccess(c *gin.Context, ownerID uuid.UUID) bool {
+	if isAdmin(c) {
+		return true
+	}
+	userID, err := currentUserID(c)
+	return err == nil && userID == ownerID
+}
+
+// clientInfo describes the caller for the fraud checks. The IP comes from
+// X-Forwarded-For only when the request came through a trusted proxy (see
+// TRUSTED_PROXIES). The device ID is an opaque value the client keeps, so it
+// is only trusted as far as it is capped.
+func clientInfo(c *gin.Context) models.ClientInfo {
+	deviceID := strings.TrimSpace(c.GetHeader("X-Device-ID"))
+	if len(deviceID) > 100 {
+		deviceID = deviceID[:100]
+	}
+	return models.ClientInfo{IPAddress: c.ClientIP(), DeviceID: deviceID}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CouponHandler struct {
+	couponService services.CouponService
+}
+
+func NewCouponHandler(couponService services.CouponService) *CouponHandler {
+	return &CouponHandler{couponService: couponService}
+}
+
+// CreateCoupon endpoint
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, coupon)
+}
+
+// GetAllCoupons endpoint
+func (h *CouponHandler) GetAllCoupons(c *gin.Context) {
+	coupons, err := h.couponService.GetAllCoupons(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, coupons)
+}
+
+// GetCouponByID endpoint
+func (h *CouponHandler) GetCouponByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	coupon, err := h.couponService.GetCouponByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Coupon not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, coupon)
+}
+
+// UpdateCoupon endpoint
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, coupon)
+}
+
+// DeleteCoupon endpoint
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	if err := h.couponService.DeleteCoupon(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Coupon deleted successfully"})
+}
+
+// ApplyCoupon endpoint
+func (h *CouponHandler) ApplyCoupon(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.ApplyCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	order, err := h.couponService.ApplyCoupon(c.Request.Context(), orderID, userID, req.Code)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, repositories.ErrCouponLimitReached) {
+			status = http.StatusConflict
+		}
+		utils.ErrorResponse(c, status, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"order": order})
+}
+
+// RemoveCoupon endpoint
+func (h *CouponHandler) RemoveCoupon(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	order, err := h.couponService.RemoveCoupon(c.Request.Context(), orderID, userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"order": order})
+}
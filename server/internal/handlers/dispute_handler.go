@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DisputeHandler struct {
+	disputeService services.DisputeService
+	maxSize        int64
+}
+
+func NewDisputeHandler(disputeService services.DisputeService, maxSize int64) *DisputeHandler {
+	return &DisputeHandler{disputeService: disputeService, maxSize: maxSize}
+}
+
+// disputeErrorStatus maps a dispute in the wrong status to 409 and the rest to 400
+func disputeErrorStatus(err error) int {
+	if errors.Is(err, repositories.ErrDisputeState) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// OpenDispute records a dispute or chargeback against a transaction (admin only)
+// @Summary Open dispute
+// @Tags disputes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param dispute body models.CreateDisputeRequest true "Dispute"
+// @Success 201 {object} utils.SuccessResponse{data=models.Dispute}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /disputes [post]
+func (h *DisputeHandler) OpenDispute(c *gin.Context) {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req models.CreateDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dispute, err := h.disputeService.OpenDispute(c.Request.Context(), &req, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, dispute)
+}
+
+// GetDisputes lists disputes by evidence deadline, soonest first (admin only)
+// @Summary List disputes
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "OPEN, EVIDENCE_SUBMITTED, WON or LOST"
+// @Param transaction_id query string false "Transaction ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Dispute}
+// @Router /disputes [get]
+func (h *DisputeHandler) GetDisputes(c *gin.Context) {
+	var transactionID *uuid.UUID
+	if s := c.Query("transaction_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction_id")
+			return
+		}
+		transactionID = &id
+	}
+
+	disputes, err := h.disputeService.GetDisputes(c.Request.Context(), strings.ToUpper(c.Query("status")), transactionID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, disputes)
+}
+
+// GetDispute returns a dispute with its evidence (admin only)
+// @Summary Get dispute
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.Dispute}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /disputes/{id} [get]
+func (h *DisputeHandler) GetDispute(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dispute ID")
+		return
+	}
+
+	dispute, err := h.disputeService.GetDispute(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Dispute not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, dispute)
+}
+
+// AddEvidence attaches an evidence file to an open dispute (admin only)
+// @Summary Upload dispute evidence
+// @Tags disputes
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dispute ID"
+// @Param file formData file true "Evidence (JPEG, PNG, WebP or PDF)"
+// @Param description formData string false "What the file shows"
+// @Success 201 {object} utils.SuccessResponse{data=models.DisputeEvidence}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /disputes/{id}/evidence [post]
+func (h *DisputeHandler) AddEvidence(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dispute ID")
+		return
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var form models.DisputeEvidenceUpload
+	header, file, ok := bindUpload(c, h.maxSize, &form)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	evidence, err := h.disputeService.AddEvidence(c.Request.Context(), id, adminID, attachmentName(header.Filename), file, &form)
+	if err != nil {
+		utils.ErrorResponse(c, disputeErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, evidence)
+}
+
+// GetEvidenceFile serves a dispute evidence file (admin only)
+// @Summary Download dispute evidence
+// @Tags disputes
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param evidenceId path string true "Evidence ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /disputes/evidence/{evidenceId}/file [get]
+func (h *DisputeHandler) GetEvidenceFile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("evidenceId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid evidence ID")
+		return
+	}
+
+	evidence, file, err := h.disputeService.OpenEvidenceFile(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	defer file.Close()
+
+	serveStoredFile(c, evidence.FileName, evidence.ContentType, evidence.Size, file)
+}
+
+// SubmitEvidence marks a dispute's evidence as sent to the gateway or issuer (admin only)
+// @Summary Submit dispute evidence
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dispute ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.Dispute}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /disputes/{id}/submit [post]
+func (h *DisputeHandler) SubmitEvidence(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dispute ID")
+		return
+	}
+
+	dispute, err := h.disputeService.SubmitEvidence(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, disputeErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, dispute)
+}
+
+// ResolveDispute closes a dispute as won or lost; a lost dispute is reversed against the order (admin only)
+// @Summary Resolve dispute
+// @Tags disputes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Dispute ID"
+// @Param resolution body models.ResolveDisputeRequest true "Outcome"
+// @Success 200 {object} utils.SuccessResponse{data=models.Dispute}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /disputes/{id}/resolve [post]
+func (h *DisputeHandler) ResolveDispute(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dispute ID")
+		return
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dispute, err := h.disputeService.Resolve(c.Request.Context(), id, &req, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, disputeErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, dispute)
+}
+
+// GetNotifications lists evidence deadline alerts, newest first (admin only)
+// @Summary Dispute deadline notifications
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.DisputeNotification}
+// @Router /disputes/notifications [get]
+func (h *DisputeHandler) GetNotifications(c *gin.Context) {
+	notifications, err := h.disputeService.GetNotifications(c.Request.Context(), c.Query("unread") == "true")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, notifications)
+}
+
+// MarkNotificationRead dismisses a deadline notification (admin only)
+// @Summary Mark dispute notification read
+// @Tags disputes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /disputes/notifications/{id}/read [post]
+func (h *DisputeHandler) MarkNotificationRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	if err := h.disputeService.MarkNotificationRead(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Notification marked read"})
+}
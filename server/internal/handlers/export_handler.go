@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ExportHandler struct {
+	exportService services.ExportService
+}
+
+func NewExportHandler(exportService services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// exportErrorStatus maps unknown jobs to 404, expired files to 410 and jobs
+// still running to 409
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrExportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrExportExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrExportNotReady):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// ExportTransactions streams transactions as CSV or XLSX (admin only)
+// @Summary Export transactions
+// @Description Takes the filters and sort of GET /transactions. Exports over the configured row limit, or with async=true, are queued and answered with 202 and the job.
+// @Tags transactions
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "csv (default) or xlsx"
+// @Param columns query string false "Comma-separated columns, e.g. ref,created_at,amount; default all"
+// @Param tz query string false "IANA timezone dates are written in, default Asia/Kathmandu"
+// @Param calendar query string false "ad (default) or bs, for both the from/to filter and the dates written"
+// @Param async query bool false "Always run as a background job"
+// @Param status query string false "Comma-separated statuses"
+// @Param payment_method query string false "Comma-separated methods; matches split tenders too"
+// @Param from query string false "Created on or after, YYYY-MM-DD in the requested calendar"
+// @Param to query string false "Created on or before, YYYY-MM-DD in the requested calendar"
+// @Param sort query string false "created_at, -created_at (default), amount or -amount"
+// @Success 200 {file} binary
+// @Success 202 {object} utils.SuccessResponse{data=models.ExportJob}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /transactions/export [get]
+func (h *ExportHandler) ExportTransactions(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query, err := transactionListParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := exportParams(c, models.ExportKindTransactions, calendar)
+	req.Transactions = &query.Filter
+	req.Sort = query.Sort
+	h.export(c, req)
+}
+
+// ExportOrders streams orders as CSV or XLSX, newest first (admin only)
+// @Summary Export orders
+// @Description Exports over the configured row limit, or with async=true, are queued and answered with 202 and the job.
+// @Tags orders
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "csv (default) or xlsx"
+// @Param columns query string false "Comma-separated columns, e.g. ref,created_at,total_price; default all"
+// @Param tz query string false "IANA timezone dates are written in, default Asia/Kathmandu"
+// @Param calendar query string false "ad (default) or bs, for both the from/to filter and the dates written"
+// @Param async query bool false "Always run as a background job"
+// @Param ref query string false "Order reference"
+// @Param status query string false "Comma-separated statuses"
+// @Param user_id query string false "Customer ID"
+// @Param from query string false "Created on or after, YYYY-MM-DD in the requested calendar"
+// @Param to query string false "Created on or before, YYYY-MM-DD in the requested calendar"
+// @Success 200 {file} binary
+// @Success 202 {object} utils.SuccessResponse{data=models.ExportJob}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /orders/export [get]
+func (h *ExportHandler) ExportOrders(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f := &models.OrderFilter{
+		Ref:      strings.TrimSpace(c.Query("ref")),
+		Statuses: upperList(c.Query("status")),
+	}
+	if f.UserID, err = optionalUUIDQuery(c, "user_id"); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if f.From, f.To, err = optionalDateRange(c, calendar); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := exportParams(c, models.ExportKindOrders, calendar)
+	req.Orders = f
+	h.export(c, req)
+}
+
+// exportParams reads the format, column and date options shared by every export
+func exportParams(c *gin.Context, kind, calendar string) *models.ExportRequest {
+	req := &models.ExportRequest{
+		Kind:     kind,
+		Format:   strings.ToLower(c.DefaultQuery("format", models.ExportFormatCSV)),
+		Timezone: strings.TrimSpace(c.Query("tz")),
+		Calendar: calendar,
+	}
+	for _, column := range strings.Split(c.Query("columns"), ",") {
+		if column = strings.ToLower(strings.TrimSpace(column)); column != "" {
+			req.Columns = append(req.Columns, column)
+		}
+	}
+	return req
+}
+
+// export streams the file, or queues a job when it is too large or the caller asked for one
+func (h *ExportHandler) export(c *gin.Context, req *models.ExportRequest) {
+	if err := h.exportService.Validate(req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	queue, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "async must be true or false")
+		return
+	}
+	if !queue {
+		if queue, err = h.exportService.ShouldQueue(c.Request.Context(), req); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if queue {
+		adminID, err := currentUserID(c)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		job, err := h.exportService.Queue(c.Request.Context(), req, adminID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		utils.SuccessResponse(c, http.StatusAccepted, job)
+		return
+	}
+
+	c.Header("Content-Type", services.ExportContentType(req.Format))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": services.ExportFileName(req, time.Now())}))
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	// Headers are gone by the time a row fails, so the error can only be logged
+	if _, err := h.exportService.Write(c.Request.Context(), req, c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// GetExports lists recent export jobs, newest first (admin only)
+// @Summary List export jobs
+// @Tags exports
+// @Produce json
+// @Security BearerAuth
+// @Param kind query string false "transactions or orders"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.ExportJob}
+// @Router /exports [get]
+func (h *ExportHandler) GetExports(c *gin.Context) {
+	jobs, err := h.exportService.GetJobs(c.Request.Context(), strings.ToLower(c.Query("kind")))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, jobs)
+}
+
+// GetExport shows an export job's progress; download_url is set once it is done (admin only)
+// @Summary Get export job
+// @Tags exports
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Export job ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.ExportJob}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /exports/{id} [get]
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid export ID")
+		return
+	}
+
+	job, err := h.exportService.GetJob(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, exportErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, job)
+}
+
+// DownloadExport sends a finished export file (admin only)
+// @Summary Download export file
+// @Tags exports
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "Export job ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 410 {object} utils.ErrorResponse
+// @Router /exports/{id}/download [get]
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid export ID")
+		return
+	}
+
+	job, file, err := h.exportService.OpenFile(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, exportErrorStatus(err), err.Error())
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, job.FileSize, services.ExportContentType(job.Format), file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": job.FileName}),
+		"Cache-Control":       "private, no-store",
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FeeScheduleHandler struct {
+	feeService services.FeeService
+}
+
+func NewFeeScheduleHandler(feeService services.FeeService) *FeeScheduleHandler {
+	return &FeeScheduleHandler{feeService: feeService}
+}
+
+// CreateFeeSchedule endpoint
+func (h *FeeScheduleHandler) CreateFeeSchedule(c *gin.Context) {
+	var req models.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	schedule, err := h.feeService.CreateSchedule(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, schedule)
+}
+
+// GetAllFeeSchedules endpoint, optionally filtered by ?payment_method=
+func (h *FeeScheduleHandler) GetAllFeeSchedules(c *gin.Context) {
+	schedules, err := h.feeService.GetAllSchedules(c.Request.Context(), c.Query("payment_method"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, schedules)
+}
+
+// GetFeeScheduleByID endpoint
+func (h *FeeScheduleHandler) GetFeeScheduleByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fee schedule ID")
+		return
+	}
+
+	schedule, err := h.feeService.GetScheduleByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Fee schedule not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, schedule)
+}
+
+// UpdateFeeSchedule endpoint
+func (h *FeeScheduleHandler) UpdateFeeSchedule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fee schedule ID")
+		return
+	}
+
+	var req models.FeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	schedule, err := h.feeService.UpdateSchedule(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, schedule)
+}
+
+// DeleteFeeSchedule endpoint
+func (h *FeeScheduleHandler) DeleteFeeSchedule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fee schedule ID")
+		return
+	}
+
+	if err := h.feeService.DeleteSchedule(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Fee schedule deleted successfully"})
+}
+
+// QuoteFee endpoint: the current fee on ?payment_method=&amount=
+func (h *FeeScheduleHandler) QuoteFee(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil || amount <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "amount must be a positive number")
+		return
+	}
+	method := c.Query("payment_method")
+	if method == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "payment_method is required")
+		return
+	}
+
+	quote, err := h.feeService.Quote(c.Request.Context(), method, amount, time.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, quote)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GiftCardHandler struct {
+	giftCardService services.GiftCardService
+}
+
+func NewGiftCardHandler(giftCardService services.GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{giftCardService: giftCardService}
+}
+
+// IssueGiftCard issues a gift card by hand (admin only)
+// @Summary Issue a gift card
+// @Tags gift-cards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param giftCard body models.IssueGiftCardRequest true "Gift card"
+// @Success 201 {object} utils.SuccessResponse{data=models.GiftCard}
+// @Router /gift-cards [post]
+func (h *GiftCardHandler) IssueGiftCard(c *gin.Context) {
+	var req models.IssueGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	card, err := h.giftCardService.IssueGiftCard(c.Request.Context(), &req, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, card)
+}
+
+// GetAllGiftCards lists every gift card (admin only)
+// @Summary Get all gift cards
+// @Tags gift-cards
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.GiftCard}
+// @Router /gift-cards [get]
+func (h *GiftCardHandler) GetAllGiftCards(c *gin.Context) {
+	cards, err := h.giftCardService.GetAllGiftCards(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, cards)
+}
+
+// GetMyGiftCards lists the gift cards the current customer has bought
+// @Summary Get current user's gift cards
+// @Tags gift-cards
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.GiftCard}
+// @Router /gift-cards/mine [get]
+func (h *GiftCardHandler) GetMyGiftCards(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	cards, err := h.giftCardService.GetUserGiftCards(c.Request.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, cards)
+}
+
+// CheckBalance returns the balance of a gift card code. Codes travel in the
+// body so they stay out of access logs; repeated wrong codes are locked out.
+// @Summary Check a gift card balance
+// @Tags gift-cards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body models.GiftCardCodeRequest true "Gift card code"
+// @Success 200 {object} utils.SuccessResponse{data=models.GiftCardBalance}
+// @Failure 429 {object} utils.ErrorResponse
+// @Router /gift-cards/balance [post]
+func (h *GiftCardHandler) CheckBalance(c *gin.Context) {
+	var req models.GiftCardCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	balance, err := h.giftCardService.CheckBalance(c.Request.Context(), req.Code, "user:"+userID.String(), "ip:"+c.ClientIP())
+	if err != nil {
+		utils.ErrorResponse(c, giftCardErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, balance)
+}
+
+// GetGiftCardByID gets a gift card (admin only)
+// @Summary Get a gift card
+// @Tags gift-cards
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Gift card ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.GiftCard}
+// @Router /gift-cards/{id} [get]
+func (h *GiftCardHandler) GetGiftCardByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid gift card ID")
+		return
+	}
+
+	card, err := h.giftCardService.GetGiftCardByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Gift card not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, card)
+}
+
+// GetGiftCardTransactions lists the balance history of a gift card (admin only)
+// @Summary Get gift card history
+// @Tags gift-cards
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Gift card ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.GiftCardTransaction}
+// @Router /gift-cards/{id}/transactions [get]
+func (h *GiftCardHandler) GetGiftCardTransactions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid gift card ID")
+		return
+	}
+
+	txns, err := h.giftCardService.GetGiftCardTransactions(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, txns)
+}
+
+// VoidGiftCard voids a gift card and writes off its balance (admin only)
+// @Summary Void a gift card
+// @Tags gift-cards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Gift card ID"
+// @Param void body models.VoidGiftCardRequest true "Reason"
+// @Success 200 {object} utils.SuccessResponse{data=models.GiftCard}
+// @Router /gift-cards/{id}/void [post]
+func (h *GiftCardHandler) VoidGiftCard(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid gift card ID")
+		return
+	}
+
+	var req models.VoidGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	card, err := h.giftCardService.VoidGiftCard(c.Request.Context(), id, req.Reason, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, card)
+}
+
+// giftCardErrorStatus maps gift card lookup errors to HTTP status codes
+func giftCardErrorStatus(err error) int {
+	if errors.Is(err, services.ErrGiftCardLookupLocked) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadRequest
+}
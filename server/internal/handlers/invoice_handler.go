@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvoiceHandler struct {
+	invoiceService services.InvoiceService
+	orderService   *services.OrderService
+}
+
+func NewInvoiceHandler(invoiceService services.InvoiceService, orderService *services.OrderService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: invoiceService, orderService: orderService}
+}
+
+// GetOrderInvoice downloads the tax invoice for an order
+// @Summary Download order invoice
+// @Tags invoices
+// @Produce application/pdf,text/html,json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param format query string false "pdf (default), html or json"
+// @Success 200
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /orders/{id}/invoice [get]
+func (h *InvoiceHandler) GetOrderInvoice(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	// Ownership is checked before anything is looked up or issued for the order
+	order, err := h.orderService.GetOrderByID(c.Request.Context(), orderID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Order not found")
+		return
+	}
+	if !canAccess(c, order.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	invoice, err := h.invoiceService.GetInvoiceForOrder(c.Request.Context(), orderID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	h.writeInvoice(c, invoice)
+}
+
+// GetInvoiceByID gets an invoice by ID (admin only)
+// @Summary Get invoice by ID
+// @Tags invoices
+// @Produce application/pdf,text/html,json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param format query string false "json (default), pdf or html"
+// @Success 200 {object} utils.SuccessResponse{data=models.Invoice}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /invoices/{id} [get]
+func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invoice ID")
+		return
+	}
+
+	invoice, err := h.invoiceService.GetInvoiceByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Invoice not found")
+		return
+	}
+
+	if c.Query("format") == "" {
+		utils.SuccessResponse(c, http.StatusOK, invoice)
+		return
+	}
+	h.writeInvoice(c, invoice)
+}
+
+// GetAllInvoices lists all invoices (admin only)
+// @Summary Get all invoices
+// @Tags invoices
+// @Produce json
+// @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Invoice}
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /invoices [get]
+func (h *InvoiceHandler) GetAllInvoices(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	invoices, err := h.invoiceService.GetAllInvoices(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if calendar == models.CalendarBS {
+		withBSInvoiceDates(invoices)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, invoices)
+}
+
+// CancelInvoice cancels an invoice by issuing a credit note (admin only)
+// @Summary Cancel invoice with a credit note
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param body body models.CancelInvoiceRequest true "Cancellation reason"
+// @Success 200 {object} utils.SuccessResponse{data=models.Invoice}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /invoices/{id}/cancel [post]
+func (h *InvoiceHandler) CancelInvoice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invoice ID")
+		return
+	}
+
+	var req models.CancelInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	invoice, err := h.invoiceService.CancelInvoice(c.Request.Context(), id, req.Reason, userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, invoice)
+}
+
+func (h *InvoiceHandler) writeInvoice(c *gin.Context, invoice *models.Invoice) {
+	filename := "invoice-" + strings.ReplaceAll(invoice.InvoiceNumber, "/", "-")
+
+	switch c.DefaultQuery("format", "pdf") {
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+		c.Status(http.StatusOK)
+		if err := services.WriteInvoicePDF(c.Writer, invoice); err != nil {
+			c.Error(err)
+		}
+	case "html":
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.html"`, filename))
+		c.Status(http.StatusOK)
+		if err := services.WriteInvoiceHTML(c.Writer, invoice); err != nil {
+			c.Error(err)
+		}
+	case "json":
+		utils.SuccessResponse(c, http.StatusOK, invoice)
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "format must be pdf, html or json")
+	}
+}
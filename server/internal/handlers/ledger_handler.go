@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerService services.LedgerService
+	feeService    services.FeeService
+}
+
+func NewLedgerHandler(ledgerService services.LedgerService, feeService services.FeeService) *LedgerHandler {
+	return &LedgerHandler{ledgerService: ledgerService, feeService: feeService}
+}
+
+// GetAccounts lists the ledger accounts (admin only)
+// @Summary List ledger accounts
+// @Tags ledger
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.LedgerAccount}
+// @Router /ledger/accounts [get]
+func (h *LedgerHandler) GetAccounts(c *gin.Context) {
+	accounts, err := h.ledgerService.GetAccounts(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, accounts)
+}
+
+// GetTrialBalance returns every account's totals up to and including a date (admin only)
+// @Summary Trial balance
+// @Tags ledger
+// @Produce json
+// @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs; applies to as_of"
+// @Param as_of query string false "Last date included, YYYY-MM-DD (default now)"
+// @Success 200 {object} utils.SuccessResponse{data=models.TrialBalance}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /ledger/trial-balance [get]
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asOf := time.Now()
+	if s := c.Query("as_of"); s != "" {
+		date, err := parseCalendarDate(s, calendar)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		asOf = date.AddDate(0, 0, 1)
+	}
+
+	tb, err := h.ledgerService.TrialBalance(c.Request.Context(), asOf)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, tb)
+}
+
+// GetAccountStatement lists an account's journal lines with running balances (admin only)
+// @Summary Account statement
+// @Tags ledger
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Account code, e.g. ESEWA_CLEARING"
+// @Param calendar query string false "ad (default) or bs; applies to from/to and returned dates"
+// @Param fiscal_year query string false "Fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date YYYY-MM-DD (inclusive)"
+// @Param to query string false "End date YYYY-MM-DD (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=models.AccountStatement}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /ledger/accounts/{code}/statement [get]
+func (h *LedgerHandler) GetAccountStatement(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	statement, err := h.ledgerService.AccountStatement(c.Request.Context(), c.Param("code"), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if calendar == models.CalendarBS {
+		for i := range statement.Lines {
+			statement.Lines[i].PostedAtBS = bsDateTime(statement.Lines[i].PostedAt)
+		}
+	}
+	utils.SuccessResponse(c, http.StatusOK, statement)
+}
+
+// GetJournalEntries lists journal entries with their lines (admin only)
+// @Summary List journal entries
+// @Tags ledger
+// @Produce json
+// @Security BearerAuth
+// @Param source_type query string false "TRANSACTION, REFUND, GATEWAY_FEE, WALLET_CREDIT, WALLET_EXPIRY, GIFT_CARD_ISSUE or GIFT_CARD_VOID"
+// @Param calendar query string false "ad (default) or bs; applies to from/to and returned dates"
+// @Param fiscal_year query string false "Fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date YYYY-MM-DD (inclusive)"
+// @Param to query string false "End date YYYY-MM-DD (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.JournalEntry}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /ledger/journal-entries [get]
+func (h *LedgerHandler) GetJournalEntries(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := h.ledgerService.GetJournalEntries(c.Request.Context(), c.Query("source_type"), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if calendar == models.CalendarBS {
+		for i := range entries {
+			entries[i].PostedAtBS = bsDateTime(entries[i].PostedAt)
+		}
+	}
+	utils.SuccessResponse(c, http.StatusOK, entries)
+}
+
+// RecordGatewayFee records the fee a gateway kept from a transaction and posts it (admin only)
+// @Summary Record a gateway fee
+// @Tags ledger
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param fee body models.GatewayFeeRequest true "Gateway fee"
+// @Success 201 {object} utils.SuccessResponse{data=models.JournalEntry}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /ledger/gateway-fees [post]
+func (h *LedgerHandler) RecordGatewayFee(c *gin.Context) {
+	var req models.GatewayFeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entry, err := h.feeService.RecordSettledFee(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, entry)
+}
+
+// CheckLedger verifies that every journal balances and every payment is posted (admin only)
+// @Summary Check ledger invariants
+// @Tags ledger
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=models.LedgerCheck}
+// @Router /ledger/check [get]
+func (h *LedgerHandler) CheckLedger(c *gin.Context) {
+	check, err := h.ledgerService.Check(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, check)
+}
+
+// PostMissing posts successful transactions and completed refunds that have no journal yet (admin only)
+// @Summary Post missing journals
+// @Tags ledger
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /ledger/post-missing [post]
+func (h *LedgerHandler) PostMissing(c *gin.Context) {
+	posted, err := h.ledgerService.PostMissing(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"posted": posted})
+}
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"bookstore/internal/models"
+	"bookstore/internal/repositories"
 	"bookstore/internal/services"
 	"bookstore/pkg/utils"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -26,9 +29,18 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	userIDStr, _ := c.Get("user_id")
-	userID, _ := uuid.Parse(userIDStr.(string))
-	order.UserID = userID
+	// Admins take phone orders on a customer's behalf and name the customer;
+	// customers always order for themselves
+	if isAdmin(c) {
+		if order.UserID == uuid.Nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "user_id is required when placing an order for a customer")
+			return
+		}
+	} else {
+		userIDStr, _ := c.Get("user_id")
+		userID, _ := uuid.Parse(userIDStr.(string))
+		order.UserID = userID
+	}
 
 	createdOrder, err := h.orderService.CreateOrder(c, &order)
 	if err != nil {
@@ -39,17 +51,64 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, gin.H{"order": createdOrder})
 }
 
-// GetAllOrders endpoint
+// QuoteOrder endpoint prices a cart with promotions and tax without placing the order
+func (h *OrderHandler) QuoteOrder(c *gin.Context) {
+	var order models.Order
+	if err := c.ShouldBindJSON(&order); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quote, err := h.orderService.QuoteOrder(c, &order)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"order": quote})
+}
+
+// GetAllOrders endpoint. ?ref= looks up one order by its reference, e.g.
+// BK-2082-000457, and returns it as a one-item list.
 func (h *OrderHandler) GetAllOrders(c *gin.Context) {
-	orders, err := h.orderService.GetAllOrders(c)
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var orders []models.Order
+	if ref := strings.TrimSpace(c.Query("ref")); ref != "" {
+		orders, err = h.ordersByRef(c, ref)
+	} else {
+		orders, err = h.orderService.GetAllOrders(c)
+	}
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if calendar == models.CalendarBS {
+		withBSOrderDates(orders)
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, gin.H{"orders": orders})
 }
 
+// ordersByRef finds the order with ref. Customers only find their own orders.
+func (h *OrderHandler) ordersByRef(c *gin.Context, ref string) ([]models.Order, error) {
+	order, err := h.orderService.GetOrderByRef(c, ref)
+	if errors.Is(err, services.ErrOrderNotFound) {
+		return []models.Order{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !canAccess(c, order.UserID) {
+		return []models.Order{}, nil
+	}
+	return []models.Order{*order}, nil
+}
+
 // GetOrderByID endpoint
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	idStr := c.Param("id")
@@ -86,6 +145,10 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	updatedOrder, err := h.orderService.UpdateOrderStatus(c, id, body.Status)
+	if errors.Is(err, repositories.ErrOrderNotPending) {
+		utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
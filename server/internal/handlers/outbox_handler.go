@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OutboxHandler struct {
+	outboxRelay services.OutboxRelay
+}
+
+func NewOutboxHandler(outboxRelay services.OutboxRelay) *OutboxHandler {
+	return &OutboxHandler{outboxRelay: outboxRelay}
+}
+
+// GetEvents lists recent outbox events, newest first (admin only)
+// @Summary List outbox events
+// @Tags outbox
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "PENDING, PUBLISHED or FAILED"
+// @Param aggregate_type query string false "order, transaction or refund"
+// @Param aggregate_id query string false "Order, transaction or refund ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.OutboxEvent}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /outbox/events [get]
+func (h *OutboxHandler) GetEvents(c *gin.Context) {
+	var aggregateID *uuid.UUID
+	if s := c.Query("aggregate_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid aggregate_id")
+			return
+		}
+		aggregateID = &id
+	}
+
+	events, err := h.outboxRelay.GetEvents(c.Request.Context(), strings.ToUpper(c.Query("status")), strings.ToLower(c.Query("aggregate_type")), aggregateID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, events)
+}
+
+// ReplayEvent requeues a single unpublished event (admin only)
+// @Summary Replay an outbox event
+// @Tags outbox
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Event ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /outbox/events/{id}/replay [post]
+func (h *OutboxHandler) ReplayEvent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	requeued, err := h.outboxRelay.Replay(c.Request.Context(), &id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if requeued == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Event not found or already published")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Event requeued"})
+}
+
+// ReplayFailedEvents requeues every FAILED event (admin only)
+// @Summary Replay failed outbox events
+// @Tags outbox
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /outbox/events/replay [post]
+func (h *OutboxHandler) ReplayFailedEvents(c *gin.Context) {
+	requeued, err := h.outboxRelay.Replay(c.Request.Context(), nil)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"requeued": requeued})
+}
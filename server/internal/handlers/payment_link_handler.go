@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentLinkHandler struct {
+	paymentLinkService services.PaymentLinkService
+}
+
+func NewPaymentLinkHandler(paymentLinkService services.PaymentLinkService) *PaymentLinkHandler {
+	return &PaymentLinkHandler{paymentLinkService: paymentLinkService}
+}
+
+// paymentLinkErrorStatus maps unknown links to 404, links no longer active
+// to 409 and payments refused by the risk checks to 403
+func paymentLinkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPaymentLinkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrPaymentLinkState):
+		return http.StatusConflict
+	}
+	return transactionErrorStatus(err)
+}
+
+// CreatePaymentLink makes a signed, expiring link the customer can pay the order with (admin only)
+// @Summary Create payment link
+// @Tags payment-links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param link body models.PaymentLinkRequest false "Lifetime and note"
+// @Success 201 {object} utils.SuccessResponse{data=models.PaymentLink}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /orders/{id}/payment-links [post]
+func (h *PaymentLinkHandler) CreatePaymentLink(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req models.PaymentLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	link, err := h.paymentLinkService.CreateLink(c.Request.Context(), orderID, &req, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, link)
+}
+
+// GetPaymentLinks lists payment links, newest first (admin only)
+// @Summary List payment links
+// @Tags payment-links
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "ACTIVE, PAID, REVOKED or EXPIRED"
+// @Param order_id query string false "Order ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.PaymentLink}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /payment-links [get]
+func (h *PaymentLinkHandler) GetPaymentLinks(c *gin.Context) {
+	var orderID *uuid.UUID
+	if s := c.Query("order_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order_id")
+			return
+		}
+		orderID = &id
+	}
+
+	links, err := h.paymentLinkService.GetLinks(c.Request.Context(), strings.ToUpper(c.Query("status")), orderID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, links)
+}
+
+// RevokePaymentLink stops an active link from being used (admin only)
+// @Summary Revoke payment link
+// @Tags payment-links
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Payment link ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.PaymentLink}
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /payment-links/{id}/revoke [post]
+func (h *PaymentLinkHandler) RevokePaymentLink(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment link ID")
+		return
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	link, err := h.paymentLinkService.RevokeLink(c.Request.Context(), id, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, paymentLinkErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, link)
+}
+
+// OpenPaymentLink shows the order behind a payment link (public)
+// @Summary Open payment link
+// @Tags payment-links
+// @Produce json
+// @Param token path string true "Link token"
+// @Success 200 {object} utils.SuccessResponse{data=models.PaymentLinkView}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /pay/{token} [get]
+func (h *PaymentLinkHandler) OpenPaymentLink(c *gin.Context) {
+	view, err := h.paymentLinkService.Open(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		utils.ErrorResponse(c, paymentLinkErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, view)
+}
+
+// StartEsewaPayment starts the eSewa payment for a link's order (public)
+// @Summary Pay a payment link with eSewa
+// @Tags payment-links
+// @Produce json
+// @Param token path string true "Link token"
+// @Success 200 {object} utils.SuccessResponse{data=models.Transaction}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /pay/{token}/esewa [post]
+func (h *PaymentLinkHandler) StartEsewaPayment(c *gin.Context) {
+	transaction, err := h.paymentLinkService.StartEsewa(c.Request.Context(), c.Param("token"), clientInfo(c))
+	if err != nil {
+		utils.ErrorResponse(c, paymentLinkErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"transaction_id": transaction.ID,
+		"status":         transaction.Status,
+		"amount":         transaction.Amount,
+		"payment_url":    transaction.PaymentURL,
+	})
+}
+
+// VerifyEsewaPayment confirms the eSewa payment for a link's order (public)
+// @Summary Verify a payment link's eSewa payment
+// @Description Takes the signed callback eSewa redirects back with. The payment is only marked paid once eSewa's status API confirms it; until then it stays PENDING.
+// @Tags payment-links
+// @Accept json
+// @Produce json
+// @Param token path string true "Link token"
+// @Param body body models.EsewaResponseData true "eSewa response data"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /pay/{token}/esewa/verify [post]
+func (h *PaymentLinkHandler) VerifyEsewaPayment(c *gin.Context) {
+	var esewaResponse models.EsewaResponseData
+	if err := c.ShouldBindJSON(&esewaResponse); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transaction, err := h.paymentLinkService.VerifyEsewa(c.Request.Context(), c.Param("token"), &esewaResponse, clientInfo(c))
+	if err != nil {
+		utils.ErrorResponse(c, paymentLinkErrorStatus(err), err.Error())
+		return
+	}
+
+	// The caller is not logged in, so only the outcome is returned
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"transaction_id": transaction.ID,
+		"status":         transaction.Status,
+		"failure_reason": transaction.FailureReason,
+	})
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentProofHandler struct {
+	paymentProofService services.PaymentProofService
+	maxSize             int64
+}
+
+func NewPaymentProofHandler(paymentProofService services.PaymentProofService, maxSize int64) *PaymentProofHandler {
+	return &PaymentProofHandler{paymentProofService: paymentProofService, maxSize: maxSize}
+}
+
+// proofErrorStatus maps an already reviewed proof to 409 and the rest to 400
+func proofErrorStatus(err error) int {
+	if errors.Is(err, repositories.ErrProofReviewed) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// attachmentName reduces an uploaded file name to something safe to echo back
+// in a Content-Disposition header
+func attachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "document"
+	}
+	return name
+}
+
+// bindUpload binds the form fields of a multipart upload and opens its "file"
+// part, writing the error response and returning false if either fails
+func bindUpload(c *gin.Context, maxSize int64, form interface{}) (*multipart.FileHeader, multipart.File, bool) {
+	// Leave headroom for the other form fields and multipart framing
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	if err := c.ShouldBind(form); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Upload is too large")
+			return nil, nil, false
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "A file is required")
+		return nil, nil, false
+	}
+	if header.Size > maxSize {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("File must be at most %d KB", maxSize>>10))
+		return nil, nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+	return header, file, true
+}
+
+// serveStoredFile sends an uploaded file so browsers display it as the stored
+// type without running anything in it, and do not keep copies
+func serveStoredFile(c *gin.Context, name, contentType string, size int64, file io.Reader) {
+	c.DataFromReader(http.StatusOK, size, contentType, file, map[string]string{
+		"Content-Disposition":     mime.FormatMediaType("inline", map[string]string{"filename": attachmentName(name)}),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'",
+		"Cache-Control":           "private, no-store",
+	})
+}
+
+// UploadPaymentProof attaches a deposit slip to the customer's pending bank transfer
+// @Summary Upload bank deposit slip
+// @Tags payment-proofs
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Transaction ID"
+// @Param file formData file true "Deposit slip (JPEG, PNG, WebP or PDF)"
+// @Param bank_name formData string false "Bank the money was deposited at"
+// @Param deposit_reference formData string false "Voucher or transfer reference"
+// @Param deposited_at formData string false "Deposit date (YYYY-MM-DD)"
+// @Success 201 {object} utils.SuccessResponse{data=models.PaymentProof}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 413 {object} utils.ErrorResponse
+// @Router /transactions/{id}/payment-proofs [post]
+func (h *PaymentProofHandler) UploadPaymentProof(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var form models.PaymentProofUpload
+	header, file, ok := bindUpload(c, h.maxSize, &form)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	proof, err := h.paymentProofService.Upload(c.Request.Context(), id, userID, attachmentName(header.Filename), file, &form)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, proof)
+}
+
+// GetTransactionProofs lists the deposit slips sent for a transaction
+// @Summary List transaction deposit slips
+// @Tags payment-proofs
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.PaymentProof}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /transactions/{id}/payment-proofs [get]
+func (h *PaymentProofHandler) GetTransactionProofs(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	transaction, proofs, err := h.paymentProofService.GetTransactionProofs(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if !canAccess(c, transaction.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, proofs)
+}
+
+// GetReviewQueue lists deposit slips by status, oldest first (admin only)
+// @Summary Deposit slip review queue
+// @Tags payment-proofs
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "PENDING (default), APPROVED or REJECTED"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.PaymentProof}
+// @Router /payment-proofs [get]
+func (h *PaymentProofHandler) GetReviewQueue(c *gin.Context) {
+	proofs, err := h.paymentProofService.GetReviewQueue(c.Request.Context(), strings.ToUpper(c.Query("status")))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, proofs)
+}
+
+// GetPaymentProofFile serves a deposit slip to its uploader or an admin
+// @Summary Download deposit slip
+// @Tags payment-proofs
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "Payment proof ID"
+// @Success 200 {file} binary
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /payment-proofs/{id}/file [get]
+func (h *PaymentProofHandler) GetPaymentProofFile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment proof ID")
+		return
+	}
+
+	proof, file, err := h.paymentProofService.OpenFile(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	defer file.Close()
+	if !canAccess(c, proof.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	serveStoredFile(c, proof.FileName, proof.ContentType, proof.Size, file)
+}
+
+// ApprovePaymentProof accepts a deposit slip and marks the transaction paid (admin only)
+// @Summary Approve deposit slip
+// @Tags payment-proofs
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Payment proof ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.PaymentProof}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /payment-proofs/{id}/approve [post]
+func (h *PaymentProofHandler) ApprovePaymentProof(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment proof ID")
+		return
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	proof, err := h.paymentProofService.Approve(c.Request.Context(), id, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, proofErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, proof)
+}
+
+// RejectPaymentProof turns down a deposit slip with a reason (admin only)
+// @Summary Reject deposit slip
+// @Tags payment-proofs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Payment proof ID"
+// @Param rejection body models.RejectPaymentProofRequest true "Reason"
+// @Success 200 {object} utils.SuccessResponse{data=models.PaymentProof}
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Router /payment-proofs/{id}/reject [post]
+func (h *PaymentProofHandler) RejectPaymentProof(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment proof ID")
+		return
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req models.RejectPaymentProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	proof, err := h.paymentProofService.Reject(c.Request.Context(), id, adminID, req.Reason)
+	if err != nil {
+		utils.ErrorResponse(c, proofErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, proof)
+}
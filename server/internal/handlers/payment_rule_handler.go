@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PaymentRuleHandler struct {
+	paymentRuleService services.PaymentRuleService
+}
+
+func NewPaymentRuleHandler(paymentRuleService services.PaymentRuleService) *PaymentRuleHandler {
+	return &PaymentRuleHandler{paymentRuleService: paymentRuleService}
+}
+
+// CreatePaymentRule endpoint
+func (h *PaymentRuleHandler) CreatePaymentRule(c *gin.Context) {
+	var req models.PaymentMethodRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.paymentRuleService.CreateRule(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, rule)
+}
+
+// GetAllPaymentRules endpoint, optionally filtered by ?payment_method=
+func (h *PaymentRuleHandler) GetAllPaymentRules(c *gin.Context) {
+	rules, err := h.paymentRuleService.GetAllRules(c.Request.Context(), c.Query("payment_method"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, rules)
+}
+
+// GetPaymentRuleByID endpoint
+func (h *PaymentRuleHandler) GetPaymentRuleByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment rule ID")
+		return
+	}
+
+	rule, err := h.paymentRuleService.GetRuleByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Payment rule not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, rule)
+}
+
+// UpdatePaymentRule endpoint
+func (h *PaymentRuleHandler) UpdatePaymentRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment rule ID")
+		return
+	}
+
+	var req models.PaymentMethodRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := h.paymentRuleService.UpdateRule(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, rule)
+}
+
+// DeletePaymentRule endpoint
+func (h *PaymentRuleHandler) DeletePaymentRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment rule ID")
+		return
+	}
+
+	if err := h.paymentRuleService.DeleteRule(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Payment rule deleted successfully"})
+}
+
+// GetOrderPaymentMethods endpoint lists the payment methods and whether each can pay for the order
+func (h *PaymentRuleHandler) GetOrderPaymentMethods(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	order, options, err := h.paymentRuleService.GetOrderPaymentMethods(c.Request.Context(), orderID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if !canAccess(c, order.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"order_id": order.ID, "payment_methods": options})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PromotionHandler struct {
+	promotionService services.PromotionService
+}
+
+func NewPromotionHandler(promotionService services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+// CreatePromotion endpoint
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	promotion, err := h.promotionService.CreatePromotion(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, promotion)
+}
+
+// GetAllPromotions endpoint
+func (h *PromotionHandler) GetAllPromotions(c *gin.Context) {
+	promotions, err := h.promotionService.GetAllPromotions(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, promotions)
+}
+
+// GetPromotionByID endpoint
+func (h *PromotionHandler) GetPromotionByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	promotion, err := h.promotionService.GetPromotionByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Promotion not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, promotion)
+}
+
+// UpdatePromotion endpoint
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	var req models.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	promotion, err := h.promotionService.UpdatePromotion(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, promotion)
+}
+
+// DeletePromotion endpoint
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	if err := h.promotionService.DeletePromotion(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
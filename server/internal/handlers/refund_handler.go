@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RefundHandler struct {
+	refundService      services.RefundService
+	transactionService services.TransactionService
+}
+
+func NewRefundHandler(refundService services.RefundService, transactionService services.TransactionService) *RefundHandler {
+	return &RefundHandler{refundService: refundService, transactionService: transactionService}
+}
+
+// CreateRefund refunds a transaction to store credit or the original method (admin only)
+// @Summary Refund a transaction
+// @Tags refunds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Transaction ID"
+// @Param refund body models.CreateRefundRequest true "Refund"
+// @Success 201 {object} utils.SuccessResponse{data=models.Refund}
+// @Router /transactions/{id}/refunds [post]
+func (h *RefundHandler) CreateRefund(c *gin.Context) {
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	var req models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	refund, err := h.refundService.CreateRefund(c.Request.Context(), transactionID, &req, adminID)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, repositories.ErrRefundExceedsAmount) {
+			status = http.StatusConflict
+		}
+		utils.ErrorResponse(c, status, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, refund)
+}
+
+// GetTransactionRefunds lists refunds of a transaction
+// @Summary Get refunds of a transaction
+// @Tags refunds
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Refund}
+// @Router /transactions/{id}/refunds [get]
+func (h *RefundHandler) GetTransactionRefunds(c *gin.Context) {
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	transaction, err := h.transactionService.GetTransactionByID(c.Request.Context(), transactionID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Transaction not found")
+		return
+	}
+	if !canAccess(c, transaction.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	refunds, err := h.refundService.GetTransactionRefunds(c.Request.Context(), transactionID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, refunds)
+}
+
+// GetAllRefunds lists refunds, optionally by status (admin only)
+// @Summary Get all refunds
+// @Tags refunds
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "PENDING or COMPLETED"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Refund}
+// @Router /refunds [get]
+func (h *RefundHandler) GetAllRefunds(c *gin.Context) {
+	refunds, err := h.refundService.GetAllRefunds(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, refunds)
+}
+
+// CompleteRefund marks a refund to the original payment method as paid out (admin only)
+// @Summary Complete a pending refund
+// @Tags refunds
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Refund ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.Refund}
+// @Router /refunds/{id}/complete [post]
+func (h *RefundHandler) CompleteRefund(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid refund ID")
+		return
+	}
+
+	refund, err := h.refundService.CompleteRefund(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, refund)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	reportService services.ReportService
+}
+
+func NewReportHandler(reportService services.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// GetSalesReport returns invoiced sales grouped by month or fiscal year (admin only)
+// @Summary Sales report
+// @Tags reports
+// @Produce json
+// @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs; applies to from/to and month grouping"
+// @Param group_by query string false "month (default) or fiscal_year"
+// @Param fiscal_year query string false "Fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date YYYY-MM-DD (inclusive)"
+// @Param to query string false "End date YYYY-MM-DD (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.SalesReportRow}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /reports/sales [get]
+func (h *ReportHandler) GetSalesReport(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", models.ReportGroupByMonth)
+	report, err := h.reportService.SalesReport(c.Request.Context(), from, to, groupBy, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"calendar": calendar,
+		"group_by": groupBy,
+		"rows":     report,
+	})
+}
+
+// GetSettlementReport returns gross, fees, refunds and net payout per day and gateway (admin only)
+// @Summary Gateway settlement report
+// @Tags reports
+// @Produce json
+// @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs; applies to from/to and row dates"
+// @Param fiscal_year query string false "Fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date YYYY-MM-DD (inclusive)"
+// @Param to query string false "End date YYYY-MM-DD (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.SettlementReportRow}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /reports/settlements [get]
+func (h *ReportHandler) GetSettlementReport(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.reportService.SettlementReport(c.Request.Context(), from, to, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"calendar": calendar,
+		"rows":     report,
+	})
+}
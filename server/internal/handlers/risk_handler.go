@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RiskHandler struct {
+	riskService services.RiskService
+}
+
+func NewRiskHandler(riskService services.RiskService) *RiskHandler {
+	return &RiskHandler{riskService: riskService}
+}
+
+// GetAssessments lists fraud check decisions, newest first (admin only)
+// @Summary List risk assessments
+// @Tags risk
+// @Produce json
+// @Security BearerAuth
+// @Param decision query string false "ALLOW, REVIEW or BLOCK"
+// @Param user_id query string false "User ID"
+// @Param calendar query string false "ad (default) or bs"
+// @Param fiscal_year query string false "BS fiscal year, e.g. 2082/83"
+// @Param from query string false "Start date, YYYY-MM-DD in the requested calendar"
+// @Param to query string false "End date (inclusive)"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.RiskAssessment}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /risk/assessments [get]
+func (h *RiskHandler) GetAssessments(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, to, err := dateRangeParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var userID *uuid.UUID
+	if s := c.Query("user_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user_id")
+			return
+		}
+		userID = &id
+	}
+
+	assessments, err := h.riskService.GetAssessments(c.Request.Context(), strings.ToUpper(c.Query("decision")), userID, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, assessments)
+}
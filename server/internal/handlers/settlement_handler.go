@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxStatementSize caps uploaded settlement statements
+const maxStatementSize = 10 << 20
+
+type SettlementHandler struct {
+	settlementService services.SettlementService
+}
+
+func NewSettlementHandler(settlementService services.SettlementService) *SettlementHandler {
+	return &SettlementHandler{settlementService: settlementService}
+}
+
+// ImportEsewaStatement uploads an eSewa settlement statement CSV and reconciles it (admin only)
+// @Summary Import eSewa settlement statement
+// @Tags settlements
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Statement CSV exported from the eSewa merchant portal"
+// @Success 201 {object} utils.SuccessResponse{data=models.SettlementImport}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /settlements/esewa [post]
+func (h *SettlementHandler) ImportEsewaStatement(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Statement file is required")
+		return
+	}
+	if header.Size > maxStatementSize {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Statement file is too large")
+		return
+	}
+
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	settlement, err := h.settlementService.ImportEsewaStatement(c.Request.Context(), header.Filename, file, &adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, settlement)
+}
+
+// GetImports lists imported settlement statements with their totals (admin only)
+// @Summary List settlement imports
+// @Tags settlements
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.SettlementImport}
+// @Router /settlements [get]
+func (h *SettlementHandler) GetImports(c *gin.Context) {
+	settlements, err := h.settlementService.GetImports(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, settlements)
+}
+
+// GetImport returns a settlement import with its reconciliation report (admin only)
+// @Summary Get settlement reconciliation report
+// @Tags settlements
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Settlement import ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.SettlementImport}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /settlements/{id} [get]
+func (h *SettlementHandler) GetImport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid settlement import ID")
+		return
+	}
+
+	settlement, err := h.settlementService.GetImport(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Settlement import not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, settlement)
+}
+
+// GetReviewQueue lists transactions flagged for review by reconciliation (admin only)
+// @Summary List transactions needing review
+// @Tags settlements
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.Transaction}
+// @Router /settlements/review [get]
+func (h *SettlementHandler) GetReviewQueue(c *gin.Context) {
+	transactions, err := h.settlementService.GetReviewQueue(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, transactions)
+}
+
+// ResolveReview clears a transaction's review flag (admin only)
+// @Summary Resolve a flagged transaction
+// @Tags settlements
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param transactionId path string true "Transaction ID"
+// @Param resolution body models.ResolveReviewRequest true "Resolution note"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /settlements/review/{transactionId}/resolve [post]
+func (h *SettlementHandler) ResolveReview(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("transactionId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	var req models.ResolveReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.settlementService.ResolveReview(c.Request.Context(), id, req.Note); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Review resolved"})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bookstore/internal/events"
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StreamHandler struct {
+	feed               *events.Broadcaster
+	transactionService services.TransactionService
+	orderService       *services.OrderService
+}
+
+func NewStreamHandler(feed *events.Broadcaster, transactionService services.TransactionService, orderService *services.OrderService) *StreamHandler {
+	return &StreamHandler{feed: feed, transactionService: transactionService, orderService: orderService}
+}
+
+// streamKeepAlive is how often a comment is sent on an idle stream so
+// proxies do not close it
+const streamKeepAlive = 15 * time.Second
+
+// StreamTransaction pushes status changes for a transaction to its owner
+// @Summary Stream a transaction's status
+// @Description Server-Sent Events. The first event is a snapshot of the current state.
+// @Tags transactions
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} models.StatusUpdate
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /transactions/{id}/stream [get]
+func (h *StreamHandler) StreamTransaction(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	// Listen before reading the current state so nothing falls in between
+	listener := h.feed.Listen(lastEventID(c), models.TransactionStatusTopic(id))
+	defer listener.Close()
+
+	transaction, err := h.transactionService.GetTransactionByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Transaction not found")
+		return
+	}
+	if !canAccess(c, transaction.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	streamEvents(c, listener, models.TransactionStatusUpdate(models.StatusEventSnapshot, transaction))
+}
+
+// StreamOrder pushes status changes for an order and its payment to its owner
+// @Summary Stream an order's status
+// @Description Server-Sent Events. The first event is a snapshot of the current state.
+// @Tags orders
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.StatusUpdate
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /orders/{id}/stream [get]
+func (h *StreamHandler) StreamOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	listener := h.feed.Listen(lastEventID(c), models.OrderStatusTopic(id))
+	defer listener.Close()
+
+	order, err := h.orderService.GetOrderByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Order not found")
+		return
+	}
+	if !canAccess(c, order.UserID) {
+		utils.ErrorResponse(c, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	snapshot := models.OrderStatusUpdate(models.StatusEventSnapshot, order)
+	if transaction, err := h.transactionService.GetTransactionByOrderID(c.Request.Context(), id); err == nil {
+		snapshot = models.TransactionStatusUpdate(models.StatusEventSnapshot, transaction)
+	}
+	streamEvents(c, listener, snapshot)
+}
+
+// StreamAdminFeed pushes new orders and payment outcomes store-wide (admin only)
+// @Summary Stream store-wide order and payment events
+// @Description Server-Sent Events. Send Last-Event-ID to resume; a reset event means updates were missed and lists should be reloaded.
+// @Tags streams
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {object} models.StatusUpdate
+// @Router /streams/admin [get]
+func (h *StreamHandler) StreamAdminFeed(c *gin.Context) {
+	listener := h.feed.Listen(lastEventID(c), models.StatusTopicAdmin)
+	defer listener.Close()
+
+	streamEvents(c, listener, nil)
+}
+
+// IssueStreamTicket gives the caller a ticket for opening a status stream
+// @Summary Get a stream ticket
+// @Description EventSource cannot send an Authorization header, so streams are opened with ?ticket= instead. A ticket only opens streams and expires after a minute; get a new one for every connection.
+// @Tags streams
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse
+// @Router /streams/ticket [post]
+func (h *StreamHandler) IssueStreamTicket(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+	role, _ := c.Get("role")
+	roleName, _ := role.(string)
+
+	ticket, expiresAt, err := utils.GenerateStreamTicket(userID.String(), roleName)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to issue stream ticket")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{
+		"ticket":     ticket,
+		"expires_at": expiresAt,
+	})
+}
+
+// lastEventID reads the ID a reconnecting EventSource sends. Clients that
+// cannot set headers may pass last_event_id instead.
+func lastEventID(c *gin.Context) uint64 {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	id, _ := strconv.ParseUint(value, 10, 64)
+	return id
+}
+
+// streamEvents writes the snapshot, any backlog and then live messages as
+// Server-Sent Events until the client goes away or falls behind
+func streamEvents(c *gin.Context, listener *events.Listener, snapshot *models.StatusUpdate) {
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if snapshot != nil {
+		body, _ := json.Marshal(snapshot)
+		writeEvent(c, events.Message{Event: models.StatusEventSnapshot, Data: body})
+	}
+	if listener.Missed {
+		writeEvent(c, events.Message{Event: models.StatusEventReset, Data: json.RawMessage(`{}`)})
+	}
+	for _, msg := range listener.Backlog {
+		writeEvent(c, msg)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-listener.C:
+			if !ok {
+				return
+			}
+			writeEvent(c, msg)
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeEvent writes one event. Messages without an ID do not move the
+// client's Last-Event-ID.
+func writeEvent(c *gin.Context, msg events.Message) {
+	if msg.ID != 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", msg.ID)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", msg.Event, msg.Data)
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"bookstore/internal/models"
+	"bookstore/internal/repositories"
 	"bookstore/internal/services"
 	"bookstore/pkg/utils"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,6 +24,14 @@ func NewTransactionHandler(transactionService services.TransactionService) *Tran
 	}
 }
 
+// transactionErrorStatus maps payments refused by the risk checks to 403
+func transactionErrorStatus(err error) int {
+	if errors.Is(err, services.ErrRiskBlocked) {
+		return http.StatusForbidden
+	}
+	return giftCardErrorStatus(err)
+}
+
 // CreateTransaction creates a new transaction
 // @Summary Create a new transaction
 // @Tags transactions
@@ -29,6 +41,7 @@ func NewTransactionHandler(transactionService services.TransactionService) *Tran
 // @Param transaction body models.CreateTransactionRequest true "Transaction data"
 // @Success 201 {object} utils.SuccessResponse{data=models.Transaction}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /transactions [post]
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
@@ -57,31 +70,153 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.transactionService.CreateTransaction(c.Request.Context(), &req, userUUID)
+	transaction, err := h.transactionService.CreateTransaction(c.Request.Context(), &req, userUUID, clientInfo(c))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.ErrorResponse(c, transactionErrorStatus(err), err.Error())
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusCreated, transaction)
 }
 
-// GetAllTransactions gets all transactions (admin only)
-// @Summary Get all transactions
+// GetAllTransactions lists transactions a page at a time (admin only)
+// @Summary List transactions
+// @Description Keyset paginated: pass next_cursor back as cursor, with the same filters and sort, for the next page.
 // @Tags transactions
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} utils.SuccessResponse{data=[]models.Transaction}
+// @Param status query string false "Comma-separated statuses, e.g. PENDING,FAILED"
+// @Param payment_method query string false "Comma-separated methods; matches split payments by any tender"
+// @Param user_id query string false "User ID"
+// @Param order_id query string false "Order ID"
+// @Param from query string false "Created on or after, YYYY-MM-DD in the requested calendar"
+// @Param to query string false "Created on or before, YYYY-MM-DD in the requested calendar"
+// @Param min_amount query number false "Minimum amount"
+// @Param max_amount query number false "Maximum amount"
+// @Param sort query string false "created_at, -created_at (default), amount or -amount"
+// @Param limit query int false "Page size, default 50, at most 200"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param view query string false "summary (default) or full, which includes the order and gateway data"
+// @Param calendar query string false "ad (default) or bs"
+// @Success 200 {object} utils.SuccessResponse{data=models.TransactionPage}
+// @Failure 400 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /transactions [get]
 func (h *TransactionHandler) GetAllTransactions(c *gin.Context) {
-	transactions, err := h.transactionService.GetAllTransactions(c.Request.Context())
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query, err := transactionListParams(c, calendar)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.transactionService.ListTransactions(c.Request.Context(), query)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if calendar == models.CalendarBS {
+		switch items := page.Items.(type) {
+		case []models.Transaction:
+			withBSTransactionDates(items)
+		case []models.TransactionSummary:
+			for i := range items {
+				items[i].CreatedAtBS = bsDateTime(items[i].CreatedAt)
+			}
+		}
+	}
 
-	utils.SuccessResponse(c, http.StatusOK, transactions)
+	utils.SuccessResponse(c, http.StatusOK, page)
+}
+
+// transactionListParams reads the filter, sort and page parameters of GET /transactions
+func transactionListParams(c *gin.Context, calendar string) (*models.TransactionListQuery, error) {
+	q := &models.TransactionListQuery{
+		Sort: c.DefaultQuery("sort", models.TransactionSortCreatedAtDesc),
+		View: c.DefaultQuery("view", models.TransactionViewSummary),
+	}
+	switch q.Sort {
+	case models.TransactionSortCreatedAt, models.TransactionSortCreatedAtDesc, models.TransactionSortAmount, models.TransactionSortAmountDesc:
+	default:
+		return nil, errors.New("sort must be created_at, -created_at, amount or -amount")
+	}
+	if q.View != models.TransactionViewSummary && q.View != models.TransactionViewFull {
+		return nil, errors.New("view must be summary or full")
+	}
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return nil, errors.New("limit must be a positive number")
+		}
+		q.Limit = limit
+	}
+	if s := c.Query("cursor"); s != "" {
+		cursor, err := models.ParseTransactionCursor(s, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		q.After = cursor
+	}
+
+	f := &q.Filter
+	f.Statuses = upperList(c.Query("status"))
+	f.PaymentMethods = upperList(c.Query("payment_method"))
+	var err error
+	if f.UserID, err = optionalUUIDQuery(c, "user_id"); err != nil {
+		return nil, err
+	}
+	if f.OrderID, err = optionalUUIDQuery(c, "order_id"); err != nil {
+		return nil, err
+	}
+	if f.From, f.To, err = optionalDateRange(c, calendar); err != nil {
+		return nil, err
+	}
+	if f.MinAmount, err = optionalFloatQuery(c, "min_amount"); err != nil {
+		return nil, err
+	}
+	if f.MaxAmount, err = optionalFloatQuery(c, "max_amount"); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func optionalUUIDQuery(c *gin.Context, name string) (*uuid.UUID, error) {
+	s := c.Query(name)
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, errors.New("invalid " + name)
+	}
+	return &id, nil
+}
+
+func optionalFloatQuery(c *gin.Context, name string) (*float64, error) {
+	s := c.Query(name)
+	if s == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, errors.New("invalid " + name)
+	}
+	return &value, nil
+}
+
+// upperList splits a comma-separated query value, e.g. "pending,failed"
+func upperList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // GetTransactionByID gets a specific transaction by ID
@@ -131,10 +266,17 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 // @Tags transactions
 // @Produce json
 // @Security BearerAuth
+// @Param calendar query string false "ad (default) or bs"
 // @Success 200 {object} utils.SuccessResponse{data=[]models.Transaction}
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /transactions/user/my-transactions [get]
 func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
+	calendar, err := calendarParam(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	userID, exists := c.Get("user_id")
 	if !exists {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
@@ -159,6 +301,9 @@ func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if calendar == models.CalendarBS {
+		withBSTransactionDates(transactions)
+	}
 
 	utils.SuccessResponse(c, http.StatusOK, transactions)
 }
@@ -215,6 +360,7 @@ func (h *TransactionHandler) GetTransactionByOrderID(c *gin.Context) {
 // @Param body body models.TransactionUpdateRequest true "Status update"
 // @Success 200 {object} utils.SuccessResponse{data=models.Transaction}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /transactions/{id}/status [put]
 func (h *TransactionHandler) UpdateTransactionStatus(c *gin.Context) {
@@ -232,7 +378,11 @@ func (h *TransactionHandler) UpdateTransactionStatus(c *gin.Context) {
 
 	transaction, err := h.transactionService.UpdateTransactionStatus(c.Request.Context(), id, &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		status := http.StatusBadRequest
+		if errors.Is(err, repositories.ErrTransactionFinal) {
+			status = http.StatusConflict
+		}
+		utils.ErrorResponse(c, status, err.Error())
 		return
 	}
 
@@ -331,6 +481,7 @@ func (h *TransactionHandler) InitiateEsewaPayment(c *gin.Context) {
 // @Param body body models.EsewaResponseData true "eSewa response data"
 // @Success 200 {object} utils.SuccessResponse{data=models.Transaction}
 // @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /transactions/esewa/verify [post]
 func (h *TransactionHandler) VerifyEsewaPayment(c *gin.Context) {
@@ -340,9 +491,15 @@ func (h *TransactionHandler) VerifyEsewaPayment(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.transactionService.VerifyEsewaPayment(c.Request.Context(), &esewaResponse)
+	userID, err := currentUserID(c)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	transaction, err := h.transactionService.VerifyEsewaPayment(c.Request.Context(), &esewaResponse, userID, clientInfo(c))
+	if err != nil {
+		utils.ErrorResponse(c, transactionErrorStatus(err), err.Error())
 		return
 	}
 
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WalletHandler struct {
+	walletService services.WalletService
+}
+
+func NewWalletHandler(walletService services.WalletService) *WalletHandler {
+	return &WalletHandler{walletService: walletService}
+}
+
+// GetMyWallet returns the current customer's store credit balance and ledger
+// @Summary Get current user's store credit wallet
+// @Tags wallet
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=models.Wallet}
+// @Router /wallet [get]
+func (h *WalletHandler) GetMyWallet(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	wallet, err := h.walletService.GetWallet(c.Request.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, wallet)
+}
+
+// GetUserWallet returns a customer's wallet (admin only)
+// @Summary Get a user's store credit wallet
+// @Tags wallet
+// @Produce json
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.Wallet}
+// @Router /wallet/users/{userId} [get]
+func (h *WalletHandler) GetUserWallet(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	wallet, err := h.walletService.GetWallet(c.Request.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, wallet)
+}
+
+// IssueCredit adds goodwill store credit to a customer's wallet (admin only)
+// @Summary Issue goodwill store credit
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param credit body models.WalletCreditRequest true "Credit"
+// @Success 201 {object} utils.SuccessResponse{data=models.WalletEntry}
+// @Router /wallet/credits [post]
+func (h *WalletHandler) IssueCredit(c *gin.Context) {
+	var req models.WalletCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	entry, err := h.walletService.IssueCredit(c.Request.Context(), &req, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, entry)
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/services"
+	"bookstore/pkg/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookService
+}
+
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// CreateEndpoint registers a webhook endpoint and returns its signing secret once (admin only)
+// @Summary Create webhook endpoint
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param endpoint body models.WebhookEndpointRequest true "Endpoint"
+// @Success 201 {object} utils.SuccessResponse{data=models.WebhookSecretResponse}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /webhooks/endpoints [post]
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	var req models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := h.webhookService.CreateEndpoint(c.Request.Context(), &req, adminID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, created)
+}
+
+// GetEndpoints lists webhook endpoints (admin only)
+// @Summary List webhook endpoints
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.SuccessResponse{data=[]models.WebhookEndpoint}
+// @Router /webhooks/endpoints [get]
+func (h *WebhookHandler) GetEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookService.GetEndpoints(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, endpoints)
+}
+
+// GetEndpoint returns a webhook endpoint (admin only)
+// @Summary Get webhook endpoint
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.WebhookEndpoint}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /webhooks/endpoints/{id} [get]
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Webhook endpoint not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, endpoint)
+}
+
+// UpdateEndpoint changes an endpoint's URL, subscriptions or active flag (admin only)
+// @Summary Update webhook endpoint
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Endpoint ID"
+// @Param endpoint body models.WebhookEndpointRequest true "Endpoint"
+// @Success 200 {object} utils.SuccessResponse{data=models.WebhookEndpoint}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /webhooks/endpoints/{id} [put]
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	var req models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(c.Request.Context(), id, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, endpoint)
+}
+
+// RotateSecret issues a new signing secret for an endpoint (admin only)
+// @Summary Rotate webhook secret
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} utils.SuccessResponse{data=models.WebhookSecretResponse}
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /webhooks/endpoints/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	rotated, err := h.webhookService.RotateSecret(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, rotated)
+}
+
+// DeleteEndpoint removes an endpoint with its delivery history (admin only)
+// @Summary Delete webhook endpoint
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Router /webhooks/endpoints/{id} [delete]
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Webhook endpoint deleted successfully"})
+}
+
+// GetDeliveries lists recent webhook deliveries, newest first (admin only)
+// @Summary List webhook deliveries
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "PENDING, DELIVERED or DEAD"
+// @Param endpoint_id query string false "Endpoint ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.WebhookDelivery}
+// @Failure 400 {object} utils.ErrorResponse
+// @Router /webhooks/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	var endpointID *uuid.UUID
+	if s := c.Query("endpoint_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid endpoint_id")
+			return
+		}
+		endpointID = &id
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), strings.ToUpper(c.Query("status")), endpointID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, deliveries)
+}
+
+// GetDelivery returns a delivery with every attempt made for it (admin only)
+// @Summary Get webhook delivery
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /webhooks/deliveries/{id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, attempts, err := h.webhookService.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"delivery": delivery, "attempts": attempts})
+}
+
+// Redeliver queues a delivery to be sent again now, including dead-lettered ones (admin only)
+// @Summary Redeliver webhook
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /webhooks/deliveries/{id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	if err := h.webhookService.Redeliver(c.Request.Context(), id); err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, gin.H{"message": "Webhook delivery queued"})
+}
@@ -13,6 +13,7 @@ type Book struct {
 	Price       float64   `gorm:"not null" json:"price"`
 	Stock       int       `gorm:"not null;default:0" json:"stock"`
 	Description string    `gorm:"type:text" json:"description"`
+	ProductType string    `gorm:"type:varchar(20);not null;default:'BOOK'" json:"product_type" binding:"omitempty,oneof=BOOK GIFT_CARD"` // GIFT_CARD items issue a gift card of Price each
 
 	CategoryID uuid.UUID `gorm:"type:uuid;not null" json:"category_id"`
 	Category   Category  `json:"category"`
@@ -20,3 +21,9 @@ type Book struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// Product type constants
+const (
+	ProductTypeBook     = "BOOK"
+	ProductTypeGiftCard = "GIFT_CARD"
+)
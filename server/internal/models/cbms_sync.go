@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CBMSSyncJob tracks reporting one invoice or credit note to the IRD CBMS.
+// Jobs are retried with backoff until SYNCED or, after too many attempts, FAILED.
+type CBMSSyncJob struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DocumentType   string     `gorm:"type:varchar(20);not null" json:"document_type"` // INVOICE, CREDIT_NOTE
+	DocumentID     uuid.UUID  `gorm:"type:uuid;not null" json:"document_id"`
+	DocumentNumber string     `gorm:"type:varchar(30);not null" json:"document_number"`
+	InvoiceID      uuid.UUID  `gorm:"type:uuid;not null" json:"invoice_id"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"` // PENDING, SYNCED, FAILED
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LastError      string     `gorm:"type:text" json:"last_error"`
+	SyncedAt       *time.Time `json:"synced_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CBMS sync status constants
+const (
+	CBMSSyncStatusPending = "PENDING"
+	CBMSSyncStatusSynced  = "SYNCED"
+	CBMSSyncStatusFailed  = "FAILED"
+)
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryAgent is a courier rider who delivers orders and collects cash on delivery
+type DeliveryAgent struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Phone     string    `gorm:"type:varchar(20)" json:"phone"`
+	Courier   string    `gorm:"type:varchar(100)" json:"courier"` // Courier company, empty for in-house riders
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CODDelivery tracks the cash collection for one CASH transaction. The
+// transaction succeeds once the full amount is collected and fails if the
+// customer refuses the delivery.
+type CODDelivery struct {
+	ID              uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TransactionID   uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex" json:"transaction_id"`
+	OrderID         uuid.UUID      `gorm:"type:uuid;not null" json:"order_id"`
+	AgentID         uuid.UUID      `gorm:"type:uuid;not null" json:"agent_id"`
+	Agent           *DeliveryAgent `gorm:"foreignKey:AgentID" json:"agent,omitempty"`
+	Status          string         `gorm:"type:varchar(30);not null" json:"status"` // ASSIGNED, PARTIALLY_COLLECTED, COLLECTED, REFUSED
+	ExpectedAmount  float64        `gorm:"type:decimal(10,2);not null" json:"expected_amount"`
+	CollectedAmount float64        `gorm:"type:decimal(10,2);not null;default:0" json:"collected_amount"`
+	RefusalReason   string         `gorm:"type:varchar(255)" json:"refusal_reason,omitempty"`
+	AssignedAt      time.Time      `gorm:"not null" json:"assigned_at"`
+	CompletedAt     *time.Time     `json:"completed_at,omitempty"` // Fully collected or refused
+
+	Collections []CODCollection `gorm:"foreignKey:DeliveryID" json:"collections,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Outstanding is the cash still to collect
+func (d *CODDelivery) Outstanding() float64 {
+	return d.ExpectedAmount - d.CollectedAmount
+}
+
+// CODCollection is cash taken from the customer on one delivery attempt.
+// It is remitted to us with the agent's daily remittance.
+type CODCollection struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DeliveryID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"delivery_id"`
+	AgentID      uuid.UUID  `gorm:"type:uuid;not null" json:"agent_id"`
+	Amount       float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CollectedBy  string     `gorm:"type:varchar(100);not null" json:"collected_by"` // Who took the cash, defaults to the agent
+	CollectedAt  time.Time  `gorm:"not null" json:"collected_at"`
+	Note         string     `gorm:"type:varchar(255)" json:"note,omitempty"`
+	RemittanceID *uuid.UUID `gorm:"type:uuid" json:"remittance_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CODRemittance is the cash an agent hands over for a day, reconciled
+// against the collections not remitted before
+type CODRemittance struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	AgentID        uuid.UUID      `gorm:"type:uuid;not null" json:"agent_id"`
+	Agent          *DeliveryAgent `gorm:"foreignKey:AgentID" json:"agent,omitempty"`
+	RemittanceDate time.Time      `gorm:"not null" json:"remittance_date"`                    // Collections up to the end of this day are included
+	Amount         float64        `gorm:"type:decimal(10,2);not null" json:"amount"`          // Cash handed over
+	ExpectedAmount float64        `gorm:"type:decimal(10,2);not null" json:"expected_amount"` // Sum of the included collections
+	Difference     float64        `gorm:"type:decimal(10,2);not null" json:"difference"`      // Amount - ExpectedAmount
+	Status         string         `gorm:"type:varchar(20);not null" json:"status"`            // BALANCED, SHORT, OVER
+	Note           string         `gorm:"type:varchar(255)" json:"note,omitempty"`
+	RecordedBy     *uuid.UUID     `gorm:"type:uuid" json:"recorded_by,omitempty"`
+
+	Collections []CODCollection `gorm:"foreignKey:RemittanceID" json:"collections,omitempty"`
+
+	CreatedAt        time.Time `json:"created_at"`
+	RemittanceDateBS string    `gorm:"-" json:"remittance_date_bs,omitempty"` // Filled when ?calendar=bs
+}
+
+// COD delivery statuses
+const (
+	CODStatusAssigned           = "ASSIGNED"
+	CODStatusPartiallyCollected = "PARTIALLY_COLLECTED"
+	CODStatusCollected          = "COLLECTED"
+	CODStatusRefused            = "REFUSED"
+	CODStatusCancelled          = "CANCELLED" // The transaction failed or was cancelled while the delivery was open
+)
+
+// COD remittance statuses
+const (
+	RemittanceBalanced = "BALANCED"
+	RemittanceShort    = "SHORT"
+	RemittanceOver     = "OVER"
+)
+
+// COD discrepancy types
+const (
+	CODDiscrepancyShort      = "SHORT"
+	CODDiscrepancyOver       = "OVER"
+	CODDiscrepancyUnremitted = "UNREMITTED" // Collected but not handed over by the next day
+)
+
+// CODDiscrepancy is a day on which an agent's cash did not match their collections
+type CODDiscrepancy struct {
+	Type         string     `json:"type"` // SHORT, OVER, UNREMITTED
+	Date         string     `json:"date"` // Remittance or collection date, in the requested calendar
+	AgentID      uuid.UUID  `json:"agent_id"`
+	AgentName    string     `json:"agent_name"`
+	RemittanceID *uuid.UUID `json:"remittance_id,omitempty"`
+	Expected     float64    `json:"expected"`
+	Remitted     float64    `json:"remitted"`
+	Difference   float64    `json:"difference"`
+	Collections  int        `json:"collections"`
+}
+
+type DeliveryAgentRequest struct {
+	Name    string `json:"name" binding:"required,max=100"`
+	Phone   string `json:"phone" binding:"max=20"`
+	Courier string `json:"courier" binding:"max=100"`
+	Active  *bool  `json:"active"`
+}
+
+type AssignCODRequest struct {
+	TransactionID uuid.UUID `json:"transaction_id" binding:"required"`
+	AgentID       uuid.UUID `json:"agent_id" binding:"required"`
+}
+
+type CODCollectionRequest struct {
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	CollectedBy string     `json:"collected_by" binding:"max=100"` // Defaults to the agent's name
+	CollectedAt *time.Time `json:"collected_at"`                   // Defaults to now
+	Note        string     `json:"note" binding:"max=255"`
+}
+
+type RefuseCODRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type CODRemittanceRequest struct {
+	AgentID uuid.UUID `json:"agent_id" binding:"required"`
+	Date    string    `json:"date" binding:"required"` // YYYY-MM-DD in ?calendar=, the day being remitted
+	Amount  float64   `json:"amount" binding:"min=0"`
+	Note    string    `json:"note" binding:"max=255"`
+}
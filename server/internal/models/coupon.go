@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Coupon struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Code          string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"` // Stored upper-case
+	Description   string     `gorm:"type:varchar(255)" json:"description"`
+	DiscountType  string     `gorm:"type:varchar(20);not null" json:"discount_type"`               // PERCENTAGE, FIXED
+	Value         float64    `gorm:"type:decimal(10,2);not null" json:"value"`                     // Percent (0-100) or rupees
+	MaxDiscount   float64    `gorm:"type:decimal(10,2);not null;default:0" json:"max_discount"`    // Cap for percentage coupons, 0 = none
+	MinOrderValue float64    `gorm:"type:decimal(10,2);not null;default:0" json:"min_order_value"` // Compared with the order subtotal
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	UsageLimit    int        `gorm:"not null;default:0" json:"usage_limit"`    // Total redemptions, 0 = unlimited
+	PerUserLimit  int        `gorm:"not null;default:0" json:"per_user_limit"` // Redemptions per customer, 0 = unlimited
+	Active        bool       `gorm:"not null;default:true" json:"active"`
+
+	// Restrictions; when both are empty the coupon applies to every item
+	Categories []Category `gorm:"many2many:coupon_categories" json:"categories"`
+	Books      []Book     `gorm:"many2many:coupon_books" json:"books"`
+
+	UsedCount int64 `gorm:"-" json:"used_count"` // Redemptions on orders that are not cancelled
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CouponRedemption records a coupon applied to an order. An order holds at most one coupon.
+type CouponRedemption struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CouponID  uuid.UUID `gorm:"type:uuid;not null" json:"coupon_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	OrderID   uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"order_id"`
+	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Coupon discount type constants
+const (
+	CouponTypePercentage = "PERCENTAGE"
+	CouponTypeFixed      = "FIXED"
+)
+
+type CouponRequest struct {
+	Code          string      `json:"code" binding:"required,max=50"`
+	Description   string      `json:"description"`
+	DiscountType  string      `json:"discount_type" binding:"required,oneof=PERCENTAGE FIXED"`
+	Value         float64     `json:"value" binding:"required,gt=0"`
+	MaxDiscount   float64     `json:"max_discount" binding:"min=0"`
+	MinOrderValue float64     `json:"min_order_value" binding:"min=0"`
+	StartsAt      *time.Time  `json:"starts_at"`
+	ExpiresAt     *time.Time  `json:"expires_at"`
+	UsageLimit    int         `json:"usage_limit" binding:"min=0"`
+	PerUserLimit  int         `json:"per_user_limit" binding:"min=0"`
+	Active        *bool       `json:"active"`
+	CategoryIDs   []uuid.UUID `json:"category_ids"`
+	BookIDs       []uuid.UUID `json:"book_ids"`
+}
+
+type ApplyCouponRequest struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dispute is a chargeback or dispute raised by eSewa or a card issuer against
+// a successful transaction. Evidence must be submitted before EvidenceDueAt.
+// A LOST dispute reverses the disputed amount through a CHARGEBACK refund.
+type Dispute struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TransactionID uuid.UUID `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	OrderID       uuid.UUID `gorm:"type:uuid;not null" json:"order_id"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+
+	CaseReference string    `gorm:"type:varchar(100)" json:"case_reference"` // The gateway's or issuer's case number
+	ReasonCode    string    `gorm:"type:varchar(50);not null" json:"reason_code"`
+	Description   string    `gorm:"type:text" json:"description"`
+	Amount        float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	OpenedAt      time.Time `gorm:"not null" json:"opened_at"`
+	EvidenceDueAt time.Time `gorm:"not null" json:"evidence_due_at"`
+
+	Status              string     `gorm:"type:varchar(20);not null;default:'OPEN'" json:"status"` // OPEN, EVIDENCE_SUBMITTED, WON, LOST
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	ResolutionNote      string     `gorm:"type:text" json:"resolution_note,omitempty"`
+	ReversalID          *uuid.UUID `gorm:"type:uuid" json:"reversal_id,omitempty"` // CHARGEBACK refund recorded when LOST
+	CreatedBy           *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+
+	Transaction *Transaction      `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	Evidence    []DisputeEvidence `gorm:"foreignKey:DisputeID" json:"evidence,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Dispute statuses
+const (
+	DisputeStatusOpen              = "OPEN"
+	DisputeStatusEvidenceSubmitted = "EVIDENCE_SUBMITTED"
+	DisputeStatusWon               = "WON"
+	DisputeStatusLost              = "LOST"
+)
+
+// DisputeEvidence is a file attached to a dispute, such as a delivery receipt
+type DisputeEvidence struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DisputeID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"dispute_id"`
+	FileKey     string     `gorm:"type:varchar(255);not null" json:"-"` // Location in the file store
+	FileName    string     `gorm:"type:varchar(255)" json:"file_name"`
+	ContentType string     `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64      `gorm:"not null" json:"size"`
+	Description string     `gorm:"type:varchar(255)" json:"description"`
+	UploadedBy  *uuid.UUID `gorm:"type:uuid" json:"uploaded_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName keeps evidence uncountable rather than GORM's "dispute_evidences"
+func (DisputeEvidence) TableName() string {
+	return "dispute_evidence"
+}
+
+// DisputeNotification alerts admins to a dispute whose evidence deadline is
+// near or has passed. Each kind is raised at most once per dispute.
+type DisputeNotification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DisputeID uuid.UUID  `gorm:"type:uuid;not null" json:"dispute_id"`
+	Kind      string     `gorm:"type:varchar(30);not null" json:"kind"` // DEADLINE_APPROACHING, DEADLINE_PASSED
+	Message   string     `gorm:"type:varchar(255);not null" json:"message"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	Dispute *Dispute `gorm:"foreignKey:DisputeID" json:"dispute,omitempty"`
+}
+
+// Dispute notification kinds
+const (
+	DisputeDeadlineApproaching = "DEADLINE_APPROACHING"
+	DisputeDeadlinePassed      = "DEADLINE_PASSED"
+)
+
+type CreateDisputeRequest struct {
+	TransactionID uuid.UUID  `json:"transaction_id" binding:"required"`
+	CaseReference string     `json:"case_reference" binding:"max=100"`
+	ReasonCode    string     `json:"reason_code" binding:"required,max=50"`
+	Description   string     `json:"description"`
+	Amount        float64    `json:"amount" binding:"gte=0"` // Defaults to the transaction amount
+	OpenedAt      *time.Time `json:"opened_at"`              // Defaults to now
+	EvidenceDueAt time.Time  `json:"evidence_due_at" binding:"required"`
+}
+
+type ResolveDisputeRequest struct {
+	Outcome string `json:"outcome" binding:"required,oneof=WON LOST"`
+	Note    string `json:"note"`
+}
+
+// DisputeEvidenceUpload is the form sent with an evidence file
+type DisputeEvidenceUpload struct {
+	Description string `form:"description" binding:"max=255"`
+}
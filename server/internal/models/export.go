@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Export kinds
+const (
+	ExportKindTransactions = "transactions"
+	ExportKindOrders       = "orders"
+)
+
+// Export file formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// Export job status constants
+const (
+	ExportStatusPending = "PENDING"
+	ExportStatusRunning = "RUNNING"
+	ExportStatusDone    = "DONE"
+	ExportStatusFailed  = "FAILED"
+	ExportStatusExpired = "EXPIRED" // The file was deleted after the retention period
+)
+
+// ExportRequest describes one export: which rows, which columns and how dates
+// are written. Background jobs keep it as their params.
+type ExportRequest struct {
+	Kind     string   `json:"kind"`
+	Format   string   `json:"format"`
+	Columns  []string `json:"columns,omitempty"` // Empty means every column, in the default order
+	Timezone string   `json:"timezone"`          // IANA name dates are written in, e.g. Asia/Kathmandu
+	Calendar string   `json:"calendar"`          // ad or bs
+
+	Transactions *TransactionFilter `json:"transactions,omitempty"`
+	Orders       *OrderFilter       `json:"orders,omitempty"`
+	Sort         string             `json:"sort,omitempty"` // Transaction exports only
+}
+
+// ExportJob is an export too large to stream in the request. A worker writes
+// the file to the file store; it can be downloaded until ExpiresAt.
+type ExportJob struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Kind        string         `gorm:"type:varchar(20);not null" json:"kind"`
+	Format      string         `gorm:"type:varchar(10);not null" json:"format"`
+	Params      datatypes.JSON `gorm:"type:jsonb;not null" json:"params"`                         // The ExportRequest
+	Status      string         `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"` // PENDING, RUNNING, DONE, FAILED, EXPIRED
+	RowCount    int            `gorm:"not null;default:0" json:"row_count"`
+	FileKey     string         `gorm:"type:varchar(255)" json:"-"`
+	FileName    string         `gorm:"type:varchar(255)" json:"file_name,omitempty"`
+	FileSize    int64          `gorm:"not null;default:0" json:"file_size"`
+	Error       string         `gorm:"type:text" json:"error,omitempty"`
+	RequestedBy uuid.UUID      `gorm:"type:uuid;not null" json:"requested_by"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time     `json:"expires_at,omitempty"`
+
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"` // Set once the file is ready
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransactionExportRow is a transaction as it is exported, with its order
+// reference and customer
+type TransactionExportRow struct {
+	ID             uuid.UUID
+	Ref            *string
+	OrderID        uuid.UUID
+	OrderRef       *string
+	UserName       string
+	UserEmail      string
+	PaymentMethod  string
+	TransactionID  string
+	Amount         float64
+	TaxAmount      float64
+	ServiceCharge  float64
+	DeliveryCharge float64
+	GatewayFee     float64
+	NetAmount      float64
+	Status         string
+	FailureReason  string
+	NeedsReview    bool
+	PaidAt         *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// OrderExportRow is an order as it is exported, with its customer, item count
+// and latest payment
+type OrderExportRow struct {
+	ID               uuid.UUID
+	Ref              *string
+	UserName         string
+	UserEmail        string
+	Status           string
+	BuyerPAN         string
+	DeliveryDistrict string
+	ItemCount        int
+	SubTotal         float64
+	DiscountTotal    float64
+	TaxableAmount    float64
+	ExemptAmount     float64
+	TaxAmount        float64
+	ServiceCharge    float64
+	DeliveryCharge   float64
+	TotalPrice       float64
+	PaymentRef       *string
+	PaymentMethod    *string
+	PaymentStatus    *string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
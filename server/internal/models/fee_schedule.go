@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// FeeSchedule is how a gateway charges for collecting a payment. The
+// schedule in force for a method is the active one with the latest
+// EffectiveFrom not after the payment.
+type FeeSchedule struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PaymentMethod string    `gorm:"type:varchar(50);not null" json:"payment_method"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type          string    `gorm:"type:varchar(20);not null" json:"type"` // PERCENTAGE, FIXED, SLAB
+
+	Percentage  float64                      `gorm:"type:decimal(6,3);not null;default:0" json:"percentage"`    // PERCENTAGE: percent of the amount
+	FixedAmount float64                      `gorm:"type:decimal(10,2);not null;default:0" json:"fixed_amount"` // FIXED, or added to PERCENTAGE
+	Slabs       datatypes.JSONSlice[FeeSlab] `gorm:"type:jsonb" json:"slabs,omitempty"`                         // SLAB only, ascending UpTo
+	MinFee      float64                      `gorm:"type:decimal(10,2);not null;default:0" json:"min_fee"`
+	MaxFee      float64                      `gorm:"type:decimal(10,2);not null;default:0" json:"max_fee"` // Cap, 0 = uncapped
+
+	EffectiveFrom time.Time `gorm:"not null" json:"effective_from"`
+	Active        bool      `gorm:"not null;default:true" json:"active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FeeSlab charges Fixed plus Percentage of the amount for amounts up to UpTo.
+// The last slab also covers everything above; it may leave UpTo at 0.
+type FeeSlab struct {
+	UpTo       float64 `json:"up_to"`
+	Percentage float64 `json:"percentage"`
+	Fixed      float64 `json:"fixed"`
+}
+
+// Fee schedule types
+const (
+	FeeTypePercentage = "PERCENTAGE"
+	FeeTypeFixed      = "FIXED"
+	FeeTypeSlab       = "SLAB"
+)
+
+// Transaction fee sources
+const (
+	FeeSourceComputed = "COMPUTED" // From the fee schedule when the payment succeeded
+	FeeSourceSettled  = "SETTLED"  // As deducted on the gateway's settlement
+)
+
+type FeeScheduleRequest struct {
+	PaymentMethod string     `json:"payment_method" binding:"required,oneof=ESEWA CASH CARD CONNECTIPS BANK_TRANSFER"`
+	Name          string     `json:"name" binding:"required,max=100"`
+	Type          string     `json:"type" binding:"required,oneof=PERCENTAGE FIXED SLAB"`
+	Percentage    float64    `json:"percentage" binding:"min=0,max=100"`
+	FixedAmount   float64    `json:"fixed_amount" binding:"min=0"`
+	Slabs         []FeeSlab  `json:"slabs"`
+	MinFee        float64    `json:"min_fee" binding:"min=0"`
+	MaxFee        float64    `json:"max_fee" binding:"min=0"`
+	EffectiveFrom *time.Time `json:"effective_from"` // Defaults to now
+	Active        *bool      `json:"active"`
+}
+
+// FeeQuote is the fee a schedule charges on an amount
+type FeeQuote struct {
+	PaymentMethod string       `json:"payment_method"`
+	Amount        float64      `json:"amount"`
+	Fee           float64      `json:"fee"`
+	NetAmount     float64      `json:"net_amount"`
+	Schedule      *FeeSchedule `json:"schedule,omitempty"` // nil when no schedule applies
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GiftCard is a prepaid code that can be spent at checkout, in part or in full
+type GiftCard struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Code           string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"code,omitempty"` // Only shown to the purchaser and admins
+	Last4          string     `gorm:"type:varchar(4);not null" json:"last4"`
+	InitialBalance float64    `gorm:"type:decimal(10,2);not null" json:"initial_balance"`
+	Balance        float64    `gorm:"type:decimal(10,2);not null" json:"balance"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'ACTIVE'" json:"status"` // ACTIVE, VOID
+	PurchaserID    *uuid.UUID `gorm:"type:uuid" json:"purchaser_id,omitempty"`
+	OrderID        *uuid.UUID `gorm:"type:uuid" json:"order_id,omitempty"`      // Order that bought the card
+	OrderItemID    *uuid.UUID `gorm:"type:uuid" json:"order_item_id,omitempty"` // Line that bought the card
+	IssuedBy       *uuid.UUID `gorm:"type:uuid" json:"issued_by,omitempty"`     // Admin for manually issued cards
+	Note           string     `gorm:"type:varchar(255)" json:"note"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	VoidedAt       *time.Time `json:"voided_at,omitempty"`
+	VoidReason     string     `gorm:"type:text" json:"void_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// GiftCardTransaction is an append-only record of a change to a gift card balance
+type GiftCardTransaction struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	GiftCardID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"gift_card_id"`
+	Type          string     `gorm:"type:varchar(20);not null" json:"type"`     // ISSUE, REDEEM, REVERSAL, VOID
+	Amount        float64    `gorm:"type:decimal(10,2);not null" json:"amount"` // Signed change to the balance
+	BalanceAfter  float64    `gorm:"type:decimal(10,2);not null" json:"balance_after"`
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	Description   string     `gorm:"type:varchar(255)" json:"description"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Gift card status constants
+const (
+	GiftCardStatusActive = "ACTIVE"
+	GiftCardStatusVoid   = "VOID"
+)
+
+// Gift card transaction type constants
+const (
+	GiftCardTxnIssue    = "ISSUE"
+	GiftCardTxnRedeem   = "REDEEM"
+	GiftCardTxnReversal = "REVERSAL"
+	GiftCardTxnVoid     = "VOID"
+)
+
+// GiftCardBalance is the public view of a gift card returned by a balance check
+type GiftCardBalance struct {
+	Last4     string     `json:"last4"`
+	Balance   float64    `json:"balance"`
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type IssueGiftCardRequest struct {
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	PurchaserID *uuid.UUID `json:"purchaser_id"`
+	Note        string     `json:"note" binding:"max=255"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type VoidGiftCardRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type GiftCardCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice is an IRD tax invoice (VAT bill) issued for a paid order.
+// Invoices are never deleted; they are cancelled with a CreditNote.
+type Invoice struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	InvoiceNumber string    `gorm:"type:varchar(30);uniqueIndex;not null" json:"invoice_number"` // e.g. 2082/83-000123
+	FiscalYear    string    `gorm:"type:varchar(10);not null" json:"fiscal_year"`                // e.g. 2082/83
+	Sequence      int64     `gorm:"not null" json:"sequence"`
+
+	OrderID       uuid.UUID `gorm:"type:uuid;not null" json:"order_id"`
+	TransactionID uuid.UUID `gorm:"type:uuid;not null" json:"transaction_id"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	PaymentMethod string    `gorm:"type:varchar(50)" json:"payment_method"`
+
+	SellerName    string `gorm:"type:varchar(200);not null" json:"seller_name"`
+	SellerPAN     string `gorm:"type:varchar(20);not null" json:"seller_pan"`
+	SellerAddress string `gorm:"type:varchar(255)" json:"seller_address"`
+	BuyerName     string `gorm:"type:varchar(100)" json:"buyer_name"`
+	BuyerEmail    string `gorm:"type:varchar(100)" json:"buyer_email"`
+	BuyerPAN      string `gorm:"type:varchar(20)" json:"buyer_pan"`
+
+	SubTotal       float64 `gorm:"type:decimal(10,2);not null" json:"sub_total"`
+	DiscountTotal  float64 `gorm:"type:decimal(10,2);not null;default:0" json:"discount_total"`
+	TaxableAmount  float64 `gorm:"type:decimal(10,2);not null" json:"taxable_amount"`
+	ExemptAmount   float64 `gorm:"type:decimal(10,2);not null" json:"exempt_amount"`
+	TaxAmount      float64 `gorm:"type:decimal(10,2);not null" json:"tax_amount"`
+	ServiceCharge  float64 `gorm:"type:decimal(10,2);not null" json:"service_charge"`
+	DeliveryCharge float64 `gorm:"type:decimal(10,2);not null" json:"delivery_charge"`
+	TotalAmount    float64 `gorm:"type:decimal(10,2);not null" json:"total_amount"`
+
+	Status      string     `gorm:"type:varchar(20);not null;default:'ISSUED'" json:"status"` // ISSUED, CANCELLED
+	IssuedAt    time.Time  `gorm:"not null" json:"issued_at"`
+	IssuedAtBS  string     `gorm:"-" json:"issued_at_bs,omitempty"` // Filled when ?calendar=bs
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	Items      []InvoiceItem `gorm:"foreignKey:InvoiceID" json:"items"`
+	CreditNote *CreditNote   `gorm:"foreignKey:InvoiceID" json:"credit_note,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type InvoiceItem struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	InvoiceID      uuid.UUID `gorm:"type:uuid;not null" json:"invoice_id"`
+	BookID         uuid.UUID `gorm:"type:uuid;not null" json:"book_id"`
+	Description    string    `gorm:"type:varchar(255);not null" json:"description"`
+	Quantity       int       `gorm:"not null" json:"quantity"`
+	UnitPrice      float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	DiscountAmount float64   `gorm:"type:decimal(10,2);not null;default:0" json:"discount_amount"`
+	Amount         float64   `gorm:"type:decimal(10,2);not null" json:"amount"` // Quantity x UnitPrice less DiscountAmount
+	TaxExempt      bool      `gorm:"not null" json:"tax_exempt"`
+	TaxAmount      float64   `gorm:"type:decimal(10,2);not null" json:"tax_amount"`
+}
+
+// CreditNote cancels an invoice in full. It has its own gapless number series.
+type CreditNote struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	CreditNoteNumber string    `gorm:"type:varchar(30);uniqueIndex;not null" json:"credit_note_number"` // e.g. CN-2082/83-000004
+	FiscalYear       string    `gorm:"type:varchar(10);not null" json:"fiscal_year"`
+	Sequence         int64     `gorm:"not null" json:"sequence"`
+	InvoiceID        uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"invoice_id"`
+	Reason           string    `gorm:"type:text;not null" json:"reason"`
+	TaxableAmount    float64   `gorm:"type:decimal(10,2);not null" json:"taxable_amount"`
+	ExemptAmount     float64   `gorm:"type:decimal(10,2);not null" json:"exempt_amount"`
+	TaxAmount        float64   `gorm:"type:decimal(10,2);not null" json:"tax_amount"`
+	TotalAmount      float64   `gorm:"type:decimal(10,2);not null" json:"total_amount"`
+	IssuedBy         uuid.UUID `gorm:"type:uuid;not null" json:"issued_by"`
+	IssuedAt         time.Time `gorm:"not null" json:"issued_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Invoice status constants
+const (
+	InvoiceStatusIssued    = "ISSUED"
+	InvoiceStatusCancelled = "CANCELLED"
+)
+
+// Document types numbered by the document_sequences table
+const (
+	DocumentTypeInvoice    = "INVOICE"
+	DocumentTypeCreditNote = "CREDIT_NOTE"
+)
+
+type CancelInvoiceRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerAccount is an account in the double-entry ledger. Accounts are seeded
+// by migration and looked up by Code.
+type LedgerAccount struct {
+	ID   uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Code string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
+	Name string    `gorm:"type:varchar(100);not null" json:"name"`
+	Type string    `gorm:"type:varchar(20);not null" json:"type"` // ASSET, LIABILITY, EQUITY, REVENUE, EXPENSE
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalDebit reports whether the account's balance grows with debits
+func (a *LedgerAccount) NormalDebit() bool {
+	return a.Type == LedgerAccountAsset || a.Type == LedgerAccountExpense
+}
+
+// Ledger account types
+const (
+	LedgerAccountAsset     = "ASSET"
+	LedgerAccountLiability = "LIABILITY"
+	LedgerAccountEquity    = "EQUITY"
+	LedgerAccountRevenue   = "REVENUE"
+	LedgerAccountExpense   = "EXPENSE"
+)
+
+// Ledger account codes seeded by migrations 013, 016, 018 and 019
+const (
+	AccountEsewaClearing        = "ESEWA_CLEARING"
+	AccountCash                 = "CASH"
+	AccountCardClearing         = "CARD_CLEARING"
+	AccountConnectIPSClearing   = "CONNECTIPS_CLEARING"
+	AccountBank                 = "BANK"
+	AccountSalesRevenue         = "SALES_REVENUE"
+	AccountServiceRevenue       = "SERVICE_REVENUE"
+	AccountVATPayable           = "VAT_PAYABLE"
+	AccountStoreCreditLiability = "STORE_CREDIT_LIABILITY"
+	AccountGiftCardLiability    = "GIFT_CARD_LIABILITY"
+	AccountSalesReturns         = "SALES_RETURNS"
+	AccountGatewayFees          = "GATEWAY_FEES"
+	AccountPromotionalExpense   = "PROMOTIONAL_EXPENSE"
+	AccountBreakageIncome       = "BREAKAGE_INCOME"
+	AccountChargebacks          = "CHARGEBACKS"
+)
+
+// JournalEntry is one balanced posting to the ledger. Each business event is
+// posted at most once, keyed by SourceType and SourceID.
+type JournalEntry struct {
+	ID          uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SourceType  string        `gorm:"type:varchar(30);not null" json:"source_type"`
+	SourceID    string        `gorm:"type:varchar(100);not null" json:"source_id"`
+	Description string        `gorm:"type:varchar(255)" json:"description"`
+	PostedAt    time.Time     `gorm:"not null" json:"posted_at"`
+	Lines       []JournalLine `gorm:"foreignKey:JournalEntryID" json:"lines"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	PostedAtBS string    `gorm:"-" json:"posted_at_bs,omitempty"` // Filled when ?calendar=bs
+}
+
+// JournalLine debits or credits one account. Exactly one of Debit and Credit is non-zero.
+type JournalLine struct {
+	ID             uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	JournalEntryID uuid.UUID     `gorm:"type:uuid;not null" json:"journal_entry_id"`
+	AccountID      uuid.UUID     `gorm:"type:uuid;not null" json:"account_id"`
+	Account        LedgerAccount `gorm:"foreignKey:AccountID" json:"account"`
+	Debit          float64       `gorm:"type:decimal(12,2);not null;default:0" json:"debit"`
+	Credit         float64       `gorm:"type:decimal(12,2);not null;default:0" json:"credit"`
+	Description    string        `gorm:"type:varchar(255)" json:"description,omitempty"`
+}
+
+// Journal entry source types
+const (
+	JournalSourceTransaction   = "TRANSACTION"
+	JournalSourceRefund        = "REFUND"
+	JournalSourceGatewayFee    = "GATEWAY_FEE"
+	JournalSourceWalletCredit  = "WALLET_CREDIT"
+	JournalSourceWalletExpiry  = "WALLET_EXPIRY"
+	JournalSourceGiftCardIssue = "GIFT_CARD_ISSUE"
+	JournalSourceGiftCardVoid  = "GIFT_CARD_VOID"
+)
+
+// TrialBalanceRow is one account's totals up to a point in time
+type TrialBalanceRow struct {
+	Code    string  `json:"code"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"` // On the account's normal side
+}
+
+// TrialBalance lists every account; TotalDebit equals TotalCredit when the ledger balances
+type TrialBalance struct {
+	AsOf        time.Time         `json:"as_of"`
+	Rows        []TrialBalanceRow `json:"rows"`
+	TotalDebit  float64           `json:"total_debit"`
+	TotalCredit float64           `json:"total_credit"`
+}
+
+// AccountStatementLine is a journal line with the account's running balance after it
+type AccountStatementLine struct {
+	JournalEntryID uuid.UUID `json:"journal_entry_id"`
+	PostedAt       time.Time `json:"posted_at"`
+	PostedAtBS     string    `json:"posted_at_bs,omitempty"`
+	SourceType     string    `json:"source_type"`
+	SourceID       string    `json:"source_id"`
+	Description    string    `json:"description"`
+	Debit          float64   `json:"debit"`
+	Credit         float64   `json:"credit"`
+	Balance        float64   `json:"balance"`
+}
+
+// AccountStatement is an account's activity over [From, To)
+type AccountStatement struct {
+	Account        LedgerAccount          `json:"account"`
+	From           time.Time              `json:"from"`
+	To             time.Time              `json:"to"`
+	OpeningBalance float64                `json:"opening_balance"`
+	ClosingBalance float64                `json:"closing_balance"`
+	Lines          []AccountStatementLine `json:"lines"`
+}
+
+// UnbalancedJournal is a journal entry whose debits and credits differ
+type UnbalancedJournal struct {
+	JournalEntryID uuid.UUID `json:"journal_entry_id"`
+	SourceType     string    `json:"source_type"`
+	SourceID       string    `json:"source_id"`
+	Debit          float64   `json:"debit"`
+	Credit         float64   `json:"credit"`
+}
+
+// LedgerCheck is the result of the ledger invariant check
+type LedgerCheck struct {
+	Balanced             bool                `json:"balanced"`
+	TotalDebit           float64             `json:"total_debit"`
+	TotalCredit          float64             `json:"total_credit"`
+	JournalCount         int64               `json:"journal_count"`
+	UnbalancedJournals   []UnbalancedJournal `json:"unbalanced_journals"`
+	UnpostedTransactions []uuid.UUID         `json:"unposted_transactions"` // SUCCESS transactions without a journal
+	UnpostedRefunds      []uuid.UUID         `json:"unposted_refunds"`      // COMPLETED refunds without a journal
+}
+
+type GatewayFeeRequest struct {
+	TransactionID uuid.UUID  `json:"transaction_id" binding:"required"`
+	Amount        float64    `json:"amount" binding:"required,gt=0"`
+	Reference     string     `json:"reference" binding:"max=100"` // Settlement or statement reference
+	SettledAt     *time.Time `json:"settled_at"`                  // Defaults to now
+}
@@ -7,29 +7,78 @@ import (
 )
 
 type Order struct {
-	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID     uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	User       User      `json:"user"`
-	Status     string    `gorm:"type:varchar(20);default:'PENDING'" json:"status"` // PENDING, PAID, CANCELLED
-	TotalPrice float64   `gorm:"not null" json:"total_price"`
+	ID     uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Ref    *string   `gorm:"type:varchar(40)" json:"ref,omitempty"` // Human-readable reference, e.g. BK-2082-000457
+	UserID uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	User   User      `json:"user"`
+	Status string    `gorm:"type:varchar(20);default:'PENDING'" json:"status"` // PENDING, PAID, CANCELLED
 
-	Items []OrderItem `gorm:"foreignKey:OrderID" json:"items"`
+	BuyerPAN string `gorm:"type:varchar(20)" json:"buyer_pan,omitempty"` // Printed on the VAT bill for business buyers
 
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	DeliveryDistrict string `gorm:"type:varchar(50)" json:"delivery_district,omitempty"` // e.g. Kathmandu; decides which payment methods are offered
+
+	// Bill breakdown. TotalPrice = TaxableAmount + ExemptAmount + TaxAmount + ServiceCharge + DeliveryCharge
+	SubTotal       float64 `gorm:"type:decimal(10,2);not null;default:0" json:"sub_total"`      // Sum of item price * quantity as listed
+	DiscountTotal  float64 `gorm:"type:decimal(10,2);not null;default:0" json:"discount_total"` // Sum of item discounts
+	TaxableAmount  float64 `gorm:"type:decimal(10,2);not null;default:0" json:"taxable_amount"` // VAT-able amount excluding VAT
+	ExemptAmount   float64 `gorm:"type:decimal(10,2);not null;default:0" json:"exempt_amount"`  // VAT-exempt amount
+	TaxAmount      float64 `gorm:"type:decimal(10,2);not null;default:0" json:"tax_amount"`     // VAT
+	ServiceCharge  float64 `gorm:"type:decimal(10,2);not null;default:0" json:"service_charge"`
+	DeliveryCharge float64 `gorm:"type:decimal(10,2);not null;default:0" json:"delivery_charge"`
+	TotalPrice     float64 `gorm:"not null" json:"total_price"`
+
+	Items     []OrderItem     `gorm:"foreignKey:OrderID" json:"items"`
+	Discounts []OrderDiscount `gorm:"foreignKey:OrderID" json:"discounts"`
+
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAtBS string    `gorm:"-" json:"created_at_bs,omitempty"` // Filled when ?calendar=bs
 }
 
 type OrderItem struct {
-	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	OrderID  uuid.UUID `gorm:"type:uuid;not null" json:"order_id"`
-	BookID   uuid.UUID `gorm:"type:uuid;not null" json:"book_id"`
-	Book     Book      `json:"book"`
-	Quantity int       `gorm:"not null" json:"quantity"`
-	Price    float64   `gorm:"not null" json:"price"`
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID   uuid.UUID `gorm:"type:uuid;not null" json:"order_id"`
+	BookID    uuid.UUID `gorm:"type:uuid;not null" json:"book_id"`
+	Book      Book      `json:"book"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Price     float64   `gorm:"not null" json:"price"`
+	TaxExempt bool      `gorm:"not null;default:false" json:"tax_exempt"`
+	TaxAmount float64   `gorm:"type:decimal(10,2);not null;default:0" json:"tax_amount"`
+
+	DiscountAmount float64 `gorm:"type:decimal(10,2);not null;default:0" json:"discount_amount"` // Share of the order's discounts
+}
+
+// OrderDiscount is a discount line on an order, e.g. an applied coupon or
+// promotion. DELIVERY lines waive delivery charge instead of item amounts.
+type OrderDiscount struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID     uuid.UUID  `gorm:"type:uuid;not null" json:"order_id"`
+	Type        string     `gorm:"type:varchar(20);not null" json:"type"` // COUPON, PROMOTION, DELIVERY
+	CouponID    *uuid.UUID `gorm:"type:uuid" json:"coupon_id,omitempty"`
+	PromotionID *uuid.UUID `gorm:"type:uuid" json:"promotion_id,omitempty"`
+	Code        string     `gorm:"type:varchar(50)" json:"code,omitempty"`
+	Description string     `gorm:"type:varchar(255)" json:"description"`
+	Amount      float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
 }
 
+// Order discount line types
+const (
+	DiscountTypeCoupon    = "COUPON"
+	DiscountTypePromotion = "PROMOTION"
+	DiscountTypeDelivery  = "DELIVERY"
+)
+
 const (
 	OrderStatusPending   = "PENDING"
 	OrderStatusPaid      = "PAID"
 	OrderStatusCancelled = "CANCELLED"
 )
+
+// OrderFilter narrows an order export. Zero values match everything.
+type OrderFilter struct {
+	Ref      string     `json:"ref,omitempty"`
+	Statuses []string   `json:"statuses,omitempty"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	From     *time.Time `json:"from,omitempty"` // created at or after
+	To       *time.Time `json:"to,omitempty"`   // created before
+}
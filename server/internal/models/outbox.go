@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// OutboxEvent is a domain event written in the same database transaction as
+// the change it describes, so it exists if and only if the change committed.
+// The relay hands it to every subscriber until each has processed it, then
+// marks it PUBLISHED; after too many failed rounds it is marked FAILED.
+type OutboxEvent struct {
+	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"` // Doubles as the consumers' dedup ID
+	EventType     string         `gorm:"type:varchar(50);not null" json:"event_type"`
+	AggregateType string         `gorm:"type:varchar(30);not null" json:"aggregate_type"` // order, transaction, refund
+	AggregateID   uuid.UUID      `gorm:"type:uuid;not null" json:"aggregate_id"`
+	Payload       datatypes.JSON `gorm:"type:jsonb;not null" json:"payload"`                        // Snapshot of the aggregate after the change
+	Status        string         `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"` // PENDING, PUBLISHED, FAILED
+	Attempts      int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time      `gorm:"not null" json:"next_attempt_at"`
+	LastError     string         `gorm:"type:text" json:"last_error"`
+	PublishedAt   *time.Time     `json:"published_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OutboxConsumption records that a subscriber has processed an event, so a
+// redelivered event is not processed by it twice
+type OutboxConsumption struct {
+	EventID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"event_id"`
+	Consumer    string    `gorm:"type:varchar(50);primaryKey" json:"consumer"`
+	ProcessedAt time.Time `gorm:"not null" json:"processed_at"`
+}
+
+// Outbox event types. The order, transaction and refund ones that webhooks
+// subscribe to share the webhook names.
+const (
+	EventOrderCreated         = "order.created"
+	EventOrderCancelled       = "order.cancelled"
+	EventTransactionSucceeded = "transaction.succeeded"
+	EventTransactionFailed    = "transaction.failed"
+	EventTransactionCancelled = "transaction.cancelled"
+	EventRefundCompleted      = "refund.completed"
+)
+
+// Outbox aggregate types
+const (
+	AggregateOrder       = "order"
+	AggregateTransaction = "transaction"
+	AggregateRefund      = "refund"
+)
+
+// Outbox event status constants
+const (
+	OutboxStatusPending   = "PENDING"
+	OutboxStatusPublished = "PUBLISHED"
+	OutboxStatusFailed    = "FAILED"
+)
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentLink lets a customer pay for an order staff placed for them, without
+// logging in. The token in its URL is signed and carries the expiry; the
+// link stops working once revoked or once the order is paid.
+type PaymentLink struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	OrderID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"order_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`         // The customer the order belongs to
+	Amount        float64    `gorm:"type:decimal(10,2);not null" json:"amount"` // Order total when the link was made
+	Note          string     `gorm:"type:varchar(255)" json:"note,omitempty"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'ACTIVE'" json:"status"` // ACTIVE, PAID, REVOKED; EXPIRED is worked out when read
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transaction_id,omitempty"`                // The payment that used the link
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy     *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+
+	URL string `gorm:"-" json:"url,omitempty"` // Only filled in for admins
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Order *Order `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+}
+
+// StatusAt is the link's status at the given time, including EXPIRED
+func (l *PaymentLink) StatusAt(now time.Time) string {
+	if l.Status == PaymentLinkActive && !now.Before(l.ExpiresAt) {
+		return PaymentLinkExpired
+	}
+	return l.Status
+}
+
+// Payment link statuses
+const (
+	PaymentLinkActive  = "ACTIVE"
+	PaymentLinkPaid    = "PAID"
+	PaymentLinkRevoked = "REVOKED"
+	PaymentLinkExpired = "EXPIRED"
+)
+
+type PaymentLinkRequest struct {
+	ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1,max=720"` // Defaults to the configured lifetime
+	Note           string `json:"note" binding:"max=255"`
+}
+
+// PaymentLinkView is what the customer sees when opening a link. It leaves
+// out anything about the customer that is not needed to pay.
+type PaymentLinkView struct {
+	Status            string            `json:"status"`
+	ExpiresAt         time.Time         `json:"expires_at"`
+	OrderID           uuid.UUID         `json:"order_id"`
+	OrderRef          *string           `json:"order_ref,omitempty"`
+	Items             []PaymentLinkItem `json:"items"`
+	SubTotal          float64           `json:"sub_total"`
+	DiscountTotal     float64           `json:"discount_total"`
+	TaxAmount         float64           `json:"tax_amount"`
+	ServiceCharge     float64           `json:"service_charge"`
+	DeliveryCharge    float64           `json:"delivery_charge"`
+	Amount            float64           `json:"amount"`
+	TransactionStatus string            `json:"transaction_status,omitempty"`
+}
+
+type PaymentLinkItem struct {
+	Title    string  `json:"title"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentProof is a deposit slip a customer uploads for a BANK_TRANSFER
+// transaction. An admin approves it, which completes the payment, or rejects
+// it with a reason, after which the customer may upload another.
+type PaymentProof struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TransactionID uuid.UUID `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+
+	FileKey     string `gorm:"type:varchar(255);not null" json:"-"` // Location in the file store
+	FileName    string `gorm:"type:varchar(255)" json:"file_name"`  // As uploaded, for display only
+	ContentType string `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64  `gorm:"not null" json:"size"`
+
+	BankName         string     `gorm:"type:varchar(100)" json:"bank_name"`
+	DepositReference string     `gorm:"type:varchar(100)" json:"deposit_reference"` // Voucher or transfer reference on the slip
+	DepositedAt      *time.Time `json:"deposited_at,omitempty"`
+
+	Status          string     `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"` // PENDING, APPROVED, REJECTED
+	RejectionReason string     `gorm:"type:varchar(255)" json:"rejection_reason,omitempty"`
+	ReviewedBy      *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+
+	Transaction *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Payment proof statuses
+const (
+	PaymentProofPending  = "PENDING"
+	PaymentProofApproved = "APPROVED"
+	PaymentProofRejected = "REJECTED"
+)
+
+// PaymentProofUpload is the form sent with a deposit slip
+type PaymentProofUpload struct {
+	BankName         string     `form:"bank_name" binding:"max=100"`
+	DepositReference string     `form:"deposit_reference" binding:"max=100"`
+	DepositedAt      *time.Time `form:"deposited_at" time_format:"2006-01-02"`
+}
+
+type RejectPaymentProofRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
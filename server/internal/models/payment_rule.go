@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// PaymentMethodRule limits when a payment method is offered. A method is
+// available for an order only if every active rule for it passes; a method
+// without rules is always available. Empty conditions do not restrict.
+type PaymentMethodRule struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	PaymentMethod string    `gorm:"type:varchar(50);not null" json:"payment_method"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Message       string    `gorm:"type:varchar(255)" json:"message"` // Shown to the customer when the rule fails
+
+	MinAmount float64 `gorm:"type:decimal(10,2);not null;default:0" json:"min_amount"` // Order total must be at least this
+	MaxAmount float64 `gorm:"type:decimal(10,2);not null;default:0" json:"max_amount"` // Order total must be below this, 0 = no limit
+
+	Districts       datatypes.JSONSlice[string] `gorm:"type:jsonb" json:"districts,omitempty"`     // Delivery districts allowed
+	AccountTypes    datatypes.JSONSlice[string] `gorm:"type:jsonb" json:"account_types,omitempty"` // INDIVIDUAL, INSTITUTIONAL
+	RequireVerified bool                        `gorm:"not null;default:false" json:"require_verified"`
+
+	// Daily window in Nepal time, "HH:MM". An end before the start runs past midnight.
+	Weekdays  datatypes.JSONSlice[int] `gorm:"type:jsonb" json:"weekdays,omitempty"` // 0 = Sunday
+	StartTime string                   `gorm:"type:varchar(5)" json:"start_time,omitempty"`
+	EndTime   string                   `gorm:"type:varchar(5)" json:"end_time,omitempty"`
+
+	Active bool `gorm:"not null;default:true" json:"active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PaymentMethodRuleRequest struct {
+	PaymentMethod   string   `json:"payment_method" binding:"required,oneof=ESEWA CASH CARD CONNECTIPS BANK_TRANSFER STORE_CREDIT GIFT_CARD"`
+	Name            string   `json:"name" binding:"required,max=100"`
+	Message         string   `json:"message" binding:"max=255"`
+	MinAmount       float64  `json:"min_amount" binding:"min=0"`
+	MaxAmount       float64  `json:"max_amount" binding:"min=0"`
+	Districts       []string `json:"districts"`
+	AccountTypes    []string `json:"account_types" binding:"omitempty,dive,oneof=INDIVIDUAL INSTITUTIONAL"`
+	RequireVerified bool     `json:"require_verified"`
+	Weekdays        []int    `json:"weekdays" binding:"omitempty,dive,min=0,max=6"`
+	StartTime       string   `json:"start_time"`
+	EndTime         string   `json:"end_time"`
+	Active          *bool    `json:"active"`
+}
+
+// PaymentMethodOption is whether a payment method can be used for an order
+type PaymentMethodOption struct {
+	PaymentMethod string   `json:"payment_method"`
+	Available     bool     `json:"available"`
+	Reasons       []string `json:"reasons,omitempty"` // Why an unavailable method was ruled out
+}
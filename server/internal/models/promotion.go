@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Promotion is an automatic discount rule evaluated when an order is priced
+type Promotion struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Type        string    `gorm:"type:varchar(30);not null" json:"type"` // CATEGORY_PERCENT, AUTHOR_PERCENT, BUY_X_GET_Y, FREE_DELIVERY
+
+	// Scope. CategoryID is required for CATEGORY_PERCENT and optional for BUY_X_GET_Y;
+	// Author is required for AUTHOR_PERCENT.
+	CategoryID *uuid.UUID `gorm:"type:uuid" json:"category_id,omitempty"`
+	Category   *Category  `json:"category,omitempty"`
+	Author     string     `gorm:"type:varchar(100)" json:"author,omitempty"`
+
+	Percentage    float64 `gorm:"type:decimal(5,2);not null;default:0" json:"percentage"`       // Percent off for *_PERCENT rules
+	MinQuantity   int     `gorm:"not null;default:0" json:"min_quantity"`                       // Units in scope needed for *_PERCENT rules
+	BuyQuantity   int     `gorm:"not null;default:0" json:"buy_quantity"`                       // BUY_X_GET_Y: X
+	FreeQuantity  int     `gorm:"not null;default:0" json:"free_quantity"`                      // BUY_X_GET_Y: Y
+	MinOrderValue float64 `gorm:"type:decimal(10,2);not null;default:0" json:"min_order_value"` // Order value after earlier discounts
+
+	// Stackable promotions combine with each other; the rest apply alone.
+	// Higher Priority is applied first and wins ties.
+	Stackable bool `gorm:"not null;default:false" json:"stackable"`
+	Priority  int  `gorm:"not null;default:0" json:"priority"`
+
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Active    bool       `gorm:"not null;default:true" json:"active"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Promotion type constants
+const (
+	PromotionTypeCategoryPercent = "CATEGORY_PERCENT"
+	PromotionTypeAuthorPercent   = "AUTHOR_PERCENT"
+	PromotionTypeBuyXGetY        = "BUY_X_GET_Y"
+	PromotionTypeFreeDelivery    = "FREE_DELIVERY"
+)
+
+type PromotionRequest struct {
+	Name          string     `json:"name" binding:"required,max=100"`
+	Description   string     `json:"description"`
+	Type          string     `json:"type" binding:"required,oneof=CATEGORY_PERCENT AUTHOR_PERCENT BUY_X_GET_Y FREE_DELIVERY"`
+	CategoryID    *uuid.UUID `json:"category_id"`
+	Author        string     `json:"author"`
+	Percentage    float64    `json:"percentage" binding:"min=0,max=100"`
+	MinQuantity   int        `json:"min_quantity" binding:"min=0"`
+	BuyQuantity   int        `json:"buy_quantity" binding:"min=0"`
+	FreeQuantity  int        `json:"free_quantity" binding:"min=0"`
+	MinOrderValue float64    `json:"min_order_value" binding:"min=0"`
+	Stackable     bool       `json:"stackable"`
+	Priority      int        `json:"priority"`
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Active        *bool      `json:"active"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Refund returns part or all of a successful transaction to the customer,
+// either as store credit or through the original payment method
+type Refund struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TransactionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	OrderID       uuid.UUID  `gorm:"type:uuid;not null" json:"order_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Amount        float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Method        string     `gorm:"type:varchar(20);not null" json:"method"` // STORE_CREDIT, ORIGINAL, CHARGEBACK
+	Status        string     `gorm:"type:varchar(20);not null" json:"status"` // PENDING, COMPLETED
+	Reason        string     `gorm:"type:text" json:"reason"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Refund method constants
+const (
+	RefundMethodStoreCredit = "STORE_CREDIT"
+	RefundMethodOriginal    = "ORIGINAL"   // Paid back through the gateway by hand
+	RefundMethodChargeback  = "CHARGEBACK" // Taken back by the gateway or issuer for a lost dispute
+)
+
+// Refund status constants
+const (
+	RefundStatusPending   = "PENDING"
+	RefundStatusCompleted = "COMPLETED"
+)
+
+type CreateRefundRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Method string  `json:"method" binding:"required,oneof=STORE_CREDIT ORIGINAL"`
+	Reason string  `json:"reason" binding:"required"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SalesReportRow aggregates invoiced sales for one period, net of credit notes
+type SalesReportRow struct {
+	Period          string  `json:"period"`       // e.g. 2082-04, 2025-07 or 2082/83
+	PeriodLabel     string  `json:"period_label"` // e.g. Shrawan 2082
+	FiscalYear      string  `json:"fiscal_year"`
+	InvoiceCount    int     `json:"invoice_count"`
+	CreditNoteCount int     `json:"credit_note_count"`
+	TaxableAmount   float64 `json:"taxable_amount"`
+	ExemptAmount    float64 `json:"exempt_amount"`
+	TaxAmount       float64 `json:"tax_amount"`
+	TotalAmount     float64 `json:"total_amount"`
+}
+
+// Report grouping and calendar options
+const (
+	ReportGroupByMonth      = "month"
+	ReportGroupByFiscalYear = "fiscal_year"
+
+	CalendarAD = "ad"
+	CalendarBS = "bs"
+)
+
+// SettlementReportRow is one gateway's takings for one day. Net is what the
+// gateway should pay out: Gross less Fees and refunds sent back through it.
+type SettlementReportRow struct {
+	Date             string  `json:"date"` // YYYY-MM-DD in the requested calendar
+	Gateway          string  `json:"gateway"`
+	TransactionCount int     `json:"transaction_count"`
+	Gross            float64 `json:"gross"`
+	Fees             float64 `json:"fees"`
+	Refunds          float64 `json:"refunds"`
+	Net              float64 `json:"net"`
+	UnsettledCount   int     `json:"unsettled_count"` // Payments whose fee is estimated or unknown
+}
+
+// GatewayPayment is the part of a successful transaction collected through one gateway
+type GatewayPayment struct {
+	TransactionID uuid.UUID
+	PaidAt        time.Time
+	PaymentMethod string
+	Amount        float64
+	Fee           float64
+	FeeSource     string
+}
+
+// GatewayRefund is a completed refund paid back through the original gateway
+type GatewayRefund struct {
+	CompletedAt   time.Time
+	PaymentMethod string
+	Amount        float64
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ClientInfo identifies where a payment request came from
+type ClientInfo struct {
+	IPAddress string
+	DeviceID  string // Sent by the client in the X-Device-ID header
+}
+
+// RiskAssessment records one fraud check and its decision. Every check is
+// kept, whatever the outcome, and the rows double as the attempt history the
+// velocity signals count.
+type RiskAssessment struct {
+	ID            uuid.UUID                       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Stage         string                          `gorm:"type:varchar(20);not null" json:"stage"` // CREATE, VERIFY
+	UserID        uuid.UUID                       `gorm:"type:uuid;not null" json:"user_id"`
+	OrderID       uuid.UUID                       `gorm:"type:uuid;not null" json:"order_id"`
+	TransactionID *uuid.UUID                      `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	Amount        float64                         `gorm:"type:decimal(10,2);not null" json:"amount"`
+	IPAddress     string                          `gorm:"type:varchar(45)" json:"ip_address"`
+	DeviceID      string                          `gorm:"type:varchar(100)" json:"device_id,omitempty"`
+	Score         int                             `gorm:"not null" json:"score"`
+	Decision      string                          `gorm:"type:varchar(10);not null" json:"decision"` // ALLOW, REVIEW, BLOCK
+	Signals       datatypes.JSONSlice[RiskSignal] `gorm:"type:jsonb" json:"signals"`
+	CreatedAt     time.Time                       `json:"created_at"`
+}
+
+// RiskSignal is one reason a check added to the score
+type RiskSignal struct {
+	Code   string `json:"code"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// Summary lists the signals that fired, e.g. for a review reason
+func (a *RiskAssessment) Summary() string {
+	codes := make([]string, 0, len(a.Signals))
+	for _, signal := range a.Signals {
+		codes = append(codes, signal.Code)
+	}
+	return fmt.Sprintf("Risk score %d: %s", a.Score, strings.Join(codes, ", "))
+}
+
+// Risk check stages
+const (
+	RiskStageCreate = "CREATE" // Before a transaction is created
+	RiskStageVerify = "VERIFY" // Before a gateway confirmation is applied
+)
+
+// Risk decisions
+const (
+	RiskAllow  = "ALLOW"
+	RiskReview = "REVIEW"
+	RiskBlock  = "BLOCK"
+)
+
+// Risk signal codes
+const (
+	RiskSignalUserVelocity   = "USER_VELOCITY"
+	RiskSignalIPVelocity     = "IP_VELOCITY"
+	RiskSignalDeviceVelocity = "DEVICE_VELOCITY"
+	RiskSignalFailures       = "REPEATED_FAILURES"
+	RiskSignalUnusualAmount  = "UNUSUAL_AMOUNT"
+	RiskSignalNewAccount     = "NEW_ACCOUNT_HIGH_VALUE"
+	RiskSignalUnavailable    = "CHECKS_UNAVAILABLE" // The checks could not run
+)
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SettlementImport is one uploaded gateway settlement statement and the
+// outcome of reconciling it against our transactions
+type SettlementImport struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Gateway    string     `gorm:"type:varchar(20);not null" json:"gateway"` // ESEWA
+	FileName   string     `gorm:"type:varchar(255)" json:"file_name"`
+	PeriodFrom *time.Time `json:"period_from,omitempty"` // Earliest payment date on the statement
+	PeriodTo   *time.Time `json:"period_to,omitempty"`   // Latest payment date on the statement
+	ImportedBy *uuid.UUID `gorm:"type:uuid" json:"imported_by,omitempty"`
+
+	RowCount         int     `gorm:"not null;default:0" json:"row_count"`
+	Matched          int     `gorm:"not null;default:0" json:"matched"`
+	MissingLocally   int     `gorm:"not null;default:0" json:"missing_locally"`
+	MissingAtGateway int     `gorm:"not null;default:0" json:"missing_at_gateway"`
+	AmountMismatches int     `gorm:"not null;default:0" json:"amount_mismatches"`
+	Duplicates       int     `gorm:"not null;default:0" json:"duplicates"`
+	Skipped          int     `gorm:"not null;default:0" json:"skipped"` // Rows that are not completed payments
+	GrossAmount      float64 `gorm:"type:decimal(12,2);not null;default:0" json:"gross_amount"`
+	FeeTotal         float64 `gorm:"type:decimal(12,2);not null;default:0" json:"fee_total"`
+
+	Items []SettlementItem `gorm:"foreignKey:ImportID" json:"items,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SettlementItem is one reconciliation result: a statement row, a local
+// transaction, or both
+type SettlementItem struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ImportID      uuid.UUID  `gorm:"type:uuid;not null" json:"import_id"`
+	Status        string     `gorm:"type:varchar(20);not null" json:"status"` // MATCHED, MISSING_LOCALLY, MISSING_AT_GATEWAY, AMOUNT_MISMATCH, DUPLICATE, SKIPPED
+	Line          int        `json:"line,omitempty"`                          // Statement line, 0 for MISSING_AT_GATEWAY
+	RefID         string     `gorm:"type:varchar(100)" json:"ref_id"`
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	GatewayAmount float64    `gorm:"type:decimal(10,2);not null;default:0" json:"gateway_amount"`
+	LocalAmount   float64    `gorm:"type:decimal(10,2);not null;default:0" json:"local_amount"`
+	Fee           float64    `gorm:"type:decimal(10,2);not null;default:0" json:"fee"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	Note          string     `gorm:"type:varchar(255)" json:"note,omitempty"`
+}
+
+// Settlement item statuses
+const (
+	SettlementMatched          = "MATCHED"
+	SettlementMissingLocally   = "MISSING_LOCALLY"
+	SettlementMissingAtGateway = "MISSING_AT_GATEWAY"
+	SettlementAmountMismatch   = "AMOUNT_MISMATCH"
+	SettlementDuplicate        = "DUPLICATE" // The statement lists a transaction more than once
+	SettlementSkipped          = "SKIPPED"
+)
+
+type ResolveReviewRequest struct {
+	Note string `json:"note" binding:"required,max=255"`
+}
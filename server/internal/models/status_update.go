@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusUpdate is the body of a live status event. Transaction fields are
+// empty for order events that have no payment yet.
+type StatusUpdate struct {
+	Event             string     `json:"event"` // order.created, order.cancelled, payment.succeeded, payment.failed, or snapshot on connect
+	OrderID           uuid.UUID  `json:"order_id"`
+	OrderStatus       string     `json:"order_status"`
+	TransactionID     *uuid.UUID `json:"transaction_id,omitempty"`
+	TransactionStatus string     `json:"transaction_status,omitempty"`
+	PaymentMethod     string     `json:"payment_method,omitempty"`
+	Amount            float64    `json:"amount"`
+	FailureReason     string     `json:"failure_reason,omitempty"`
+	At                time.Time  `json:"at"`
+}
+
+// Status event names and topics for the live streams
+const (
+	StatusEventSnapshot = "snapshot"
+	StatusEventReset    = "reset" // Sent when updates were missed; reload instead of relying on the stream
+
+	StatusTopicAdmin = "admin"
+)
+
+// OrderStatusTopic is the stream topic carrying an order's updates
+func OrderStatusTopic(id uuid.UUID) string { return "order:" + id.String() }
+
+// TransactionStatusTopic is the stream topic carrying a transaction's updates
+func TransactionStatusTopic(id uuid.UUID) string { return "transaction:" + id.String() }
+
+// OrderStatusUpdate describes an order's current state
+func OrderStatusUpdate(event string, order *Order) *StatusUpdate {
+	return &StatusUpdate{
+		Event:       event,
+		OrderID:     order.ID,
+		OrderStatus: order.Status,
+		Amount:      order.TotalPrice,
+		At:          order.UpdatedAt,
+	}
+}
+
+// TransactionStatusUpdate describes a transaction's current state and its order's
+func TransactionStatusUpdate(event string, transaction *Transaction) *StatusUpdate {
+	id := transaction.ID
+	return &StatusUpdate{
+		Event:             event,
+		OrderID:           transaction.OrderID,
+		OrderStatus:       transaction.Order.Status,
+		TransactionID:     &id,
+		TransactionStatus: transaction.Status,
+		PaymentMethod:     transaction.PaymentMethod,
+		Amount:            transaction.Amount,
+		FailureReason:     transaction.FailureReason,
+		At:                transaction.UpdatedAt,
+	}
+}
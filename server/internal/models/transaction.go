@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,24 +9,91 @@ import (
 )
 
 type Transaction struct {
-	ID            uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	OrderID       uuid.UUID      `gorm:"type:uuid;not null" json:"order_id"`
-	Order         Order          `gorm:"foreignKey:OrderID" json:"order"`
-	UserID        uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	User          User           `gorm:"foreignKey:UserID" json:"user"`
-	PaymentMethod string         `gorm:"type:varchar(50);not null" json:"payment_method"` // ESEWA, CASH, CARD, etc.
-	TransactionID string         `gorm:"type:varchar(100);unique" json:"transaction_id"`  // External transaction ID
-	Amount        float64        `gorm:"type:decimal(10,2);not null" json:"amount"`
-	Status        string         `gorm:"type:varchar(20);default:'PENDING'" json:"status"` // PENDING, SUCCESS, FAILED, CANCELLED
-	PaymentURL    string         `gorm:"type:text" json:"payment_url"`                     // For redirect-based payments
-	MerchantCode  string         `gorm:"type:varchar(100)" json:"merchant_code"`
-	ProductCode   string         `gorm:"type:varchar(100)" json:"product_code"`
-	ProductName   string         `gorm:"type:varchar(200)" json:"product_name"`
-	EsewaResponse datatypes.JSON `gorm:"type:json" json:"esewa_response"` // Store structured eSewa response
-	FailureReason string         `gorm:"type:text" json:"failure_reason"`
-
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Ref            *string        `gorm:"type:varchar(40)" json:"ref,omitempty"` // Human-readable reference, e.g. TX-2082-000123
+	OrderID        uuid.UUID      `gorm:"type:uuid;not null" json:"order_id"`
+	Order          Order          `gorm:"foreignKey:OrderID" json:"order"`
+	UserID         uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	User           User           `gorm:"foreignKey:UserID" json:"user"`
+	PaymentMethod  string         `gorm:"type:varchar(50);not null" json:"payment_method"` // ESEWA, CASH, CARD, CONNECTIPS, etc.
+	TransactionID  string         `gorm:"type:varchar(100);unique" json:"transaction_id"`  // External transaction ID
+	Amount         float64        `gorm:"type:decimal(10,2);not null" json:"amount"`
+	TaxAmount      float64        `gorm:"type:decimal(10,2);not null;default:0" json:"tax_amount"`      // VAT included in Amount
+	ServiceCharge  float64        `gorm:"type:decimal(10,2);not null;default:0" json:"service_charge"`  // Service charge included in Amount
+	DeliveryCharge float64        `gorm:"type:decimal(10,2);not null;default:0" json:"delivery_charge"` // Delivery charge included in Amount
+	Status         string         `gorm:"type:varchar(20);default:'PENDING'" json:"status"`             // PENDING, SUCCESS, FAILED, CANCELLED
+	PaidAt         *time.Time     `json:"paid_at,omitempty"`                                            // When the transaction moved to SUCCESS
+	GatewayFee     float64        `gorm:"type:decimal(10,2);not null;default:0" json:"gateway_fee"`     // Fee kept by the gateway
+	NetAmount      float64        `gorm:"type:decimal(10,2);not null;default:0" json:"net_amount"`      // Amount less GatewayFee
+	FeeSource      string         `gorm:"type:varchar(20)" json:"fee_source,omitempty"`                 // COMPUTED, SETTLED
+	PaymentURL     string         `gorm:"type:text" json:"payment_url"`                                 // For redirect-based payments
+	MerchantCode   string         `gorm:"type:varchar(100)" json:"merchant_code"`
+	ProductCode    string         `gorm:"type:varchar(100)" json:"product_code"`
+	ProductName    string         `gorm:"type:varchar(200)" json:"product_name"`
+	EsewaResponse  datatypes.JSON `gorm:"type:json" json:"esewa_response"` // Store structured eSewa response
+	FailureReason  string         `gorm:"type:text" json:"failure_reason"`
+	NeedsReview    bool           `gorm:"not null;default:false" json:"needs_review"` // Flagged by settlement reconciliation or the risk checks
+	ReviewReason   string         `gorm:"type:varchar(255)" json:"review_reason,omitempty"`
+
+	// How Amount is paid. A single-method payment has one tender.
+	Tenders []TransactionTender `gorm:"foreignKey:TransactionID" json:"tenders"`
+
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAtBS string    `gorm:"-" json:"created_at_bs,omitempty"` // Filled when ?calendar=bs
+}
+
+// TransactionTender is the part of a transaction paid with one method
+type TransactionTender struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TransactionID uuid.UUID  `gorm:"type:uuid;not null" json:"transaction_id"`
+	PaymentMethod string     `gorm:"type:varchar(50);not null" json:"payment_method"`
+	Amount        float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	GiftCardID    *uuid.UUID `gorm:"type:uuid" json:"gift_card_id,omitempty"` // GIFT_CARD tenders only
+}
+
+// RefOrID is the transaction's reference, or its ID if it was created before
+// references were introduced
+func (t *Transaction) RefOrID() string {
+	if t.Ref != nil {
+		return *t.Ref
+	}
+	return t.ID.String()
+}
+
+// TenderAmount returns the amount paid with method
+func (t *Transaction) TenderAmount(method string) float64 {
+	var amount float64
+	for _, tender := range t.Tenders {
+		if tender.PaymentMethod == method {
+			amount += tender.Amount
+		}
+	}
+	return amount
+}
+
+// GatewayAmount returns the amount paid other than with store credit or a
+// gift card: the part that can be refunded through the original payment
+func (t *Transaction) GatewayAmount() float64 {
+	if len(t.Tenders) == 0 {
+		if t.PaymentMethod == PaymentMethodStoreCredit || t.PaymentMethod == PaymentMethodGiftCard {
+			return 0
+		}
+		return t.Amount
+	}
+	return t.Amount - t.TenderAmount(PaymentMethodStoreCredit) - t.TenderAmount(PaymentMethodGiftCard)
+}
+
+// PaymentMethods lists the tender methods, e.g. "ESEWA + STORE_CREDIT"
+func (t *Transaction) PaymentMethods() string {
+	if len(t.Tenders) == 0 {
+		return t.PaymentMethod
+	}
+	methods := make([]string, 0, len(t.Tenders))
+	for _, tender := range t.Tenders {
+		methods = append(methods, tender.PaymentMethod)
+	}
+	return strings.Join(methods, " + ")
 }
 
 // Transaction status constants
@@ -41,12 +109,18 @@ const (
 	PaymentMethodEsewa = "ESEWA"
 	PaymentMethodCash  = "CASH"
 	PaymentMethodCard  = "CARD"
+
+	PaymentMethodConnectIPS   = "CONNECTIPS"
+	PaymentMethodBankTransfer = "BANK_TRANSFER"
+
+	PaymentMethodStoreCredit = "STORE_CREDIT"
+	PaymentMethodGiftCard    = "GIFT_CARD"
 )
 
 type EsewaPaymentRequest struct {
 	Amount                float64 `json:"amount" binding:"required,min=0.01"`
 	TaxAmount             float64 `json:"tax_amount" binding:"min=0"`
-	ProductCode           string  `json:"product_code" binding:"required"`
+	ProductCode           string  `json:"product_code"` // Ignored; the merchant's configured product code is used
 	ProductName           string  `json:"product_name" binding:"required"`
 	ProductServiceCharge  float64 `json:"product_service_charge" binding:"min=0"`
 	ProductDeliveryCharge float64 `json:"product_delivery_charge" binding:"min=0"`
@@ -65,10 +139,12 @@ type EsewaPaymentResponse struct {
 	RefID         string `json:"ref_id"`
 }
 
+// EsewaResponseData is the decoded callback eSewa sends to the success URL
 type EsewaResponseData struct {
-	TransactionCode  string `json:"transaction_code"`
+	TransactionCode  string `json:"transaction_code"` // eSewa's reference for the payment
 	Status           string `json:"status"`
 	TotalAmount      string `json:"total_amount"`
+	TransactionUUID  string `json:"transaction_uuid"` // Our transaction ID as sent with the form
 	ProductCode      string `json:"product_code"`
 	RefID            string `json:"ref_id"`
 	Message          string `json:"message"`
@@ -76,6 +152,23 @@ type EsewaResponseData struct {
 	Signature        string `json:"signature"`
 }
 
+// Reference is the transaction the callback is for. Older clients sent our
+// transaction ID as transaction_code with no transaction_uuid.
+func (d *EsewaResponseData) Reference() string {
+	if d.TransactionUUID != "" {
+		return d.TransactionUUID
+	}
+	return d.TransactionCode
+}
+
+// GatewayRef is eSewa's reference for the payment
+func (d *EsewaResponseData) GatewayRef() string {
+	if d.RefID == "" && d.TransactionUUID != "" {
+		return d.TransactionCode
+	}
+	return d.RefID
+}
+
 type TransactionUpdateRequest struct {
 	Status        string `json:"status" binding:"required,oneof=PENDING SUCCESS FAILED CANCELLED"`
 	TransactionID string `json:"transaction_id"`
@@ -85,6 +178,16 @@ type TransactionUpdateRequest struct {
 
 type CreateTransactionRequest struct {
 	OrderID       uuid.UUID `json:"order_id" binding:"required"`
-	PaymentMethod string    `json:"payment_method" binding:"required,oneof=ESEWA CASH CARD"`
+	PaymentMethod string    `json:"payment_method" binding:"required,oneof=ESEWA CASH CARD CONNECTIPS BANK_TRANSFER STORE_CREDIT GIFT_CARD"`
 	Amount        float64   `json:"amount" binding:"required,min=0.01,gt=0"`
+
+	// Optional split payment, e.g. part STORE_CREDIT and part ESEWA.
+	// Tender amounts must add up to Amount.
+	Tenders []TenderRequest `json:"tenders" binding:"omitempty,dive"`
+}
+
+type TenderRequest struct {
+	PaymentMethod string  `json:"payment_method" binding:"required,oneof=ESEWA CASH CARD CONNECTIPS BANK_TRANSFER STORE_CREDIT GIFT_CARD"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	GiftCardCode  string  `json:"gift_card_code" binding:"required_if=PaymentMethod GIFT_CARD"`
 }
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort orders for transaction listings; a leading "-" means descending
+const (
+	TransactionSortCreatedAt     = "created_at"
+	TransactionSortCreatedAtDesc = "-created_at"
+	TransactionSortAmount        = "amount"
+	TransactionSortAmountDesc    = "-amount"
+)
+
+// Transaction list views. The summary view leaves out the order, its items
+// and the gateway payloads; full loads every transaction as GET /transactions/:id does.
+const (
+	TransactionViewSummary = "summary"
+	TransactionViewFull    = "full"
+)
+
+// TransactionFilter narrows a transaction listing. Zero values match everything.
+type TransactionFilter struct {
+	Statuses       []string   `json:"statuses,omitempty"`
+	PaymentMethods []string   `json:"payment_methods,omitempty"` // matches the transaction's method or any of its tenders
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	OrderID        *uuid.UUID `json:"order_id,omitempty"`
+	From           *time.Time `json:"from,omitempty"` // created at or after
+	To             *time.Time `json:"to,omitempty"`   // created before
+	MinAmount      *float64   `json:"min_amount,omitempty"`
+	MaxAmount      *float64   `json:"max_amount,omitempty"`
+}
+
+// TransactionCursor marks the last row of a page: the next page starts after it
+type TransactionCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"c"`
+	Amount    float64   `json:"a,omitempty"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// Encode returns the cursor as an opaque string for the next_cursor field
+func (c *TransactionCursor) Encode() string {
+	body, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+// ParseTransactionCursor reads a cursor made by Encode. It must have been
+// made for the same sort order.
+func ParseTransactionCursor(s, sort string) (*TransactionCursor, error) {
+	body, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor TransactionCursor
+	if err := json.Unmarshal(body, &cursor); err != nil || cursor.Sort != sort || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// TransactionListQuery is one page of a transaction listing
+type TransactionListQuery struct {
+	Filter TransactionFilter
+	Sort   string
+	Limit  int
+	After  *TransactionCursor
+	View   string
+}
+
+// TransactionSummary is the summary view of a transaction
+type TransactionSummary struct {
+	ID            uuid.UUID  `json:"id"`
+	Ref           *string    `json:"ref,omitempty"`
+	OrderID       uuid.UUID  `json:"order_id"`
+	OrderRef      *string    `json:"order_ref,omitempty"`
+	UserID        uuid.UUID  `json:"user_id"`
+	UserName      string     `json:"user_name"`
+	UserEmail     string     `json:"user_email"`
+	PaymentMethod string     `json:"payment_method"`
+	TransactionID string     `json:"transaction_id"` // External transaction ID
+	Amount        float64    `json:"amount"`
+	Status        string     `json:"status"`
+	ProductName   string     `json:"product_name"`
+	FailureReason string     `json:"failure_reason"`
+	NeedsReview   bool       `json:"needs_review"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	CreatedAtBS   string     `json:"created_at_bs,omitempty"` // Filled when ?calendar=bs
+}
+
+// TransactionTotals sums every transaction matching the filter, not just
+// the page. ByStatus ignores the status filter so it can label status tabs.
+type TransactionTotals struct {
+	Count    int64                           `json:"count"`
+	Amount   float64                         `json:"amount"`
+	ByStatus map[string]TransactionStatusSum `json:"by_status"`
+}
+
+type TransactionStatusSum struct {
+	Count  int64   `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// TransactionPage is a page of a transaction listing. Items holds
+// []TransactionSummary or, for the full view, []Transaction. NextCursor is
+// empty on the last page.
+type TransactionPage struct {
+	Items      interface{}       `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Totals     TransactionTotals `json:"totals"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	tests := []TransactionCursor{
+		{Sort: TransactionSortCreatedAtDesc, CreatedAt: time.Date(2025, time.July, 17, 10, 30, 0, 123456000, time.UTC), ID: uuid.New()},
+		{Sort: TransactionSortAmount, CreatedAt: time.Date(2025, time.July, 17, 4, 45, 0, 0, time.UTC), Amount: 1556.75, ID: uuid.New()},
+		{Sort: TransactionSortAmountDesc, CreatedAt: time.Date(2025, time.July, 17, 4, 45, 0, 0, time.UTC), Amount: 0, ID: uuid.New()},
+	}
+	for _, want := range tests {
+		encoded := want.Encode()
+		got, err := ParseTransactionCursor(encoded, want.Sort)
+		if err != nil {
+			t.Fatalf("%s: ParseTransactionCursor(%q): %v", want.Sort, encoded, err)
+		}
+		if got.Sort != want.Sort || !got.CreatedAt.Equal(want.CreatedAt) || got.Amount != want.Amount || got.ID != want.ID {
+			t.Errorf("%s: round trip = %+v, want %+v", want.Sort, *got, want)
+		}
+	}
+}
+
+func TestParseTransactionCursorRejects(t *testing.T) {
+	cursor := TransactionCursor{Sort: TransactionSortCreatedAtDesc, CreatedAt: time.Now(), ID: uuid.New()}
+	encoded := cursor.Encode()
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{"made for another sort", encoded, TransactionSortAmount},
+		{"not base64", "not a cursor!", TransactionSortCreatedAtDesc},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"-created_at"}`)), TransactionSortCreatedAtDesc},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("hello")), TransactionSortCreatedAtDesc},
+		{"no ID", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-created_at","c":"2025-07-17T10:30:00Z"}`)), TransactionSortCreatedAtDesc},
+		{"bad time", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-created_at","c":"yesterday","i":"` + uuid.NewString() + `"}`)), TransactionSortCreatedAtDesc},
+		{"empty", "", TransactionSortCreatedAtDesc},
+	}
+	for _, tt := range tests {
+		if _, err := ParseTransactionCursor(tt.cursor, tt.sort); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+}
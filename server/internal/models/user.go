@@ -7,11 +7,26 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
-	Email     string    `gorm:"uniqueIndex;type:varchar(100);not null" json:"email"`
-	Password  string    `gorm:"type:varchar(255);not null" json:"-"`
-	Role      string    `gorm:"type:varchar(20);default:'customer'" json:"role"` // customer, admin
+	ID       uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name     string    `gorm:"type:varchar(100);not null" json:"name"`
+	Email    string    `gorm:"uniqueIndex;type:varchar(100);not null" json:"email"`
+	Password string    `gorm:"type:varchar(255);not null" json:"-"`
+	Role     string    `gorm:"type:varchar(20);default:'customer'" json:"role"` // customer, admin
+
+	AccountType string `gorm:"type:varchar(20);not null;default:'INDIVIDUAL'" json:"account_type"` // INDIVIDUAL, INSTITUTIONAL
+	Verified    bool   `gorm:"not null;default:false" json:"verified"`                             // Set by an admin after KYC
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// User account types
+const (
+	AccountTypeIndividual    = "INDIVIDUAL"
+	AccountTypeInstitutional = "INSTITUTIONAL"
+)
+
+type UpdateUserAccountRequest struct {
+	AccountType string `json:"account_type" binding:"required,oneof=INDIVIDUAL INSTITUTIONAL"`
+	Verified    bool   `json:"verified"`
+}
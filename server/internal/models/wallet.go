@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WalletEntry is a row in a customer's append-only store credit ledger.
+//
+// CREDIT rows add a lot of credit that may expire. DEBIT and EXPIRY rows take
+// value out of exactly one lot, named by CreditID, so the unused part of every
+// lot can be worked out from the ledger alone.
+type WalletEntry struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type          string     `gorm:"type:varchar(20);not null" json:"type"`     // CREDIT, DEBIT, EXPIRY
+	Source        string     `gorm:"type:varchar(20);not null" json:"source"`   // GOODWILL, REFUND, REVERSAL, PAYMENT, EXPIRY
+	Amount        float64    `gorm:"type:decimal(10,2);not null" json:"amount"` // Always positive
+	CreditID      *uuid.UUID `gorm:"type:uuid" json:"credit_id,omitempty"`      // Lot a DEBIT or EXPIRY draws from
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`                      // CREDIT only, nil = never
+	TransactionID *uuid.UUID `gorm:"type:uuid" json:"transaction_id,omitempty"`
+	RefundID      *uuid.UUID `gorm:"type:uuid" json:"refund_id,omitempty"`
+	Description   string     `gorm:"type:varchar(255)" json:"description"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Wallet entry type constants
+const (
+	WalletEntryCredit = "CREDIT"
+	WalletEntryDebit  = "DEBIT"
+	WalletEntryExpiry = "EXPIRY"
+)
+
+// Wallet entry source constants
+const (
+	WalletSourceGoodwill = "GOODWILL"
+	WalletSourceRefund   = "REFUND"
+	WalletSourceReversal = "REVERSAL" // Credit returned when a payment using it fails
+	WalletSourcePayment  = "PAYMENT"
+	WalletSourceExpiry   = "EXPIRY"
+)
+
+// SignedAmount is the entry's effect on the balance
+func (e *WalletEntry) SignedAmount() float64 {
+	if e.Type == WalletEntryCredit {
+		return e.Amount
+	}
+	return -e.Amount
+}
+
+// Wallet is a customer's store credit balance with its ledger
+type Wallet struct {
+	UserID  uuid.UUID     `json:"user_id"`
+	Balance float64       `json:"balance"`
+	Entries []WalletEntry `json:"entries"`
+}
+
+type WalletCreditRequest struct {
+	UserID      uuid.UUID  `json:"user_id" binding:"required"`
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	Description string     `json:"description" binding:"required,max=255"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
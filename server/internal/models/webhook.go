@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// WebhookEndpoint is a URL that receives the events it subscribes to
+type WebhookEndpoint struct {
+	ID          uuid.UUID                   `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	URL         string                      `gorm:"type:varchar(500);not null" json:"url"`
+	Description string                      `gorm:"type:varchar(255)" json:"description"`
+	Events      datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"events"`
+	Secret      string                      `gorm:"type:varchar(100);not null" json:"-"` // HMAC key, only shown when created or rotated
+	Active      bool                        `gorm:"not null;default:true" json:"active"`
+	CreatedBy   *uuid.UUID                  `gorm:"type:uuid" json:"created_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint takes events of the given type
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	for _, event := range e.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one endpoint. It is retried with
+// backoff until DELIVERED or, after too many attempts, DEAD.
+type WebhookDelivery struct {
+	ID             uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	EndpointID     uuid.UUID      `gorm:"type:uuid;not null" json:"endpoint_id"`
+	EventID        uuid.UUID      `gorm:"type:uuid;not null" json:"event_id"` // Shared by every endpoint's copy of the event
+	EventType      string         `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        datatypes.JSON `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string         `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"` // PENDING, DELIVERED, DEAD
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`                        // Since it was last queued
+	NextAttemptAt  time.Time      `gorm:"not null" json:"next_attempt_at"`
+	LastStatusCode int            `json:"last_status_code,omitempty"`
+	LastError      string         `gorm:"type:text" json:"last_error"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Endpoint *WebhookEndpoint `gorm:"foreignKey:EndpointID" json:"endpoint,omitempty"`
+}
+
+// WebhookAttempt logs one request made for a delivery
+type WebhookAttempt struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DeliveryID   uuid.UUID `gorm:"type:uuid;not null" json:"delivery_id"`
+	StatusCode   int       `json:"status_code,omitempty"` // 0 when no response was received
+	ResponseBody string    `gorm:"type:text" json:"response_body,omitempty"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMs   int64     `gorm:"not null" json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WebhookEvent is the JSON body sent to endpoints. ID is the outbox event
+// ID, the same for every endpoint and every redelivery.
+type WebhookEvent struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=order.created order.cancelled transaction.succeeded transaction.failed refund.completed"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookSecretResponse returns an endpoint with its signing secret
+type WebhookSecretResponse struct {
+	Endpoint *WebhookEndpoint `json:"endpoint"`
+	Secret   string           `json:"secret"`
+}
+
+// Webhook event types, a subset of the outbox events
+var WebhookEvents = []string{
+	EventOrderCreated,
+	EventOrderCancelled,
+	EventTransactionSucceeded,
+	EventTransactionFailed,
+	EventRefundCompleted,
+}
+
+// Webhook delivery status constants
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryDead      = "DEAD"
+)
@@ -72,6 +72,9 @@ func (r *bookRepository) Update(ctx context.Context, id uuid.UUID, updateData mo
 	book.Stock = updateData.Stock
 	book.Description = updateData.Description
 	book.CategoryID = updateData.CategoryID
+	if updateData.ProductType != "" {
+		book.ProductType = updateData.ProductType
+	}
 
 	if err := r.db.WithContext(ctx).Save(&book).Error; err != nil {
 		return nil, err
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CBMSSyncRepository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.CBMSSyncJob, error)
+	MarkSynced(ctx context.Context, id uuid.UUID) error
+	MarkAttemptFailed(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, lastError string) error
+	GetAll(ctx context.Context, status string) ([]models.CBMSSyncJob, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.CBMSSyncJob, error)
+	GetByInvoiceID(ctx context.Context, invoiceID uuid.UUID) ([]models.CBMSSyncJob, error)
+	Requeue(ctx context.Context, id *uuid.UUID) (int64, error)
+}
+
+type cbmsSyncRepository struct {
+	db *gorm.DB
+}
+
+func NewCBMSSyncRepository(db *gorm.DB) CBMSSyncRepository {
+	return &cbmsSyncRepository{db: db}
+}
+
+// enqueueCBMSSync adds a job inside the transaction that issues its document,
+// so a stored document is always queued. A job already queued for the same
+// document is left alone.
+func enqueueCBMSSync(tx *gorm.DB, job *models.CBMSSyncJob) error {
+	if job.NextAttemptAt.IsZero() {
+		job.NextAttemptAt = time.Now()
+	}
+	job.Status = models.CBMSSyncStatusPending
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+}
+
+// ClaimDue returns up to limit due PENDING jobs and pushes their next attempt
+// out by lease, so concurrent workers do not pick the same job.
+func (r *cbmsSyncRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.CBMSSyncJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var jobs []models.CBMSSyncJob
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE cbms_sync_jobs SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM cbms_sync_jobs
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, time.Now().Add(lease), models.CBMSSyncStatusPending, time.Now(), limit).
+		Scan(&jobs).Error
+	return jobs, err
+}
+
+func (r *cbmsSyncRepository) MarkSynced(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&models.CBMSSyncJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.CBMSSyncStatusSynced,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": "",
+		"synced_at":  now,
+	}).Error
+}
+
+func (r *cbmsSyncRepository) MarkAttemptFailed(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&models.CBMSSyncJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}).Error
+}
+
+// GetAll lists jobs, newest first, optionally filtered by status
+func (r *cbmsSyncRepository) GetAll(ctx context.Context, status string) ([]models.CBMSSyncJob, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var jobs []models.CBMSSyncJob
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *cbmsSyncRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CBMSSyncJob, error) {
+	var job models.CBMSSyncJob
+	if err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *cbmsSyncRepository) GetByInvoiceID(ctx context.Context, invoiceID uuid.UUID) ([]models.CBMSSyncJob, error) {
+	var jobs []models.CBMSSyncJob
+	if err := r.db.WithContext(ctx).
+		Where("invoice_id = ?", invoiceID).
+		Order("created_at").
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Requeue resets FAILED jobs (or the one given) to PENDING and due now
+func (r *cbmsSyncRepository) Requeue(ctx context.Context, id *uuid.UUID) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.CBMSSyncJob{})
+	if id != nil {
+		query = query.Where("id = ? AND status <> ?", *id, models.CBMSSyncStatusSynced)
+	} else {
+		query = query.Where("status = ?", models.CBMSSyncStatusFailed)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"status":          models.CBMSSyncStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
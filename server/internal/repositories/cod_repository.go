@@ -0,0 +1,322 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCODClosed is returned when a delivery is already fully collected or refused
+	ErrCODClosed = errors.New("delivery is already collected or refused")
+	// ErrCODOverCollection is returned when a collection exceeds what is still owed
+	ErrCODOverCollection = errors.New("collection exceeds the outstanding amount")
+	// ErrCODTransactionClosed is returned for a collection on a transaction that is no longer pending
+	ErrCODTransactionClosed = errors.New("transaction is no longer pending")
+)
+
+// codOpenStatuses are the delivery statuses that still take collections
+var codOpenStatuses = []string{models.CODStatusAssigned, models.CODStatusPartiallyCollected}
+
+// closeCODDeliveries cancels the open delivery of a transaction that failed
+// or was cancelled. Must run in the transaction that changes its status.
+func closeCODDeliveries(tx *gorm.DB, transactionID uuid.UUID) error {
+	return tx.Model(&models.CODDelivery{}).
+		Where("transaction_id = ? AND status IN ?", transactionID, codOpenStatuses).
+		Updates(map[string]interface{}{"status": models.CODStatusCancelled, "completed_at": time.Now()}).Error
+}
+
+type CODRepository interface {
+	CreateAgent(ctx context.Context, agent *models.DeliveryAgent) (*models.DeliveryAgent, error)
+	GetAgents(ctx context.Context) ([]models.DeliveryAgent, error)
+	GetAgentByID(ctx context.Context, id uuid.UUID) (*models.DeliveryAgent, error)
+	UpdateAgent(ctx context.Context, id uuid.UUID, updateData *models.DeliveryAgent) (*models.DeliveryAgent, error)
+
+	CreateDelivery(ctx context.Context, delivery *models.CODDelivery) (*models.CODDelivery, error)
+	GetDeliveryByID(ctx context.Context, id uuid.UUID) (*models.CODDelivery, error)
+	GetDeliveryByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.CODDelivery, error)
+	GetDeliveries(ctx context.Context, status string, agentID *uuid.UUID) ([]models.CODDelivery, error)
+	Reassign(ctx context.Context, id, agentID uuid.UUID) (*models.CODDelivery, error)
+	// AwaitingAssignment lists pending CASH transactions that have no delivery yet
+	AwaitingAssignment(ctx context.Context) ([]models.Transaction, error)
+	// AddCollection records cash taken on a delivery and marks it COLLECTED
+	// once nothing is outstanding. The delivery's transaction must still be
+	// PENDING; otherwise ErrCODTransactionClosed is returned.
+	AddCollection(ctx context.Context, deliveryID uuid.UUID, collection *models.CODCollection) (*models.CODDelivery, error)
+	// Refuse closes a delivery on which no cash was collected
+	Refuse(ctx context.Context, id uuid.UUID, reason string) (*models.CODDelivery, error)
+
+	// CreateRemittance saves a remittance together with the agent's collections
+	// before cutoff that no earlier remittance covered
+	CreateRemittance(ctx context.Context, remittance *models.CODRemittance, cutoff time.Time) (*models.CODRemittance, error)
+	GetRemittances(ctx context.Context, agentID *uuid.UUID, from, to time.Time) ([]models.CODRemittance, error)
+	GetRemittanceByID(ctx context.Context, id uuid.UUID) (*models.CODRemittance, error)
+	UnremittedCollections(ctx context.Context, from, to time.Time) ([]models.CODCollection, error)
+}
+
+type codRepository struct {
+	db *gorm.DB
+}
+
+func NewCODRepository(db *gorm.DB) CODRepository {
+	return &codRepository{db: db}
+}
+
+func (r *codRepository) CreateAgent(ctx context.Context, agent *models.DeliveryAgent) (*models.DeliveryAgent, error) {
+	if err := r.db.WithContext(ctx).Create(agent).Error; err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+func (r *codRepository) GetAgents(ctx context.Context) ([]models.DeliveryAgent, error) {
+	var agents []models.DeliveryAgent
+	err := r.db.WithContext(ctx).Order("name").Find(&agents).Error
+	return agents, err
+}
+
+func (r *codRepository) GetAgentByID(ctx context.Context, id uuid.UUID) (*models.DeliveryAgent, error) {
+	var agent models.DeliveryAgent
+	if err := r.db.WithContext(ctx).First(&agent, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+func (r *codRepository) UpdateAgent(ctx context.Context, id uuid.UUID, updateData *models.DeliveryAgent) (*models.DeliveryAgent, error) {
+	var agent models.DeliveryAgent
+	if err := r.db.WithContext(ctx).First(&agent, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&agent).
+		Select("name", "phone", "courier", "active").
+		Updates(updateData).Error; err != nil {
+		return nil, err
+	}
+	return r.GetAgentByID(ctx, id)
+}
+
+func (r *codRepository) CreateDelivery(ctx context.Context, delivery *models.CODDelivery) (*models.CODDelivery, error) {
+	if err := r.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	return r.GetDeliveryByID(ctx, delivery.ID)
+}
+
+func (r *codRepository) preloadDelivery(db *gorm.DB) *gorm.DB {
+	return db.Preload("Agent").
+		Preload("Collections", func(db *gorm.DB) *gorm.DB { return db.Order("collected_at") })
+}
+
+func (r *codRepository) GetDeliveryByID(ctx context.Context, id uuid.UUID) (*models.CODDelivery, error) {
+	var delivery models.CODDelivery
+	if err := r.preloadDelivery(r.db.WithContext(ctx)).First(&delivery, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *codRepository) GetDeliveryByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.CODDelivery, error) {
+	var delivery models.CODDelivery
+	if err := r.preloadDelivery(r.db.WithContext(ctx)).First(&delivery, "transaction_id = ?", transactionID).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *codRepository) GetDeliveries(ctx context.Context, status string, agentID *uuid.UUID) ([]models.CODDelivery, error) {
+	query := r.preloadDelivery(r.db.WithContext(ctx)).Order("assigned_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if agentID != nil {
+		query = query.Where("agent_id = ?", *agentID)
+	}
+
+	var deliveries []models.CODDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *codRepository) Reassign(ctx context.Context, id, agentID uuid.UUID) (*models.CODDelivery, error) {
+	result := r.db.WithContext(ctx).Model(&models.CODDelivery{}).
+		Where("id = ? AND status IN ?", id, codOpenStatuses).
+		Updates(map[string]interface{}{"agent_id": agentID, "assigned_at": time.Now()})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrCODClosed
+	}
+	return r.GetDeliveryByID(ctx, id)
+}
+
+func (r *codRepository) AwaitingAssignment(ctx context.Context) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).
+		Preload("Order").
+		Preload("User").
+		Preload("Tenders").
+		Where("status = ? AND payment_method = ?", models.TransactionStatusPending, models.PaymentMethodCash).
+		Where("NOT EXISTS (SELECT 1 FROM cod_deliveries d WHERE d.transaction_id = transactions.id)").
+		Order("created_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *codRepository) AddCollection(ctx context.Context, deliveryID uuid.UUID, collection *models.CODCollection) (*models.CODDelivery, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var delivery models.CODDelivery
+		if err := tx.Select("transaction_id").First(&delivery, "id = ?", deliveryID).Error; err != nil {
+			return err
+		}
+		// Locked before the delivery, in the order a status change takes them
+		var transaction models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id", "status").
+			First(&transaction, "id = ?", delivery.TransactionID).Error; err != nil {
+			return err
+		}
+		if transaction.Status != models.TransactionStatusPending {
+			return ErrCODTransactionClosed
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, "id = ?", deliveryID).Error; err != nil {
+			return err
+		}
+		if delivery.Status != models.CODStatusAssigned && delivery.Status != models.CODStatusPartiallyCollected {
+			return ErrCODClosed
+		}
+		if utils.RoundMoney(collection.Amount) > utils.RoundMoney(delivery.Outstanding()) {
+			return ErrCODOverCollection
+		}
+
+		collection.DeliveryID = delivery.ID
+		collection.AgentID = delivery.AgentID
+		if err := tx.Create(collection).Error; err != nil {
+			return err
+		}
+
+		collected := utils.RoundMoney(delivery.CollectedAmount + collection.Amount)
+		updates := map[string]interface{}{
+			"collected_amount": collected,
+			"status":           models.CODStatusPartiallyCollected,
+		}
+		if collected >= utils.RoundMoney(delivery.ExpectedAmount) {
+			updates["status"] = models.CODStatusCollected
+			updates["completed_at"] = collection.CollectedAt
+		}
+		return tx.Model(&delivery).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDeliveryByID(ctx, deliveryID)
+}
+
+func (r *codRepository) Refuse(ctx context.Context, id uuid.UUID, reason string) (*models.CODDelivery, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var delivery models.CODDelivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, "id = ?", id).Error; err != nil {
+			return err
+		}
+		switch delivery.Status {
+		case models.CODStatusAssigned:
+		case models.CODStatusPartiallyCollected:
+			return errors.New("cash was already collected on this delivery; collect the rest or return it before refusing")
+		default:
+			return ErrCODClosed
+		}
+
+		return tx.Model(&delivery).Updates(map[string]interface{}{
+			"status":         models.CODStatusRefused,
+			"refusal_reason": reason,
+			"completed_at":   time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetDeliveryByID(ctx, id)
+}
+
+func (r *codRepository) CreateRemittance(ctx context.Context, remittance *models.CODRemittance, cutoff time.Time) (*models.CODRemittance, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var collections []models.CODCollection
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("agent_id = ? AND remittance_id IS NULL AND collected_at < ?", remittance.AgentID, cutoff).
+			Find(&collections).Error; err != nil {
+			return err
+		}
+
+		var expected float64
+		ids := make([]uuid.UUID, 0, len(collections))
+		for _, collection := range collections {
+			expected += collection.Amount
+			ids = append(ids, collection.ID)
+		}
+		remittance.ExpectedAmount = utils.RoundMoney(expected)
+		remittance.Difference = utils.RoundMoney(remittance.Amount - remittance.ExpectedAmount)
+		switch {
+		case remittance.Difference < 0:
+			remittance.Status = models.RemittanceShort
+		case remittance.Difference > 0:
+			remittance.Status = models.RemittanceOver
+		default:
+			remittance.Status = models.RemittanceBalanced
+		}
+
+		if err := tx.Create(remittance).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.CODCollection{}).Where("id IN ?", ids).Update("remittance_id", remittance.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetRemittanceByID(ctx, remittance.ID)
+}
+
+func (r *codRepository) GetRemittances(ctx context.Context, agentID *uuid.UUID, from, to time.Time) ([]models.CODRemittance, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Agent").
+		Preload("Collections").
+		Where("remittance_date >= ? AND remittance_date < ?", from, to).
+		Order("remittance_date DESC, created_at DESC")
+	if agentID != nil {
+		query = query.Where("agent_id = ?", *agentID)
+	}
+
+	var remittances []models.CODRemittance
+	err := query.Find(&remittances).Error
+	return remittances, err
+}
+
+func (r *codRepository) GetRemittanceByID(ctx context.Context, id uuid.UUID) (*models.CODRemittance, error) {
+	var remittance models.CODRemittance
+	if err := r.db.WithContext(ctx).
+		Preload("Agent").
+		Preload("Collections", func(db *gorm.DB) *gorm.DB { return db.Order("collected_at") }).
+		First(&remittance, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &remittance, nil
+}
+
+func (r *codRepository) UnremittedCollections(ctx context.Context, from, to time.Time) ([]models.CODCollection, error) {
+	var collections []models.CODCollection
+	err := r.db.WithContext(ctx).
+		Where("remittance_id IS NULL AND collected_at >= ? AND collected_at < ?", from, to).
+		Order("agent_id, collected_at").
+		Find(&collections).Error
+	return collections, err
+}
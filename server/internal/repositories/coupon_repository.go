@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCouponLimitReached is returned when applying a coupon would exceed its usage limits
+var ErrCouponLimitReached = errors.New("coupon usage limit reached")
+
+type CouponRepository interface {
+	Create(ctx context.Context, coupon *models.Coupon) (*models.Coupon, error)
+	GetAll(ctx context.Context) ([]models.Coupon, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error)
+	GetByCode(ctx context.Context, code string) (*models.Coupon, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Coupon, error)
+	Update(ctx context.Context, id uuid.UUID, updateData *models.Coupon) (*models.Coupon, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	ApplyToOrder(ctx context.Context, coupon *models.Coupon, order *models.Order, amount float64) error
+	RemoveFromOrder(ctx context.Context, order *models.Order) error
+}
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+// countRedemptions counts redemptions on orders that are not cancelled,
+// optionally for one user and ignoring one order. Unpaid orders hold their
+// redemption until they are cancelled or expire; see OrderService.ExpireAbandoned.
+func countRedemptions(tx *gorm.DB, couponID uuid.UUID, userID *uuid.UUID, excludeOrderID *uuid.UUID) (int64, error) {
+	query := tx.Model(&models.CouponRedemption{}).
+		Joins("JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupon_redemptions.coupon_id = ? AND orders.status <> ?", couponID, models.OrderStatusCancelled)
+	if userID != nil {
+		query = query.Where("coupon_redemptions.user_id = ?", *userID)
+	}
+	if excludeOrderID != nil {
+		query = query.Where("coupon_redemptions.order_id <> ?", *excludeOrderID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *couponRepository) withUsage(ctx context.Context, coupon *models.Coupon) error {
+	count, err := countRedemptions(r.db.WithContext(ctx), coupon.ID, nil, nil)
+	coupon.UsedCount = count
+	return err
+}
+
+func (r *couponRepository) Create(ctx context.Context, coupon *models.Coupon) (*models.Coupon, error) {
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	if err := r.db.WithContext(ctx).Omit("Categories.*", "Books.*").Create(coupon).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, coupon.ID)
+}
+
+func (r *couponRepository) GetAll(ctx context.Context) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	if err := r.db.WithContext(ctx).
+		Preload("Categories").
+		Preload("Books").
+		Order("created_at DESC").
+		Find(&coupons).Error; err != nil {
+		return nil, err
+	}
+	for i := range coupons {
+		if err := r.withUsage(ctx, &coupons[i]); err != nil {
+			return nil, err
+		}
+	}
+	return coupons, nil
+}
+
+func (r *couponRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := r.db.WithContext(ctx).
+		Preload("Categories").
+		Preload("Books").
+		First(&coupon, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, r.withUsage(ctx, &coupon)
+}
+
+func (r *couponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	if err := r.db.WithContext(ctx).
+		Preload("Categories").
+		Preload("Books").
+		First(&coupon, "code = ?", strings.ToUpper(strings.TrimSpace(code))).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, r.withUsage(ctx, &coupon)
+}
+
+// GetByOrderID returns the coupon applied to an order
+func (r *couponRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Coupon, error) {
+	var redemption models.CouponRedemption
+	if err := r.db.WithContext(ctx).First(&redemption, "order_id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, redemption.CouponID)
+}
+
+func (r *couponRepository) Update(ctx context.Context, id uuid.UUID, updateData *models.Coupon) (*models.Coupon, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		if err := tx.First(&coupon, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&coupon).
+			Select("code", "description", "discount_type", "value", "max_discount", "min_order_value",
+				"starts_at", "expires_at", "usage_limit", "per_user_limit", "active").
+			Updates(&models.Coupon{
+				Code:          strings.ToUpper(strings.TrimSpace(updateData.Code)),
+				Description:   updateData.Description,
+				DiscountType:  updateData.DiscountType,
+				Value:         updateData.Value,
+				MaxDiscount:   updateData.MaxDiscount,
+				MinOrderValue: updateData.MinOrderValue,
+				StartsAt:      updateData.StartsAt,
+				ExpiresAt:     updateData.ExpiresAt,
+				UsageLimit:    updateData.UsageLimit,
+				PerUserLimit:  updateData.PerUserLimit,
+				Active:        updateData.Active,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&coupon).Association("Categories").Replace(updateData.Categories); err != nil {
+			return err
+		}
+		return tx.Model(&coupon).Association("Books").Replace(updateData.Books)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *couponRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Select("Categories", "Books").Delete(&models.Coupon{ID: id}).Error
+}
+
+// ApplyToOrder records the redemption and saves the repriced order in one transaction.
+// The coupon row is locked while usage limits are rechecked, so concurrent
+// checkouts cannot exceed them.
+func (r *couponRepository) ApplyToOrder(ctx context.Context, coupon *models.Coupon, order *models.Order, amount float64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked models.Coupon
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", coupon.ID).Error; err != nil {
+			return err
+		}
+
+		if locked.UsageLimit > 0 {
+			used, err := countRedemptions(tx, locked.ID, nil, &order.ID)
+			if err != nil {
+				return err
+			}
+			if used >= int64(locked.UsageLimit) {
+				return ErrCouponLimitReached
+			}
+		}
+		if locked.PerUserLimit > 0 {
+			used, err := countRedemptions(tx, locked.ID, &order.UserID, &order.ID)
+			if err != nil {
+				return err
+			}
+			if used >= int64(locked.PerUserLimit) {
+				return ErrCouponLimitReached
+			}
+		}
+
+		if err := tx.Where("order_id = ?", order.ID).Delete(&models.CouponRedemption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.CouponRedemption{
+			CouponID: locked.ID,
+			UserID:   order.UserID,
+			OrderID:  order.ID,
+			Amount:   amount,
+		}).Error; err != nil {
+			return err
+		}
+
+		return saveOrderPricing(tx, order)
+	})
+}
+
+// RemoveFromOrder deletes the order's redemption and saves the repriced order
+func (r *couponRepository) RemoveFromOrder(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("order_id = ?", order.ID).Delete(&models.CouponRedemption{}).Error; err != nil {
+			return err
+		}
+		return saveOrderPricing(tx, order)
+	})
+}
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDisputeState is returned when a dispute is not in a status the change is allowed from
+var ErrDisputeState = errors.New("dispute is not in a state that allows this")
+
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *models.Dispute) (*models.Dispute, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	// GetAll lists disputes by evidence deadline, soonest first
+	GetAll(ctx context.Context, status string, transactionID *uuid.UUID) ([]models.Dispute, error)
+	// Transition applies updates if the dispute is in one of the from statuses
+	Transition(ctx context.Context, id uuid.UUID, from []string, updates map[string]interface{}) (*models.Dispute, error)
+	// Reverse saves the chargeback refund and links it to the dispute in one
+	// transaction; ErrDisputeState means the dispute already has a reversal
+	Reverse(ctx context.Context, id uuid.UUID, refund *models.Refund) (*models.Refund, error)
+
+	AddEvidence(ctx context.Context, evidence *models.DisputeEvidence) (*models.DisputeEvidence, error)
+	GetEvidence(ctx context.Context, id uuid.UUID) (*models.DisputeEvidence, error)
+
+	// DueForNotice lists OPEN disputes due before the given time that have no notification of kind yet
+	DueForNotice(ctx context.Context, before time.Time, kind string) ([]models.Dispute, error)
+	// Notify saves a notification unless the dispute already has one of its kind
+	Notify(ctx context.Context, notification *models.DisputeNotification) (bool, error)
+	GetNotifications(ctx context.Context, unreadOnly bool) ([]models.DisputeNotification, error)
+	MarkNotificationRead(ctx context.Context, id uuid.UUID) error
+}
+
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &disputeRepository{db: db}
+}
+
+func (r *disputeRepository) Create(ctx context.Context, dispute *models.Dispute) (*models.Dispute, error) {
+	if err := r.db.WithContext(ctx).Create(dispute).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, dispute.ID)
+}
+
+func (r *disputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.db.WithContext(ctx).
+		Preload("Transaction").
+		Preload("Evidence", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&dispute, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) GetAll(ctx context.Context, status string, transactionID *uuid.UUID) ([]models.Dispute, error) {
+	query := r.db.WithContext(ctx).Preload("Transaction").Order("evidence_due_at")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if transactionID != nil {
+		query = query.Where("transaction_id = ?", *transactionID)
+	}
+
+	var disputes []models.Dispute
+	err := query.Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) Transition(ctx context.Context, id uuid.UUID, from []string, updates map[string]interface{}) (*models.Dispute, error) {
+	result := r.db.WithContext(ctx).Model(&models.Dispute{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrDisputeState
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *disputeRepository) Reverse(ctx context.Context, id uuid.UUID, refund *models.Refund) (*models.Refund, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the dispute so two resolutions cannot both record a chargeback
+		var dispute models.Dispute
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&dispute, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if dispute.ReversalID != nil {
+			return ErrDisputeState
+		}
+		if err := createRefund(tx, refund, nil); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Dispute{}).
+			Where("id = ? AND reversal_id IS NULL", id).
+			Update("reversal_id", refund.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDisputeState
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (r *disputeRepository) AddEvidence(ctx context.Context, evidence *models.DisputeEvidence) (*models.DisputeEvidence, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the dispute so evidence cannot be added while it is being submitted
+		var dispute models.Dispute
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&dispute, "id = ?", evidence.DisputeID).Error; err != nil {
+			return err
+		}
+		if dispute.Status != models.DisputeStatusOpen {
+			return ErrDisputeState
+		}
+		return tx.Create(evidence).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return evidence, nil
+}
+
+func (r *disputeRepository) GetEvidence(ctx context.Context, id uuid.UUID) (*models.DisputeEvidence, error) {
+	var evidence models.DisputeEvidence
+	if err := r.db.WithContext(ctx).First(&evidence, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &evidence, nil
+}
+
+func (r *disputeRepository) DueForNotice(ctx context.Context, before time.Time, kind string) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND evidence_due_at < ?", models.DisputeStatusOpen, before).
+		Where("NOT EXISTS (SELECT 1 FROM dispute_notifications n WHERE n.dispute_id = disputes.id AND n.kind = ?)", kind).
+		Order("evidence_due_at").
+		Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) Notify(ctx context.Context, notification *models.DisputeNotification) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "dispute_id"}, {Name: "kind"}}, DoNothing: true}).
+		Create(notification)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *disputeRepository) GetNotifications(ctx context.Context, unreadOnly bool) ([]models.DisputeNotification, error) {
+	query := r.db.WithContext(ctx).Preload("Dispute").Order("created_at DESC")
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.DisputeNotification
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *disputeRepository) MarkNotificationRead(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&models.DisputeNotification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.WithContext(ctx).Model(&models.DisputeNotification{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExportRepository interface {
+	Create(ctx context.Context, job *models.ExportJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ExportJob, error)
+	GetAll(ctx context.Context, kind string) ([]models.ExportJob, error)
+	// ClaimNext marks the oldest PENDING job RUNNING and returns it, or nil if
+	// there is none. A RUNNING job started more than stale ago is taken over,
+	// as its worker is assumed to have died.
+	ClaimNext(ctx context.Context, stale time.Duration) (*models.ExportJob, error)
+	MarkDone(ctx context.Context, job *models.ExportJob) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+	// GetExpired returns DONE jobs whose file is past its expiry
+	GetExpired(ctx context.Context, now time.Time) ([]models.ExportJob, error)
+	MarkExpired(ctx context.Context, id uuid.UUID) error
+}
+
+type exportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+func (r *exportRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *exportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetAll lists the latest jobs, newest first, optionally of one kind
+func (r *exportRepository) GetAll(ctx context.Context, kind string) ([]models.ExportJob, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(100)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var jobs []models.ExportJob
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *exportRepository) ClaimNext(ctx context.Context, stale time.Duration) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var jobs []models.ExportJob
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE export_jobs SET status = ?, started_at = ?, error = ''
+		WHERE id IN (
+			SELECT id FROM export_jobs
+			WHERE status = ? OR (status = ? AND started_at < ?)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, models.ExportStatusRunning, now,
+		models.ExportStatusPending, models.ExportStatusRunning, now.Add(-stale)).
+		Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// MarkDone records the finished file of a RUNNING job
+func (r *exportRepository) MarkDone(ctx context.Context, job *models.ExportJob) error {
+	result := r.db.WithContext(ctx).Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", job.ID, models.ExportStatusRunning).
+		Updates(map[string]interface{}{
+			"status":       models.ExportStatusDone,
+			"row_count":    job.RowCount,
+			"file_key":     job.FileKey,
+			"file_name":    job.FileName,
+			"file_size":    job.FileSize,
+			"completed_at": job.CompletedAt,
+			"expires_at":   job.ExpiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("export job is no longer running")
+	}
+	return nil
+}
+
+func (r *exportRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.db.WithContext(ctx).Model(&models.ExportJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.ExportStatusFailed,
+		"error":        reason,
+		"completed_at": time.Now(),
+	}).Error
+}
+
+func (r *exportRepository) GetExpired(ctx context.Context, now time.Time) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", models.ExportStatusDone, now).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *exportRepository) MarkExpired(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.ExportJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    models.ExportStatusExpired,
+		"file_key":  "",
+		"file_size": 0,
+	}).Error
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FeeScheduleRepository interface {
+	Create(ctx context.Context, schedule *models.FeeSchedule) (*models.FeeSchedule, error)
+	GetAll(ctx context.Context, paymentMethod string) ([]models.FeeSchedule, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.FeeSchedule, error)
+	GetEffective(ctx context.Context, paymentMethod string, at time.Time) (*models.FeeSchedule, error)
+	Update(ctx context.Context, id uuid.UUID, updateData *models.FeeSchedule) (*models.FeeSchedule, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type feeScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeScheduleRepository(db *gorm.DB) FeeScheduleRepository {
+	return &feeScheduleRepository{db: db}
+}
+
+func (r *feeScheduleRepository) Create(ctx context.Context, schedule *models.FeeSchedule) (*models.FeeSchedule, error) {
+	if err := r.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (r *feeScheduleRepository) GetAll(ctx context.Context, paymentMethod string) ([]models.FeeSchedule, error) {
+	query := r.db.WithContext(ctx).Order("payment_method, effective_from DESC")
+	if paymentMethod != "" {
+		query = query.Where("payment_method = ?", paymentMethod)
+	}
+
+	var schedules []models.FeeSchedule
+	err := query.Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *feeScheduleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.FeeSchedule, error) {
+	var schedule models.FeeSchedule
+	if err := r.db.WithContext(ctx).First(&schedule, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// GetEffective returns the active schedule for a method with the latest EffectiveFrom not after at
+func (r *feeScheduleRepository) GetEffective(ctx context.Context, paymentMethod string, at time.Time) (*models.FeeSchedule, error) {
+	var schedule models.FeeSchedule
+	if err := r.db.WithContext(ctx).
+		Where("payment_method = ? AND active = ? AND effective_from <= ?", paymentMethod, true, at).
+		Order("effective_from DESC").
+		First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *feeScheduleRepository) Update(ctx context.Context, id uuid.UUID, updateData *models.FeeSchedule) (*models.FeeSchedule, error) {
+	var schedule models.FeeSchedule
+	if err := r.db.WithContext(ctx).First(&schedule, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&schedule).
+		Select("payment_method", "name", "type", "percentage", "fixed_amount", "slabs",
+			"min_fee", "max_fee", "effective_from", "active").
+		Updates(updateData).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *feeScheduleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.FeeSchedule{}, "id = ?", id).Error
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrGiftCardUnusable is returned when a gift card is void or expired
+	ErrGiftCardUnusable = errors.New("gift card is void or expired")
+	// ErrGiftCardBalance is returned when a redemption exceeds the card balance
+	ErrGiftCardBalance = errors.New("insufficient gift card balance")
+)
+
+type GiftCardRepository interface {
+	Create(ctx context.Context, cards []models.GiftCard, createdBy *uuid.UUID) ([]models.GiftCard, error)
+	GetAll(ctx context.Context) ([]models.GiftCard, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	GetByCode(ctx context.Context, code string) (*models.GiftCard, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.GiftCard, error)
+	GetByPurchaserID(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error)
+	GetTransactions(ctx context.Context, id uuid.UUID) ([]models.GiftCardTransaction, error)
+	Void(ctx context.Context, id uuid.UUID, reason string, adminID uuid.UUID) (*models.GiftCard, error)
+	ReverseTransactionRedemptions(ctx context.Context, transactionID uuid.UUID) error
+}
+
+type giftCardRepository struct {
+	db *gorm.DB
+}
+
+func NewGiftCardRepository(db *gorm.DB) GiftCardRepository {
+	return &giftCardRepository{db: db}
+}
+
+// redeemGiftCard takes amount off a gift card inside a transaction
+func redeemGiftCard(tx *gorm.DB, giftCardID uuid.UUID, amount float64, transactionID *uuid.UUID) error {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "id = ?", giftCardID).Error; err != nil {
+		return err
+	}
+	if card.Status != models.GiftCardStatusActive || (card.ExpiresAt != nil && !card.ExpiresAt.After(time.Now())) {
+		return ErrGiftCardUnusable
+	}
+	amount = utils.RoundMoney(amount)
+	if amount > card.Balance {
+		return ErrGiftCardBalance
+	}
+
+	balance := utils.RoundMoney(card.Balance - amount)
+	if err := tx.Model(&card).Update("balance", balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.GiftCardTransaction{
+		GiftCardID:    card.ID,
+		Type:          models.GiftCardTxnRedeem,
+		Amount:        -amount,
+		BalanceAfter:  balance,
+		TransactionID: transactionID,
+		Description:   "Redeemed at checkout",
+	}).Error
+}
+
+// Create saves new gift cards with their ISSUE entries in one transaction
+func (r *giftCardRepository) Create(ctx context.Context, cards []models.GiftCard, createdBy *uuid.UUID) ([]models.GiftCard, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range cards {
+			if err := tx.Create(&cards[i]).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.GiftCardTransaction{
+				GiftCardID:   cards[i].ID,
+				Type:         models.GiftCardTxnIssue,
+				Amount:       cards[i].InitialBalance,
+				BalanceAfter: cards[i].Balance,
+				Description:  "Gift card issued",
+				CreatedBy:    createdBy,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (r *giftCardRepository) GetAll(ctx context.Context) ([]models.GiftCard, error) {
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&cards).Error
+	return cards, err
+}
+
+func (r *giftCardRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	var card models.GiftCard
+	if err := r.db.WithContext(ctx).First(&card, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *giftCardRepository) GetByCode(ctx context.Context, code string) (*models.GiftCard, error) {
+	var card models.GiftCard
+	if err := r.db.WithContext(ctx).First(&card, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *giftCardRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]models.GiftCard, error) {
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at").Find(&cards).Error
+	return cards, err
+}
+
+func (r *giftCardRepository) GetByPurchaserID(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error) {
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).Where("purchaser_id = ?", userID).Order("created_at DESC").Find(&cards).Error
+	return cards, err
+}
+
+func (r *giftCardRepository) GetTransactions(ctx context.Context, id uuid.UUID) ([]models.GiftCardTransaction, error) {
+	var txns []models.GiftCardTransaction
+	err := r.db.WithContext(ctx).Where("gift_card_id = ?", id).Order("created_at").Find(&txns).Error
+	return txns, err
+}
+
+// Void cancels a gift card and writes off its remaining balance
+func (r *giftCardRepository) Void(ctx context.Context, id uuid.UUID, reason string, adminID uuid.UUID) (*models.GiftCard, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var card models.GiftCard
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if card.Status == models.GiftCardStatusVoid {
+			return errors.New("gift card is already void")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&card).Updates(map[string]interface{}{
+			"status":      models.GiftCardStatusVoid,
+			"balance":     0,
+			"voided_at":   now,
+			"void_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.GiftCardTransaction{
+			GiftCardID:  card.ID,
+			Type:        models.GiftCardTxnVoid,
+			Amount:      -card.Balance,
+			Description: reason,
+			CreatedBy:   &adminID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// ReverseTransactionRedemptions puts back what a failed payment took off gift
+// cards. It does nothing if the payment was already reversed, and skips void cards.
+func (r *giftCardRepository) ReverseTransactionRedemptions(ctx context.Context, transactionID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemptions []models.GiftCardTransaction
+		if err := tx.Where("transaction_id = ? AND type = ?", transactionID, models.GiftCardTxnRedeem).
+			Find(&redemptions).Error; err != nil {
+			return err
+		}
+
+		for _, redemption := range redemptions {
+			var card models.GiftCard
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "id = ?", redemption.GiftCardID).Error; err != nil {
+				return err
+			}
+
+			var reversed int64
+			if err := tx.Model(&models.GiftCardTransaction{}).
+				Where("transaction_id = ? AND gift_card_id = ? AND type = ?", transactionID, card.ID, models.GiftCardTxnReversal).
+				Count(&reversed).Error; err != nil {
+				return err
+			}
+			if reversed > 0 || card.Status == models.GiftCardStatusVoid {
+				continue
+			}
+
+			balance := utils.RoundMoney(card.Balance - redemption.Amount)
+			if err := tx.Model(&card).Update("balance", balance).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.GiftCardTransaction{
+				GiftCardID:    card.ID,
+				Type:          models.GiftCardTxnReversal,
+				Amount:        -redemption.Amount,
+				BalanceAfter:  balance,
+				TransactionID: &transactionID,
+				Description:   "Payment did not complete, balance returned",
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
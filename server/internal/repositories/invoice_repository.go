@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceRepository interface {
+	// Issue stores the invoice under the next bill number. If its transaction
+	// already has an issued invoice, that one is returned and no number is used.
+	// With CBMS sync on, the invoice is queued for reporting in the same commit.
+	Issue(ctx context.Context, invoice *models.Invoice) (*models.Invoice, error)
+	GetAll(ctx context.Context) ([]models.Invoice, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Invoice, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error)
+	// Cancel issues a credit note for the invoice, queued for CBMS like Issue
+	Cancel(ctx context.Context, id uuid.UUID, creditNote *models.CreditNote) (*models.Invoice, error)
+	GetIssuedBetween(ctx context.Context, from, to time.Time) ([]models.Invoice, error)
+	GetCreditNotesBetween(ctx context.Context, from, to time.Time) ([]models.CreditNote, error)
+	GetCreditNoteByID(ctx context.Context, id uuid.UUID) (*models.CreditNote, error)
+}
+
+type invoiceRepository struct {
+	db       *gorm.DB
+	syncCBMS bool
+}
+
+func NewInvoiceRepository(db *gorm.DB, syncCBMS bool) InvoiceRepository {
+	return &invoiceRepository{db: db, syncCBMS: syncCBMS}
+}
+
+// nextDocumentNumber increments the counter for a document type and fiscal year.
+// Must run inside a transaction: the row stays locked until commit, which keeps
+// numbering gapless and ordered.
+func nextDocumentNumber(tx *gorm.DB, documentType, fiscalYear string) (int64, error) {
+	var next int64
+	err := tx.Raw(`
+		INSERT INTO document_sequences (document_type, fiscal_year, last_value)
+		VALUES (?, ?, 1)
+		ON CONFLICT (document_type, fiscal_year)
+		DO UPDATE SET last_value = document_sequences.last_value + 1
+		RETURNING last_value`, documentType, fiscalYear).Scan(&next).Error
+	return next, err
+}
+
+// Issue assigns the next bill number for invoice.FiscalYear and stores the invoice
+func (r *invoiceRepository) Issue(ctx context.Context, invoice *models.Invoice) (*models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq, err := nextDocumentNumber(tx, models.DocumentTypeInvoice, invoice.FiscalYear)
+		if err != nil {
+			return err
+		}
+		invoice.Sequence = seq
+		invoice.InvoiceNumber = fmt.Sprintf("%s-%06d", invoice.FiscalYear, seq)
+		invoice.Status = models.InvoiceStatusIssued
+
+		// idx_invoices_transaction_issued allows one issued invoice per
+		// transaction; losing the race rolls the number back too
+		result := tx.Omit(clause.Associations).
+			Clauses(clause.OnConflict{
+				Columns:     []clause.Column{{Name: "transaction_id"}},
+				TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'ISSUED'"}}},
+				DoNothing:   true,
+			}).
+			Create(invoice)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvoiceExists
+		}
+
+		items := invoice.Items
+		for i := range items {
+			items[i].InvoiceID = invoice.ID
+		}
+		if len(items) > 0 {
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		if !r.syncCBMS {
+			return nil
+		}
+		return enqueueCBMSSync(tx, &models.CBMSSyncJob{
+			DocumentType:   models.DocumentTypeInvoice,
+			DocumentID:     invoice.ID,
+			DocumentNumber: invoice.InvoiceNumber,
+			InvoiceID:      invoice.ID,
+		})
+	})
+	if errors.Is(err, errInvoiceExists) {
+		return r.getIssuedForTransaction(ctx, invoice.TransactionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, invoice.ID)
+}
+
+// errInvoiceExists rolls back an Issue that lost to an earlier invoice
+var errInvoiceExists = errors.New("transaction already has an invoice")
+
+func (r *invoiceRepository) getIssuedForTransaction(ctx context.Context, transactionID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("CreditNote").
+		First(&invoice, "transaction_id = ? AND status = ?", transactionID, models.InvoiceStatusIssued).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) GetAll(ctx context.Context) ([]models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var invoices []models.Invoice
+	if err := r.db.WithContext(ctx).
+		Preload("CreditNote").
+		Order("issued_at DESC").
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("CreditNote").
+		First(&invoice, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetByOrderID returns the most recently issued invoice for an order
+func (r *invoiceRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("CreditNote").
+		Where("order_id = ?", orderID).
+		Order("issued_at DESC").
+		First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// Cancel numbers and stores the credit note and marks the invoice CANCELLED
+func (r *invoiceRepository) Cancel(ctx context.Context, id uuid.UUID, creditNote *models.CreditNote) (*models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invoice models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if invoice.Status == models.InvoiceStatusCancelled {
+			return errors.New("invoice is already cancelled")
+		}
+
+		seq, err := nextDocumentNumber(tx, models.DocumentTypeCreditNote, creditNote.FiscalYear)
+		if err != nil {
+			return err
+		}
+		creditNote.Sequence = seq
+		creditNote.CreditNoteNumber = fmt.Sprintf("CN-%s-%06d", creditNote.FiscalYear, seq)
+		creditNote.InvoiceID = invoice.ID
+		creditNote.TaxableAmount = invoice.TaxableAmount
+		creditNote.ExemptAmount = invoice.ExemptAmount
+		creditNote.TaxAmount = invoice.TaxAmount
+		creditNote.TotalAmount = invoice.TotalAmount
+		if err := tx.Create(creditNote).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&invoice).Updates(map[string]interface{}{
+			"status":       models.InvoiceStatusCancelled,
+			"cancelled_at": creditNote.IssuedAt,
+		}).Error; err != nil {
+			return err
+		}
+		if !r.syncCBMS {
+			return nil
+		}
+		return enqueueCBMSSync(tx, &models.CBMSSyncJob{
+			DocumentType:   models.DocumentTypeCreditNote,
+			DocumentID:     creditNote.ID,
+			DocumentNumber: creditNote.CreditNoteNumber,
+			InvoiceID:      invoice.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetIssuedBetween returns invoices issued in [from, to), including later cancelled ones
+func (r *invoiceRepository) GetIssuedBetween(ctx context.Context, from, to time.Time) ([]models.Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var invoices []models.Invoice
+	if err := r.db.WithContext(ctx).
+		Where("issued_at >= ? AND issued_at < ?", from, to).
+		Order("issued_at").
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// GetCreditNotesBetween returns credit notes issued in [from, to)
+func (r *invoiceRepository) GetCreditNotesBetween(ctx context.Context, from, to time.Time) ([]models.CreditNote, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var creditNotes []models.CreditNote
+	if err := r.db.WithContext(ctx).
+		Where("issued_at >= ? AND issued_at < ?", from, to).
+		Order("issued_at").
+		Find(&creditNotes).Error; err != nil {
+		return nil, err
+	}
+	return creditNotes, nil
+}
+
+func (r *invoiceRepository) GetCreditNoteByID(ctx context.Context, id uuid.UUID) (*models.CreditNote, error) {
+	var creditNote models.CreditNote
+	if err := r.db.WithContext(ctx).First(&creditNote, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &creditNote, nil
+}
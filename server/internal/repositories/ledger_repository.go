@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository interface {
+	// Post saves a journal entry whose lines name their account by Account.Code.
+	// Posting the same source twice returns the existing entry.
+	Post(ctx context.Context, entry *models.JournalEntry) (*models.JournalEntry, error)
+	GetAccounts(ctx context.Context) ([]models.LedgerAccount, error)
+	GetAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error)
+	GetEntries(ctx context.Context, sourceType string, from, to time.Time) ([]models.JournalEntry, error)
+	GetEntryBySource(ctx context.Context, sourceType, sourceID string) (*models.JournalEntry, error)
+	TrialBalance(ctx context.Context, asOf time.Time) ([]models.TrialBalanceRow, error)
+	AccountTotals(ctx context.Context, accountID uuid.UUID, before time.Time) (debit, credit float64, err error)
+	AccountLines(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]models.AccountStatementLine, error)
+	UnbalancedJournals(ctx context.Context) ([]models.UnbalancedJournal, error)
+	Totals(ctx context.Context) (debit, credit float64, journals int64, err error)
+	UnpostedTransactions(ctx context.Context) ([]uuid.UUID, error)
+	UnpostedRefunds(ctx context.Context) ([]uuid.UUID, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (r *ledgerRepository) Post(ctx context.Context, entry *models.JournalEntry) (*models.JournalEntry, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var accounts []models.LedgerAccount
+		if err := tx.Find(&accounts).Error; err != nil {
+			return err
+		}
+		accountIDs := make(map[string]uuid.UUID, len(accounts))
+		for _, account := range accounts {
+			accountIDs[account.Code] = account.ID
+		}
+
+		lines := entry.Lines
+		for i := range lines {
+			id, ok := accountIDs[lines[i].Account.Code]
+			if !ok {
+				return fmt.Errorf("unknown ledger account %q", lines[i].Account.Code)
+			}
+			lines[i].AccountID = id
+		}
+
+		result := tx.Omit(clause.Associations).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+
+		for i := range lines {
+			lines[i].JournalEntryID = entry.ID
+			if err := tx.Omit("Account").Create(&lines[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return r.GetEntryBySource(ctx, entry.SourceType, entry.SourceID)
+	}
+	return r.getEntry(ctx, entry.ID)
+}
+
+func (r *ledgerRepository) getEntry(ctx context.Context, id uuid.UUID) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	if err := r.db.WithContext(ctx).
+		Preload("Lines").
+		Preload("Lines.Account").
+		First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *ledgerRepository) GetAccounts(ctx context.Context) ([]models.LedgerAccount, error) {
+	var accounts []models.LedgerAccount
+	err := r.db.WithContext(ctx).Order("code").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *ledgerRepository) GetAccountByCode(ctx context.Context, code string) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	if err := r.db.WithContext(ctx).First(&account, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetEntries returns journals posted in [from, to), optionally of one source type
+func (r *ledgerRepository) GetEntries(ctx context.Context, sourceType string, from, to time.Time) ([]models.JournalEntry, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Lines").
+		Preload("Lines.Account").
+		Where("posted_at >= ? AND posted_at < ?", from, to).
+		Order("posted_at, created_at")
+	if sourceType != "" {
+		query = query.Where("source_type = ?", sourceType)
+	}
+
+	var entries []models.JournalEntry
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+func (r *ledgerRepository) GetEntryBySource(ctx context.Context, sourceType, sourceID string) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	if err := r.db.WithContext(ctx).
+		Preload("Lines").
+		Preload("Lines.Account").
+		First(&entry, "source_type = ? AND source_id = ?", sourceType, sourceID).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// TrialBalance sums every account's lines posted before asOf
+func (r *ledgerRepository) TrialBalance(ctx context.Context, asOf time.Time) ([]models.TrialBalanceRow, error) {
+	var rows []models.TrialBalanceRow
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT a.code, a.name, a.type,
+			COALESCE(SUM(l.debit), 0) AS debit,
+			COALESCE(SUM(l.credit), 0) AS credit
+		FROM ledger_accounts a
+		LEFT JOIN journal_lines l ON l.account_id = a.id
+			AND l.journal_entry_id IN (SELECT id FROM journal_entries WHERE posted_at < ?)
+		GROUP BY a.id
+		ORDER BY a.code`, asOf).Scan(&rows).Error
+	return rows, err
+}
+
+// AccountTotals sums an account's debits and credits posted before the given time
+func (r *ledgerRepository) AccountTotals(ctx context.Context, accountID uuid.UUID, before time.Time) (float64, float64, error) {
+	var totals struct {
+		Debit  float64
+		Credit float64
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(SUM(l.debit), 0) AS debit, COALESCE(SUM(l.credit), 0) AS credit
+		FROM journal_lines l
+		JOIN journal_entries e ON e.id = l.journal_entry_id
+		WHERE l.account_id = ? AND e.posted_at < ?`, accountID, before).Scan(&totals).Error
+	return totals.Debit, totals.Credit, err
+}
+
+// AccountLines returns an account's lines posted in [from, to), oldest first, without running balances
+func (r *ledgerRepository) AccountLines(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]models.AccountStatementLine, error) {
+	var lines []models.AccountStatementLine
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT e.id AS journal_entry_id, e.posted_at, e.source_type, e.source_id,
+			COALESCE(NULLIF(l.description, ''), e.description) AS description,
+			l.debit, l.credit
+		FROM journal_lines l
+		JOIN journal_entries e ON e.id = l.journal_entry_id
+		WHERE l.account_id = ? AND e.posted_at >= ? AND e.posted_at < ?
+		ORDER BY e.posted_at, e.created_at, l.id`, accountID, from, to).Scan(&lines).Error
+	return lines, err
+}
+
+func (r *ledgerRepository) UnbalancedJournals(ctx context.Context) ([]models.UnbalancedJournal, error) {
+	var journals []models.UnbalancedJournal
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT e.id AS journal_entry_id, e.source_type, e.source_id,
+			COALESCE(SUM(l.debit), 0) AS debit,
+			COALESCE(SUM(l.credit), 0) AS credit
+		FROM journal_entries e
+		LEFT JOIN journal_lines l ON l.journal_entry_id = e.id
+		GROUP BY e.id
+		HAVING COALESCE(SUM(l.debit), 0) <> COALESCE(SUM(l.credit), 0)
+			OR COUNT(l.id) < 2
+		ORDER BY e.posted_at`).Scan(&journals).Error
+	return journals, err
+}
+
+func (r *ledgerRepository) Totals(ctx context.Context) (float64, float64, int64, error) {
+	var totals struct {
+		Debit  float64
+		Credit float64
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(SUM(debit), 0) AS debit, COALESCE(SUM(credit), 0) AS credit
+		FROM journal_lines`).Scan(&totals).Error; err != nil {
+		return 0, 0, 0, err
+	}
+
+	var journals int64
+	if err := r.db.WithContext(ctx).Model(&models.JournalEntry{}).Count(&journals).Error; err != nil {
+		return 0, 0, 0, err
+	}
+	return totals.Debit, totals.Credit, journals, nil
+}
+
+// UnpostedTransactions lists successful transactions with no journal entry
+func (r *ledgerRepository) UnpostedTransactions(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("status = ?", models.TransactionStatusSuccess).
+		Where("NOT EXISTS (SELECT 1 FROM journal_entries e WHERE e.source_type = ? AND e.source_id = transactions.id::text)",
+			models.JournalSourceTransaction).
+		Order("created_at").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UnpostedRefunds lists completed refunds with no journal entry
+func (r *ledgerRepository) UnpostedRefunds(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Where("status = ?", models.RefundStatusCompleted).
+		Where("NOT EXISTS (SELECT 1 FROM journal_entries e WHERE e.source_type = ? AND e.source_id = refunds.id::text)",
+			models.JournalSourceRefund).
+		Order("created_at").
+		Pluck("id", &ids).Error
+	return ids, err
+}
@@ -2,39 +2,86 @@ package repositories
 
 import (
 	"bookstore/internal/models"
+	"bookstore/pkg/refno"
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrOrderNotPending is returned for a change to an order that has already
+// been paid or cancelled
+var ErrOrderNotPending = errors.New("order is no longer pending")
+
 type OrderRepository interface {
+	// Create saves the order with the next reference number
 	Create(ctx context.Context, order *models.Order) (*models.Order, error)
 	GetAll(ctx context.Context) ([]models.Order, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
+	GetByRef(ctx context.Context, ref string) (*models.Order, error)
+	// Count and ExportRows cover the orders matching f; ExportRows reads rows
+	// from the database as fn consumes them, newest first
+	Count(ctx context.Context, f *models.OrderFilter) (int64, error)
+	ExportRows(ctx context.Context, f *models.OrderFilter, fn func(*models.OrderExportRow) error) error
+	// UpdateStatus moves a PENDING order to status. Setting the status it
+	// already has is a no-op; any other change to a PAID or CANCELLED order
+	// returns ErrOrderNotPending.
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) (*models.Order, error)
+	// GetAbandoned returns PENDING orders created before cutoff that have no
+	// payment in progress or completed
+	GetAbandoned(ctx context.Context, cutoff time.Time) ([]models.Order, error)
+	// CancelAbandoned cancels an order GetAbandoned returned if it is still
+	// abandoned, or returns ErrOrderNotPending
+	CancelAbandoned(ctx context.Context, id uuid.UUID, cutoff time.Time) (*models.Order, error)
+	UpdatePricing(ctx context.Context, order *models.Order) (*models.Order, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type orderRepository struct {
-	db *gorm.DB
+	db   *gorm.DB
+	refs refno.Scheme
 }
 
-func NewOrderRepository(db *gorm.DB) OrderRepository {
-	return &orderRepository{db: db}
+func NewOrderRepository(db *gorm.DB, refs refno.Scheme) OrderRepository {
+	return &orderRepository{db: db, refs: refs}
 }
 
-func (r *orderRepository) Create(ctx context.Context, order *models.Order) (*models.Order, error) {
-	if err := r.db.WithContext(ctx).Create(order).Error; err != nil {
+// nextRef takes the next number from a Postgres sequence and formats it.
+// Sequences are not rolled back, so concurrent inserts never share a number.
+func nextRef(tx *gorm.DB, sequence string, scheme refno.Scheme) (*string, error) {
+	var seq int64
+	if err := tx.Raw("SELECT nextval(?)", sequence).Scan(&seq).Error; err != nil {
 		return nil, err
 	}
+	ref := scheme.Format(time.Now(), seq)
+	return &ref, nil
+}
 
-	// Preload User and Items → Book
-	if err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Items").
-		Preload("Items.Book").
-		First(order, "id = ?", order.ID).Error; err != nil {
+func (r *orderRepository) Create(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ref, err := nextRef(tx, "order_ref_seq", r.refs)
+		if err != nil {
+			return err
+		}
+		order.Ref = ref
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		// Preload User and Items → Book
+		if err := tx.
+			Preload("User").
+			Preload("Items").
+			Preload("Items.Book").
+			Preload("Discounts").
+			First(order, "id = ?", order.ID).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventOrderCreated, models.AggregateOrder, order.ID, order)
+	}); err != nil {
 		return nil, err
 	}
 
@@ -47,6 +94,7 @@ func (r *orderRepository) GetAll(ctx context.Context) ([]models.Order, error) {
 		Preload("User").
 		Preload("Items").
 		Preload("Items.Book").
+		Preload("Discounts").
 		Find(&orders).Error; err != nil {
 		return nil, err
 	}
@@ -59,32 +107,199 @@ func (r *orderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Or
 		Preload("User").
 		Preload("Items").
 		Preload("Items.Book").
+		Preload("Discounts").
 		First(&order, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
+func (r *orderRepository) GetByRef(ctx context.Context, ref string) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Items").
+		Preload("Items.Book").
+		Preload("Discounts").
+		First(&order, "ref = ?", ref).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// filterOrders applies f, qualifying columns so the query may join
+func filterOrders(db *gorm.DB, f *models.OrderFilter) *gorm.DB {
+	if f.Ref != "" {
+		db = db.Where("orders.ref = ?", f.Ref)
+	}
+	if len(f.Statuses) > 0 {
+		db = db.Where("orders.status IN ?", f.Statuses)
+	}
+	if f.UserID != nil {
+		db = db.Where("orders.user_id = ?", *f.UserID)
+	}
+	if f.From != nil {
+		db = db.Where("orders.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("orders.created_at < ?", *f.To)
+	}
+	return db
+}
+
+func (r *orderRepository) Count(ctx context.Context, f *models.OrderFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var count int64
+	err := filterOrders(r.db.WithContext(ctx).Model(&models.Order{}), f).Count(&count).Error
+	return count, err
+}
+
+func (r *orderRepository) ExportRows(ctx context.Context, f *models.OrderFilter, fn func(*models.OrderExportRow) error) error {
+	query := r.db.WithContext(ctx).Table("orders").
+		Select(`orders.id, orders.ref, users.name AS user_name, users.email AS user_email,
+			orders.status, orders.buyer_pan, orders.delivery_district,
+			(SELECT COALESCE(SUM(oi.quantity), 0) FROM order_items oi WHERE oi.order_id = orders.id) AS item_count,
+			orders.sub_total, orders.discount_total, orders.taxable_amount, orders.exempt_amount,
+			orders.tax_amount, orders.service_charge, orders.delivery_charge, orders.total_price,
+			payment.ref AS payment_ref, payment.payment_method, payment.status AS payment_status,
+			orders.created_at, orders.updated_at`).
+		Joins("LEFT JOIN users ON users.id = orders.user_id").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT t.ref, t.payment_method, t.status FROM transactions t
+			WHERE t.order_id = orders.id
+			ORDER BY t.created_at DESC
+			LIMIT 1) payment ON true`).
+		Order("orders.created_at DESC, orders.id DESC")
+	return eachRow(filterOrders(query, f), fn)
+}
+
 func (r *orderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) (*models.Order, error) {
+	return r.changeStatus(ctx, id, status, nil)
+}
+
+// changeStatus moves a PENDING order to status, writing the order.cancelled
+// outbox event for a cancellation. With where, the row must also match it
+// and an order already in status is not a no-op but ErrOrderNotPending.
+func (r *orderRepository) changeStatus(ctx context.Context, id uuid.UUID, status string, where func(*gorm.DB) *gorm.DB) (*models.Order, error) {
 	var order models.Order
-	if err := r.db.WithContext(ctx).First(&order, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error; err != nil {
+			return err
+		}
+		changed := order.Status != status
+		if !changed && where != nil {
+			return ErrOrderNotPending
+		}
+		if changed {
+			query := tx.Model(&models.Order{}).Where("id = ? AND status = ?", id, models.OrderStatusPending)
+			if where != nil {
+				query = where(query)
+			}
+			result := query.Update("status", status)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrOrderNotPending
+			}
+		}
+
+		// Reload with associations
+		if err := tx.
+			Preload("User").
+			Preload("Items").
+			Preload("Items.Book").
+			Preload("Discounts").
+			First(&order, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if changed && status == models.OrderStatusCancelled {
+			return addOutboxEvent(tx, models.EventOrderCancelled, models.AggregateOrder, order.ID, &order)
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	order.Status = status
-	if err := r.db.WithContext(ctx).Save(&order).Error; err != nil {
+
+	return &order, nil
+}
+
+// livePayment matches orders with a payment in progress or completed
+const livePayment = "EXISTS (SELECT 1 FROM transactions WHERE transactions.order_id = orders.id AND transactions.status IN ?)"
+
+var liveTransactionStatuses = []string{models.TransactionStatusPending, models.TransactionStatusSuccess}
+
+// openPaymentLink matches orders with a payment link the customer can still use
+const openPaymentLink = "EXISTS (SELECT 1 FROM payment_links WHERE payment_links.order_id = orders.id AND payment_links.status = ? AND payment_links.expires_at > ?)"
+
+// abandoned narrows a query to orders created before cutoff that nobody is
+// paying for or can still pay for through a link
+func abandoned(query *gorm.DB, cutoff time.Time) *gorm.DB {
+	return query.Where("created_at < ?", cutoff).
+		Where("NOT "+livePayment, liveTransactionStatuses).
+		Where("NOT "+openPaymentLink, models.PaymentLinkActive, time.Now())
+}
+
+func (r *orderRepository) GetAbandoned(ctx context.Context, cutoff time.Time) ([]models.Order, error) {
+	var orders []models.Order
+	if err := abandoned(r.db.WithContext(ctx), cutoff).
+		Where("status = ?", models.OrderStatusPending).
+		Order("created_at").
+		Limit(500).
+		Find(&orders).Error; err != nil {
 		return nil, err
 	}
+	return orders, nil
+}
 
-	// Reload with associations
-	if err := r.db.WithContext(ctx).
-		Preload("User").
-		Preload("Items").
-		Preload("Items.Book").
-		First(&order, "id = ?", id).Error; err != nil {
+// CancelAbandoned rechecks the order under its row lock, so a payment or
+// payment link started since GetAbandoned keeps it
+func (r *orderRepository) CancelAbandoned(ctx context.Context, id uuid.UUID, cutoff time.Time) (*models.Order, error) {
+	return r.changeStatus(ctx, id, models.OrderStatusCancelled, func(tx *gorm.DB) *gorm.DB {
+		return abandoned(tx, cutoff)
+	})
+}
+
+// UpdatePricing saves the order's totals, item discounts and tax, and discount lines
+func (r *orderRepository) UpdatePricing(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveOrderPricing(tx, order)
+	}); err != nil {
 		return nil, err
 	}
+	return r.GetByID(ctx, order.ID)
+}
 
-	return &order, nil
+// saveOrderPricing writes every priced field of order. Run it inside a transaction.
+func saveOrderPricing(tx *gorm.DB, order *models.Order) error {
+	if err := tx.Model(&models.Order{ID: order.ID}).
+		Select("sub_total", "discount_total", "taxable_amount", "exempt_amount", "tax_amount",
+			"service_charge", "delivery_charge", "total_price").
+		Updates(order).Error; err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		if err := tx.Model(&models.OrderItem{ID: item.ID}).
+			Select("discount_amount", "tax_amount").
+			Updates(&item).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderDiscount{}).Error; err != nil {
+		return err
+	}
+	for i := range order.Discounts {
+		order.Discounts[i].ID = uuid.Nil
+		order.Discounts[i].OrderID = order.ID
+	}
+	if len(order.Discounts) > 0 {
+		return tx.Create(&order.Discounts).Error
+	}
+	return nil
 }
 
 func (r *orderRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkAttemptFailed(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, lastError string) error
+	GetConsumers(ctx context.Context, eventID uuid.UUID) (map[string]bool, error)
+	RecordConsumed(ctx context.Context, eventID uuid.UUID, consumer string) error
+	GetAll(ctx context.Context, status, aggregateType string, aggregateID *uuid.UUID) ([]models.OutboxEvent, error)
+	Requeue(ctx context.Context, id *uuid.UUID) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// addOutboxEvent writes an event with a snapshot of its aggregate. Run it
+// inside the transaction that makes the change.
+func addOutboxEvent(tx *gorm.DB, eventType, aggregateType string, aggregateID uuid.UUID, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %v", eventType, err)
+	}
+	return tx.Create(&models.OutboxEvent{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       datatypes.JSON(data),
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// ClaimDue returns up to limit due PENDING events, oldest first, and pushes
+// their next attempt out by lease so concurrent relays do not pick them up
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE outbox_events SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY created_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, time.Now().Add(lease), models.OutboxStatusPending, time.Now(), limit).
+		Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the subquery's order
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.OutboxStatusPublished,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+		"published_at": time.Now(),
+	}).Error
+}
+
+func (r *outboxRepository) MarkAttemptFailed(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}).Error
+}
+
+// GetConsumers returns the subscribers that have already processed an event
+func (r *outboxRepository) GetConsumers(ctx context.Context, eventID uuid.UUID) (map[string]bool, error) {
+	var consumptions []models.OutboxConsumption
+	if err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Find(&consumptions).Error; err != nil {
+		return nil, err
+	}
+	consumers := make(map[string]bool, len(consumptions))
+	for _, consumption := range consumptions {
+		consumers[consumption.Consumer] = true
+	}
+	return consumers, nil
+}
+
+func (r *outboxRepository) RecordConsumed(ctx context.Context, eventID uuid.UUID, consumer string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.OutboxConsumption{EventID: eventID, Consumer: consumer, ProcessedAt: time.Now()}).Error
+}
+
+// GetAll lists events, newest first, optionally filtered by status and aggregate
+func (r *outboxRepository) GetAll(ctx context.Context, status, aggregateType string, aggregateID *uuid.UUID) ([]models.OutboxEvent, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(500)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if aggregateType != "" {
+		query = query.Where("aggregate_type = ?", aggregateType)
+	}
+	if aggregateID != nil {
+		query = query.Where("aggregate_id = ?", *aggregateID)
+	}
+
+	var events []models.OutboxEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Requeue resets FAILED events (or the one given) to PENDING and due now.
+// Subscribers that already processed an event are still skipped.
+func (r *outboxRepository) Requeue(ctx context.Context, id *uuid.UUID) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.OutboxEvent{})
+	if id != nil {
+		query = query.Where("id = ? AND status <> ?", *id, models.OutboxStatusPublished)
+	} else {
+		query = query.Where("status = ?", models.OutboxStatusFailed)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"status":          models.OutboxStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
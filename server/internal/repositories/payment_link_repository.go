@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPaymentLinkState is returned when a link is no longer ACTIVE
+var ErrPaymentLinkState = errors.New("payment link is no longer active")
+
+type PaymentLinkRepository interface {
+	Create(ctx context.Context, link *models.PaymentLink) (*models.PaymentLink, error)
+	// GetByID loads a link with its order and the order's books
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PaymentLink, error)
+	// GetAll lists links, newest first
+	GetAll(ctx context.Context, status string, orderID *uuid.UUID) ([]models.PaymentLink, error)
+	Revoke(ctx context.Context, id, adminID uuid.UUID) (*models.PaymentLink, error)
+	// MarkPaid closes the order's ACTIVE links, recording the payment that
+	// settled them, and returns how many were closed
+	MarkPaid(ctx context.Context, orderID, transactionID uuid.UUID) (int64, error)
+}
+
+type paymentLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentLinkRepository(db *gorm.DB) PaymentLinkRepository {
+	return &paymentLinkRepository{db: db}
+}
+
+func (r *paymentLinkRepository) Create(ctx context.Context, link *models.PaymentLink) (*models.PaymentLink, error) {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Held until commit, so the order cannot expire under a new link
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id", "status").
+			First(&order, "id = ?", link.OrderID).Error; err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return ErrOrderNotPending
+		}
+		return tx.Create(link).Error
+	}); err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, link.ID)
+}
+
+func (r *paymentLinkRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PaymentLink, error) {
+	var link models.PaymentLink
+	err := r.db.WithContext(ctx).
+		Preload("Order").
+		Preload("Order.Items").
+		Preload("Order.Items.Book").
+		First(&link, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *paymentLinkRepository) GetAll(ctx context.Context, status string, orderID *uuid.UUID) ([]models.PaymentLink, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	switch status {
+	case "":
+	case models.PaymentLinkExpired:
+		query = query.Where("status = ? AND expires_at <= ?", models.PaymentLinkActive, time.Now())
+	case models.PaymentLinkActive:
+		query = query.Where("status = ? AND expires_at > ?", models.PaymentLinkActive, time.Now())
+	default:
+		query = query.Where("status = ?", status)
+	}
+	if orderID != nil {
+		query = query.Where("order_id = ?", *orderID)
+	}
+
+	var links []models.PaymentLink
+	err := query.Find(&links).Error
+	return links, err
+}
+
+func (r *paymentLinkRepository) Revoke(ctx context.Context, id, adminID uuid.UUID) (*models.PaymentLink, error) {
+	result := r.db.WithContext(ctx).Model(&models.PaymentLink{}).
+		Where("id = ? AND status = ?", id, models.PaymentLinkActive).
+		Updates(map[string]interface{}{
+			"status":     models.PaymentLinkRevoked,
+			"revoked_at": time.Now(),
+			"revoked_by": adminID,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrPaymentLinkState
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *paymentLinkRepository) MarkPaid(ctx context.Context, orderID, transactionID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.PaymentLink{}).
+		Where("order_id = ? AND status = ?", orderID, models.PaymentLinkActive).
+		Updates(map[string]interface{}{
+			"status":         models.PaymentLinkPaid,
+			"paid_at":        time.Now(),
+			"transaction_id": transactionID,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrProofReviewed is returned when a payment proof was already approved or rejected
+var ErrProofReviewed = errors.New("payment proof has already been reviewed")
+
+type PaymentProofRepository interface {
+	Create(ctx context.Context, proof *models.PaymentProof) (*models.PaymentProof, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PaymentProof, error)
+	GetByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]models.PaymentProof, error)
+	// GetByStatus lists proofs oldest first, with their transaction, order and customer
+	GetByStatus(ctx context.Context, status string) ([]models.PaymentProof, error)
+	HasPending(ctx context.Context, transactionID uuid.UUID) (bool, error)
+	// Review moves a PENDING proof to APPROVED or REJECTED
+	Review(ctx context.Context, id uuid.UUID, status, reason string, adminID uuid.UUID) (*models.PaymentProof, error)
+	// Reopen moves an APPROVED proof back to PENDING, undoing a review whose
+	// follow-up failed
+	Reopen(ctx context.Context, id uuid.UUID) error
+}
+
+type paymentProofRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentProofRepository(db *gorm.DB) PaymentProofRepository {
+	return &paymentProofRepository{db: db}
+}
+
+func (r *paymentProofRepository) Create(ctx context.Context, proof *models.PaymentProof) (*models.PaymentProof, error) {
+	if err := r.db.WithContext(ctx).Create(proof).Error; err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func (r *paymentProofRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PaymentProof, error) {
+	var proof models.PaymentProof
+	if err := r.db.WithContext(ctx).First(&proof, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+func (r *paymentProofRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]models.PaymentProof, error) {
+	var proofs []models.PaymentProof
+	err := r.db.WithContext(ctx).Where("transaction_id = ?", transactionID).Order("created_at DESC").Find(&proofs).Error
+	return proofs, err
+}
+
+func (r *paymentProofRepository) GetByStatus(ctx context.Context, status string) ([]models.PaymentProof, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Transaction").
+		Preload("Transaction.Order").
+		Preload("Transaction.User").
+		Order("created_at")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var proofs []models.PaymentProof
+	err := query.Find(&proofs).Error
+	return proofs, err
+}
+
+func (r *paymentProofRepository) HasPending(ctx context.Context, transactionID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PaymentProof{}).
+		Where("transaction_id = ? AND status = ?", transactionID, models.PaymentProofPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *paymentProofRepository) Review(ctx context.Context, id uuid.UUID, status, reason string, adminID uuid.UUID) (*models.PaymentProof, error) {
+	result := r.db.WithContext(ctx).Model(&models.PaymentProof{}).
+		Where("id = ? AND status = ?", id, models.PaymentProofPending).
+		Updates(map[string]interface{}{
+			"status":           status,
+			"rejection_reason": reason,
+			"reviewed_by":      adminID,
+			"reviewed_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrProofReviewed
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *paymentProofRepository) Reopen(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.PaymentProof{}).
+		Where("id = ? AND status = ?", id, models.PaymentProofApproved).
+		Updates(map[string]interface{}{
+			"status":      models.PaymentProofPending,
+			"reviewed_by": nil,
+			"reviewed_at": nil,
+		}).Error
+}
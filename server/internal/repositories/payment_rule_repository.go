@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentRuleRepository interface {
+	Create(ctx context.Context, rule *models.PaymentMethodRule) (*models.PaymentMethodRule, error)
+	GetAll(ctx context.Context, paymentMethod string) ([]models.PaymentMethodRule, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PaymentMethodRule, error)
+	GetActive(ctx context.Context) ([]models.PaymentMethodRule, error)
+	Update(ctx context.Context, id uuid.UUID, updateData *models.PaymentMethodRule) (*models.PaymentMethodRule, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type paymentRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRuleRepository(db *gorm.DB) PaymentRuleRepository {
+	return &paymentRuleRepository{db: db}
+}
+
+func (r *paymentRuleRepository) Create(ctx context.Context, rule *models.PaymentMethodRule) (*models.PaymentMethodRule, error) {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *paymentRuleRepository) GetAll(ctx context.Context, paymentMethod string) ([]models.PaymentMethodRule, error) {
+	query := r.db.WithContext(ctx).Order("payment_method, created_at")
+	if paymentMethod != "" {
+		query = query.Where("payment_method = ?", paymentMethod)
+	}
+
+	var rules []models.PaymentMethodRule
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+func (r *paymentRuleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PaymentMethodRule, error) {
+	var rule models.PaymentMethodRule
+	if err := r.db.WithContext(ctx).First(&rule, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *paymentRuleRepository) GetActive(ctx context.Context) ([]models.PaymentMethodRule, error) {
+	var rules []models.PaymentMethodRule
+	err := r.db.WithContext(ctx).
+		Where("active = ?", true).
+		Order("payment_method, created_at").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *paymentRuleRepository) Update(ctx context.Context, id uuid.UUID, updateData *models.PaymentMethodRule) (*models.PaymentMethodRule, error) {
+	var rule models.PaymentMethodRule
+	if err := r.db.WithContext(ctx).First(&rule, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&rule).
+		Select("payment_method", "name", "message", "min_amount", "max_amount", "districts",
+			"account_types", "require_verified", "weekdays", "start_time", "end_time", "active").
+		Updates(updateData).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *paymentRuleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.PaymentMethodRule{}, "id = ?", id).Error
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *models.Promotion) (*models.Promotion, error)
+	GetAll(ctx context.Context) ([]models.Promotion, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Promotion, error)
+	GetActive(ctx context.Context, at time.Time) ([]models.Promotion, error)
+	Update(ctx context.Context, id uuid.UUID, updateData *models.Promotion) (*models.Promotion, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) Create(ctx context.Context, promotion *models.Promotion) (*models.Promotion, error) {
+	if err := r.db.WithContext(ctx).Omit("Category").Create(promotion).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, promotion.ID)
+}
+
+func (r *promotionRepository) GetAll(ctx context.Context) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Order("priority DESC, created_at DESC").
+		Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := r.db.WithContext(ctx).Preload("Category").First(&promotion, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// GetActive returns promotions that are switched on and within their validity window at the given time
+func (r *promotionRepository) GetActive(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Where("active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("expires_at IS NULL OR expires_at > ?", at).
+		Order("priority DESC, id").
+		Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepository) Update(ctx context.Context, id uuid.UUID, updateData *models.Promotion) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := r.db.WithContext(ctx).First(&promotion, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&promotion).
+		Select("name", "description", "type", "category_id", "author", "percentage", "min_quantity",
+			"buy_quantity", "free_quantity", "min_order_value", "stackable", "priority",
+			"starts_at", "expires_at", "active").
+		Updates(updateData).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *promotionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Promotion{}, "id = ?", id).Error
+}
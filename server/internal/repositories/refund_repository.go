@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRefundExceedsAmount is returned when a refund would return more than was paid
+var ErrRefundExceedsAmount = errors.New("refund exceeds the refundable amount")
+
+type RefundRepository interface {
+	// Create saves the refund. A completed store credit refund also credits
+	// the wallet with walletCredit in the same transaction.
+	Create(ctx context.Context, refund *models.Refund, walletCredit *models.WalletEntry) (*models.Refund, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+	GetByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]models.Refund, error)
+	GetAll(ctx context.Context, status string) ([]models.Refund, error)
+	Complete(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) Create(ctx context.Context, refund *models.Refund, walletCredit *models.WalletEntry) (*models.Refund, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createRefund(tx, refund, walletCredit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// createRefund saves a refund, with its wallet credit if there is one, after
+// checking it against what is left of the transaction. Run it inside a transaction.
+func createRefund(tx *gorm.DB, refund *models.Refund, walletCredit *models.WalletEntry) error {
+	// Lock the transaction so concurrent refunds cannot exceed its amount
+	var transaction models.Transaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Tenders").
+		First(&transaction, "id = ?", refund.TransactionID).Error; err != nil {
+		return err
+	}
+
+	var refunds []models.Refund
+	if err := tx.Where("transaction_id = ?", refund.TransactionID).Find(&refunds).Error; err != nil {
+		return err
+	}
+	var refunded, refundedToOriginal float64
+	for _, existing := range refunds {
+		refunded += existing.Amount
+		if existing.Method != models.RefundMethodStoreCredit {
+			refundedToOriginal += existing.Amount
+		}
+	}
+	if refund.Amount > utils.RoundMoney(transaction.Amount-refunded) {
+		return ErrRefundExceedsAmount
+	}
+	// Store credit and gift card balances spent on the order can only come back as store credit
+	if refund.Method != models.RefundMethodStoreCredit {
+		if refund.Amount > utils.RoundMoney(transaction.GatewayAmount()-refundedToOriginal) {
+			return ErrRefundExceedsAmount
+		}
+	}
+
+	if err := tx.Create(refund).Error; err != nil {
+		return err
+	}
+	if refund.Status == models.RefundStatusCompleted {
+		if err := addOutboxEvent(tx, models.EventRefundCompleted, models.AggregateRefund, refund.ID, refund); err != nil {
+			return err
+		}
+	}
+	if walletCredit == nil {
+		return nil
+	}
+	walletCredit.RefundID = &refund.ID
+	return creditWallet(tx, walletCredit)
+}
+
+func (r *refundRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	if err := r.db.WithContext(ctx).First(&refund, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) GetAll(ctx context.Context, status string) ([]models.Refund, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var refunds []models.Refund
+	err := query.Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) Complete(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Refund{}).
+			Where("id = ? AND status = ?", id, models.RefundStatusPending).
+			Updates(map[string]interface{}{
+				"status":       models.RefundStatusCompleted,
+				"completed_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.First(&refund, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return addOutboxEvent(tx, models.EventRefundCompleted, models.AggregateRefund, refund.ID, &refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RiskRepository interface {
+	Create(ctx context.Context, assessment *models.RiskAssessment) (*models.RiskAssessment, error)
+	// GetAll lists assessments in [from, to), newest first
+	GetAll(ctx context.Context, decision string, userID *uuid.UUID, from, to time.Time) ([]models.RiskAssessment, error)
+
+	CountUserAttempts(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+	CountIPAttempts(ctx context.Context, ip string, since time.Time) (int64, error)
+	CountDeviceAttempts(ctx context.Context, deviceID string, since time.Time) (int64, error)
+	CountFailures(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+	// PaymentHistory returns the number and average amount of the user's successful transactions
+	PaymentHistory(ctx context.Context, userID uuid.UUID) (int64, float64, error)
+}
+
+type riskRepository struct {
+	db *gorm.DB
+}
+
+func NewRiskRepository(db *gorm.DB) RiskRepository {
+	return &riskRepository{db: db}
+}
+
+func (r *riskRepository) Create(ctx context.Context, assessment *models.RiskAssessment) (*models.RiskAssessment, error) {
+	if err := r.db.WithContext(ctx).Create(assessment).Error; err != nil {
+		return nil, err
+	}
+	return assessment, nil
+}
+
+func (r *riskRepository) GetAll(ctx context.Context, decision string, userID *uuid.UUID, from, to time.Time) ([]models.RiskAssessment, error) {
+	query := r.db.WithContext(ctx).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at DESC")
+	if decision != "" {
+		query = query.Where("decision = ?", decision)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var assessments []models.RiskAssessment
+	err := query.Find(&assessments).Error
+	return assessments, err
+}
+
+func (r *riskRepository) countAttempts(ctx context.Context, column string, value interface{}, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.RiskAssessment{}).
+		Where(column+" = ? AND created_at >= ?", value, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *riskRepository) CountUserAttempts(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	return r.countAttempts(ctx, "user_id", userID, since)
+}
+
+func (r *riskRepository) CountIPAttempts(ctx context.Context, ip string, since time.Time) (int64, error) {
+	return r.countAttempts(ctx, "ip_address", ip, since)
+}
+
+func (r *riskRepository) CountDeviceAttempts(ctx context.Context, deviceID string, since time.Time) (int64, error) {
+	return r.countAttempts(ctx, "device_id", deviceID, since)
+}
+
+func (r *riskRepository) CountFailures(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("user_id = ? AND status = ? AND updated_at >= ?", userID, models.TransactionStatusFailed, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *riskRepository) PaymentHistory(ctx context.Context, userID uuid.UUID) (int64, float64, error) {
+	var history struct {
+		Count   int64
+		Average float64
+	}
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Select("COUNT(*) AS count, COALESCE(AVG(amount), 0) AS average").
+		Where("user_id = ? AND status = ?", userID, models.TransactionStatusSuccess).
+		Scan(&history).Error
+	return history.Count, history.Average, err
+}
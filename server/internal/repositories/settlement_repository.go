@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SettlementRepository interface {
+	Create(ctx context.Context, settlement *models.SettlementImport) (*models.SettlementImport, error)
+	GetAll(ctx context.Context) ([]models.SettlementImport, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SettlementImport, error)
+	// FindTransactions returns transactions whose gateway reference is in
+	// refIDs, whose ID is in ids or whose own reference is in refs
+	FindTransactions(ctx context.Context, refIDs []string, ids []uuid.UUID, refs []string) ([]models.Transaction, error)
+	// UnsettledTransactions returns successful transactions with a tender of
+	// method paid in [from, to) that no earlier statement has matched
+	UnsettledTransactions(ctx context.Context, method string, from, to time.Time) ([]models.Transaction, error)
+	GatewayPayments(ctx context.Context, from, to time.Time) ([]models.GatewayPayment, error)
+	GatewayRefunds(ctx context.Context, from, to time.Time) ([]models.GatewayRefund, error)
+}
+
+type settlementRepository struct {
+	db *gorm.DB
+}
+
+func NewSettlementRepository(db *gorm.DB) SettlementRepository {
+	return &settlementRepository{db: db}
+}
+
+func (r *settlementRepository) Create(ctx context.Context, settlement *models.SettlementImport) (*models.SettlementImport, error) {
+	if err := r.db.WithContext(ctx).Create(settlement).Error; err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+func (r *settlementRepository) GetAll(ctx context.Context) ([]models.SettlementImport, error) {
+	var settlements []models.SettlementImport
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&settlements).Error
+	return settlements, err
+}
+
+func (r *settlementRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SettlementImport, error) {
+	var settlement models.SettlementImport
+	if err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("status, line")
+		}).
+		First(&settlement, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+func (r *settlementRepository) FindTransactions(ctx context.Context, refIDs []string, ids []uuid.UUID, refs []string) ([]models.Transaction, error) {
+	var conditions []string
+	var args []interface{}
+	if len(refIDs) > 0 {
+		conditions = append(conditions, "transaction_id IN ?")
+		args = append(args, refIDs)
+	}
+	if len(ids) > 0 {
+		conditions = append(conditions, "id IN ?")
+		args = append(args, ids)
+	}
+	if len(refs) > 0 {
+		conditions = append(conditions, "ref IN ?")
+		args = append(args, refs)
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).Preload("Tenders").
+		Where(strings.Join(conditions, " OR "), args...).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *settlementRepository) UnsettledTransactions(ctx context.Context, method string, from, to time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).
+		Preload("Tenders").
+		Where("status = ? AND paid_at >= ? AND paid_at < ?", models.TransactionStatusSuccess, from, to).
+		Where("EXISTS (SELECT 1 FROM transaction_tenders t WHERE t.transaction_id = transactions.id AND t.payment_method = ?)", method).
+		Where("NOT EXISTS (SELECT 1 FROM settlement_items s WHERE s.transaction_id = transactions.id AND s.status IN ?)",
+			[]string{models.SettlementMatched, models.SettlementAmountMismatch}).
+		Order("paid_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// GatewayPayments returns the gateway tenders of transactions paid in [from, to).
+// Store credit and gift card tenders never reach a gateway and are left out.
+func (r *settlementRepository) GatewayPayments(ctx context.Context, from, to time.Time) ([]models.GatewayPayment, error) {
+	var payments []models.GatewayPayment
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT t.id AS transaction_id, t.paid_at, tt.payment_method, tt.amount,
+			t.gateway_fee AS fee, COALESCE(t.fee_source, '') AS fee_source
+		FROM transactions t
+		JOIN transaction_tenders tt ON tt.transaction_id = t.id
+		WHERE t.status = ? AND t.paid_at >= ? AND t.paid_at < ?
+			AND tt.payment_method NOT IN ?
+		ORDER BY t.paid_at`,
+		models.TransactionStatusSuccess, from, to,
+		[]string{models.PaymentMethodStoreCredit, models.PaymentMethodGiftCard}).Scan(&payments).Error
+	return payments, err
+}
+
+// GatewayRefunds returns refunds to the original method and chargebacks completed in [from, to)
+func (r *settlementRepository) GatewayRefunds(ctx context.Context, from, to time.Time) ([]models.GatewayRefund, error) {
+	var refunds []models.GatewayRefund
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT r.completed_at, t.payment_method, r.amount
+		FROM refunds r
+		JOIN transactions t ON t.id = r.transaction_id
+		WHERE r.method IN ? AND r.status = ? AND r.completed_at >= ? AND r.completed_at < ?
+		ORDER BY r.completed_at`,
+		[]string{models.RefundMethodOriginal, models.RefundMethodChargeback}, models.RefundStatusCompleted, from, to).Scan(&refunds).Error
+	return refunds, err
+}
@@ -2,44 +2,108 @@ package repositories
 
 import (
 	"bookstore/internal/models"
+	"bookstore/pkg/refno"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrTransactionFinal is returned for a status change to a transaction that
+// has already succeeded, failed or been cancelled
+var ErrTransactionFinal = errors.New("transaction is already settled; money can only be returned with a refund")
+
 type TransactionRepository interface {
+	// Create saves the transaction with the next reference number
 	Create(ctx context.Context, transaction *models.Transaction) (*models.Transaction, error)
-	GetAll(ctx context.Context) ([]models.Transaction, error)
+	// ListSummaries and ListFull return up to q.Limit+1 transactions of a
+	// listing page, in the summary and full views
+	ListSummaries(ctx context.Context, q *models.TransactionListQuery) ([]models.TransactionSummary, error)
+	ListFull(ctx context.Context, q *models.TransactionListQuery) ([]models.Transaction, error)
+	// Totals counts and sums the transactions matching f, by status
+	Totals(ctx context.Context, f *models.TransactionFilter) (*models.TransactionTotals, error)
+	// ExportRows calls fn for every transaction matching f in the given sort
+	// order, reading rows from the database as fn consumes them
+	ExportRows(ctx context.Context, f *models.TransactionFilter, sort string, fn func(*models.TransactionExportRow) error) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	GetByRef(ctx context.Context, ref string) (*models.Transaction, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Transaction, error)
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Transaction, error)
 	Update(ctx context.Context, id uuid.UUID, updateData *models.Transaction) (*models.Transaction, error)
-	UpdateStatus(ctx context.Context, id uuid.UUID, status, failureReason string, esewaResponse datatypes.JSON) (*models.Transaction, error)
+	// ChangeStatus applies a status update, moves the order to orderStatus
+	// (unless empty) and writes the matching outbox event, all in one
+	// database transaction, and returns the status it moved from. A
+	// transaction in a final status cannot move to another one
+	// (ErrTransactionFinal); repeating its status changes nothing.
+	ChangeStatus(ctx context.Context, id uuid.UUID, updateData *models.Transaction, orderStatus string) (*models.Transaction, string, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	SetReview(ctx context.Context, id uuid.UUID, needsReview bool, reason string) error
+	// SetFee records the gateway fee and net amount. A COMPUTED fee never
+	// replaces a SETTLED one.
+	SetFee(ctx context.Context, id uuid.UUID, fee float64, source string) error
+	GetNeedingReview(ctx context.Context) ([]models.Transaction, error)
+	// GetPendingEsewa returns PENDING eSewa transactions sent to eSewa since then
+	GetPendingEsewa(ctx context.Context, since time.Time) ([]models.Transaction, error)
 }
 
 type transactionRepository struct {
-	db *gorm.DB
+	db   *gorm.DB
+	refs refno.Scheme
 }
 
-func NewTransactionRepository(db *gorm.DB) TransactionRepository {
-	return &transactionRepository{db: db}
+func NewTransactionRepository(db *gorm.DB, refs refno.Scheme) TransactionRepository {
+	return &transactionRepository{db: db, refs: refs}
 }
 
 func (r *transactionRepository) Create(ctx context.Context, transaction *models.Transaction) (*models.Transaction, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if err := r.db.WithContext(ctx).Create(transaction).Error; err != nil {
+	// Store credit and gift card tenders are taken with the transaction
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Held until commit, so the order cannot be cancelled under a new payment
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id", "status").
+			First(&order, "id = ?", transaction.OrderID).Error; err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return ErrOrderNotPending
+		}
+		ref, err := nextRef(tx, "transaction_ref_seq", r.refs)
+		if err != nil {
+			return err
+		}
+		transaction.Ref = ref
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		for _, tender := range transaction.Tenders {
+			switch tender.PaymentMethod {
+			case models.PaymentMethodStoreCredit:
+				if err := debitWallet(tx, transaction.UserID, tender.Amount, &transaction.ID, "Payment for order "+transaction.OrderID.String()); err != nil {
+					return err
+				}
+			case models.PaymentMethodGiftCard:
+				if err := redeemGiftCard(tx, *tender.GiftCardID, tender.Amount, &transaction.ID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
 
 	// Preload associations with proper nested preloading
 	if err := r.db.WithContext(ctx).
 		Preload("User").
+		Preload("Tenders").
 		Preload("Order", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("User").Preload("Items.Book.Category")
 		}).
@@ -50,23 +114,183 @@ func (r *transactionRepository) Create(ctx context.Context, transaction *models.
 	return transaction, nil
 }
 
-func (r *transactionRepository) GetAll(ctx context.Context) ([]models.Transaction, error) {
+// transactionColumns are the transactions columns a listing sorts on
+var transactionColumns = map[string]string{
+	models.TransactionSortCreatedAt: "transactions.created_at",
+	models.TransactionSortAmount:    "transactions.amount",
+}
+
+// filterTransactions applies f, qualifying columns so the query may join
+func filterTransactions(db *gorm.DB, f *models.TransactionFilter, withStatus bool) *gorm.DB {
+	if withStatus && len(f.Statuses) > 0 {
+		db = db.Where("transactions.status IN ?", f.Statuses)
+	}
+	if len(f.PaymentMethods) > 0 {
+		db = db.Where(`(transactions.payment_method IN ? OR EXISTS (
+			SELECT 1 FROM transaction_tenders tt
+			WHERE tt.transaction_id = transactions.id AND tt.payment_method IN ?))`, f.PaymentMethods, f.PaymentMethods)
+	}
+	if f.UserID != nil {
+		db = db.Where("transactions.user_id = ?", *f.UserID)
+	}
+	if f.OrderID != nil {
+		db = db.Where("transactions.order_id = ?", *f.OrderID)
+	}
+	if f.From != nil {
+		db = db.Where("transactions.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("transactions.created_at < ?", *f.To)
+	}
+	if f.MinAmount != nil {
+		db = db.Where("transactions.amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		db = db.Where("transactions.amount <= ?", *f.MaxAmount)
+	}
+	return db
+}
+
+// pageTransactions sorts by the query's column with the ID as tiebreaker,
+// starts after the cursor and fetches one row more than the limit so the
+// caller can tell whether another page follows
+func pageTransactions(db *gorm.DB, q *models.TransactionListQuery) *gorm.DB {
+	sort, desc := strings.CutPrefix(q.Sort, "-")
+	column := transactionColumns[sort]
+	direction, compare := "ASC", ">"
+	if desc {
+		direction, compare = "DESC", "<"
+	}
+
+	if q.After != nil {
+		var value interface{} = q.After.CreatedAt
+		if sort == models.TransactionSortAmount {
+			value = q.After.Amount
+		}
+		db = db.Where(fmt.Sprintf("(%s, transactions.id) %s (?, ?)", column, compare), value, q.After.ID)
+	}
+	return db.
+		Order(fmt.Sprintf("%s %s, transactions.id %s", column, direction, direction)).
+		Limit(q.Limit + 1)
+}
+
+func (r *transactionRepository) ListSummaries(ctx context.Context, q *models.TransactionListQuery) ([]models.TransactionSummary, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	var transactions []models.Transaction
-	if err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).Table("transactions").
+		Select(`transactions.id, transactions.ref, transactions.order_id, orders.ref AS order_ref,
+			transactions.user_id, users.name AS user_name, users.email AS user_email,
+			transactions.payment_method, transactions.transaction_id, transactions.amount,
+			transactions.status, transactions.product_name, transactions.failure_reason,
+			transactions.needs_review, transactions.paid_at, transactions.created_at, transactions.updated_at`).
+		Joins("LEFT JOIN orders ON orders.id = transactions.order_id").
+		Joins("LEFT JOIN users ON users.id = transactions.user_id")
+	query = pageTransactions(filterTransactions(query, &q.Filter, true), q)
+
+	var summaries []models.TransactionSummary
+	if err := query.Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func (r *transactionRepository) ListFull(ctx context.Context, q *models.TransactionListQuery) ([]models.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Model(&models.Transaction{}).
 		Preload("User").
+		Preload("Tenders").
 		Preload("Order", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("User").Preload("Items.Book.Category")
-		}).
-		Order("created_at DESC").
-		Find(&transactions).Error; err != nil {
+		})
+	query = pageTransactions(filterTransactions(query, &q.Filter, true), q)
+
+	var transactions []models.Transaction
+	if err := query.Find(&transactions).Error; err != nil {
 		return nil, err
 	}
 	return transactions, nil
 }
 
+func (r *transactionRepository) Totals(ctx context.Context, f *models.TransactionFilter) (*models.TransactionTotals, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var rows []struct {
+		Status string
+		Count  int64
+		Amount float64
+	}
+	query := r.db.WithContext(ctx).Table("transactions").
+		Select("transactions.status, COUNT(*) AS count, COALESCE(SUM(transactions.amount), 0) AS amount").
+		Group("transactions.status")
+	if err := filterTransactions(query, f, false).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	statuses := map[string]bool{}
+	for _, status := range f.Statuses {
+		statuses[status] = true
+	}
+	totals := &models.TransactionTotals{ByStatus: map[string]models.TransactionStatusSum{}}
+	for _, row := range rows {
+		totals.ByStatus[row.Status] = models.TransactionStatusSum{Count: row.Count, Amount: row.Amount}
+		if len(statuses) == 0 || statuses[row.Status] {
+			totals.Count += row.Count
+			totals.Amount += row.Amount
+		}
+	}
+	return totals, nil
+}
+
+func (r *transactionRepository) ExportRows(ctx context.Context, f *models.TransactionFilter, sort string, fn func(*models.TransactionExportRow) error) error {
+	sort, desc := strings.CutPrefix(sort, "-")
+	column, ok := transactionColumns[sort]
+	if !ok {
+		column = transactionColumns[models.TransactionSortCreatedAt]
+	}
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	query := r.db.WithContext(ctx).Table("transactions").
+		Select(`transactions.id, transactions.ref, transactions.order_id, orders.ref AS order_ref,
+			users.name AS user_name, users.email AS user_email,
+			transactions.payment_method, transactions.transaction_id, transactions.amount,
+			transactions.tax_amount, transactions.service_charge, transactions.delivery_charge,
+			transactions.gateway_fee, transactions.net_amount, transactions.status,
+			transactions.failure_reason, transactions.needs_review, transactions.paid_at,
+			transactions.created_at, transactions.updated_at`).
+		Joins("LEFT JOIN orders ON orders.id = transactions.order_id").
+		Joins("LEFT JOIN users ON users.id = transactions.user_id").
+		Order(fmt.Sprintf("%s %s, transactions.id %s", column, direction, direction))
+	return eachRow(filterTransactions(query, f, true), fn)
+}
+
+// eachRow runs query and scans its rows one at a time into fn, so a large
+// result is never held in memory at once
+func eachRow[T any](query *gorm.DB, fn func(*T) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row T
+		if err := query.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (r *transactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -74,6 +298,7 @@ func (r *transactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 	var transaction models.Transaction
 	if err := r.db.WithContext(ctx).
 		Preload("User").
+		Preload("Tenders").
 		Preload("Order", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("User").Preload("Items.Book.Category")
 		}).
@@ -83,6 +308,23 @@ func (r *transactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 	return &transaction, nil
 }
 
+func (r *transactionRepository) GetByRef(ctx context.Context, ref string) (*models.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var transaction models.Transaction
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Tenders").
+		Preload("Order", func(db *gorm.DB) *gorm.DB {
+			return db.Preload("User").Preload("Items.Book.Category")
+		}).
+		First(&transaction, "ref = ?", ref).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
 func (r *transactionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Transaction, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -90,6 +332,7 @@ func (r *transactionRepository) GetByUserID(ctx context.Context, userID uuid.UUI
 	var transactions []models.Transaction
 	if err := r.db.WithContext(ctx).
 		Preload("User").
+		Preload("Tenders").
 		Preload("Order", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("User").Preload("Items.Book.Category")
 		}).
@@ -108,6 +351,7 @@ func (r *transactionRepository) GetByOrderID(ctx context.Context, orderID uuid.U
 	var transaction models.Transaction
 	if err := r.db.WithContext(ctx).
 		Preload("User").
+		Preload("Tenders").
 		Preload("Order", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("User").Preload("Items.Book.Category")
 		}).
@@ -117,16 +361,21 @@ func (r *transactionRepository) GetByOrderID(ctx context.Context, orderID uuid.U
 	return &transaction, nil
 }
 
-func (r *transactionRepository) Update(ctx context.Context, id uuid.UUID, updateData *models.Transaction) (*models.Transaction, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	var transaction models.Transaction
-	if err := r.db.WithContext(ctx).First(&transaction, "id = ?", id).Error; err != nil {
-		return nil, err
+// markPaid stamps PaidAt the first time a transaction is saved as SUCCESS.
+// Until a fee is known the whole amount counts as net.
+func markPaid(transaction *models.Transaction) {
+	if transaction.Status != models.TransactionStatusSuccess || transaction.PaidAt != nil {
+		return
+	}
+	now := time.Now()
+	transaction.PaidAt = &now
+	if transaction.FeeSource == "" {
+		transaction.NetAmount = transaction.Amount
 	}
+}
 
-	// Update fields
+// applyUpdate copies the non-empty fields of updateData onto transaction
+func applyUpdate(transaction, updateData *models.Transaction) {
 	if updateData.Status != "" {
 		transaction.Status = updateData.Status
 	}
@@ -142,14 +391,58 @@ func (r *transactionRepository) Update(ctx context.Context, id uuid.UUID, update
 	if updateData.PaymentURL != "" {
 		transaction.PaymentURL = updateData.PaymentURL
 	}
+	if updateData.MerchantCode != "" {
+		transaction.MerchantCode = updateData.MerchantCode
+	}
+	if updateData.ProductCode != "" {
+		transaction.ProductCode = updateData.ProductCode
+	}
+	if updateData.ProductName != "" {
+		transaction.ProductName = updateData.ProductName
+	}
+}
+
+// transactionEvents maps a status to the outbox event for moving into it
+var transactionEvents = map[string]string{
+	models.TransactionStatusSuccess:   models.EventTransactionSucceeded,
+	models.TransactionStatusFailed:    models.EventTransactionFailed,
+	models.TransactionStatusCancelled: models.EventTransactionCancelled,
+}
 
-	if err := r.db.WithContext(ctx).Save(&transaction).Error; err != nil {
+// checkTransition refuses to move a transaction out of a final status. A
+// settled payment is reversed through a refund, which leaves the status alone.
+func checkTransition(previous, next string) error {
+	if _, final := transactionEvents[previous]; final && next != "" && next != previous {
+		return ErrTransactionFinal
+	}
+	return nil
+}
+
+func (r *transactionRepository) Update(ctx context.Context, id uuid.UUID, updateData *models.Transaction) (*models.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var transaction models.Transaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transaction, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := checkTransition(transaction.Status, updateData.Status); err != nil {
+			return err
+		}
+
+		applyUpdate(&transaction, updateData)
+		markPaid(&transaction)
+		return tx.Save(&transaction).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Reload with associations
 	if err := r.db.WithContext(ctx).
 		Preload("User").
+		Preload("Tenders").
 		Preload("Order", func(db *gorm.DB) *gorm.DB {
 			return db.Preload("User").Preload("Items.Book.Category")
 		}).
@@ -160,43 +453,126 @@ func (r *transactionRepository) Update(ctx context.Context, id uuid.UUID, update
 	return &transaction, nil
 }
 
-func (r *transactionRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status, failureReason string, esewaResponse datatypes.JSON) (*models.Transaction, error) {
+func (r *transactionRepository) ChangeStatus(ctx context.Context, id uuid.UUID, updateData *models.Transaction, orderStatus string) (*models.Transaction, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var transaction models.Transaction
-	if err := r.db.WithContext(ctx).First(&transaction, "id = ?", id).Error; err != nil {
-		return nil, err
+	var previous string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transaction, "id = ?", id).Error; err != nil {
+			return err
+		}
+		previous = transaction.Status
+		if err := checkTransition(previous, updateData.Status); err != nil {
+			return err
+		}
+
+		// Repeating a final status, e.g. a second callback, changes nothing
+		if _, final := transactionEvents[previous]; !final {
+			applyUpdate(&transaction, updateData)
+			markPaid(&transaction)
+			if err := tx.Save(&transaction).Error; err != nil {
+				return err
+			}
+			if orderStatus != "" {
+				if err := tx.Model(&models.Order{}).Where("id = ?", transaction.OrderID).Update("status", orderStatus).Error; err != nil {
+					return err
+				}
+			}
+			if transaction.Status == models.TransactionStatusFailed || transaction.Status == models.TransactionStatusCancelled {
+				if err := closeCODDeliveries(tx, transaction.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.
+			Preload("User").
+			Preload("Tenders").
+			Preload("Order", func(db *gorm.DB) *gorm.DB {
+				return db.Preload("User").Preload("Items.Book.Category")
+			}).
+			First(&transaction, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		// Only a move into a final status is an event; repeating it is not
+		if eventType, ok := transactionEvents[transaction.Status]; ok && transaction.Status != previous {
+			return addOutboxEvent(tx, eventType, models.AggregateTransaction, transaction.ID, &transaction)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
 	}
+	return &transaction, previous, nil
+}
 
-	transaction.Status = status
-	if failureReason != "" {
-		transaction.FailureReason = failureReason
+func (r *transactionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Delete(&models.Transaction{}, "id = ?", id).Error
+}
+
+// SetReview flags a transaction for manual review, or clears the flag
+func (r *transactionRepository) SetReview(ctx context.Context, id uuid.UUID, needsReview bool, reason string) error {
+	result := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"needs_review":  needsReview,
+			"review_reason": reason,
+		})
+	if result.Error != nil {
+		return result.Error
 	}
-	if esewaResponse != nil {
-		transaction.EsewaResponse = esewaResponse
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
+	return nil
+}
 
-	if err := r.db.WithContext(ctx).Save(&transaction).Error; err != nil {
-		return nil, err
-	}
+func (r *transactionRepository) GetNeedingReview(ctx context.Context) ([]models.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-	// Reload with associations
+	var transactions []models.Transaction
 	if err := r.db.WithContext(ctx).
 		Preload("User").
-		Preload("Order", func(db *gorm.DB) *gorm.DB {
-			return db.Preload("User").Preload("Items.Book.Category")
-		}).
-		First(&transaction, "id = ?", id).Error; err != nil {
+		Preload("Tenders").
+		Where("needs_review = ?", true).
+		Order("created_at").
+		Find(&transactions).Error; err != nil {
 		return nil, err
 	}
-
-	return &transaction, nil
+	return transactions, nil
 }
 
-func (r *transactionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+func (r *transactionRepository) GetPendingEsewa(ctx context.Context, since time.Time) ([]models.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	return r.db.WithContext(ctx).Delete(&models.Transaction{}, "id = ?", id).Error
+	var transactions []models.Transaction
+	if err := r.db.WithContext(ctx).
+		Preload("Tenders").
+		Where("status = ? AND payment_method = ? AND payment_url <> '' AND created_at >= ?",
+			models.TransactionStatusPending, models.PaymentMethodEsewa, since).
+		Order("created_at").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *transactionRepository) SetFee(ctx context.Context, id uuid.UUID, fee float64, source string) error {
+	query := r.db.WithContext(ctx).Model(&models.Transaction{}).Where("id = ?", id)
+	if source != models.FeeSourceSettled {
+		query = query.Where("fee_source IS NULL OR fee_source <> ?", models.FeeSourceSettled)
+	}
+	return query.Updates(map[string]interface{}{
+		"gateway_fee": fee,
+		"net_amount":  gorm.Expr("amount - ?", fee),
+		"fee_source":  source,
+	}).Error
 }
@@ -35,3 +35,16 @@ func (r *UserRepository) FindByID(id string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// UpdateAccount sets the account type and verification used by payment method rules
+func (r *UserRepository) UpdateAccount(id string, accountType string, verified bool) (*models.User, error) {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"account_type": accountType, "verified": verified})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.FindByID(id)
+}
@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientBalance is returned when a debit exceeds the usable store credit
+var ErrInsufficientBalance = errors.New("insufficient store credit balance")
+
+type WalletRepository interface {
+	GetEntries(ctx context.Context, userID uuid.UUID) ([]models.WalletEntry, error)
+	GetBalance(ctx context.Context, userID uuid.UUID, at time.Time) (float64, error)
+	Credit(ctx context.Context, entry *models.WalletEntry) (*models.WalletEntry, error)
+	ReverseTransactionDebits(ctx context.Context, transactionID uuid.UUID) error
+	ExpireDue(ctx context.Context, now time.Time) ([]models.WalletEntry, error)
+}
+
+type walletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepository{db: db}
+}
+
+// walletLot is a credit entry with the part of it not yet debited or expired
+type walletLot struct {
+	ID        uuid.UUID
+	Remaining float64
+	ExpiresAt *time.Time
+}
+
+// lockWallet serialises ledger writes for one user for the rest of the transaction
+func lockWallet(tx *gorm.DB, userID uuid.UUID) error {
+	var user models.User
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error
+}
+
+// walletLots returns a user's credit lots with value left, soonest expiry first
+func walletLots(tx *gorm.DB, userID uuid.UUID) ([]walletLot, error) {
+	var lots []walletLot
+	err := tx.Raw(`
+		SELECT c.id, c.amount - COALESCE(SUM(d.amount), 0) AS remaining, c.expires_at
+		FROM wallet_entries c
+		LEFT JOIN wallet_entries d ON d.credit_id = c.id
+		WHERE c.user_id = ? AND c.type = ?
+		GROUP BY c.id
+		HAVING c.amount - COALESCE(SUM(d.amount), 0) > 0
+		ORDER BY c.expires_at ASC NULLS LAST, c.created_at ASC`,
+		userID, models.WalletEntryCredit).Scan(&lots).Error
+	return lots, err
+}
+
+// expireWallet writes EXPIRY entries for the user's lapsed lots. Call it with the wallet locked.
+func expireWallet(tx *gorm.DB, userID uuid.UUID, now time.Time) ([]models.WalletEntry, error) {
+	lots, err := walletLots(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []models.WalletEntry
+	for _, lot := range lots {
+		if lot.ExpiresAt == nil || lot.ExpiresAt.After(now) {
+			continue
+		}
+		lotID := lot.ID
+		entry := models.WalletEntry{
+			UserID:      userID,
+			Type:        models.WalletEntryExpiry,
+			Source:      models.WalletSourceExpiry,
+			Amount:      utils.RoundMoney(lot.Remaining),
+			CreditID:    &lotID,
+			Description: "Store credit expired",
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return expired, err
+		}
+		expired = append(expired, entry)
+	}
+	return expired, nil
+}
+
+// creditWallet adds a credit lot to a user's wallet inside a transaction
+func creditWallet(tx *gorm.DB, entry *models.WalletEntry) error {
+	if err := lockWallet(tx, entry.UserID); err != nil {
+		return err
+	}
+	entry.Type = models.WalletEntryCredit
+	entry.CreditID = nil
+	entry.Amount = utils.RoundMoney(entry.Amount)
+	return tx.Create(entry).Error
+}
+
+// debitWallet takes amount from a user's wallet inside a transaction, drawing
+// on the lots that expire soonest. Lapsed lots are skipped; the expiry sweep
+// writes them off. It fails with ErrInsufficientBalance rather than debit
+// part of the amount.
+func debitWallet(tx *gorm.DB, userID uuid.UUID, amount float64, transactionID *uuid.UUID, description string) error {
+	if err := lockWallet(tx, userID); err != nil {
+		return err
+	}
+
+	lots, err := walletLots(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	remaining := utils.RoundMoney(amount)
+	for _, lot := range lots {
+		if remaining <= 0 {
+			break
+		}
+		if lot.ExpiresAt != nil && !lot.ExpiresAt.After(now) {
+			continue
+		}
+		take := utils.RoundMoney(min(lot.Remaining, remaining))
+		lotID := lot.ID
+		if err := tx.Create(&models.WalletEntry{
+			UserID:        userID,
+			Type:          models.WalletEntryDebit,
+			Source:        models.WalletSourcePayment,
+			Amount:        take,
+			CreditID:      &lotID,
+			TransactionID: transactionID,
+			Description:   description,
+		}).Error; err != nil {
+			return err
+		}
+		remaining = utils.RoundMoney(remaining - take)
+	}
+
+	if remaining > 0 {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+func (r *walletRepository) GetEntries(ctx context.Context, userID uuid.UUID) ([]models.WalletEntry, error) {
+	var entries []models.WalletEntry
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetBalance returns the credit usable at the given time, leaving out lots that have lapsed
+func (r *walletRepository) GetBalance(ctx context.Context, userID uuid.UUID, at time.Time) (float64, error) {
+	lots, err := walletLots(r.db.WithContext(ctx), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance float64
+	for _, lot := range lots {
+		if lot.ExpiresAt == nil || lot.ExpiresAt.After(at) {
+			balance += lot.Remaining
+		}
+	}
+	return utils.RoundMoney(balance), nil
+}
+
+func (r *walletRepository) Credit(ctx context.Context, entry *models.WalletEntry) (*models.WalletEntry, error) {
+	if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return creditWallet(tx, entry)
+	}); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ReverseTransactionDebits gives back the credit a failed payment drew, with
+// each lot's original expiry. It does nothing if the payment was already reversed.
+func (r *walletRepository) ReverseTransactionDebits(ctx context.Context, transactionID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var debits []models.WalletEntry
+		if err := tx.Where("transaction_id = ? AND type = ?", transactionID, models.WalletEntryDebit).
+			Order("created_at").Find(&debits).Error; err != nil {
+			return err
+		}
+		if len(debits) == 0 {
+			return nil
+		}
+
+		if err := lockWallet(tx, debits[0].UserID); err != nil {
+			return err
+		}
+		var reversed int64
+		if err := tx.Model(&models.WalletEntry{}).
+			Where("transaction_id = ? AND type = ? AND source = ?", transactionID, models.WalletEntryCredit, models.WalletSourceReversal).
+			Count(&reversed).Error; err != nil {
+			return err
+		}
+		if reversed > 0 {
+			return nil
+		}
+
+		for _, debit := range debits {
+			var lot models.WalletEntry
+			if err := tx.First(&lot, "id = ?", debit.CreditID).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.WalletEntry{
+				UserID:        debit.UserID,
+				Type:          models.WalletEntryCredit,
+				Source:        models.WalletSourceReversal,
+				Amount:        debit.Amount,
+				ExpiresAt:     lot.ExpiresAt,
+				TransactionID: &transactionID,
+				Description:   "Payment did not complete, store credit returned",
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExpireDue writes EXPIRY entries for every lapsed lot and returns them
+func (r *walletRepository) ExpireDue(ctx context.Context, now time.Time) ([]models.WalletEntry, error) {
+	var userIDs []uuid.UUID
+	if err := r.db.WithContext(ctx).Model(&models.WalletEntry{}).
+		Distinct("user_id").
+		Where("type = ? AND expires_at <= ?", models.WalletEntryCredit, now).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var all []models.WalletEntry
+	for _, userID := range userIDs {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lockWallet(tx, userID); err != nil {
+				return err
+			}
+			expired, err := expireWallet(tx, userID, now)
+			if err != nil {
+				return err
+			}
+			all = append(all, expired...)
+			return nil
+		})
+		if err != nil {
+			return all, err
+		}
+	}
+	return all, nil
+}
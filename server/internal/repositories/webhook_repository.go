@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"bookstore/internal/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error)
+	GetEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	GetEndpointByID(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error)
+	GetActiveEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, id uuid.UUID, updateData *models.WebhookEndpoint) (*models.WebhookEndpoint, error)
+	SetSecret(ctx context.Context, id uuid.UUID, secret string) error
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+
+	Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt) error
+	MarkDelivered(ctx context.Context, id uuid.UUID, statusCode int) error
+	MarkAttemptFailed(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, statusCode int, lastError string) error
+	GetDeliveries(ctx context.Context, status string, endpointID *uuid.UUID) ([]models.WebhookDelivery, error)
+	GetDeliveryByID(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+	GetAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookAttempt, error)
+	Requeue(ctx context.Context, id uuid.UUID) (int64, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	if err := r.db.WithContext(ctx).Create(endpoint).Error; err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (r *webhookRepository) GetEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Order("created_at").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepository) GetEndpointByID(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.db.WithContext(ctx).First(&endpoint, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookRepository) GetActiveEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("active = ?", true).Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepository) UpdateEndpoint(ctx context.Context, id uuid.UUID, updateData *models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.db.WithContext(ctx).First(&endpoint, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&endpoint).
+		Select("url", "description", "events", "active").
+		Updates(updateData).Error; err != nil {
+		return nil, err
+	}
+	return r.GetEndpointByID(ctx, id)
+}
+
+func (r *webhookRepository) SetSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	result := r.db.WithContext(ctx).Model(&models.WebhookEndpoint{}).Where("id = ?", id).Update("secret", secret)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.WebhookEndpoint{}, "id = ?", id).Error
+}
+
+// Enqueue adds deliveries; an event already queued for an endpoint is left alone
+func (r *webhookRepository) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range deliveries {
+		deliveries[i].Status = models.WebhookDeliveryPending
+		if deliveries[i].NextAttemptAt.IsZero() {
+			deliveries[i].NextAttemptAt = now
+		}
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&deliveries).Error
+}
+
+// ClaimDue returns up to limit due PENDING deliveries and pushes their next
+// attempt out by lease, so concurrent workers do not send the same one.
+func (r *webhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, time.Now().Add(lease), models.WebhookDeliveryPending, time.Now(), limit).
+		Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+func (r *webhookRepository) MarkDelivered(ctx context.Context, id uuid.UUID, statusCode int) error {
+	return r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":           models.WebhookDeliveryDelivered,
+		"attempts":         gorm.Expr("attempts + 1"),
+		"last_status_code": statusCode,
+		"last_error":       "",
+		"delivered_at":     time.Now(),
+	}).Error
+}
+
+func (r *webhookRepository) MarkAttemptFailed(ctx context.Context, id uuid.UUID, status string, nextAttemptAt time.Time, statusCode int, lastError string) error {
+	return r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":           status,
+		"attempts":         gorm.Expr("attempts + 1"),
+		"next_attempt_at":  nextAttemptAt,
+		"last_status_code": statusCode,
+		"last_error":       lastError,
+	}).Error
+}
+
+// GetDeliveries lists deliveries, newest first, optionally filtered by status and endpoint
+func (r *webhookRepository) GetDeliveries(ctx context.Context, status string, endpointID *uuid.UUID) ([]models.WebhookDelivery, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC").Limit(500)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if endpointID != nil {
+		query = query.Where("endpoint_id = ?", *endpointID)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetDeliveryByID(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.WithContext(ctx).Preload("Endpoint").First(&delivery, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetAttempts lists the requests made for a delivery, oldest first
+func (r *webhookRepository) GetAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookAttempt, error) {
+	var attempts []models.WebhookAttempt
+	err := r.db.WithContext(ctx).Where("delivery_id = ?", deliveryID).Order("created_at").Find(&attempts).Error
+	return attempts, err
+}
+
+// Requeue resets a delivery, whatever its status, to PENDING and due now
+func (r *webhookRepository) Requeue(ctx context.Context, id uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          models.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
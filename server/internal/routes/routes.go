@@ -13,6 +13,17 @@ func SetupRoutes(
 	categoryHandler *handlers.CategoryHandler,
 	bookHandler *handlers.BookHandler,
 	orderHandler *handlers.OrderHandler, transactionHandler *handlers.TransactionHandler,
+	invoiceHandler *handlers.InvoiceHandler, reportHandler *handlers.ReportHandler,
+	cbmsHandler *handlers.CBMSHandler, couponHandler *handlers.CouponHandler,
+	promotionHandler *handlers.PromotionHandler, walletHandler *handlers.WalletHandler,
+	refundHandler *handlers.RefundHandler, giftCardHandler *handlers.GiftCardHandler,
+	ledgerHandler *handlers.LedgerHandler, settlementHandler *handlers.SettlementHandler,
+	feeScheduleHandler *handlers.FeeScheduleHandler, paymentRuleHandler *handlers.PaymentRuleHandler,
+	codHandler *handlers.CODHandler, paymentProofHandler *handlers.PaymentProofHandler,
+	disputeHandler *handlers.DisputeHandler, riskHandler *handlers.RiskHandler,
+	webhookHandler *handlers.WebhookHandler, outboxHandler *handlers.OutboxHandler,
+	streamHandler *handlers.StreamHandler, paymentLinkHandler *handlers.PaymentLinkHandler,
+	exportHandler *handlers.ExportHandler,
 ) {
 	api := router.Group("/api")
 
@@ -32,6 +43,14 @@ func SetupRoutes(
 			auth.POST("/login", authHandler.Login)
 		}
 
+		// Public payment link routes; the signed token stands in for a login
+		pay := api.Group("/pay")
+		{
+			pay.GET("/:token", paymentLinkHandler.OpenPaymentLink)
+			pay.POST("/:token/esewa", paymentLinkHandler.StartEsewaPayment)
+			pay.POST("/:token/esewa/verify", paymentLinkHandler.VerifyEsewaPayment)
+		}
+
 		// Protected routes (require JWT)
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware())
@@ -42,6 +61,12 @@ func SetupRoutes(
 				authProtected.GET("/me", authHandler.GetCurrentUser)
 			}
 
+			// User account routes
+			users := protected.Group("/users")
+			{
+				users.PUT("/:id/account", middleware.RequireRole("admin"), authHandler.UpdateUserAccount)
+			}
+
 			// Category routes
 			categories := protected.Group("/categories")
 			{
@@ -65,25 +90,244 @@ func SetupRoutes(
 			// Order routes
 			orders := protected.Group("/orders")
 			{
-				orders.POST("/", middleware.RequireRole("customer"), orderHandler.CreateOrder)
+				orders.POST("/", middleware.RequireRole("admin", "customer"), orderHandler.CreateOrder)
+				orders.POST("/quote", middleware.RequireRole("customer"), orderHandler.QuoteOrder)
 				orders.GET("/", middleware.RequireRole("admin", "customer"), orderHandler.GetAllOrders)
+				orders.GET("/export", middleware.RequireRole("admin"), exportHandler.ExportOrders)
 				orders.GET("/:id", middleware.RequireRole("admin", "customer"), orderHandler.GetOrderByID)
+				orders.GET("/:id/stream", middleware.RequireRole("admin", "customer"), streamHandler.StreamOrder)
+				orders.GET("/:id/invoice", middleware.RequireRole("admin", "customer"), invoiceHandler.GetOrderInvoice)
+				orders.GET("/:id/payment-methods", middleware.RequireRole("admin", "customer"), paymentRuleHandler.GetOrderPaymentMethods)
+				orders.POST("/:id/coupon", middleware.RequireRole("customer"), couponHandler.ApplyCoupon)
+				orders.DELETE("/:id/coupon", middleware.RequireRole("customer"), couponHandler.RemoveCoupon)
+				orders.POST("/:id/payment-links", middleware.RequireRole("admin"), paymentLinkHandler.CreatePaymentLink)
 				orders.PUT("/:id/status", middleware.RequireRole("admin"), orderHandler.UpdateOrderStatus)
 				orders.DELETE("/:id", middleware.RequireRole("admin"), orderHandler.DeleteOrder)
 			}
 
+			// Coupon routes
+			coupons := protected.Group("/coupons")
+			{
+				coupons.POST("", middleware.RequireRole("admin"), couponHandler.CreateCoupon)
+				coupons.GET("", middleware.RequireRole("admin"), couponHandler.GetAllCoupons)
+				coupons.GET("/:id", middleware.RequireRole("admin"), couponHandler.GetCouponByID)
+				coupons.PUT("/:id", middleware.RequireRole("admin"), couponHandler.UpdateCoupon)
+				coupons.DELETE("/:id", middleware.RequireRole("admin"), couponHandler.DeleteCoupon)
+			}
+
+			// Promotion routes
+			promotions := protected.Group("/promotions")
+			{
+				promotions.POST("", middleware.RequireRole("admin"), promotionHandler.CreatePromotion)
+				promotions.GET("", middleware.RequireRole("admin"), promotionHandler.GetAllPromotions)
+				promotions.GET("/:id", middleware.RequireRole("admin"), promotionHandler.GetPromotionByID)
+				promotions.PUT("/:id", middleware.RequireRole("admin"), promotionHandler.UpdatePromotion)
+				promotions.DELETE("/:id", middleware.RequireRole("admin"), promotionHandler.DeletePromotion)
+			}
+
 			// Transaction routes
 			transactions := protected.Group("/transactions")
 			{
 				transactions.POST("", middleware.RequireRole("customer"), transactionHandler.CreateTransaction)
 				transactions.GET("", middleware.RequireRole("admin"), transactionHandler.GetAllTransactions)
+				transactions.GET("/export", middleware.RequireRole("admin"), exportHandler.ExportTransactions)
 				transactions.GET("/user/my-transactions", middleware.RequireRole("customer"), transactionHandler.GetUserTransactions)
 				transactions.GET("/:id", middleware.RequireRole("admin", "customer"), transactionHandler.GetTransactionByID)
+				transactions.GET("/:id/stream", middleware.RequireRole("admin", "customer"), streamHandler.StreamTransaction)
 				transactions.GET("/order/:orderId", middleware.RequireRole("admin", "customer"), transactionHandler.GetTransactionByOrderID)
 				transactions.PUT("/:id/status", middleware.RequireRole("admin"), transactionHandler.UpdateTransactionStatus)
 				transactions.POST("/esewa/initiate", middleware.RequireRole("customer"), transactionHandler.InitiateEsewaPayment)
 				transactions.POST("/esewa/verify", middleware.RequireRole("customer"), transactionHandler.VerifyEsewaPayment)
 				transactions.DELETE("/:id", middleware.RequireRole("admin"), transactionHandler.DeleteTransaction)
+				transactions.POST("/:id/refunds", middleware.RequireRole("admin"), refundHandler.CreateRefund)
+				transactions.GET("/:id/refunds", middleware.RequireRole("admin", "customer"), refundHandler.GetTransactionRefunds)
+				transactions.POST("/:id/payment-proofs", middleware.RequireRole("customer"), paymentProofHandler.UploadPaymentProof)
+				transactions.GET("/:id/payment-proofs", middleware.RequireRole("admin", "customer"), paymentProofHandler.GetTransactionProofs)
+			}
+
+			// Refund routes
+			refunds := protected.Group("/refunds")
+			{
+				refunds.GET("", middleware.RequireRole("admin"), refundHandler.GetAllRefunds)
+				refunds.POST("/:id/complete", middleware.RequireRole("admin"), refundHandler.CompleteRefund)
+			}
+
+			// Gift card routes
+			giftCards := protected.Group("/gift-cards")
+			{
+				giftCards.POST("", middleware.RequireRole("admin"), giftCardHandler.IssueGiftCard)
+				giftCards.GET("", middleware.RequireRole("admin"), giftCardHandler.GetAllGiftCards)
+				giftCards.GET("/mine", middleware.RequireRole("customer"), giftCardHandler.GetMyGiftCards)
+				giftCards.POST("/balance", middleware.RequireRole("admin", "customer"), giftCardHandler.CheckBalance)
+				giftCards.GET("/:id", middleware.RequireRole("admin"), giftCardHandler.GetGiftCardByID)
+				giftCards.GET("/:id/transactions", middleware.RequireRole("admin"), giftCardHandler.GetGiftCardTransactions)
+				giftCards.POST("/:id/void", middleware.RequireRole("admin"), giftCardHandler.VoidGiftCard)
+			}
+
+			// Store credit wallet routes
+			wallet := protected.Group("/wallet")
+			{
+				wallet.GET("", middleware.RequireRole("customer"), walletHandler.GetMyWallet)
+				wallet.GET("/users/:userId", middleware.RequireRole("admin"), walletHandler.GetUserWallet)
+				wallet.POST("/credits", middleware.RequireRole("admin"), walletHandler.IssueCredit)
+			}
+
+			// Invoice routes
+			invoices := protected.Group("/invoices")
+			{
+				invoices.GET("", middleware.RequireRole("admin"), invoiceHandler.GetAllInvoices)
+				invoices.GET("/:id", middleware.RequireRole("admin"), invoiceHandler.GetInvoiceByID)
+				invoices.POST("/:id/cancel", middleware.RequireRole("admin"), invoiceHandler.CancelInvoice)
+				invoices.GET("/:id/cbms", middleware.RequireRole("admin"), cbmsHandler.GetInvoiceSyncStatus)
+			}
+
+			// CBMS sync routes
+			cbms := protected.Group("/cbms")
+			{
+				cbms.GET("/sync-jobs", middleware.RequireRole("admin"), cbmsHandler.GetSyncJobs)
+				cbms.POST("/sync-jobs/replay", middleware.RequireRole("admin"), cbmsHandler.ReplayFailedSyncJobs)
+				cbms.POST("/sync-jobs/:id/retry", middleware.RequireRole("admin"), cbmsHandler.RetrySyncJob)
+			}
+
+			// Report routes
+			reports := protected.Group("/reports")
+			{
+				reports.GET("/sales", middleware.RequireRole("admin"), reportHandler.GetSalesReport)
+				reports.GET("/settlements", middleware.RequireRole("admin"), reportHandler.GetSettlementReport)
+			}
+
+			// Ledger routes
+			ledger := protected.Group("/ledger")
+			{
+				ledger.GET("/accounts", middleware.RequireRole("admin"), ledgerHandler.GetAccounts)
+				ledger.GET("/accounts/:code/statement", middleware.RequireRole("admin"), ledgerHandler.GetAccountStatement)
+				ledger.GET("/trial-balance", middleware.RequireRole("admin"), ledgerHandler.GetTrialBalance)
+				ledger.GET("/journal-entries", middleware.RequireRole("admin"), ledgerHandler.GetJournalEntries)
+				ledger.POST("/gateway-fees", middleware.RequireRole("admin"), ledgerHandler.RecordGatewayFee)
+				ledger.GET("/check", middleware.RequireRole("admin"), ledgerHandler.CheckLedger)
+				ledger.POST("/post-missing", middleware.RequireRole("admin"), ledgerHandler.PostMissing)
+			}
+
+			// Gateway fee schedule routes
+			feeSchedules := protected.Group("/fee-schedules")
+			{
+				feeSchedules.POST("", middleware.RequireRole("admin"), feeScheduleHandler.CreateFeeSchedule)
+				feeSchedules.GET("", middleware.RequireRole("admin"), feeScheduleHandler.GetAllFeeSchedules)
+				feeSchedules.GET("/quote", middleware.RequireRole("admin"), feeScheduleHandler.QuoteFee)
+				feeSchedules.GET("/:id", middleware.RequireRole("admin"), feeScheduleHandler.GetFeeScheduleByID)
+				feeSchedules.PUT("/:id", middleware.RequireRole("admin"), feeScheduleHandler.UpdateFeeSchedule)
+				feeSchedules.DELETE("/:id", middleware.RequireRole("admin"), feeScheduleHandler.DeleteFeeSchedule)
+			}
+
+			// Payment method availability rules
+			paymentRules := protected.Group("/payment-rules")
+			{
+				paymentRules.POST("", middleware.RequireRole("admin"), paymentRuleHandler.CreatePaymentRule)
+				paymentRules.GET("", middleware.RequireRole("admin"), paymentRuleHandler.GetAllPaymentRules)
+				paymentRules.GET("/:id", middleware.RequireRole("admin"), paymentRuleHandler.GetPaymentRuleByID)
+				paymentRules.PUT("/:id", middleware.RequireRole("admin"), paymentRuleHandler.UpdatePaymentRule)
+				paymentRules.DELETE("/:id", middleware.RequireRole("admin"), paymentRuleHandler.DeletePaymentRule)
+			}
+
+			// Cash on delivery routes
+			cod := protected.Group("/cod")
+			{
+				cod.POST("/agents", middleware.RequireRole("admin"), codHandler.CreateAgent)
+				cod.GET("/agents", middleware.RequireRole("admin"), codHandler.GetAgents)
+				cod.PUT("/agents/:id", middleware.RequireRole("admin"), codHandler.UpdateAgent)
+				cod.GET("/awaiting-assignment", middleware.RequireRole("admin"), codHandler.GetAwaitingAssignment)
+				cod.POST("/deliveries", middleware.RequireRole("admin"), codHandler.AssignDelivery)
+				cod.GET("/deliveries", middleware.RequireRole("admin"), codHandler.GetDeliveries)
+				cod.GET("/deliveries/:id", middleware.RequireRole("admin"), codHandler.GetDelivery)
+				cod.POST("/deliveries/:id/collections", middleware.RequireRole("admin"), codHandler.RecordCollection)
+				cod.POST("/deliveries/:id/refuse", middleware.RequireRole("admin"), codHandler.RefuseDelivery)
+				cod.POST("/remittances", middleware.RequireRole("admin"), codHandler.RecordRemittance)
+				cod.GET("/remittances", middleware.RequireRole("admin"), codHandler.GetRemittances)
+				cod.GET("/remittances/:id", middleware.RequireRole("admin"), codHandler.GetRemittance)
+				cod.GET("/discrepancies", middleware.RequireRole("admin"), codHandler.GetDiscrepancyReport)
+			}
+
+			// Bank transfer deposit slip routes
+			paymentProofs := protected.Group("/payment-proofs")
+			{
+				paymentProofs.GET("", middleware.RequireRole("admin"), paymentProofHandler.GetReviewQueue)
+				paymentProofs.GET("/:id/file", middleware.RequireRole("admin", "customer"), paymentProofHandler.GetPaymentProofFile)
+				paymentProofs.POST("/:id/approve", middleware.RequireRole("admin"), paymentProofHandler.ApprovePaymentProof)
+				paymentProofs.POST("/:id/reject", middleware.RequireRole("admin"), paymentProofHandler.RejectPaymentProof)
+			}
+
+			// Chargeback and dispute routes
+			disputes := protected.Group("/disputes")
+			{
+				disputes.POST("", middleware.RequireRole("admin"), disputeHandler.OpenDispute)
+				disputes.GET("", middleware.RequireRole("admin"), disputeHandler.GetDisputes)
+				disputes.GET("/notifications", middleware.RequireRole("admin"), disputeHandler.GetNotifications)
+				disputes.POST("/notifications/:id/read", middleware.RequireRole("admin"), disputeHandler.MarkNotificationRead)
+				disputes.GET("/evidence/:evidenceId/file", middleware.RequireRole("admin"), disputeHandler.GetEvidenceFile)
+				disputes.GET("/:id", middleware.RequireRole("admin"), disputeHandler.GetDispute)
+				disputes.POST("/:id/evidence", middleware.RequireRole("admin"), disputeHandler.AddEvidence)
+				disputes.POST("/:id/submit", middleware.RequireRole("admin"), disputeHandler.SubmitEvidence)
+				disputes.POST("/:id/resolve", middleware.RequireRole("admin"), disputeHandler.ResolveDispute)
+			}
+
+			// Fraud check routes
+			risk := protected.Group("/risk")
+			{
+				risk.GET("/assessments", middleware.RequireRole("admin"), riskHandler.GetAssessments)
+			}
+
+			// Outbound webhook routes
+			webhooks := protected.Group("/webhooks")
+			{
+				webhooks.POST("/endpoints", middleware.RequireRole("admin"), webhookHandler.CreateEndpoint)
+				webhooks.GET("/endpoints", middleware.RequireRole("admin"), webhookHandler.GetEndpoints)
+				webhooks.GET("/endpoints/:id", middleware.RequireRole("admin"), webhookHandler.GetEndpoint)
+				webhooks.PUT("/endpoints/:id", middleware.RequireRole("admin"), webhookHandler.UpdateEndpoint)
+				webhooks.DELETE("/endpoints/:id", middleware.RequireRole("admin"), webhookHandler.DeleteEndpoint)
+				webhooks.POST("/endpoints/:id/rotate-secret", middleware.RequireRole("admin"), webhookHandler.RotateSecret)
+				webhooks.GET("/deliveries", middleware.RequireRole("admin"), webhookHandler.GetDeliveries)
+				webhooks.GET("/deliveries/:id", middleware.RequireRole("admin"), webhookHandler.GetDelivery)
+				webhooks.POST("/deliveries/:id/redeliver", middleware.RequireRole("admin"), webhookHandler.Redeliver)
+			}
+
+			// Outbox event routes
+			outbox := protected.Group("/outbox")
+			{
+				outbox.GET("/events", middleware.RequireRole("admin"), outboxHandler.GetEvents)
+				outbox.POST("/events/replay", middleware.RequireRole("admin"), outboxHandler.ReplayFailedEvents)
+				outbox.POST("/events/:id/replay", middleware.RequireRole("admin"), outboxHandler.ReplayEvent)
+			}
+
+			// Payment link routes
+			paymentLinks := protected.Group("/payment-links")
+			{
+				paymentLinks.GET("", middleware.RequireRole("admin"), paymentLinkHandler.GetPaymentLinks)
+				paymentLinks.POST("/:id/revoke", middleware.RequireRole("admin"), paymentLinkHandler.RevokePaymentLink)
+			}
+
+			// Background export job routes
+			exports := protected.Group("/exports")
+			{
+				exports.GET("", middleware.RequireRole("admin"), exportHandler.GetExports)
+				exports.GET("/:id", middleware.RequireRole("admin"), exportHandler.GetExport)
+				exports.GET("/:id/download", middleware.RequireRole("admin"), exportHandler.DownloadExport)
+			}
+
+			// Live status stream routes
+			streams := protected.Group("/streams")
+			{
+				streams.POST("/ticket", middleware.RequireRole("admin", "customer"), streamHandler.IssueStreamTicket)
+				streams.GET("/admin", middleware.RequireRole("admin"), streamHandler.StreamAdminFeed)
+			}
+
+			// Settlement reconciliation routes
+			settlements := protected.Group("/settlements")
+			{
+				settlements.POST("/esewa", middleware.RequireRole("admin"), settlementHandler.ImportEsewaStatement)
+				settlements.GET("", middleware.RequireRole("admin"), settlementHandler.GetImports)
+				settlements.GET("/review", middleware.RequireRole("admin"), settlementHandler.GetReviewQueue)
+				settlements.POST("/review/:transactionId/resolve", middleware.RequireRole("admin"), settlementHandler.ResolveReview)
+				settlements.GET("/:id", middleware.RequireRole("admin"), settlementHandler.GetImport)
 			}
 		}
 	}
@@ -70,3 +70,8 @@ func (s *AuthService) GetUserByID(id string) (*models.User, error) {
 func (s *AuthService) GetUserByEmail(email string) (*models.User, error) {
 	return s.userRepo.FindByEmail(email)
 }
+
+// UpdateUserAccount sets a user's account type and verification (admin only)
+func (s *AuthService) UpdateUserAccount(id, accountType string, verified bool) (*models.User, error) {
+	return s.userRepo.UpdateAccount(id, accountType, verified)
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/bs"
+	"bookstore/pkg/cbms"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CBMSSyncService interface {
+	SyncDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+	GetJobs(ctx context.Context, status string) ([]models.CBMSSyncJob, error)
+	GetInvoiceJobs(ctx context.Context, invoiceID uuid.UUID) ([]models.CBMSSyncJob, error)
+	Replay(ctx context.Context, jobID *uuid.UUID) (int64, error)
+}
+
+type cbmsSyncService struct {
+	syncRepo    repositories.CBMSSyncRepository
+	invoiceRepo repositories.InvoiceRepository
+	client      *cbms.Client
+	cfg         config.CBMSConfig
+	sellerPAN   string
+}
+
+func NewCBMSSyncService(syncRepo repositories.CBMSSyncRepository, invoiceRepo repositories.InvoiceRepository, cfg config.CBMSConfig, seller config.SellerConfig) CBMSSyncService {
+	return &cbmsSyncService{
+		syncRepo:    syncRepo,
+		invoiceRepo: invoiceRepo,
+		client:      cbms.NewClient(cfg.URL, cfg.Username, cfg.Password),
+		cfg:         cfg,
+		sellerPAN:   seller.PAN,
+	}
+}
+
+const cbmsBatchSize = 20
+
+// Run syncs due jobs every poll interval until ctx is cancelled
+func (s *cbmsSyncService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SyncDue(ctx); err != nil {
+			log.Printf("cbms sync: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncDue posts every due job to CBMS and returns how many were synced
+func (s *cbmsSyncService) SyncDue(ctx context.Context) (int, error) {
+	synced := 0
+	for {
+		jobs, err := s.syncRepo.ClaimDue(ctx, cbmsBatchSize, 5*time.Minute)
+		if err != nil {
+			return synced, err
+		}
+		if len(jobs) == 0 {
+			return synced, nil
+		}
+
+		for _, job := range jobs {
+			if err := s.syncJob(ctx, &job); err != nil {
+				s.recordFailure(ctx, &job, err)
+				continue
+			}
+			if err := s.syncRepo.MarkSynced(ctx, job.ID); err != nil {
+				return synced, err
+			}
+			synced++
+		}
+	}
+}
+
+func (s *cbmsSyncService) recordFailure(ctx context.Context, job *models.CBMSSyncJob, syncErr error) {
+	attempts := job.Attempts + 1
+	status := models.CBMSSyncStatusPending
+
+	// Bad credentials or an invalid bill will not fix themselves; stop retrying
+	var respErr *cbms.ResponseError
+	if attempts >= s.cfg.MaxAttempts ||
+		(errors.As(syncErr, &respErr) && (respErr.Code == cbms.CodeInvalidCredential || respErr.Code == cbms.CodeInvalidModel)) {
+		status = models.CBMSSyncStatusFailed
+	}
+
+	// Exponential backoff from the poll interval, capped at an hour
+	backoff := s.cfg.PollInterval << min(attempts, 10)
+	if backoff > time.Hour {
+		backoff = time.Hour
+	}
+
+	log.Printf("cbms sync %s %s attempt %d failed: %v", job.DocumentType, job.DocumentNumber, attempts, syncErr)
+	if err := s.syncRepo.MarkAttemptFailed(ctx, job.ID, status, time.Now().Add(backoff), syncErr.Error()); err != nil {
+		log.Printf("cbms sync: failed to record attempt for %s: %v", job.ID, err)
+	}
+}
+
+func (s *cbmsSyncService) syncJob(ctx context.Context, job *models.CBMSSyncJob) error {
+	invoice, err := s.invoiceRepo.GetByID(ctx, job.InvoiceID)
+	if err != nil {
+		return fmt.Errorf("load invoice: %v", err)
+	}
+	bill, err := s.billFor(invoice)
+	if err != nil {
+		return err
+	}
+
+	switch job.DocumentType {
+	case models.DocumentTypeInvoice:
+		return s.client.PostBill(ctx, bill)
+	case models.DocumentTypeCreditNote:
+		creditNote, err := s.invoiceRepo.GetCreditNoteByID(ctx, job.DocumentID)
+		if err != nil {
+			return fmt.Errorf("load credit note: %v", err)
+		}
+		creditNoteDate, err := cbmsDate(creditNote.IssuedAt)
+		if err != nil {
+			return err
+		}
+		bill.FiscalYear = cbms.FiscalYear(creditNote.FiscalYear)
+		return s.client.PostBillReturn(ctx, cbms.BillReturn{
+			Bill:             bill,
+			RefInvoiceNumber: invoice.InvoiceNumber,
+			CreditNoteNumber: creditNote.CreditNoteNumber,
+			CreditNoteDate:   creditNoteDate,
+			ReasonForReturn:  creditNote.Reason,
+		})
+	default:
+		return fmt.Errorf("unknown document type %s", job.DocumentType)
+	}
+}
+
+func (s *cbmsSyncService) billFor(invoice *models.Invoice) (cbms.Bill, error) {
+	invoiceDate, err := cbmsDate(invoice.IssuedAt)
+	if err != nil {
+		return cbms.Bill{}, err
+	}
+	return cbms.Bill{
+		SellerPAN:     s.sellerPAN,
+		BuyerPAN:      invoice.BuyerPAN,
+		BuyerName:     invoice.BuyerName,
+		FiscalYear:    cbms.FiscalYear(invoice.FiscalYear),
+		InvoiceNumber: invoice.InvoiceNumber,
+		InvoiceDate:   invoiceDate,
+		// Service and delivery charges carry no VAT, so they are reported with exempt sales
+		TotalSales:       invoice.TotalAmount,
+		TaxableSalesVAT:  invoice.TaxableAmount,
+		VAT:              invoice.TaxAmount,
+		TaxExemptedSales: invoice.ExemptAmount + invoice.ServiceCharge + invoice.DeliveryCharge,
+		IsRealtime:       true,
+		DatetimeClient:   time.Now().In(bs.Location()).Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// cbmsDate formats t as a BS date in the CBMS form YYYY.MM.DD
+func cbmsDate(t time.Time) (string, error) {
+	d, err := bs.FromAD(t)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(d.String(), "-", "."), nil
+}
+
+func (s *cbmsSyncService) GetJobs(ctx context.Context, status string) ([]models.CBMSSyncJob, error) {
+	return s.syncRepo.GetAll(ctx, status)
+}
+
+func (s *cbmsSyncService) GetInvoiceJobs(ctx context.Context, invoiceID uuid.UUID) ([]models.CBMSSyncJob, error) {
+	return s.syncRepo.GetByInvoiceID(ctx, invoiceID)
+}
+
+// Replay requeues one job, or every FAILED job when jobID is nil
+func (s *cbmsSyncService) Replay(ctx context.Context, jobID *uuid.UUID) (int64, error) {
+	return s.syncRepo.Requeue(ctx, jobID)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CODService interface {
+	CreateAgent(ctx context.Context, req *models.DeliveryAgentRequest) (*models.DeliveryAgent, error)
+	GetAgents(ctx context.Context) ([]models.DeliveryAgent, error)
+	UpdateAgent(ctx context.Context, id uuid.UUID, req *models.DeliveryAgentRequest) (*models.DeliveryAgent, error)
+
+	GetAwaitingAssignment(ctx context.Context) ([]models.Transaction, error)
+	Assign(ctx context.Context, req *models.AssignCODRequest) (*models.CODDelivery, error)
+	GetDeliveries(ctx context.Context, status string, agentID *uuid.UUID) ([]models.CODDelivery, error)
+	GetDelivery(ctx context.Context, id uuid.UUID) (*models.CODDelivery, error)
+	RecordCollection(ctx context.Context, deliveryID uuid.UUID, req *models.CODCollectionRequest) (*models.CODDelivery, error)
+	Refuse(ctx context.Context, deliveryID uuid.UUID, reason string) (*models.CODDelivery, error)
+
+	RecordRemittance(ctx context.Context, agentID uuid.UUID, date time.Time, amount float64, note string, adminID *uuid.UUID) (*models.CODRemittance, error)
+	GetRemittances(ctx context.Context, agentID *uuid.UUID, from, to time.Time) ([]models.CODRemittance, error)
+	GetRemittance(ctx context.Context, id uuid.UUID) (*models.CODRemittance, error)
+	DiscrepancyReport(ctx context.Context, from, to time.Time, calendar string) ([]models.CODDiscrepancy, error)
+}
+
+type codService struct {
+	codRepo            repositories.CODRepository
+	transactionRepo    repositories.TransactionRepository
+	orders             *OrderService
+	transactionService TransactionService
+}
+
+func NewCODService(codRepo repositories.CODRepository, transactionRepo repositories.TransactionRepository, orders *OrderService, transactionService TransactionService) CODService {
+	return &codService{
+		codRepo:            codRepo,
+		transactionRepo:    transactionRepo,
+		orders:             orders,
+		transactionService: transactionService,
+	}
+}
+
+func (s *codService) CreateAgent(ctx context.Context, req *models.DeliveryAgentRequest) (*models.DeliveryAgent, error) {
+	return s.codRepo.CreateAgent(ctx, &models.DeliveryAgent{
+		Name:    req.Name,
+		Phone:   req.Phone,
+		Courier: req.Courier,
+		Active:  req.Active == nil || *req.Active,
+	})
+}
+
+func (s *codService) GetAgents(ctx context.Context) ([]models.DeliveryAgent, error) {
+	return s.codRepo.GetAgents(ctx)
+}
+
+func (s *codService) UpdateAgent(ctx context.Context, id uuid.UUID, req *models.DeliveryAgentRequest) (*models.DeliveryAgent, error) {
+	return s.codRepo.UpdateAgent(ctx, id, &models.DeliveryAgent{
+		Name:    req.Name,
+		Phone:   req.Phone,
+		Courier: req.Courier,
+		Active:  req.Active == nil || *req.Active,
+	})
+}
+
+// GetAwaitingAssignment lists CASH orders that no agent has been given yet
+func (s *codService) GetAwaitingAssignment(ctx context.Context) ([]models.Transaction, error) {
+	return s.codRepo.AwaitingAssignment(ctx)
+}
+
+// Assign hands a pending CASH transaction's order to an agent, or moves an
+// open delivery to another agent
+func (s *codService) Assign(ctx context.Context, req *models.AssignCODRequest) (*models.CODDelivery, error) {
+	agent, err := s.codRepo.GetAgentByID(ctx, req.AgentID)
+	if err != nil {
+		return nil, errors.New("delivery agent not found")
+	}
+	if !agent.Active {
+		return nil, errors.New("delivery agent is inactive")
+	}
+
+	existing, err := s.codRepo.GetDeliveryByTransactionID(ctx, req.TransactionID)
+	if err == nil {
+		return s.codRepo.Reassign(ctx, existing.ID, agent.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	transaction, err := s.transactionRepo.GetByID(ctx, req.TransactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	if transaction.PaymentMethod != models.PaymentMethodCash {
+		return nil, errors.New("only CASH transactions are collected on delivery")
+	}
+	if transaction.Status != models.TransactionStatusPending {
+		return nil, fmt.Errorf("transaction is %s, not PENDING", transaction.Status)
+	}
+
+	expected := transaction.Amount
+	if len(transaction.Tenders) > 0 {
+		expected = transaction.TenderAmount(models.PaymentMethodCash)
+	}
+	return s.codRepo.CreateDelivery(ctx, &models.CODDelivery{
+		TransactionID:  transaction.ID,
+		OrderID:        transaction.OrderID,
+		AgentID:        agent.ID,
+		Status:         models.CODStatusAssigned,
+		ExpectedAmount: utils.RoundMoney(expected),
+		AssignedAt:     time.Now(),
+	})
+}
+
+func (s *codService) GetDeliveries(ctx context.Context, status string, agentID *uuid.UUID) ([]models.CODDelivery, error) {
+	return s.codRepo.GetDeliveries(ctx, status, agentID)
+}
+
+func (s *codService) GetDelivery(ctx context.Context, id uuid.UUID) (*models.CODDelivery, error) {
+	return s.codRepo.GetDeliveryByID(ctx, id)
+}
+
+// RecordCollection adds cash collected on delivery. Once the full amount is
+// in, the transaction succeeds and the order is paid.
+func (s *codService) RecordCollection(ctx context.Context, deliveryID uuid.UUID, req *models.CODCollectionRequest) (*models.CODDelivery, error) {
+	delivery, err := s.codRepo.GetDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, errors.New("delivery not found")
+	}
+
+	collection := &models.CODCollection{
+		Amount:      utils.RoundMoney(req.Amount),
+		CollectedBy: req.CollectedBy,
+		CollectedAt: time.Now(),
+		Note:        req.Note,
+	}
+	if collection.CollectedBy == "" && delivery.Agent != nil {
+		collection.CollectedBy = delivery.Agent.Name
+	}
+	if req.CollectedAt != nil {
+		if req.CollectedAt.After(time.Now()) {
+			return nil, errors.New("collected_at is in the future")
+		}
+		collection.CollectedAt = *req.CollectedAt
+	}
+
+	delivery, err = s.codRepo.AddCollection(ctx, deliveryID, collection)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == models.CODStatusCollected {
+		if _, err := s.transactionService.UpdateTransactionStatus(ctx, delivery.TransactionID, &models.TransactionUpdateRequest{
+			Status: models.TransactionStatusSuccess,
+		}); err != nil {
+			return nil, fmt.Errorf("cash collected but failed to mark the transaction paid: %v", err)
+		}
+	}
+	return delivery, nil
+}
+
+// Refuse records that the customer refused the delivery: the transaction
+// fails and the order is cancelled
+func (s *codService) Refuse(ctx context.Context, deliveryID uuid.UUID, reason string) (*models.CODDelivery, error) {
+	delivery, err := s.codRepo.Refuse(ctx, deliveryID, reason)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("delivery not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.transactionService.UpdateTransactionStatus(ctx, delivery.TransactionID, &models.TransactionUpdateRequest{
+		Status:        models.TransactionStatusFailed,
+		FailureReason: "Delivery refused: " + reason,
+	}); err != nil {
+		return nil, fmt.Errorf("delivery refused but failed to update the transaction: %v", err)
+	}
+	if _, err := s.orders.UpdateOrderStatus(ctx, delivery.OrderID, models.OrderStatusCancelled); err != nil {
+		log.Printf("failed to cancel order %s after refused delivery: %v", delivery.OrderID, err)
+	}
+	return delivery, nil
+}
+
+// RecordRemittance reconciles the cash an agent hands over for a day against
+// every collection of theirs up to the end of that day not yet remitted
+func (s *codService) RecordRemittance(ctx context.Context, agentID uuid.UUID, date time.Time, amount float64, note string, adminID *uuid.UUID) (*models.CODRemittance, error) {
+	if _, err := s.codRepo.GetAgentByID(ctx, agentID); err != nil {
+		return nil, errors.New("delivery agent not found")
+	}
+	day := startOfDay(date)
+	if day.After(time.Now()) {
+		return nil, errors.New("remittance date is in the future")
+	}
+
+	return s.codRepo.CreateRemittance(ctx, &models.CODRemittance{
+		AgentID:        agentID,
+		RemittanceDate: day,
+		Amount:         utils.RoundMoney(amount),
+		Note:           note,
+		RecordedBy:     adminID,
+	}, day.AddDate(0, 0, 1))
+}
+
+func (s *codService) GetRemittances(ctx context.Context, agentID *uuid.UUID, from, to time.Time) ([]models.CODRemittance, error) {
+	return s.codRepo.GetRemittances(ctx, agentID, from, to)
+}
+
+func (s *codService) GetRemittance(ctx context.Context, id uuid.UUID) (*models.CODRemittance, error) {
+	return s.codRepo.GetRemittanceByID(ctx, id)
+}
+
+// DiscrepancyReport lists remittances that came in short or over, and days on
+// which an agent collected cash that was still not remitted the day after
+func (s *codService) DiscrepancyReport(ctx context.Context, from, to time.Time, calendar string) ([]models.CODDiscrepancy, error) {
+	agents, err := s.codRepo.GetAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := map[uuid.UUID]string{}
+	for _, agent := range agents {
+		names[agent.ID] = agent.Name
+	}
+
+	var report []models.CODDiscrepancy
+	remittances, err := s.codRepo.GetRemittances(ctx, nil, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, remittance := range remittances {
+		if remittance.Status == models.RemittanceBalanced {
+			continue
+		}
+		date, err := reportDate(remittance.RemittanceDate, calendar)
+		if err != nil {
+			return nil, err
+		}
+		remittanceID := remittance.ID
+		discrepancyType := models.CODDiscrepancyShort
+		if remittance.Status == models.RemittanceOver {
+			discrepancyType = models.CODDiscrepancyOver
+		}
+		report = append(report, models.CODDiscrepancy{
+			Type:         discrepancyType,
+			Date:         date,
+			AgentID:      remittance.AgentID,
+			AgentName:    names[remittance.AgentID],
+			RemittanceID: &remittanceID,
+			Expected:     remittance.ExpectedAmount,
+			Remitted:     remittance.Amount,
+			Difference:   remittance.Difference,
+			Collections:  len(remittance.Collections),
+		})
+	}
+
+	// Cash collected before yesterday should have been handed over by now
+	overdue := startOfDay(time.Now()).AddDate(0, 0, -1)
+	if overdue.Before(to) {
+		to = overdue
+	}
+	collections, err := s.codRepo.UnremittedCollections(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	unremitted := map[string]*models.CODDiscrepancy{}
+	for _, collection := range collections {
+		date, err := reportDate(collection.CollectedAt, calendar)
+		if err != nil {
+			return nil, err
+		}
+		key := collection.AgentID.String() + date
+		row, ok := unremitted[key]
+		if !ok {
+			row = &models.CODDiscrepancy{
+				Type:      models.CODDiscrepancyUnremitted,
+				Date:      date,
+				AgentID:   collection.AgentID,
+				AgentName: names[collection.AgentID],
+			}
+			unremitted[key] = row
+		}
+		row.Expected = utils.RoundMoney(row.Expected + collection.Amount)
+		row.Difference = -row.Expected
+		row.Collections++
+	}
+	for _, row := range unremitted {
+		report = append(report, *row)
+	}
+
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Date != report[j].Date {
+			return report[i].Date > report[j].Date
+		}
+		return report[i].AgentName < report[j].AgentName
+	})
+	return report, nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CouponService interface {
+	CreateCoupon(ctx context.Context, req *models.CouponRequest) (*models.Coupon, error)
+	GetAllCoupons(ctx context.Context) ([]models.Coupon, error)
+	GetCouponByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error)
+	UpdateCoupon(ctx context.Context, id uuid.UUID, req *models.CouponRequest) (*models.Coupon, error)
+	DeleteCoupon(ctx context.Context, id uuid.UUID) error
+	ApplyCoupon(ctx context.Context, orderID, userID uuid.UUID, code string) (*models.Order, error)
+	RemoveCoupon(ctx context.Context, orderID, userID uuid.UUID) (*models.Order, error)
+}
+
+type couponService struct {
+	couponRepo      repositories.CouponRepository
+	orderRepo       repositories.OrderRepository
+	transactionRepo repositories.TransactionRepository
+	promotions      *PromotionEngine
+	taxCalc         *TaxCalculator
+}
+
+func NewCouponService(couponRepo repositories.CouponRepository, orderRepo repositories.OrderRepository, transactionRepo repositories.TransactionRepository, promotions *PromotionEngine, taxCalc *TaxCalculator) CouponService {
+	return &couponService{
+		couponRepo:      couponRepo,
+		orderRepo:       orderRepo,
+		transactionRepo: transactionRepo,
+		promotions:      promotions,
+		taxCalc:         taxCalc,
+	}
+}
+
+func couponFromRequest(req *models.CouponRequest) (*models.Coupon, error) {
+	if req.DiscountType == models.CouponTypePercentage && req.Value > 100 {
+		return nil, errors.New("percentage discount cannot exceed 100")
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return nil, errors.New("expires_at must be after starts_at")
+	}
+
+	coupon := &models.Coupon{
+		Code:          req.Code,
+		Description:   req.Description,
+		DiscountType:  req.DiscountType,
+		Value:         req.Value,
+		MaxDiscount:   req.MaxDiscount,
+		MinOrderValue: req.MinOrderValue,
+		StartsAt:      req.StartsAt,
+		ExpiresAt:     req.ExpiresAt,
+		UsageLimit:    req.UsageLimit,
+		PerUserLimit:  req.PerUserLimit,
+		Active:        req.Active == nil || *req.Active,
+		Categories:    []models.Category{},
+		Books:         []models.Book{},
+	}
+	for _, id := range req.CategoryIDs {
+		coupon.Categories = append(coupon.Categories, models.Category{ID: id})
+	}
+	for _, id := range req.BookIDs {
+		coupon.Books = append(coupon.Books, models.Book{ID: id})
+	}
+	return coupon, nil
+}
+
+func (s *couponService) CreateCoupon(ctx context.Context, req *models.CouponRequest) (*models.Coupon, error) {
+	coupon, err := couponFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.couponRepo.Create(ctx, coupon)
+}
+
+func (s *couponService) GetAllCoupons(ctx context.Context) ([]models.Coupon, error) {
+	return s.couponRepo.GetAll(ctx)
+}
+
+func (s *couponService) GetCouponByID(ctx context.Context, id uuid.UUID) (*models.Coupon, error) {
+	return s.couponRepo.GetByID(ctx, id)
+}
+
+func (s *couponService) UpdateCoupon(ctx context.Context, id uuid.UUID, req *models.CouponRequest) (*models.Coupon, error) {
+	coupon, err := couponFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.couponRepo.Update(ctx, id, coupon)
+}
+
+func (s *couponService) DeleteCoupon(ctx context.Context, id uuid.UUID) error {
+	return s.couponRepo.Delete(ctx, id)
+}
+
+// checkoutOrder loads an order the user may still change at checkout
+func (s *couponService) checkoutOrder(ctx context.Context, orderID, userID uuid.UUID) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.UserID != userID {
+		return nil, errors.New("order does not belong to user")
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, errors.New("order is no longer pending")
+	}
+	// The transaction amount is fixed when it is created
+	if existing, _ := s.transactionRepo.GetByOrderID(ctx, orderID); existing != nil {
+		return nil, errors.New("payment has already been started for this order")
+	}
+	return order, nil
+}
+
+// ApplyCoupon applies a coupon code to a pending order, replacing any coupon already applied
+func (s *couponService) ApplyCoupon(ctx context.Context, orderID, userID uuid.UUID, code string) (*models.Order, error) {
+	order, err := s.checkoutOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := s.couponRepo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid coupon code")
+		}
+		return nil, err
+	}
+	if err := validateCoupon(coupon, order, time.Now()); err != nil {
+		return nil, err
+	}
+
+	discount, err := priceOrder(ctx, s.promotions, s.taxCalc, order, coupon)
+	if err != nil {
+		return nil, err
+	}
+	if discount <= 0 {
+		return nil, errors.New("coupon does not apply to any item in this order")
+	}
+
+	if err := s.couponRepo.ApplyToOrder(ctx, coupon, order, discount); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.GetByID(ctx, orderID)
+}
+
+// RemoveCoupon removes the coupon from a pending order and reprices it
+func (s *couponService) RemoveCoupon(ctx context.Context, orderID, userID uuid.UUID) (*models.Order, error) {
+	order, err := s.checkoutOrder(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := priceOrder(ctx, s.promotions, s.taxCalc, order, nil); err != nil {
+		return nil, err
+	}
+	if err := s.couponRepo.RemoveFromOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.GetByID(ctx, orderID)
+}
+
+// validateCoupon checks a coupon's status, validity window and usage. The
+// minimum order value depends on promotions, so applyCouponDiscount checks it.
+func validateCoupon(coupon *models.Coupon, order *models.Order, now time.Time) error {
+	if !coupon.Active {
+		return errors.New("coupon is not active")
+	}
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return errors.New("coupon is not valid yet")
+	}
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		return errors.New("coupon has expired")
+	}
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= int64(coupon.UsageLimit) {
+		return repositories.ErrCouponLimitReached
+	}
+	return nil
+}
+
+// applyCouponDiscount spreads the coupon discount over eligible items in
+// proportion to their amounts and adds the discount line to the order. The
+// minimum order value is checked against those items after promotions, the
+// amount the coupon actually discounts.
+func applyCouponDiscount(order *models.Order, coupon *models.Coupon) (float64, error) {
+	var eligible []int
+	var eligibleTotal float64
+	for i, item := range order.Items {
+		if couponCoversItem(coupon, &item) {
+			eligible = append(eligible, i)
+			eligibleTotal += item.Price*float64(item.Quantity) - item.DiscountAmount
+		}
+	}
+	if eligibleTotal <= 0 {
+		return 0, nil
+	}
+	if eligibleTotal < coupon.MinOrderValue {
+		return 0, fmt.Errorf("coupon requires a minimum order of Rs. %.2f", coupon.MinOrderValue)
+	}
+
+	discount := coupon.Value
+	if coupon.DiscountType == models.CouponTypePercentage {
+		discount = eligibleTotal * coupon.Value / 100
+		if coupon.MaxDiscount > 0 && discount > coupon.MaxDiscount {
+			discount = coupon.MaxDiscount
+		}
+	}
+	discount = utils.RoundMoney(min(discount, eligibleTotal))
+
+	allocateDiscount(order, eligible, eligibleTotal, discount)
+	couponID := coupon.ID
+	order.Discounts = append(order.Discounts, models.OrderDiscount{
+		Type:        models.DiscountTypeCoupon,
+		CouponID:    &couponID,
+		Code:        coupon.Code,
+		Description: coupon.Description,
+		Amount:      discount,
+	})
+	return discount, nil
+}
+
+// allocateDiscount adds discount to the given items pro rata; the last item takes the rounding remainder
+func allocateDiscount(order *models.Order, indexes []int, base, discount float64) {
+	remaining := discount
+	for n, i := range indexes {
+		item := &order.Items[i]
+		share := remaining
+		if n < len(indexes)-1 {
+			share = utils.RoundMoney(discount * (item.Price*float64(item.Quantity) - item.DiscountAmount) / base)
+			remaining = utils.RoundMoney(remaining - share)
+		}
+		item.DiscountAmount = utils.RoundMoney(item.DiscountAmount + share)
+	}
+}
+
+func couponCoversItem(coupon *models.Coupon, item *models.OrderItem) bool {
+	if item.Book.ProductType == models.ProductTypeGiftCard {
+		return false
+	}
+	if len(coupon.Categories) == 0 && len(coupon.Books) == 0 {
+		return true
+	}
+	for _, book := range coupon.Books {
+		if book.ID == item.BookID {
+			return true
+		}
+	}
+	for _, category := range coupon.Categories {
+		if category.ID == item.Book.CategoryID {
+			return true
+		}
+	}
+	return false
+}
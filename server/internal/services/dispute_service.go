@@ -0,0 +1,338 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/filestore"
+	"bookstore/pkg/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DisputeService interface {
+	OpenDispute(ctx context.Context, req *models.CreateDisputeRequest, adminID uuid.UUID) (*models.Dispute, error)
+	GetDisputes(ctx context.Context, status string, transactionID *uuid.UUID) ([]models.Dispute, error)
+	GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	AddEvidence(ctx context.Context, disputeID, adminID uuid.UUID, fileName string, r io.Reader, form *models.DisputeEvidenceUpload) (*models.DisputeEvidence, error)
+	OpenEvidenceFile(ctx context.Context, evidenceID uuid.UUID) (*models.DisputeEvidence, io.ReadCloser, error)
+	SubmitEvidence(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	Resolve(ctx context.Context, id uuid.UUID, req *models.ResolveDisputeRequest, adminID uuid.UUID) (*models.Dispute, error)
+
+	GetNotifications(ctx context.Context, unreadOnly bool) ([]models.DisputeNotification, error)
+	MarkNotificationRead(ctx context.Context, id uuid.UUID) error
+	CheckDeadlines(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+}
+
+type disputeService struct {
+	disputeRepo     repositories.DisputeRepository
+	transactionRepo repositories.TransactionRepository
+	refundRepo      repositories.RefundRepository
+	ledger          LedgerService
+	store           filestore.Store
+	maxSize         int64
+	cfg             config.DisputeConfig
+}
+
+func NewDisputeService(disputeRepo repositories.DisputeRepository, transactionRepo repositories.TransactionRepository, refundRepo repositories.RefundRepository, ledger LedgerService, store filestore.Store, maxSize int64, cfg config.DisputeConfig) DisputeService {
+	return &disputeService{
+		disputeRepo:     disputeRepo,
+		transactionRepo: transactionRepo,
+		refundRepo:      refundRepo,
+		ledger:          ledger,
+		store:           store,
+		maxSize:         maxSize,
+		cfg:             cfg,
+	}
+}
+
+// OpenDispute records a dispute raised against a successful transaction
+func (s *disputeService) OpenDispute(ctx context.Context, req *models.CreateDisputeRequest, adminID uuid.UUID) (*models.Dispute, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, req.TransactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	if transaction.Status != models.TransactionStatusSuccess {
+		return nil, errors.New("only successful transactions can be disputed")
+	}
+
+	amount := utils.RoundMoney(req.Amount)
+	if amount == 0 {
+		amount = transaction.Amount
+	}
+	if amount > transaction.Amount {
+		return nil, fmt.Errorf("disputed amount exceeds the transaction amount of Rs. %.2f", transaction.Amount)
+	}
+	openedAt := time.Now()
+	if req.OpenedAt != nil {
+		openedAt = *req.OpenedAt
+	}
+	if !req.EvidenceDueAt.After(openedAt) {
+		return nil, errors.New("evidence_due_at must be after the dispute was opened")
+	}
+
+	return s.disputeRepo.Create(ctx, &models.Dispute{
+		TransactionID: transaction.ID,
+		OrderID:       transaction.OrderID,
+		UserID:        transaction.UserID,
+		CaseReference: req.CaseReference,
+		ReasonCode:    req.ReasonCode,
+		Description:   req.Description,
+		Amount:        amount,
+		OpenedAt:      openedAt,
+		EvidenceDueAt: req.EvidenceDueAt,
+		Status:        models.DisputeStatusOpen,
+		CreatedBy:     &adminID,
+	})
+}
+
+func (s *disputeService) GetDisputes(ctx context.Context, status string, transactionID *uuid.UUID) ([]models.Dispute, error) {
+	return s.disputeRepo.GetAll(ctx, status, transactionID)
+}
+
+func (s *disputeService) GetDispute(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	return s.disputeRepo.GetByID(ctx, id)
+}
+
+// AddEvidence stores a file for an OPEN dispute
+func (s *disputeService) AddEvidence(ctx context.Context, disputeID, adminID uuid.UUID, fileName string, r io.Reader, form *models.DisputeEvidenceUpload) (*models.DisputeEvidence, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		return nil, errors.New("dispute not found")
+	}
+	if dispute.Status != models.DisputeStatusOpen {
+		return nil, fmt.Errorf("evidence cannot be added to a %s dispute", dispute.Status)
+	}
+
+	data, contentType, ext, err := readUpload(r, s.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("evidence: %v", err)
+	}
+	key := fmt.Sprintf("dispute-evidence/%s/%s%s", disputeID, uuid.New(), ext)
+	if err := s.store.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to store evidence: %v", err)
+	}
+
+	evidence, err := s.disputeRepo.AddEvidence(ctx, &models.DisputeEvidence{
+		DisputeID:   disputeID,
+		FileKey:     key,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Description: form.Description,
+		UploadedBy:  &adminID,
+	})
+	if err != nil {
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			log.Printf("failed to remove orphaned dispute evidence %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+	return evidence, nil
+}
+
+// OpenEvidenceFile returns an evidence record with its stored file; the caller closes the reader
+func (s *disputeService) OpenEvidenceFile(ctx context.Context, evidenceID uuid.UUID) (*models.DisputeEvidence, io.ReadCloser, error) {
+	evidence, err := s.disputeRepo.GetEvidence(ctx, evidenceID)
+	if err != nil {
+		return nil, nil, errors.New("evidence not found")
+	}
+	file, err := s.store.Open(ctx, evidence.FileKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("evidence file is unavailable: %v", err)
+	}
+	return evidence, file, nil
+}
+
+// SubmitEvidence records that the evidence was sent to the gateway or issuer
+func (s *disputeService) SubmitEvidence(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("dispute not found")
+	}
+	if len(dispute.Evidence) == 0 {
+		return nil, errors.New("attach at least one evidence file before submitting")
+	}
+
+	return s.disputeRepo.Transition(ctx, id, []string{models.DisputeStatusOpen}, map[string]interface{}{
+		"status":                models.DisputeStatusEvidenceSubmitted,
+		"evidence_submitted_at": time.Now(),
+	})
+}
+
+// Resolve closes a dispute as WON or LOST. A lost dispute reverses the
+// disputed amount; resolving it as LOST again retries a failed reversal.
+func (s *disputeService) Resolve(ctx context.Context, id uuid.UUID, req *models.ResolveDisputeRequest, adminID uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("dispute not found")
+	}
+	if dispute.Status == models.DisputeStatusLost && dispute.ReversalID == nil && req.Outcome == models.DisputeStatusLost {
+		return s.reverse(ctx, dispute, adminID)
+	}
+
+	dispute, err = s.disputeRepo.Transition(ctx, id,
+		[]string{models.DisputeStatusOpen, models.DisputeStatusEvidenceSubmitted},
+		map[string]interface{}{
+			"status":          req.Outcome,
+			"resolved_at":     time.Now(),
+			"resolution_note": req.Note,
+		})
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status == models.DisputeStatusLost {
+		return s.reverse(ctx, dispute, adminID)
+	}
+	return dispute, nil
+}
+
+// reverse records a lost dispute as a completed CHARGEBACK refund against
+// its order, capped at what was paid through the gateway and has not already
+// gone back through it. Store credit and gift card tenders never reach the
+// gateway, so they cannot be charged back.
+func (s *disputeService) reverse(ctx context.Context, dispute *models.Dispute, adminID uuid.UUID) (*models.Dispute, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, dispute.TransactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	refunds, err := s.refundRepo.GetByTransactionID(ctx, dispute.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	var refunded, refundedToGateway float64
+	for _, refund := range refunds {
+		refunded += refund.Amount
+		if refund.Method != models.RefundMethodStoreCredit {
+			refundedToGateway += refund.Amount
+		}
+	}
+	amount := utils.RoundMoney(math.Min(dispute.Amount, math.Min(transaction.Amount-refunded, transaction.GatewayAmount()-refundedToGateway)))
+	if amount <= 0 {
+		log.Printf("dispute %s lost but transaction %s has nothing left to reverse", dispute.ID, transaction.ID)
+		return dispute, nil
+	}
+
+	now := time.Now()
+	reversal, err := s.disputeRepo.Reverse(ctx, dispute.ID, &models.Refund{
+		TransactionID: transaction.ID,
+		OrderID:       transaction.OrderID,
+		UserID:        transaction.UserID,
+		Amount:        amount,
+		Method:        models.RefundMethodChargeback,
+		Status:        models.RefundStatusCompleted,
+		Reason:        fmt.Sprintf("Chargeback %s (dispute %s)", dispute.ReasonCode, dispute.ID),
+		CreatedBy:     &adminID,
+		CompletedAt:   &now,
+	})
+	if errors.Is(err, repositories.ErrDisputeState) {
+		// Another resolution recorded the chargeback first
+		return s.disputeRepo.GetByID(ctx, dispute.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dispute marked lost but failed to record the reversal: %v", err)
+	}
+	if _, err := s.ledger.PostRefund(ctx, reversal.ID); err != nil {
+		log.Printf("failed to post chargeback %s to the ledger: %v", reversal.ID, err)
+	}
+	return s.disputeRepo.GetByID(ctx, dispute.ID)
+}
+
+func (s *disputeService) GetNotifications(ctx context.Context, unreadOnly bool) ([]models.DisputeNotification, error) {
+	return s.disputeRepo.GetNotifications(ctx, unreadOnly)
+}
+
+func (s *disputeService) MarkNotificationRead(ctx context.Context, id uuid.UUID) error {
+	err := s.disputeRepo.MarkNotificationRead(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("notification not found")
+	}
+	return err
+}
+
+// CheckDeadlines raises a notification for each OPEN dispute whose evidence
+// deadline is within the reminder window, and another once it has passed.
+// It returns how many notifications it raised.
+func (s *disputeService) CheckDeadlines(ctx context.Context) (int, error) {
+	now := time.Now()
+	raised := 0
+
+	passed, err := s.disputeRepo.DueForNotice(ctx, now, models.DisputeDeadlinePassed)
+	if err != nil {
+		return raised, err
+	}
+	for _, dispute := range passed {
+		message := fmt.Sprintf("Evidence deadline for dispute %s (%s, Rs. %.2f) passed on %s without a submission",
+			disputeLabel(&dispute), dispute.ReasonCode, dispute.Amount, dispute.EvidenceDueAt.Format("2006-01-02 15:04"))
+		if s.notify(ctx, &dispute, models.DisputeDeadlinePassed, message) {
+			raised++
+		}
+	}
+
+	approaching, err := s.disputeRepo.DueForNotice(ctx, now.Add(s.cfg.ReminderWindow), models.DisputeDeadlineApproaching)
+	if err != nil {
+		return raised, err
+	}
+	for _, dispute := range approaching {
+		if !dispute.EvidenceDueAt.After(now) {
+			continue
+		}
+		message := fmt.Sprintf("Evidence for dispute %s (%s, Rs. %.2f) is due in %s",
+			disputeLabel(&dispute), dispute.ReasonCode, dispute.Amount, dispute.EvidenceDueAt.Sub(now).Round(time.Hour))
+		if s.notify(ctx, &dispute, models.DisputeDeadlineApproaching, message) {
+			raised++
+		}
+	}
+	return raised, nil
+}
+
+// disputeLabel names a dispute by its case reference, or its ID if it has none
+func disputeLabel(dispute *models.Dispute) string {
+	if dispute.CaseReference != "" {
+		return dispute.CaseReference
+	}
+	return dispute.ID.String()
+}
+
+func (s *disputeService) notify(ctx context.Context, dispute *models.Dispute, kind, message string) bool {
+	created, err := s.disputeRepo.Notify(ctx, &models.DisputeNotification{
+		DisputeID: dispute.ID,
+		Kind:      kind,
+		Message:   message,
+	})
+	if err != nil {
+		log.Printf("failed to raise %s notification for dispute %s: %v", kind, dispute.ID, err)
+		return false
+	}
+	if created {
+		log.Printf("dispute %s: %s", dispute.ID, message)
+	}
+	return created
+}
+
+// Run checks evidence deadlines every CheckInterval until ctx is cancelled
+func (s *disputeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.CheckDeadlines(ctx); err != nil {
+			log.Printf("dispute deadlines: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
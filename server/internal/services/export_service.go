@@ -0,0 +1,571 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/bs"
+	"bookstore/pkg/filestore"
+	"bookstore/pkg/xlsx"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	// Lets ?tz= name any zone on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportNotFound = errors.New("export not found")
+	ErrExportNotReady = errors.New("export is not ready yet")
+	ErrExportExpired  = errors.New("export file has expired")
+)
+
+type ExportService interface {
+	// Validate checks the format, columns, timezone and calendar, filling in defaults
+	Validate(req *models.ExportRequest) error
+	// ShouldQueue reports whether the export has too many rows to stream in the request
+	ShouldQueue(ctx context.Context, req *models.ExportRequest) (bool, error)
+	// Write streams the export to w and returns the number of data rows
+	Write(ctx context.Context, req *models.ExportRequest, w io.Writer) (int, error)
+	Queue(ctx context.Context, req *models.ExportRequest, adminID uuid.UUID) (*models.ExportJob, error)
+	GetJob(ctx context.Context, id uuid.UUID) (*models.ExportJob, error)
+	GetJobs(ctx context.Context, kind string) ([]models.ExportJob, error)
+	OpenFile(ctx context.Context, id uuid.UUID) (*models.ExportJob, io.ReadCloser, error)
+	// RunDue runs queued jobs until none are left and deletes expired files
+	RunDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+}
+
+type exportService struct {
+	exportRepo      repositories.ExportRepository
+	transactionRepo repositories.TransactionRepository
+	orderRepo       repositories.OrderRepository
+	store           filestore.Store
+	cfg             config.ExportConfig
+}
+
+func NewExportService(exportRepo repositories.ExportRepository, transactionRepo repositories.TransactionRepository, orderRepo repositories.OrderRepository, store filestore.Store, cfg config.ExportConfig) ExportService {
+	return &exportService{
+		exportRepo:      exportRepo,
+		transactionRepo: transactionRepo,
+		orderRepo:       orderRepo,
+		store:           store,
+		cfg:             cfg,
+	}
+}
+
+// exportColumn is one column an export of rows of type R can have
+type exportColumn[R any] struct {
+	key    string
+	header string
+	value  func(row *R, f *exportFormatter) interface{}
+}
+
+// transactionExportColumns are the transaction export columns in their default order
+var transactionExportColumns = []exportColumn[models.TransactionExportRow]{
+	{"ref", "Reference", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return textOrEmpty(r.Ref) }},
+	{"id", "Transaction ID", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.ID.String() }},
+	{"created_at", "Created", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return f.time(r.CreatedAt) }},
+	{"paid_at", "Paid", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return f.timePtr(r.PaidAt) }},
+	{"order_ref", "Order", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return textOrEmpty(r.OrderRef) }},
+	{"order_id", "Order ID", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.OrderID.String() }},
+	{"customer", "Customer", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.UserName }},
+	{"email", "Email", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.UserEmail }},
+	{"payment_method", "Payment method", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.PaymentMethod }},
+	{"gateway_ref", "Gateway reference", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.TransactionID }},
+	{"status", "Status", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.Status }},
+	{"amount", "Amount", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.Amount }},
+	{"tax_amount", "VAT", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.TaxAmount }},
+	{"service_charge", "Service charge", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.ServiceCharge }},
+	{"delivery_charge", "Delivery charge", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.DeliveryCharge }},
+	{"gateway_fee", "Gateway fee", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.GatewayFee }},
+	{"net_amount", "Net amount", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.NetAmount }},
+	{"needs_review", "Needs review", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return yesNo(r.NeedsReview) }},
+	{"failure_reason", "Failure reason", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return r.FailureReason }},
+	{"updated_at", "Updated", func(r *models.TransactionExportRow, f *exportFormatter) interface{} { return f.time(r.UpdatedAt) }},
+}
+
+// orderExportColumns are the order export columns in their default order
+var orderExportColumns = []exportColumn[models.OrderExportRow]{
+	{"ref", "Reference", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return textOrEmpty(r.Ref) }},
+	{"id", "Order ID", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.ID.String() }},
+	{"created_at", "Created", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return f.time(r.CreatedAt) }},
+	{"customer", "Customer", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.UserName }},
+	{"email", "Email", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.UserEmail }},
+	{"status", "Status", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.Status }},
+	{"items", "Items", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.ItemCount }},
+	{"sub_total", "Sub total", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.SubTotal }},
+	{"discount_total", "Discount", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.DiscountTotal }},
+	{"taxable_amount", "Taxable amount", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.TaxableAmount }},
+	{"exempt_amount", "Exempt amount", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.ExemptAmount }},
+	{"tax_amount", "VAT", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.TaxAmount }},
+	{"service_charge", "Service charge", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.ServiceCharge }},
+	{"delivery_charge", "Delivery charge", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.DeliveryCharge }},
+	{"total_price", "Total", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.TotalPrice }},
+	{"payment_ref", "Payment", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return textOrEmpty(r.PaymentRef) }},
+	{"payment_method", "Payment method", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return textOrEmpty(r.PaymentMethod) }},
+	{"payment_status", "Payment status", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return textOrEmpty(r.PaymentStatus) }},
+	{"delivery_district", "Delivery district", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.DeliveryDistrict }},
+	{"buyer_pan", "Buyer PAN", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return r.BuyerPAN }},
+	{"updated_at", "Updated", func(r *models.OrderExportRow, f *exportFormatter) interface{} { return f.time(r.UpdatedAt) }},
+}
+
+// selectColumns picks the requested columns in the requested order, or all of them
+func selectColumns[R any](all []exportColumn[R], keys []string) ([]exportColumn[R], error) {
+	if len(keys) == 0 {
+		return all, nil
+	}
+	byKey := make(map[string]exportColumn[R], len(all))
+	for _, column := range all {
+		byKey[column.key] = column
+	}
+	selected := make([]exportColumn[R], 0, len(keys))
+	seen := map[string]bool{}
+	for _, key := range keys {
+		column, ok := byKey[key]
+		if !ok {
+			known := make([]string, len(all))
+			for i, column := range all {
+				known[i] = column.key
+			}
+			return nil, fmt.Errorf("unknown column %q; columns are %s", key, strings.Join(known, ", "))
+		}
+		if !seen[key] {
+			seen[key] = true
+			selected = append(selected, column)
+		}
+	}
+	return selected, nil
+}
+
+// exportFormatter writes dates in the export's timezone and calendar
+type exportFormatter struct {
+	loc      *time.Location
+	calendar string
+}
+
+// time formats t as "2006-01-02 15:04:05" in the export's timezone. BS dates
+// are the BS date of the local day; outside the supported range they fall back to AD.
+func (f *exportFormatter) time(t time.Time) string {
+	local := t.In(f.loc)
+	if f.calendar == models.CalendarBS {
+		day := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, bs.Location())
+		if d, err := bs.FromAD(day); err == nil {
+			return d.String() + local.Format(" 15:04:05")
+		}
+	}
+	return local.Format("2006-01-02 15:04:05")
+}
+
+func (f *exportFormatter) timePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return f.time(*t)
+}
+
+func textOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// exportLocation returns the zone an export's dates are written in; Nepal time by default
+func exportLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return bs.Location(), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+func (s *exportService) Validate(req *models.ExportRequest) error {
+	if req.Format == "" {
+		req.Format = models.ExportFormatCSV
+	}
+	if req.Format != models.ExportFormatCSV && req.Format != models.ExportFormatXLSX {
+		return errors.New("format must be csv or xlsx")
+	}
+	if req.Calendar == "" {
+		req.Calendar = models.CalendarAD
+	}
+	if req.Calendar != models.CalendarAD && req.Calendar != models.CalendarBS {
+		return errors.New("calendar must be ad or bs")
+	}
+	if _, err := exportLocation(req.Timezone); err != nil {
+		return err
+	}
+
+	var err error
+	switch req.Kind {
+	case models.ExportKindTransactions:
+		if req.Transactions == nil {
+			req.Transactions = &models.TransactionFilter{}
+		}
+		if req.Sort == "" {
+			req.Sort = models.TransactionSortCreatedAtDesc
+		}
+		_, err = selectColumns(transactionExportColumns, req.Columns)
+	case models.ExportKindOrders:
+		if req.Orders == nil {
+			req.Orders = &models.OrderFilter{}
+		}
+		_, err = selectColumns(orderExportColumns, req.Columns)
+	default:
+		err = errors.New("kind must be transactions or orders")
+	}
+	return err
+}
+
+func (s *exportService) count(ctx context.Context, req *models.ExportRequest) (int64, error) {
+	if req.Kind == models.ExportKindOrders {
+		return s.orderRepo.Count(ctx, req.Orders)
+	}
+	totals, err := s.transactionRepo.Totals(ctx, req.Transactions)
+	if err != nil {
+		return 0, err
+	}
+	return totals.Count, nil
+}
+
+func (s *exportService) ShouldQueue(ctx context.Context, req *models.ExportRequest) (bool, error) {
+	count, err := s.count(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	return count > int64(s.cfg.SyncMaxRows), nil
+}
+
+func (s *exportService) Write(ctx context.Context, req *models.ExportRequest, w io.Writer) (int, error) {
+	loc, err := exportLocation(req.Timezone)
+	if err != nil {
+		return 0, err
+	}
+	f := &exportFormatter{loc: loc, calendar: req.Calendar}
+
+	out, err := newExportWriter(req, w)
+	if err != nil {
+		return 0, err
+	}
+	var rows int
+	switch req.Kind {
+	case models.ExportKindTransactions:
+		rows, err = writeExportRows(out, transactionExportColumns, req.Columns, f, func(fn func(*models.TransactionExportRow) error) error {
+			return s.transactionRepo.ExportRows(ctx, req.Transactions, req.Sort, fn)
+		})
+	case models.ExportKindOrders:
+		rows, err = writeExportRows(out, orderExportColumns, req.Columns, f, func(fn func(*models.OrderExportRow) error) error {
+			return s.orderRepo.ExportRows(ctx, req.Orders, fn)
+		})
+	default:
+		err = errors.New("kind must be transactions or orders")
+	}
+	if err != nil {
+		return rows, err
+	}
+	return rows, out.Close()
+}
+
+// writeExportRows writes the header and then each row fetch hands over
+func writeExportRows[R any](out exportWriter, all []exportColumn[R], keys []string, f *exportFormatter, fetch func(func(*R) error) error) (int, error) {
+	columns, err := selectColumns(all, keys)
+	if err != nil {
+		return 0, err
+	}
+	cells := make([]interface{}, len(columns))
+	for i, column := range columns {
+		cells[i] = column.header
+	}
+	if err := out.WriteRow(cells); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	err = fetch(func(row *R) error {
+		for i, column := range columns {
+			cells[i] = column.value(row, f)
+		}
+		rows++
+		return out.WriteRow(cells)
+	})
+	return rows, err
+}
+
+// exportWriter writes rows in one of the export formats
+type exportWriter interface {
+	WriteRow(cells []interface{}) error
+	Close() error
+}
+
+func newExportWriter(req *models.ExportRequest, w io.Writer) (exportWriter, error) {
+	if req.Format == models.ExportFormatXLSX {
+		return xlsx.NewWriter(w, req.Kind)
+	}
+	// The byte order mark makes Excel read the file as UTF-8, so Nepali names survive
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) WriteRow(cells []interface{}) error {
+	c.record = c.record[:0]
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case nil:
+			c.record = append(c.record, "")
+		case int:
+			c.record = append(c.record, strconv.Itoa(v))
+		case float64:
+			c.record = append(c.record, strconv.FormatFloat(v, 'f', 2, 64))
+		default:
+			c.record = append(c.record, csvText(fmt.Sprint(v)))
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// csvText stops spreadsheet programs from running text that looks like a
+// formula, e.g. a customer named "=HYPERLINK(...)"
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// ExportContentType is the media type of an export file
+func ExportContentType(format string) string {
+	if format == models.ExportFormatXLSX {
+		return xlsx.ContentType
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportFileName names an export made at t, e.g. transactions-20261018-1430.csv
+func ExportFileName(req *models.ExportRequest, t time.Time) string {
+	if loc, err := exportLocation(req.Timezone); err == nil {
+		t = t.In(loc)
+	}
+	return fmt.Sprintf("%s-%s.%s", req.Kind, t.Format("20060102-1504"), req.Format)
+}
+
+func (s *exportService) Queue(ctx context.Context, req *models.ExportRequest, adminID uuid.UUID) (*models.ExportJob, error) {
+	params, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	job := &models.ExportJob{
+		Kind:        req.Kind,
+		Format:      req.Format,
+		Params:      datatypes.JSON(params),
+		Status:      models.ExportStatusPending,
+		RequestedBy: adminID,
+	}
+	if err := s.exportRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// withDownloadURL points finished jobs at their download endpoint
+func withDownloadURL(job *models.ExportJob) {
+	if job.Status == models.ExportStatusDone {
+		job.DownloadURL = "/api/exports/" + job.ID.String() + "/download"
+	}
+}
+
+func (s *exportService) GetJob(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	job, err := s.exportRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	withDownloadURL(job)
+	return job, nil
+}
+
+func (s *exportService) GetJobs(ctx context.Context, kind string) ([]models.ExportJob, error) {
+	jobs, err := s.exportRepo.GetAll(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		withDownloadURL(&jobs[i])
+	}
+	return jobs, nil
+}
+
+func (s *exportService) OpenFile(ctx context.Context, id uuid.UUID) (*models.ExportJob, io.ReadCloser, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch job.Status {
+	case models.ExportStatusDone:
+	case models.ExportStatusExpired:
+		return nil, nil, ErrExportExpired
+	default:
+		return nil, nil, ErrExportNotReady
+	}
+	file, err := s.store.Open(ctx, job.FileKey)
+	if errors.Is(err, filestore.ErrNotFound) {
+		return nil, nil, ErrExportExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, file, nil
+}
+
+// Run works through queued exports every poll interval until ctx is cancelled
+func (s *exportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunDue(ctx); err != nil {
+			log.Printf("export jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *exportService) RunDue(ctx context.Context) (int, error) {
+	if err := s.deleteExpired(ctx); err != nil {
+		log.Printf("export cleanup: %v", err)
+	}
+
+	done := 0
+	for {
+		job, err := s.exportRepo.ClaimNext(ctx, s.cfg.JobTimeout)
+		if err != nil {
+			return done, err
+		}
+		if job == nil {
+			return done, nil
+		}
+		if err := s.runJob(ctx, job); err != nil {
+			log.Printf("export job %s: %v", job.ID, err)
+			if err := s.exportRepo.MarkFailed(ctx, job.ID, err.Error()); err != nil {
+				return done, err
+			}
+			continue
+		}
+		done++
+	}
+}
+
+// runJob streams the export straight into the file store through a pipe, so
+// the file is never held in memory either
+func (s *exportService) runJob(ctx context.Context, job *models.ExportJob) error {
+	var req models.ExportRequest
+	if err := json.Unmarshal(job.Params, &req); err != nil {
+		return fmt.Errorf("read export params: %v", err)
+	}
+	if err := s.Validate(&req); err != nil {
+		return err
+	}
+
+	type result struct {
+		rows int
+		err  error
+	}
+	pr, pw := io.Pipe()
+	counter := &countingWriter{w: pw}
+	written := make(chan result, 1)
+	go func() {
+		rows, err := s.Write(ctx, &req, counter)
+		pw.CloseWithError(err)
+		written <- result{rows, err}
+	}()
+
+	key := fmt.Sprintf("exports/%s.%s", job.ID, req.Format)
+	putErr := s.store.Put(ctx, key, pr)
+	pr.CloseWithError(putErr) // unblocks the writer if the store gave up early
+	res := <-written
+	if res.err != nil {
+		return res.err
+	}
+	if putErr != nil {
+		return putErr
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Retention)
+	job.RowCount = res.rows
+	job.FileKey = key
+	job.FileName = ExportFileName(&req, job.CreatedAt)
+	job.FileSize = counter.n
+	job.CompletedAt = &now
+	job.ExpiresAt = &expiresAt
+	return s.exportRepo.MarkDone(ctx, job)
+}
+
+// deleteExpired removes export files past their retention period
+func (s *exportService) deleteExpired(ctx context.Context) error {
+	jobs, err := s.exportRepo.GetExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := s.store.Delete(ctx, job.FileKey); err != nil {
+			return err
+		}
+		if err := s.exportRepo.MarkExpired(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
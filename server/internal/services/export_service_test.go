@@ -0,0 +1,54 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bytes"
+	"testing"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Ram Sharma", "Ram Sharma"},
+		{"", ""},
+		{"=HYPERLINK(\"http://evil.test\")", "'=HYPERLINK(\"http://evil.test\")"},
+		{"+977-9800000000", "'+977-9800000000"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"a=b", "a=b"},
+		{"राम", "राम"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVExportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newExportWriter(&models.ExportRequest{Format: models.ExportFormatCSV}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]interface{}{"ref", "amount", "count", "name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]interface{}{"TX-1", 1556.5, 3, "=cmd|' /C calc'!A0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]interface{}{nil, 0.0, 0, "Sita, \"Kathmandu\""}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\ufeffref,amount,count,name\n" +
+		"TX-1,1556.50,3,'=cmd|' /C calc'!A0\n" +
+		",0.00,0,\"Sita, \"\"Kathmandu\"\"\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type FeeService interface {
+	CreateSchedule(ctx context.Context, req *models.FeeScheduleRequest) (*models.FeeSchedule, error)
+	GetAllSchedules(ctx context.Context, paymentMethod string) ([]models.FeeSchedule, error)
+	GetScheduleByID(ctx context.Context, id uuid.UUID) (*models.FeeSchedule, error)
+	UpdateSchedule(ctx context.Context, id uuid.UUID, req *models.FeeScheduleRequest) (*models.FeeSchedule, error)
+	DeleteSchedule(ctx context.Context, id uuid.UUID) error
+	Quote(ctx context.Context, paymentMethod string, amount float64, at time.Time) (*models.FeeQuote, error)
+	ApplyComputedFee(ctx context.Context, transaction *models.Transaction) error
+	RecordSettledFee(ctx context.Context, req *models.GatewayFeeRequest) (*models.JournalEntry, error)
+}
+
+type feeService struct {
+	feeScheduleRepo repositories.FeeScheduleRepository
+	transactionRepo repositories.TransactionRepository
+	ledger          LedgerService
+}
+
+func NewFeeService(feeScheduleRepo repositories.FeeScheduleRepository, transactionRepo repositories.TransactionRepository, ledger LedgerService) FeeService {
+	return &feeService{
+		feeScheduleRepo: feeScheduleRepo,
+		transactionRepo: transactionRepo,
+		ledger:          ledger,
+	}
+}
+
+// feeScheduleFromRequest checks that the schedule has the fields its type needs
+func feeScheduleFromRequest(req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	switch req.Type {
+	case models.FeeTypePercentage:
+		if req.Percentage <= 0 {
+			return nil, errors.New("percentage must be greater than zero")
+		}
+	case models.FeeTypeFixed:
+		if req.FixedAmount <= 0 {
+			return nil, errors.New("fixed_amount must be greater than zero")
+		}
+	case models.FeeTypeSlab:
+		if len(req.Slabs) == 0 {
+			return nil, errors.New("slabs are required for a slab schedule")
+		}
+		for i, slab := range req.Slabs {
+			if slab.Percentage < 0 || slab.Percentage > 100 || slab.Fixed < 0 {
+				return nil, fmt.Errorf("slab %d has a negative or out of range charge", i+1)
+			}
+			last := i == len(req.Slabs)-1
+			if slab.UpTo <= 0 && !last {
+				return nil, errors.New("only the last slab may leave up_to open")
+			}
+			if i > 0 && slab.UpTo > 0 && slab.UpTo <= req.Slabs[i-1].UpTo {
+				return nil, errors.New("slabs must be in ascending up_to order")
+			}
+		}
+	}
+	if req.MaxFee > 0 && req.MaxFee < req.MinFee {
+		return nil, errors.New("max_fee must not be below min_fee")
+	}
+
+	effectiveFrom := time.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+	schedule := &models.FeeSchedule{
+		PaymentMethod: req.PaymentMethod,
+		Name:          req.Name,
+		Type:          req.Type,
+		Percentage:    req.Percentage,
+		FixedAmount:   req.FixedAmount,
+		MinFee:        req.MinFee,
+		MaxFee:        req.MaxFee,
+		EffectiveFrom: effectiveFrom,
+		Active:        req.Active == nil || *req.Active,
+	}
+	if req.Type == models.FeeTypeSlab {
+		schedule.Slabs = datatypes.NewJSONSlice(req.Slabs)
+	}
+	return schedule, nil
+}
+
+// computeFee applies a schedule to an amount. The min and max bounds apply
+// to every type; the fee never exceeds the amount itself. An amount above the
+// last slab's up_to is charged at the last slab.
+func computeFee(schedule *models.FeeSchedule, amount float64) float64 {
+	var fee float64
+	switch schedule.Type {
+	case models.FeeTypePercentage:
+		fee = amount*schedule.Percentage/100 + schedule.FixedAmount
+	case models.FeeTypeFixed:
+		fee = schedule.FixedAmount
+	case models.FeeTypeSlab:
+		for i, slab := range schedule.Slabs {
+			if slab.UpTo <= 0 || amount <= slab.UpTo || i == len(schedule.Slabs)-1 {
+				fee = amount*slab.Percentage/100 + slab.Fixed
+				break
+			}
+		}
+	}
+
+	fee = math.Max(fee, schedule.MinFee)
+	if schedule.MaxFee > 0 {
+		fee = math.Min(fee, schedule.MaxFee)
+	}
+	return utils.RoundMoney(math.Min(fee, amount))
+}
+
+func (s *feeService) CreateSchedule(ctx context.Context, req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	schedule, err := feeScheduleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.feeScheduleRepo.Create(ctx, schedule)
+}
+
+func (s *feeService) GetAllSchedules(ctx context.Context, paymentMethod string) ([]models.FeeSchedule, error) {
+	return s.feeScheduleRepo.GetAll(ctx, paymentMethod)
+}
+
+func (s *feeService) GetScheduleByID(ctx context.Context, id uuid.UUID) (*models.FeeSchedule, error) {
+	return s.feeScheduleRepo.GetByID(ctx, id)
+}
+
+func (s *feeService) UpdateSchedule(ctx context.Context, id uuid.UUID, req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	schedule, err := feeScheduleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.feeScheduleRepo.Update(ctx, id, schedule)
+}
+
+func (s *feeService) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	return s.feeScheduleRepo.Delete(ctx, id)
+}
+
+// Quote works out the fee on amount under the schedule in force at the given time
+func (s *feeService) Quote(ctx context.Context, paymentMethod string, amount float64, at time.Time) (*models.FeeQuote, error) {
+	quote := &models.FeeQuote{PaymentMethod: paymentMethod, Amount: amount, NetAmount: amount}
+
+	schedule, err := s.feeScheduleRepo.GetEffective(ctx, paymentMethod, at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return quote, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Schedule = schedule
+	quote.Fee = computeFee(schedule, amount)
+	quote.NetAmount = utils.RoundMoney(amount - quote.Fee)
+	return quote, nil
+}
+
+// ApplyComputedFee stores the scheduled fee on a successful transaction. The
+// fee is charged on the part collected through its gateway, not on store
+// credit or gift cards.
+func (s *feeService) ApplyComputedFee(ctx context.Context, transaction *models.Transaction) error {
+	if _, err := tenderAccount(transaction.PaymentMethod); err != nil ||
+		transaction.PaymentMethod == models.PaymentMethodStoreCredit ||
+		transaction.PaymentMethod == models.PaymentMethodGiftCard {
+		return nil
+	}
+
+	collected := transaction.Amount
+	if len(transaction.Tenders) > 0 {
+		collected = transaction.TenderAmount(transaction.PaymentMethod)
+	}
+	paidAt := time.Now()
+	if transaction.PaidAt != nil {
+		paidAt = *transaction.PaidAt
+	}
+
+	quote, err := s.Quote(ctx, transaction.PaymentMethod, collected, paidAt)
+	if err != nil || quote.Schedule == nil {
+		return err
+	}
+	return s.transactionRepo.SetFee(ctx, transaction.ID, quote.Fee, models.FeeSourceComputed)
+}
+
+// RecordSettledFee stores the fee a gateway actually deducted and posts it to the ledger
+func (s *feeService) RecordSettledFee(ctx context.Context, req *models.GatewayFeeRequest) (*models.JournalEntry, error) {
+	entry, err := s.ledger.PostGatewayFee(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// A fee posted earlier stands; keep the transaction in line with the ledger
+	fee := utils.RoundMoney(req.Amount)
+	for _, line := range entry.Lines {
+		if line.Account.Code == models.AccountGatewayFees {
+			fee = line.Debit
+		}
+	}
+	if err := s.transactionRepo.SetFee(ctx, req.TransactionID, fee, models.FeeSourceSettled); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"testing"
+
+	"gorm.io/datatypes"
+)
+
+func TestComputeFee(t *testing.T) {
+	slabs := datatypes.NewJSONSlice([]models.FeeSlab{
+		{UpTo: 1000, Fixed: 10},
+		{UpTo: 10000, Percentage: 1.5},
+		{UpTo: 50000, Percentage: 1, Fixed: 50},
+	})
+
+	tests := []struct {
+		name     string
+		schedule models.FeeSchedule
+		amount   float64
+		want     float64
+	}{
+		{"percentage", models.FeeSchedule{Type: models.FeeTypePercentage, Percentage: 2.5}, 1556.75, 38.92},
+		{"percentage plus fixed", models.FeeSchedule{Type: models.FeeTypePercentage, Percentage: 1.5, FixedAmount: 5}, 2000, 35},
+		{"fixed", models.FeeSchedule{Type: models.FeeTypeFixed, FixedAmount: 25}, 2000, 25},
+		{"fixed above the amount", models.FeeSchedule{Type: models.FeeTypeFixed, FixedAmount: 25}, 20, 20},
+		{"first slab", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: slabs}, 500, 10},
+		{"on a slab's upper edge", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: slabs}, 1000, 10},
+		{"just past a slab's edge", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: slabs}, 1000.01, 15},
+		{"last slab", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: slabs}, 50000, 550},
+		{"above the last slab", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: slabs}, 80000, 850},
+		{"open last slab", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: datatypes.NewJSONSlice([]models.FeeSlab{
+			{UpTo: 1000, Fixed: 10}, {Percentage: 2},
+		})}, 80000, 1600},
+		{"min fee", models.FeeSchedule{Type: models.FeeTypePercentage, Percentage: 1, MinFee: 10}, 300, 10},
+		{"max fee", models.FeeSchedule{Type: models.FeeTypePercentage, Percentage: 1, MaxFee: 500}, 80000, 500},
+		{"max fee on a slab", models.FeeSchedule{Type: models.FeeTypeSlab, Slabs: slabs, MaxFee: 600}, 80000, 600},
+		{"min fee above the amount", models.FeeSchedule{Type: models.FeeTypePercentage, Percentage: 1, MinFee: 10}, 8, 8},
+	}
+	for _, tt := range tests {
+		if got := computeFee(&tt.schedule, tt.amount); got != tt.want {
+			t.Errorf("%s: computeFee(%v) = %v, want %v", tt.name, tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/limiter"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrGiftCardLookupLocked is returned while a caller is locked out after too many wrong codes
+var ErrGiftCardLookupLocked = errors.New("too many invalid gift card codes, try again later")
+
+type GiftCardService interface {
+	IssueGiftCard(ctx context.Context, req *models.IssueGiftCardRequest, adminID uuid.UUID) (*models.GiftCard, error)
+	IssueForOrder(ctx context.Context, order *models.Order) ([]models.GiftCard, error)
+	GetAllGiftCards(ctx context.Context) ([]models.GiftCard, error)
+	GetGiftCardByID(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	GetUserGiftCards(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error)
+	GetGiftCardTransactions(ctx context.Context, id uuid.UUID) ([]models.GiftCardTransaction, error)
+	VoidGiftCard(ctx context.Context, id uuid.UUID, reason string, adminID uuid.UUID) (*models.GiftCard, error)
+	CheckBalance(ctx context.Context, code string, callerKeys ...string) (*models.GiftCardBalance, error)
+	Resolve(ctx context.Context, code string, callerKeys ...string) (*models.GiftCard, error)
+	ReverseTransactionRedemptions(ctx context.Context, transactionID uuid.UUID) error
+}
+
+type giftCardService struct {
+	giftCardRepo repositories.GiftCardRepository
+	ledger       LedgerService
+	cfg          config.GiftCardConfig
+	lookups      *limiter.FailureLimiter
+}
+
+func NewGiftCardService(giftCardRepo repositories.GiftCardRepository, ledger LedgerService, cfg config.GiftCardConfig) GiftCardService {
+	return &giftCardService{
+		giftCardRepo: giftCardRepo,
+		ledger:       ledger,
+		cfg:          cfg,
+		lookups:      limiter.NewFailureLimiter(cfg.MaxLookupFailures, cfg.LookupWindow, cfg.LookupLockout),
+	}
+}
+
+// Gift card codes use 16 characters from an alphabet without look-alikes
+// (80 bits of randomness), printed in groups of four.
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func generateGiftCardCode() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = giftCardAlphabet[int(b)%len(giftCardAlphabet)]
+	}
+	return formatGiftCardCode(string(buf)), nil
+}
+
+// normalizeGiftCardCode accepts codes typed in any case, with or without separators
+func normalizeGiftCardCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return formatGiftCardCode(code)
+}
+
+func formatGiftCardCode(code string) string {
+	var groups []string
+	for len(code) > 4 {
+		groups = append(groups, code[:4])
+		code = code[4:]
+	}
+	return strings.Join(append(groups, code), "-")
+}
+
+func (s *giftCardService) newCard(amount float64, expiresAt *time.Time) (models.GiftCard, error) {
+	code, err := generateGiftCardCode()
+	if err != nil {
+		return models.GiftCard{}, fmt.Errorf("failed to generate gift card code: %v", err)
+	}
+	return models.GiftCard{
+		Code:           code,
+		Last4:          code[len(code)-4:],
+		InitialBalance: amount,
+		Balance:        amount,
+		Status:         models.GiftCardStatusActive,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+// IssueGiftCard creates a gift card by hand, e.g. for a promotion or a replacement
+func (s *giftCardService) IssueGiftCard(ctx context.Context, req *models.IssueGiftCardRequest, adminID uuid.UUID) (*models.GiftCard, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	card, err := s.newCard(req.Amount, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	card.PurchaserID = req.PurchaserID
+	card.IssuedBy = &adminID
+	card.Note = req.Note
+
+	cards, err := s.giftCardRepo.Create(ctx, []models.GiftCard{card}, &adminID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ledger.PostGiftCardIssue(ctx, &cards[0]); err != nil {
+		log.Printf("failed to post gift card %s to the ledger: %v", cards[0].ID, err)
+	}
+	return &cards[0], nil
+}
+
+// IssueForOrder generates one card per gift card unit on a paid order.
+// It is idempotent: an order that already has cards gets no more.
+func (s *giftCardService) IssueForOrder(ctx context.Context, order *models.Order) ([]models.GiftCard, error) {
+	existing, err := s.giftCardRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return existing, nil
+	}
+
+	var expiresAt *time.Time
+	if s.cfg.Validity > 0 {
+		t := time.Now().Add(s.cfg.Validity)
+		expiresAt = &t
+	}
+
+	var cards []models.GiftCard
+	for _, item := range order.Items {
+		if item.Book.ProductType != models.ProductTypeGiftCard {
+			continue
+		}
+		for n := 0; n < item.Quantity; n++ {
+			card, err := s.newCard(item.Price, expiresAt)
+			if err != nil {
+				return nil, err
+			}
+			orderID, itemID, purchaserID := order.ID, item.ID, order.UserID
+			card.OrderID = &orderID
+			card.OrderItemID = &itemID
+			card.PurchaserID = &purchaserID
+			card.Note = item.Book.Title
+			cards = append(cards, card)
+		}
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	return s.giftCardRepo.Create(ctx, cards, nil)
+}
+
+func (s *giftCardService) GetAllGiftCards(ctx context.Context) ([]models.GiftCard, error) {
+	return s.giftCardRepo.GetAll(ctx)
+}
+
+func (s *giftCardService) GetGiftCardByID(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	return s.giftCardRepo.GetByID(ctx, id)
+}
+
+func (s *giftCardService) GetUserGiftCards(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error) {
+	return s.giftCardRepo.GetByPurchaserID(ctx, userID)
+}
+
+func (s *giftCardService) GetGiftCardTransactions(ctx context.Context, id uuid.UUID) ([]models.GiftCardTransaction, error) {
+	return s.giftCardRepo.GetTransactions(ctx, id)
+}
+
+func (s *giftCardService) VoidGiftCard(ctx context.Context, id uuid.UUID, reason string, adminID uuid.UUID) (*models.GiftCard, error) {
+	card, err := s.giftCardRepo.Void(ctx, id, reason, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The VOID entry records how much balance was written off
+	txns, err := s.giftCardRepo.GetTransactions(ctx, card.ID)
+	if err != nil {
+		log.Printf("failed to load gift card %s transactions: %v", card.ID, err)
+		return card, nil
+	}
+	for _, txn := range txns {
+		if txn.Type != models.GiftCardTxnVoid {
+			continue
+		}
+		if _, err := s.ledger.PostGiftCardVoid(ctx, card, -txn.Amount); err != nil {
+			log.Printf("failed to post voided gift card %s to the ledger: %v", card.ID, err)
+		}
+	}
+	return card, nil
+}
+
+// CheckBalance looks up a card by code for the balance-check endpoint
+func (s *giftCardService) CheckBalance(ctx context.Context, code string, callerKeys ...string) (*models.GiftCardBalance, error) {
+	card, err := s.Resolve(ctx, code, callerKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GiftCardBalance{
+		Last4:     card.Last4,
+		Balance:   card.Balance,
+		Status:    card.Status,
+		ExpiresAt: card.ExpiresAt,
+	}, nil
+}
+
+// Resolve finds a card by code. Wrong codes count against every caller key
+// (e.g. user and IP); a key with too many recent failures is locked out.
+func (s *giftCardService) Resolve(ctx context.Context, code string, callerKeys ...string) (*models.GiftCard, error) {
+	for _, key := range callerKeys {
+		if ok, _ := s.lookups.Allowed(key); !ok {
+			return nil, ErrGiftCardLookupLocked
+		}
+	}
+
+	card, err := s.giftCardRepo.GetByCode(ctx, normalizeGiftCardCode(code))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		for _, key := range callerKeys {
+			s.lookups.Fail(key)
+		}
+		return nil, errors.New("invalid gift card code")
+	}
+	return card, nil
+}
+
+func (s *giftCardService) ReverseTransactionRedemptions(ctx context.Context, transactionID uuid.UUID) error {
+	return s.giftCardRepo.ReverseTransactionRedemptions(ctx, transactionID)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/pkg/bs"
+	"bookstore/pkg/pdf"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+var invoiceHTML = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"inc":   func(i int) int { return i + 1 },
+	"money": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"date":  invoiceDate,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Tax Invoice {{.InvoiceNumber}}</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: 2em auto; color: #222; }
+table { width: 100%; border-collapse: collapse; margin-top: 1em; }
+th, td { border: 1px solid #ccc; padding: 6px; text-align: left; }
+td.num, th.num { text-align: right; }
+.cancelled { color: #b00; font-weight: bold; }
+</style>
+</head>
+<body>
+<h2>TAX INVOICE</h2>
+{{if eq .Status "CANCELLED"}}<p class="cancelled">CANCELLED{{with .CreditNote}} by credit note {{.CreditNoteNumber}}: {{.Reason}}{{end}}</p>{{end}}
+<p><strong>{{.SellerName}}</strong><br>{{.SellerAddress}}<br>PAN/VAT No: {{.SellerPAN}}</p>
+<p>Bill No: <strong>{{.InvoiceNumber}}</strong><br>Fiscal Year: {{.FiscalYear}}<br>Date: {{date .}}<br>Payment: {{.PaymentMethod}}</p>
+<p>Buyer: {{.BuyerName}}<br>{{.BuyerEmail}}{{if .BuyerPAN}}<br>Buyer PAN: {{.BuyerPAN}}{{end}}</p>
+<table>
+<tr><th>#</th><th>Description</th><th class="num">Qty</th><th class="num">Rate</th><th class="num">Discount</th><th class="num">Amount</th></tr>
+{{range $i, $item := .Items}}<tr><td>{{inc $i}}</td><td>{{$item.Description}}{{if $item.TaxExempt}} (VAT exempt){{end}}</td><td class="num">{{$item.Quantity}}</td><td class="num">{{money $item.UnitPrice}}</td><td class="num">{{money $item.DiscountAmount}}</td><td class="num">{{money $item.Amount}}</td></tr>
+{{end}}</table>
+<table>
+<tr><td>Sub Total</td><td class="num">{{money .SubTotal}}</td></tr>
+{{if .DiscountTotal}}<tr><td>Discount</td><td class="num">-{{money .DiscountTotal}}</td></tr>
+{{end}}<tr><td>Taxable Amount</td><td class="num">{{money .TaxableAmount}}</td></tr>
+<tr><td>Exempt Amount</td><td class="num">{{money .ExemptAmount}}</td></tr>
+<tr><td>VAT</td><td class="num">{{money .TaxAmount}}</td></tr>
+<tr><td>Service Charge</td><td class="num">{{money .ServiceCharge}}</td></tr>
+<tr><td>Delivery Charge</td><td class="num">{{money .DeliveryCharge}}</td></tr>
+<tr><th>Grand Total</th><th class="num">{{money .TotalAmount}}</th></tr>
+</table>
+</body>
+</html>
+`))
+
+// invoiceDate formats the issue date in BS followed by the AD date and time
+func invoiceDate(invoice *models.Invoice) string {
+	issued := invoice.IssuedAt.In(bs.Location())
+	d, err := bs.FromAD(issued)
+	if err != nil {
+		return issued.Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("%s BS (%s)", d, issued.Format("2006-01-02 15:04"))
+}
+
+// WriteInvoiceHTML renders the invoice as a printable HTML page
+func WriteInvoiceHTML(w io.Writer, invoice *models.Invoice) error {
+	return invoiceHTML.Execute(w, invoice)
+}
+
+// WriteInvoicePDF renders the invoice as a single-column PDF document
+func WriteInvoicePDF(w io.Writer, invoice *models.Invoice) error {
+	doc := pdf.New()
+	doc.AddPage()
+
+	const left = 50.0
+	y := 60.0
+	line := func(size float64, bold bool, format string, args ...interface{}) {
+		if y > pdf.PageHeight-50 {
+			doc.AddPage()
+			y = 60
+		}
+		doc.Text(left, y, size, bold, fmt.Sprintf(format, args...))
+		y += size + 5
+	}
+
+	line(16, true, "TAX INVOICE")
+	if invoice.Status == models.InvoiceStatusCancelled {
+		if invoice.CreditNote != nil {
+			line(10, true, "CANCELLED by credit note %s: %s", invoice.CreditNote.CreditNoteNumber, invoice.CreditNote.Reason)
+		} else {
+			line(10, true, "CANCELLED")
+		}
+	}
+	y += 6
+	line(11, true, "%s", invoice.SellerName)
+	line(9, false, "%s", invoice.SellerAddress)
+	line(9, false, "PAN/VAT No: %s", invoice.SellerPAN)
+	y += 6
+	line(9, false, "Bill No:     %s", invoice.InvoiceNumber)
+	line(9, false, "Fiscal Year: %s", invoice.FiscalYear)
+	line(9, false, "Date:        %s", invoiceDate(invoice))
+	line(9, false, "Payment:     %s", invoice.PaymentMethod)
+	y += 6
+	line(9, false, "Buyer:       %s <%s>", invoice.BuyerName, invoice.BuyerEmail)
+	if invoice.BuyerPAN != "" {
+		line(9, false, "Buyer PAN:   %s", invoice.BuyerPAN)
+	}
+	y += 10
+
+	line(9, true, "%-3s %-33s %5s %10s %10s %12s", "#", "Description", "Qty", "Rate", "Discount", "Amount")
+	for i, item := range invoice.Items {
+		desc := item.Description
+		if item.TaxExempt {
+			desc += " *"
+		}
+		if len(desc) > 33 {
+			desc = desc[:30] + "..."
+		}
+		line(9, false, "%-3d %-33s %5d %10.2f %10.2f %12.2f", i+1, desc, item.Quantity, item.UnitPrice, item.DiscountAmount, item.Amount)
+	}
+	line(8, false, "* VAT exempt")
+	y += 10
+
+	totals := []struct {
+		label  string
+		amount float64
+	}{
+		{"Sub Total", invoice.SubTotal},
+		{"Discount", -invoice.DiscountTotal},
+		{"Taxable Amount", invoice.TaxableAmount},
+		{"Exempt Amount", invoice.ExemptAmount},
+		{"VAT", invoice.TaxAmount},
+		{"Service Charge", invoice.ServiceCharge},
+		{"Delivery Charge", invoice.DeliveryCharge},
+	}
+	for _, t := range totals {
+		line(9, false, "%54s %23.2f", t.label, t.amount)
+	}
+	line(10, true, "%49s %23.2f", "Grand Total", invoice.TotalAmount)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/bs"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InvoiceService interface {
+	IssueForTransaction(ctx context.Context, transaction *models.Transaction) (*models.Invoice, error)
+	GetAllInvoices(ctx context.Context) ([]models.Invoice, error)
+	GetInvoiceByID(ctx context.Context, id uuid.UUID) (*models.Invoice, error)
+	GetInvoiceForOrder(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error)
+	CancelInvoice(ctx context.Context, id uuid.UUID, reason string, userID uuid.UUID) (*models.Invoice, error)
+}
+
+type invoiceService struct {
+	invoiceRepo     repositories.InvoiceRepository
+	transactionRepo repositories.TransactionRepository
+	seller          config.SellerConfig
+}
+
+func NewInvoiceService(invoiceRepo repositories.InvoiceRepository, transactionRepo repositories.TransactionRepository, seller config.SellerConfig) InvoiceService {
+	return &invoiceService{
+		invoiceRepo:     invoiceRepo,
+		transactionRepo: transactionRepo,
+		seller:          seller,
+	}
+}
+
+// IssueForTransaction issues the tax invoice for a successful transaction.
+// It is idempotent: the repository's unique index turns a second issue for the
+// transaction into the invoice already issued.
+func (s *invoiceService) IssueForTransaction(ctx context.Context, transaction *models.Transaction) (*models.Invoice, error) {
+	if transaction.Status != models.TransactionStatusSuccess {
+		return nil, errors.New("invoice can only be issued for a successful transaction")
+	}
+
+	now := time.Now()
+	fiscalYear, err := bs.FiscalYearOf(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine fiscal year: %v", err)
+	}
+
+	order := transaction.Order
+	invoice := &models.Invoice{
+		FiscalYear:     fiscalYear,
+		OrderID:        order.ID,
+		TransactionID:  transaction.ID,
+		UserID:         order.UserID,
+		PaymentMethod:  transaction.PaymentMethods(),
+		SellerName:     s.seller.Name,
+		SellerPAN:      s.seller.PAN,
+		SellerAddress:  s.seller.Address,
+		BuyerName:      order.User.Name,
+		BuyerEmail:     order.User.Email,
+		BuyerPAN:       order.BuyerPAN,
+		SubTotal:       order.SubTotal,
+		DiscountTotal:  order.DiscountTotal,
+		TaxableAmount:  order.TaxableAmount,
+		ExemptAmount:   order.ExemptAmount,
+		TaxAmount:      order.TaxAmount,
+		ServiceCharge:  order.ServiceCharge,
+		DeliveryCharge: order.DeliveryCharge,
+		TotalAmount:    order.TotalPrice,
+		IssuedAt:       now,
+	}
+	for _, item := range order.Items {
+		invoice.Items = append(invoice.Items, models.InvoiceItem{
+			BookID:         item.BookID,
+			Description:    item.Book.Title,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.Price,
+			DiscountAmount: item.DiscountAmount,
+			Amount:         utils.RoundMoney(item.Price*float64(item.Quantity) - item.DiscountAmount),
+			TaxExempt:      item.TaxExempt,
+			TaxAmount:      item.TaxAmount,
+		})
+	}
+
+	return s.invoiceRepo.Issue(ctx, invoice)
+}
+
+func (s *invoiceService) GetAllInvoices(ctx context.Context) ([]models.Invoice, error) {
+	return s.invoiceRepo.GetAll(ctx)
+}
+
+func (s *invoiceService) GetInvoiceByID(ctx context.Context, id uuid.UUID) (*models.Invoice, error) {
+	return s.invoiceRepo.GetByID(ctx, id)
+}
+
+// GetInvoiceForOrder returns the order's invoice, issuing it first if the order
+// was paid but issuing failed at payment time.
+func (s *invoiceService) GetInvoiceForOrder(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByOrderID(ctx, orderID)
+	if err == nil {
+		return invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	transaction, err := s.transactionRepo.GetByOrderID(ctx, orderID)
+	if err != nil || transaction.Status != models.TransactionStatusSuccess {
+		return nil, errors.New("invoice not available: order has not been paid")
+	}
+	return s.IssueForTransaction(ctx, transaction)
+}
+
+// CancelInvoice cancels an invoice by issuing a credit note for its full amount
+func (s *invoiceService) CancelInvoice(ctx context.Context, id uuid.UUID, reason string, userID uuid.UUID) (*models.Invoice, error) {
+	now := time.Now()
+	fiscalYear, err := bs.FiscalYearOf(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine fiscal year: %v", err)
+	}
+
+	creditNote := &models.CreditNote{
+		FiscalYear: fiscalYear,
+		Reason:     reason,
+		IssuedBy:   userID,
+		IssuedAt:   now,
+	}
+	return s.invoiceRepo.Cancel(ctx, id, creditNote)
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrUnbalancedJournal is returned for a journal entry whose debits and credits differ
+var ErrUnbalancedJournal = errors.New("journal entry does not balance")
+
+type LedgerService interface {
+	PostTransaction(ctx context.Context, transactionID uuid.UUID) (*models.JournalEntry, error)
+	PostRefund(ctx context.Context, refundID uuid.UUID) (*models.JournalEntry, error)
+	PostGatewayFee(ctx context.Context, req *models.GatewayFeeRequest) (*models.JournalEntry, error)
+	PostWalletCredit(ctx context.Context, entry *models.WalletEntry) (*models.JournalEntry, error)
+	PostWalletExpiry(ctx context.Context, entry *models.WalletEntry) (*models.JournalEntry, error)
+	PostGiftCardIssue(ctx context.Context, card *models.GiftCard) (*models.JournalEntry, error)
+	PostGiftCardVoid(ctx context.Context, card *models.GiftCard, amount float64) (*models.JournalEntry, error)
+	PostMissing(ctx context.Context) (int, error)
+	GetAccounts(ctx context.Context) ([]models.LedgerAccount, error)
+	GetJournalEntries(ctx context.Context, sourceType string, from, to time.Time) ([]models.JournalEntry, error)
+	TrialBalance(ctx context.Context, asOf time.Time) (*models.TrialBalance, error)
+	AccountStatement(ctx context.Context, code string, from, to time.Time) (*models.AccountStatement, error)
+	Check(ctx context.Context) (*models.LedgerCheck, error)
+}
+
+type ledgerService struct {
+	ledgerRepo      repositories.LedgerRepository
+	transactionRepo repositories.TransactionRepository
+	refundRepo      repositories.RefundRepository
+}
+
+func NewLedgerService(ledgerRepo repositories.LedgerRepository, transactionRepo repositories.TransactionRepository, refundRepo repositories.RefundRepository) LedgerService {
+	return &ledgerService{
+		ledgerRepo:      ledgerRepo,
+		transactionRepo: transactionRepo,
+		refundRepo:      refundRepo,
+	}
+}
+
+// tenderAccounts maps payment methods to the account the money lands in
+var tenderAccounts = map[string]string{
+	models.PaymentMethodEsewa:        models.AccountEsewaClearing,
+	models.PaymentMethodCash:         models.AccountCash,
+	models.PaymentMethodCard:         models.AccountCardClearing,
+	models.PaymentMethodConnectIPS:   models.AccountConnectIPSClearing,
+	models.PaymentMethodBankTransfer: models.AccountBank,
+	models.PaymentMethodStoreCredit:  models.AccountStoreCreditLiability,
+	models.PaymentMethodGiftCard:     models.AccountGiftCardLiability,
+}
+
+func tenderAccount(method string) (string, error) {
+	account, ok := tenderAccounts[method]
+	if !ok {
+		return "", fmt.Errorf("no ledger account for payment method %s", method)
+	}
+	return account, nil
+}
+
+func debit(account string, amount float64, description string) models.JournalLine {
+	return models.JournalLine{Account: models.LedgerAccount{Code: account}, Debit: utils.RoundMoney(amount), Description: description}
+}
+
+func credit(account string, amount float64, description string) models.JournalLine {
+	return models.JournalLine{Account: models.LedgerAccount{Code: account}, Credit: utils.RoundMoney(amount), Description: description}
+}
+
+// post drops zero lines, checks the entry balances and saves it
+func (s *ledgerService) post(ctx context.Context, entry *models.JournalEntry) (*models.JournalEntry, error) {
+	var lines []models.JournalLine
+	var debits, credits float64
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return nil, fmt.Errorf("%w: negative amount on %s", ErrUnbalancedJournal, line.Account.Code)
+		}
+		if line.Debit == 0 && line.Credit == 0 {
+			continue
+		}
+		debits += line.Debit
+		credits += line.Credit
+		lines = append(lines, line)
+	}
+	if len(lines) < 2 || utils.RoundMoney(debits) != utils.RoundMoney(credits) {
+		return nil, fmt.Errorf("%w: debit %.2f, credit %.2f", ErrUnbalancedJournal, debits, credits)
+	}
+
+	entry.Lines = lines
+	if entry.PostedAt.IsZero() {
+		entry.PostedAt = time.Now()
+	}
+	return s.ledgerRepo.Post(ctx, entry)
+}
+
+// PostTransaction records a successful sale: each tender is debited to the
+// account the money came from; the bill is credited to VAT payable, service
+// revenue, gift card liability for gift cards sold and sales revenue for the rest.
+func (s *ledgerService) PostTransaction(ctx context.Context, transactionID uuid.UUID) (*models.JournalEntry, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != models.TransactionStatusSuccess {
+		return nil, errors.New("only successful transactions are posted to the ledger")
+	}
+
+	tenders := transaction.Tenders
+	if len(tenders) == 0 {
+		tenders = []models.TransactionTender{{PaymentMethod: transaction.PaymentMethod, Amount: transaction.Amount}}
+	}
+
+	var lines []models.JournalLine
+	for _, tender := range tenders {
+		account, err := tenderAccount(tender.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, debit(account, tender.Amount, tender.PaymentMethod+" tender"))
+	}
+
+	var giftCards float64
+	for _, item := range transaction.Order.Items {
+		if item.Book.ProductType == models.ProductTypeGiftCard {
+			giftCards += item.Price*float64(item.Quantity) - item.DiscountAmount
+		}
+	}
+	charges := transaction.ServiceCharge + transaction.DeliveryCharge
+	sales := transaction.Amount - transaction.TaxAmount - charges - giftCards
+
+	lines = append(lines,
+		credit(models.AccountSalesRevenue, sales, ""),
+		credit(models.AccountGiftCardLiability, giftCards, "Gift cards sold"),
+		credit(models.AccountVATPayable, transaction.TaxAmount, ""),
+		credit(models.AccountServiceRevenue, charges, "Service and delivery charges"),
+	)
+
+	postedAt := transaction.UpdatedAt
+	if transaction.PaidAt != nil {
+		postedAt = *transaction.PaidAt
+	}
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceTransaction,
+		SourceID:    transaction.ID.String(),
+		Description: fmt.Sprintf("Payment for order %s", transaction.OrderID),
+		PostedAt:    postedAt,
+		Lines:       lines,
+	})
+}
+
+// PostRefund records a completed refund against sales returns, or a lost
+// dispute's chargeback against chargebacks, credited to store credit or to
+// the accounts of the tenders that paid for the order
+func (s *ledgerService) PostRefund(ctx context.Context, refundID uuid.UUID) (*models.JournalEntry, error) {
+	refund, err := s.refundRepo.GetByID(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status != models.RefundStatusCompleted {
+		return nil, errors.New("only completed refunds are posted to the ledger")
+	}
+
+	expense, description := models.AccountSalesReturns, "Refund"
+	if refund.Method == models.RefundMethodChargeback {
+		expense, description = models.AccountChargebacks, "Chargeback"
+	}
+	credits := []models.JournalLine{credit(models.AccountStoreCreditLiability, refund.Amount, "")}
+	if refund.Method != models.RefundMethodStoreCredit {
+		transaction, err := s.transactionRepo.GetByID(ctx, refund.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if credits, err = refundCredits(transaction, refund.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	postedAt := refund.UpdatedAt
+	if refund.CompletedAt != nil {
+		postedAt = *refund.CompletedAt
+	}
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceRefund,
+		SourceID:    refund.ID.String(),
+		Description: fmt.Sprintf("%s for order %s", description, refund.OrderID),
+		PostedAt:    postedAt,
+		Lines:       append([]models.JournalLine{debit(expense, refund.Amount, refund.Reason)}, credits...),
+	})
+}
+
+// refundCredits splits money going back through the original payment across
+// the tenders it came from, in proportion to their amounts. Store credit and
+// gift card tenders are left out: they can only be refunded as store credit,
+// as nothing reloads a gift card. The last tender takes the rounding remainder.
+func refundCredits(transaction *models.Transaction, amount float64) ([]models.JournalLine, error) {
+	tenders := transaction.Tenders
+	if len(tenders) == 0 {
+		tenders = []models.TransactionTender{{PaymentMethod: transaction.PaymentMethod, Amount: transaction.Amount}}
+	}
+	var paid []models.TransactionTender
+	var base float64
+	for _, tender := range tenders {
+		if tender.PaymentMethod == models.PaymentMethodStoreCredit || tender.PaymentMethod == models.PaymentMethodGiftCard {
+			continue
+		}
+		if tender.Amount > 0 {
+			paid = append(paid, tender)
+			base += tender.Amount
+		}
+	}
+	if base <= 0 {
+		return nil, errors.New("transaction has no tender that can take a refund to the original payment")
+	}
+
+	lines := make([]models.JournalLine, 0, len(paid))
+	remaining := utils.RoundMoney(amount)
+	for i, tender := range paid {
+		account, err := tenderAccount(tender.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		share := remaining
+		if i < len(paid)-1 {
+			share = utils.RoundMoney(amount * tender.Amount / base)
+			remaining = utils.RoundMoney(remaining - share)
+		}
+		lines = append(lines, credit(account, share, tender.PaymentMethod+" tender"))
+	}
+	return lines, nil
+}
+
+// PostGatewayFee records the fee a gateway kept from a transaction's settlement
+func (s *ledgerService) PostGatewayFee(ctx context.Context, req *models.GatewayFeeRequest) (*models.JournalEntry, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, req.TransactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	if transaction.Status != models.TransactionStatusSuccess {
+		return nil, errors.New("gateway fees can only be recorded for successful transactions")
+	}
+	account, err := tenderAccount(transaction.PaymentMethod)
+	if err != nil || account == models.AccountStoreCreditLiability || account == models.AccountGiftCardLiability {
+		return nil, errors.New("transaction was not paid through a gateway")
+	}
+
+	description := fmt.Sprintf("%s fee for transaction %s", transaction.PaymentMethod, transaction.ID)
+	if req.Reference != "" {
+		description += " (" + req.Reference + ")"
+	}
+	var postedAt time.Time
+	if req.SettledAt != nil {
+		postedAt = *req.SettledAt
+	}
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceGatewayFee,
+		SourceID:    transaction.ID.String(),
+		Description: description,
+		PostedAt:    postedAt,
+		Lines: []models.JournalLine{
+			debit(models.AccountGatewayFees, req.Amount, ""),
+			credit(account, req.Amount, ""),
+		},
+	})
+}
+
+// PostWalletCredit records goodwill credit. Refund credits are posted with their refund.
+func (s *ledgerService) PostWalletCredit(ctx context.Context, entry *models.WalletEntry) (*models.JournalEntry, error) {
+	if entry.Source != models.WalletSourceGoodwill {
+		return nil, fmt.Errorf("%s wallet credits are not posted on their own", entry.Source)
+	}
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceWalletCredit,
+		SourceID:    entry.ID.String(),
+		Description: "Goodwill store credit: " + entry.Description,
+		PostedAt:    entry.CreatedAt,
+		Lines: []models.JournalLine{
+			debit(models.AccountPromotionalExpense, entry.Amount, ""),
+			credit(models.AccountStoreCreditLiability, entry.Amount, ""),
+		},
+	})
+}
+
+// PostWalletExpiry releases expired store credit from the liability
+func (s *ledgerService) PostWalletExpiry(ctx context.Context, entry *models.WalletEntry) (*models.JournalEntry, error) {
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceWalletExpiry,
+		SourceID:    entry.ID.String(),
+		Description: "Store credit expired",
+		PostedAt:    entry.CreatedAt,
+		Lines: []models.JournalLine{
+			debit(models.AccountStoreCreditLiability, entry.Amount, ""),
+			credit(models.AccountBreakageIncome, entry.Amount, ""),
+		},
+	})
+}
+
+// PostGiftCardIssue records a gift card issued by hand. Cards sold on an
+// order are posted with the order's payment.
+func (s *ledgerService) PostGiftCardIssue(ctx context.Context, card *models.GiftCard) (*models.JournalEntry, error) {
+	if card.OrderID != nil {
+		return nil, errors.New("gift cards sold on an order are posted with its payment")
+	}
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceGiftCardIssue,
+		SourceID:    card.ID.String(),
+		Description: "Gift card issued ending " + card.Last4,
+		PostedAt:    card.CreatedAt,
+		Lines: []models.JournalLine{
+			debit(models.AccountPromotionalExpense, card.InitialBalance, card.Note),
+			credit(models.AccountGiftCardLiability, card.InitialBalance, ""),
+		},
+	})
+}
+
+// PostGiftCardVoid releases the balance written off when a card is voided
+func (s *ledgerService) PostGiftCardVoid(ctx context.Context, card *models.GiftCard, amount float64) (*models.JournalEntry, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+	return s.post(ctx, &models.JournalEntry{
+		SourceType:  models.JournalSourceGiftCardVoid,
+		SourceID:    card.ID.String(),
+		Description: "Gift card voided ending " + card.Last4,
+		Lines: []models.JournalLine{
+			debit(models.AccountGiftCardLiability, amount, card.VoidReason),
+			credit(models.AccountBreakageIncome, amount, ""),
+		},
+	})
+}
+
+// PostMissing posts successful transactions and completed refunds that have
+// no journal yet, e.g. ones from before the ledger existed or whose posting
+// failed, and returns how many it posted
+func (s *ledgerService) PostMissing(ctx context.Context) (int, error) {
+	transactionIDs, err := s.ledgerRepo.UnpostedTransactions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	posted := 0
+	for _, id := range transactionIDs {
+		if _, err := s.PostTransaction(ctx, id); err != nil {
+			return posted, fmt.Errorf("transaction %s: %w", id, err)
+		}
+		posted++
+	}
+
+	refundIDs, err := s.ledgerRepo.UnpostedRefunds(ctx)
+	if err != nil {
+		return posted, err
+	}
+	for _, id := range refundIDs {
+		if _, err := s.PostRefund(ctx, id); err != nil {
+			return posted, fmt.Errorf("refund %s: %w", id, err)
+		}
+		posted++
+	}
+	return posted, nil
+}
+
+func (s *ledgerService) GetAccounts(ctx context.Context) ([]models.LedgerAccount, error) {
+	return s.ledgerRepo.GetAccounts(ctx)
+}
+
+func (s *ledgerService) GetJournalEntries(ctx context.Context, sourceType string, from, to time.Time) ([]models.JournalEntry, error) {
+	return s.ledgerRepo.GetEntries(ctx, sourceType, from, to)
+}
+
+// TrialBalance lists every account's debits, credits and balance for journals posted before asOf
+func (s *ledgerService) TrialBalance(ctx context.Context, asOf time.Time) (*models.TrialBalance, error) {
+	rows, err := s.ledgerRepo.TrialBalance(ctx, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &models.TrialBalance{AsOf: asOf, Rows: rows}
+	for i := range rows {
+		account := models.LedgerAccount{Type: rows[i].Type}
+		rows[i].Balance = accountBalance(&account, rows[i].Debit, rows[i].Credit)
+		tb.TotalDebit += rows[i].Debit
+		tb.TotalCredit += rows[i].Credit
+	}
+	tb.TotalDebit = utils.RoundMoney(tb.TotalDebit)
+	tb.TotalCredit = utils.RoundMoney(tb.TotalCredit)
+	return tb, nil
+}
+
+// accountBalance is the net of debits and credits on the account's normal side
+func accountBalance(account *models.LedgerAccount, debits, credits float64) float64 {
+	if account.NormalDebit() {
+		return utils.RoundMoney(debits - credits)
+	}
+	return utils.RoundMoney(credits - debits)
+}
+
+// AccountStatement lists an account's lines in [from, to) with running balances
+func (s *ledgerService) AccountStatement(ctx context.Context, code string, from, to time.Time) (*models.AccountStatement, error) {
+	account, err := s.ledgerRepo.GetAccountByCode(ctx, code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("ledger account %s not found", code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	debits, credits, err := s.ledgerRepo.AccountTotals(ctx, account.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.ledgerRepo.AccountLines(ctx, account.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.AccountStatement{
+		Account:        *account,
+		From:           from,
+		To:             to,
+		OpeningBalance: accountBalance(account, debits, credits),
+		Lines:          lines,
+	}
+	balance := statement.OpeningBalance
+	for i := range lines {
+		balance = utils.RoundMoney(balance + accountBalance(account, lines[i].Debit, lines[i].Credit))
+		lines[i].Balance = balance
+	}
+	statement.ClosingBalance = balance
+	return statement, nil
+}
+
+// Check verifies the ledger invariants: every journal balances, so total
+// debits equal total credits, and every successful transaction and completed
+// refund has been posted
+func (s *ledgerService) Check(ctx context.Context) (*models.LedgerCheck, error) {
+	unbalanced, err := s.ledgerRepo.UnbalancedJournals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	debits, credits, journals, err := s.ledgerRepo.Totals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := s.ledgerRepo.UnpostedTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.ledgerRepo.UnpostedRefunds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &models.LedgerCheck{
+		TotalDebit:           utils.RoundMoney(debits),
+		TotalCredit:          utils.RoundMoney(credits),
+		JournalCount:         journals,
+		UnbalancedJournals:   unbalanced,
+		UnpostedTransactions: transactions,
+		UnpostedRefunds:      refunds,
+	}
+	check.Balanced = len(unbalanced) == 0 && check.TotalDebit == check.TotalCredit
+	return check, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"testing"
+)
+
+func TestRefundCredits(t *testing.T) {
+	tender := func(method string, amount float64) models.TransactionTender {
+		return models.TransactionTender{PaymentMethod: method, Amount: amount}
+	}
+
+	tests := []struct {
+		name        string
+		transaction models.Transaction
+		amount      float64
+		want        map[string]float64 // credit per account
+	}{
+		{
+			name:        "single method",
+			transaction: models.Transaction{PaymentMethod: models.PaymentMethodEsewa, Amount: 1000},
+			amount:      400,
+			want:        map[string]float64{models.AccountEsewaClearing: 400},
+		},
+		{
+			name: "split between card and eSewa",
+			transaction: models.Transaction{PaymentMethod: models.PaymentMethodEsewa, Amount: 1000, Tenders: []models.TransactionTender{
+				tender(models.PaymentMethodCard, 250), tender(models.PaymentMethodEsewa, 750),
+			}},
+			amount: 400,
+			want:   map[string]float64{models.AccountCardClearing: 100, models.AccountEsewaClearing: 300},
+		},
+		{
+			name: "gift card tender is left out",
+			transaction: models.Transaction{PaymentMethod: models.PaymentMethodEsewa, Amount: 1000, Tenders: []models.TransactionTender{
+				tender(models.PaymentMethodGiftCard, 250), tender(models.PaymentMethodEsewa, 750),
+			}},
+			amount: 400,
+			want:   map[string]float64{models.AccountEsewaClearing: 400},
+		},
+		{
+			name: "store credit tender is left out",
+			transaction: models.Transaction{PaymentMethod: models.PaymentMethodCard, Amount: 1000, Tenders: []models.TransactionTender{
+				tender(models.PaymentMethodStoreCredit, 500), tender(models.PaymentMethodCard, 500),
+			}},
+			amount: 500,
+			want:   map[string]float64{models.AccountCardClearing: 500},
+		},
+		{
+			name: "last tender takes the rounding remainder",
+			transaction: models.Transaction{PaymentMethod: models.PaymentMethodCash, Amount: 300, Tenders: []models.TransactionTender{
+				tender(models.PaymentMethodCard, 100), tender(models.PaymentMethodEsewa, 100), tender(models.PaymentMethodCash, 100),
+			}},
+			amount: 100,
+			want: map[string]float64{models.AccountCardClearing: 33.33, models.AccountEsewaClearing: 33.33,
+				models.AccountCash: 33.34},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := refundCredits(&tt.transaction, tt.amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]float64{}
+			for _, line := range lines {
+				got[line.Account.Code] += line.Credit
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("credits = %v, want %v", got, tt.want)
+			}
+			for account, want := range tt.want {
+				if got[account] != want {
+					t.Errorf("%s credited %v, want %v", account, got[account], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRefundCreditsNoGatewayTender(t *testing.T) {
+	transaction := &models.Transaction{PaymentMethod: models.PaymentMethodStoreCredit, Amount: 500, Tenders: []models.TransactionTender{
+		{PaymentMethod: models.PaymentMethodStoreCredit, Amount: 200},
+		{PaymentMethod: models.PaymentMethodGiftCard, Amount: 300},
+	}}
+	if _, err := refundCredits(transaction, 100); err == nil {
+		t.Error("refund to the original payment of a store credit and gift card order succeeded")
+	}
+}
@@ -1,36 +1,88 @@
 package services
 
 import (
+	"bookstore/config"
+	"bookstore/internal/events"
 	"bookstore/internal/models"
 	"bookstore/internal/repositories"
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var ErrOrderNotFound = errors.New("order not found")
+
 type OrderService struct {
-	orderRepo repositories.OrderRepository
+	orderRepo  repositories.OrderRepository
+	bookRepo   repositories.BookRepository
+	promotions *PromotionEngine
+	taxCalc    *TaxCalculator
+	bus        *events.Bus
+	cfg        config.OrderConfig
 }
 
-func NewOrderService(orderRepo repositories.OrderRepository) *OrderService {
-	return &OrderService{orderRepo: orderRepo}
+func NewOrderService(orderRepo repositories.OrderRepository, bookRepo repositories.BookRepository, promotions *PromotionEngine, taxCalc *TaxCalculator, bus *events.Bus, cfg config.OrderConfig) *OrderService {
+	return &OrderService{orderRepo: orderRepo, bookRepo: bookRepo, promotions: promotions, taxCalc: taxCalc, bus: bus, cfg: cfg}
 }
 
 // CreateOrder handles creating a new order
 func (s *OrderService) CreateOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if err := s.price(ctx, order); err != nil {
+		return nil, err
+	}
+	order.Status = "PENDING"
+
+	// Books were loaded for pricing only; saving them would write to the catalog
+	for i := range order.Items {
+		order.Items[i].Book = models.Book{}
+	}
+
+	created, err := s.orderRepo.Create(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bus.Publish(ctx, events.OrderCreated{Order: created}); err != nil {
+		log.Printf("follow-up work for order %s incomplete: %v", created.ID, err)
+	}
+	return created, nil
+}
+
+// QuoteOrder prices a cart the same way CreateOrder would, without saving it
+func (s *OrderService) QuoteOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if err := s.price(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// price fills in catalog prices, promotions and tax for a new order
+func (s *OrderService) price(ctx context.Context, order *models.Order) error {
 	if len(order.Items) == 0 {
-		return nil, errors.New("order must contain at least one item")
+		return errors.New("order must contain at least one item")
 	}
 
-	var total float64
-	for _, item := range order.Items {
-		total += item.Price * float64(item.Quantity)
+	// Price items from the catalog and snapshot their VAT treatment
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.Quantity <= 0 {
+			return errors.New("item quantity must be greater than zero")
+		}
+		book, err := s.bookRepo.GetByID(ctx, item.BookID)
+		if err != nil {
+			return fmt.Errorf("book %s not found", item.BookID)
+		}
+		item.Book = *book
+		item.Price = book.Price
+		item.TaxExempt = s.taxCalc.IsExempt(book)
 	}
-	order.TotalPrice = total
-	order.Status = "PENDING"
 
-	return s.orderRepo.Create(ctx, order)
+	_, err := priceOrder(ctx, s.promotions, s.taxCalc, order, nil)
+	return err
 }
 
 // GetAllOrders returns all orders
@@ -43,13 +95,87 @@ func (s *OrderService) GetOrderByID(ctx context.Context, id uuid.UUID) (*models.
 	return s.orderRepo.GetByID(ctx, id)
 }
 
-// UpdateOrderStatus updates the status of an order
+// GetOrderByRef returns the order with a reference such as BK-2082-000457
+func (s *OrderService) GetOrderByRef(ctx context.Context, ref string) (*models.Order, error) {
+	order, err := s.orderRepo.GetByRef(ctx, ref)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// UpdateOrderStatus updates the status of a PENDING order. Paid and cancelled
+// orders are final: changing them returns repositories.ErrOrderNotPending.
 func (s *OrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status string) (*models.Order, error) {
 	validStatuses := map[string]bool{"PENDING": true, "PAID": true, "CANCELLED": true}
 	if !validStatuses[status] {
 		return nil, errors.New("invalid order status")
 	}
-	return s.orderRepo.UpdateStatus(ctx, id, status)
+	current, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.orderRepo.UpdateStatus(ctx, id, status)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.OrderStatusCancelled && current.Status != models.OrderStatusCancelled {
+		s.onCancelled(ctx, order)
+	}
+	return order, nil
+}
+
+func (s *OrderService) onCancelled(ctx context.Context, order *models.Order) {
+	if err := s.bus.Publish(ctx, events.OrderCancelled{Order: order}); err != nil {
+		log.Printf("follow-up work for order %s incomplete: %v", order.ID, err)
+	}
+}
+
+// ExpireAbandoned cancels PENDING orders older than the pending TTL that never
+// started a payment, or whose payment failed, and have no usable payment link. Cancelling releases their coupon
+// redemptions, so unpaid carts do not use up a coupon's limits.
+func (s *OrderService) ExpireAbandoned(ctx context.Context) (int, error) {
+	if s.cfg.PendingTTL <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-s.cfg.PendingTTL)
+	orders, err := s.orderRepo.GetAbandoned(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, abandoned := range orders {
+		// Rechecked under lock: a payment started meanwhile keeps the order
+		order, err := s.orderRepo.CancelAbandoned(ctx, abandoned.ID, cutoff)
+		if errors.Is(err, repositories.ErrOrderNotPending) {
+			continue
+		}
+		if err != nil {
+			log.Printf("expire order %s: %v", abandoned.ID, err)
+			continue
+		}
+		s.onCancelled(ctx, order)
+		expired++
+	}
+	return expired, nil
+}
+
+// Run cancels abandoned orders every ExpiryInterval until ctx is cancelled
+func (s *OrderService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireAbandoned(ctx); err != nil {
+			log.Printf("order expiry: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 // DeleteOrder deletes an order by ID
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxSubscriber processes one outbox event. It may see the same event
+// again if the relay crashes before recording it as processed, so it should
+// use event.ID to make repeated work harmless.
+type OutboxSubscriber func(ctx context.Context, event *models.OutboxEvent) error
+
+type OutboxRelay interface {
+	// Subscribe registers a named consumer for an event type. Call it before Run.
+	Subscribe(eventType, consumer string, subscriber OutboxSubscriber)
+	RelayDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+	GetEvents(ctx context.Context, status, aggregateType string, aggregateID *uuid.UUID) ([]models.OutboxEvent, error)
+	Replay(ctx context.Context, eventID *uuid.UUID) (int64, error)
+}
+
+type outboxSubscription struct {
+	consumer   string
+	subscriber OutboxSubscriber
+}
+
+type outboxRelay struct {
+	outboxRepo repositories.OutboxRepository
+	cfg        config.OutboxConfig
+
+	mu            sync.RWMutex
+	subscriptions map[string][]outboxSubscription
+}
+
+func NewOutboxRelay(outboxRepo repositories.OutboxRepository, cfg config.OutboxConfig) OutboxRelay {
+	return &outboxRelay{
+		outboxRepo:    outboxRepo,
+		cfg:           cfg,
+		subscriptions: map[string][]outboxSubscription{},
+	}
+}
+
+const outboxBatchSize = 50
+
+func (r *outboxRelay) Subscribe(eventType, consumer string, subscriber OutboxSubscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[eventType] = append(r.subscriptions[eventType], outboxSubscription{consumer: consumer, subscriber: subscriber})
+}
+
+// Run relays due events every poll interval until ctx is cancelled
+func (r *outboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayDue(ctx); err != nil {
+			log.Printf("outbox relay: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayDue hands every due event to its subscribers and returns how many were fully published
+func (r *outboxRelay) RelayDue(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := r.outboxRepo.ClaimDue(ctx, outboxBatchSize, 5*time.Minute)
+		if err != nil {
+			return published, err
+		}
+		if len(events) == 0 {
+			return published, nil
+		}
+
+		for _, event := range events {
+			if err := r.relay(ctx, &event); err != nil {
+				r.recordFailure(ctx, &event, err)
+				continue
+			}
+			if err := r.outboxRepo.MarkPublished(ctx, event.ID); err != nil {
+				return published, err
+			}
+			published++
+		}
+	}
+}
+
+// relay runs the subscribers that have not yet processed the event. Each
+// success is recorded straight away, so a retry only re-runs the ones that failed.
+func (r *outboxRelay) relay(ctx context.Context, event *models.OutboxEvent) error {
+	r.mu.RLock()
+	subscriptions := r.subscriptions[event.EventType]
+	r.mu.RUnlock()
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	done, err := r.outboxRepo.GetConsumers(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, subscription := range subscriptions {
+		if done[subscription.consumer] {
+			continue
+		}
+		if err := subscription.subscriber(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", subscription.consumer, err))
+			continue
+		}
+		if err := r.outboxRepo.RecordConsumed(ctx, event.ID, subscription.consumer); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: record processed: %v", subscription.consumer, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (r *outboxRelay) recordFailure(ctx context.Context, event *models.OutboxEvent, relayErr error) {
+	attempts := event.Attempts + 1
+	status := models.OutboxStatusPending
+	if attempts >= r.cfg.MaxAttempts {
+		status = models.OutboxStatusFailed
+	}
+
+	// Exponential backoff from the poll interval, capped at an hour
+	backoff := r.cfg.PollInterval << min(attempts, 16)
+	if backoff > time.Hour {
+		backoff = time.Hour
+	}
+
+	log.Printf("outbox %s %s attempt %d failed: %v", event.EventType, event.ID, attempts, relayErr)
+	if err := r.outboxRepo.MarkAttemptFailed(ctx, event.ID, status, time.Now().Add(backoff), relayErr.Error()); err != nil {
+		log.Printf("outbox relay: failed to record attempt for %s: %v", event.ID, err)
+	}
+}
+
+func (r *outboxRelay) GetEvents(ctx context.Context, status, aggregateType string, aggregateID *uuid.UUID) ([]models.OutboxEvent, error) {
+	return r.outboxRepo.GetAll(ctx, status, aggregateType, aggregateID)
+}
+
+// Replay requeues one unpublished event, or every FAILED event when eventID is nil
+func (r *outboxRelay) Replay(ctx context.Context, eventID *uuid.UUID) (int64, error) {
+	return r.outboxRepo.Requeue(ctx, eventID)
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/paylink"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrPaymentLinkNotFound is returned for tokens that were not issued here
+var ErrPaymentLinkNotFound = errors.New("payment link not found")
+
+type PaymentLinkService interface {
+	CreateLink(ctx context.Context, orderID uuid.UUID, req *models.PaymentLinkRequest, adminID uuid.UUID) (*models.PaymentLink, error)
+	GetLinks(ctx context.Context, status string, orderID *uuid.UUID) ([]models.PaymentLink, error)
+	RevokeLink(ctx context.Context, id, adminID uuid.UUID) (*models.PaymentLink, error)
+
+	// Open describes the order behind a token, including links that can no longer be paid
+	Open(ctx context.Context, token string) (*models.PaymentLinkView, error)
+	// StartEsewa creates or reuses the order's eSewa transaction and returns it with its payment URL
+	StartEsewa(ctx context.Context, token string, client models.ClientInfo) (*models.Transaction, error)
+	VerifyEsewa(ctx context.Context, token string, esewaResponse *models.EsewaResponseData, client models.ClientInfo) (*models.Transaction, error)
+
+	// MarkPaid closes the order's open links once it has been paid by any means
+	MarkPaid(ctx context.Context, transaction *models.Transaction) error
+}
+
+type paymentLinkService struct {
+	linkRepo     repositories.PaymentLinkRepository
+	orders       *OrderService
+	transactions TransactionService
+	cfg          config.PaymentLinkConfig
+}
+
+func NewPaymentLinkService(linkRepo repositories.PaymentLinkRepository, orders *OrderService, transactions TransactionService, cfg config.PaymentLinkConfig) PaymentLinkService {
+	return &paymentLinkService{linkRepo: linkRepo, orders: orders, transactions: transactions, cfg: cfg}
+}
+
+// withURL fills in the link's shareable URL. The token is derived from the
+// link, so it is not stored.
+func (s *paymentLinkService) withURL(link *models.PaymentLink) {
+	link.URL = s.cfg.BaseURL + paylink.Sign(s.cfg.Secret, link.ID, link.ExpiresAt)
+	link.Status = link.StatusAt(time.Now())
+}
+
+func (s *paymentLinkService) CreateLink(ctx context.Context, orderID uuid.UUID, req *models.PaymentLinkRequest, adminID uuid.UUID) (*models.PaymentLink, error) {
+	order, err := s.orders.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, fmt.Errorf("order is %s, only PENDING orders can be paid by link", order.Status)
+	}
+
+	ttl := s.cfg.TTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	link, err := s.linkRepo.Create(ctx, &models.PaymentLink{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Amount:  order.TotalPrice,
+		Note:    req.Note,
+		// Tokens carry whole seconds
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+		CreatedBy: &adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.withURL(link)
+	return link, nil
+}
+
+func (s *paymentLinkService) GetLinks(ctx context.Context, status string, orderID *uuid.UUID) ([]models.PaymentLink, error) {
+	links, err := s.linkRepo.GetAll(ctx, status, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		s.withURL(&links[i])
+	}
+	return links, nil
+}
+
+func (s *paymentLinkService) RevokeLink(ctx context.Context, id, adminID uuid.UUID) (*models.PaymentLink, error) {
+	link, err := s.linkRepo.Revoke(ctx, id, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPaymentLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.withURL(link)
+	return link, nil
+}
+
+// resolve loads the link behind a token. Expired tokens still resolve so the
+// customer can be told the link has expired.
+func (s *paymentLinkService) resolve(ctx context.Context, token string) (*models.PaymentLink, error) {
+	id, err := paylink.Parse(s.cfg.Secret, token, time.Now())
+	if err != nil && !errors.Is(err, paylink.ErrExpired) {
+		return nil, ErrPaymentLinkNotFound
+	}
+	link, err := s.linkRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrPaymentLinkNotFound
+	}
+	link.Status = link.StatusAt(time.Now())
+	return link, nil
+}
+
+// payable loads a link that can still be used to pay
+func (s *paymentLinkService) payable(ctx context.Context, token string) (*models.PaymentLink, error) {
+	link, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	switch link.Status {
+	case models.PaymentLinkPaid:
+		return nil, errors.New("this order has already been paid")
+	case models.PaymentLinkRevoked:
+		return nil, errors.New("this payment link has been withdrawn")
+	case models.PaymentLinkExpired:
+		return nil, errors.New("this payment link has expired")
+	}
+	if link.Order == nil || link.Order.Status != models.OrderStatusPending {
+		return nil, errors.New("this order can no longer be paid")
+	}
+	// A coupon or reprice since the link was sent needs a new link
+	if utils.RoundMoney(link.Order.TotalPrice) != utils.RoundMoney(link.Amount) {
+		return nil, errors.New("the order total has changed, please ask for a new payment link")
+	}
+	return link, nil
+}
+
+func (s *paymentLinkService) Open(ctx context.Context, token string) (*models.PaymentLinkView, error) {
+	link, err := s.resolve(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.PaymentLinkView{
+		Status:    link.Status,
+		ExpiresAt: link.ExpiresAt,
+		OrderID:   link.OrderID,
+		Amount:    link.Amount,
+	}
+	if order := link.Order; order != nil {
+		view.OrderRef = order.Ref
+		view.SubTotal = order.SubTotal
+		view.DiscountTotal = order.DiscountTotal
+		view.TaxAmount = order.TaxAmount
+		view.ServiceCharge = order.ServiceCharge
+		view.DeliveryCharge = order.DeliveryCharge
+		for _, item := range order.Items {
+			view.Items = append(view.Items, models.PaymentLinkItem{Title: item.Book.Title, Quantity: item.Quantity, Price: item.Price})
+		}
+	}
+	if transaction, err := s.transactions.GetTransactionByOrderID(ctx, link.OrderID); err == nil {
+		view.TransactionStatus = transaction.Status
+	}
+	return view, nil
+}
+
+func (s *paymentLinkService) StartEsewa(ctx context.Context, token string, client models.ClientInfo) (*models.Transaction, error) {
+	link, err := s.payable(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// The payment is made as the customer the order belongs to
+	transaction, err := s.transactions.GetTransactionByOrderID(ctx, link.OrderID)
+	if err != nil {
+		transaction, err = s.transactions.CreateTransaction(ctx, &models.CreateTransactionRequest{
+			OrderID:       link.OrderID,
+			PaymentMethod: models.PaymentMethodEsewa,
+			Amount:        link.Amount,
+		}, link.UserID, client)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if transaction.Status != models.TransactionStatusPending || transaction.PaymentMethod != models.PaymentMethodEsewa {
+		return nil, errors.New("this order already has a payment in progress by another method")
+	}
+
+	returnURL := s.cfg.BaseURL + url.PathEscape(token)
+	return s.transactions.InitiateEsewaPayment(ctx, transaction.ID, &models.EsewaPaymentRequest{
+		ProductName: "Order " + link.OrderID.String(),
+		SuccessURL:  returnURL + "?result=success",
+		FailureURL:  returnURL + "?result=failure",
+	})
+}
+
+func (s *paymentLinkService) VerifyEsewa(ctx context.Context, token string, esewaResponse *models.EsewaResponseData, client models.ClientInfo) (*models.Transaction, error) {
+	link, err := s.payable(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if esewaResponse.Signature == "" {
+		return nil, errors.New("invalid eSewa signature")
+	}
+	transaction, err := s.transactions.GetTransactionByOrderID(ctx, link.OrderID)
+	if err != nil || transaction.PaymentMethod != models.PaymentMethodEsewa || !matchesEsewaReference(transaction, esewaResponse.Reference()) {
+		return nil, errors.New("payment does not belong to this link")
+	}
+	// VerifyEsewaPayment checks the signature and settles from eSewa's status
+	// API, never from the callback. The link is closed by MarkPaid when the
+	// payment succeeds.
+	return s.transactions.VerifyEsewaPayment(ctx, esewaResponse, link.UserID, client)
+}
+
+func (s *paymentLinkService) MarkPaid(ctx context.Context, transaction *models.Transaction) error {
+	_, err := s.linkRepo.MarkPaid(ctx, transaction.OrderID, transaction.ID)
+	return err
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/filestore"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentProofService interface {
+	Upload(ctx context.Context, transactionID, userID uuid.UUID, fileName string, r io.Reader, form *models.PaymentProofUpload) (*models.PaymentProof, error)
+	GetTransactionProofs(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, []models.PaymentProof, error)
+	GetReviewQueue(ctx context.Context, status string) ([]models.PaymentProof, error)
+	Approve(ctx context.Context, id, adminID uuid.UUID) (*models.PaymentProof, error)
+	Reject(ctx context.Context, id, adminID uuid.UUID, reason string) (*models.PaymentProof, error)
+	OpenFile(ctx context.Context, id uuid.UUID) (*models.PaymentProof, io.ReadCloser, error)
+}
+
+type paymentProofService struct {
+	proofRepo          repositories.PaymentProofRepository
+	transactionRepo    repositories.TransactionRepository
+	transactionService TransactionService
+	store              filestore.Store
+	maxSize            int64
+}
+
+func NewPaymentProofService(proofRepo repositories.PaymentProofRepository, transactionRepo repositories.TransactionRepository, transactionService TransactionService, store filestore.Store, maxSize int64) PaymentProofService {
+	return &paymentProofService{
+		proofRepo:          proofRepo,
+		transactionRepo:    transactionRepo,
+		transactionService: transactionService,
+		store:              store,
+		maxSize:            maxSize,
+	}
+}
+
+// awaitingTransfer loads a transaction that can still take a deposit slip
+func (s *paymentProofService) awaitingTransfer(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	if transaction.PaymentMethod != models.PaymentMethodBankTransfer {
+		return nil, errors.New("transaction is not a bank transfer")
+	}
+	if transaction.Status != models.TransactionStatusPending {
+		return nil, fmt.Errorf("transaction is %s, not PENDING", transaction.Status)
+	}
+	return transaction, nil
+}
+
+// Upload stores a deposit slip for the customer's pending bank transfer
+func (s *paymentProofService) Upload(ctx context.Context, transactionID, userID uuid.UUID, fileName string, r io.Reader, form *models.PaymentProofUpload) (*models.PaymentProof, error) {
+	transaction, err := s.awaitingTransfer(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.UserID != userID {
+		return nil, errors.New("transaction does not belong to user")
+	}
+	pending, err := s.proofRepo.HasPending(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("a deposit slip for this transaction is already awaiting review")
+	}
+
+	data, contentType, ext, err := readUpload(r, s.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("deposit slip: %v", err)
+	}
+
+	key := fmt.Sprintf("payment-proofs/%s/%s%s", transactionID, uuid.New(), ext)
+	if err := s.store.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to store deposit slip: %v", err)
+	}
+
+	proof, err := s.proofRepo.Create(ctx, &models.PaymentProof{
+		TransactionID:    transactionID,
+		UserID:           userID,
+		FileKey:          key,
+		FileName:         fileName,
+		ContentType:      contentType,
+		Size:             int64(len(data)),
+		BankName:         form.BankName,
+		DepositReference: form.DepositReference,
+		DepositedAt:      form.DepositedAt,
+		Status:           models.PaymentProofPending,
+	})
+	if err != nil {
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			log.Printf("failed to remove orphaned deposit slip %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+	return proof, nil
+}
+
+// GetTransactionProofs lists a transaction's deposit slips, newest first
+func (s *paymentProofService) GetTransactionProofs(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, []models.PaymentProof, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		return nil, nil, errors.New("transaction not found")
+	}
+	proofs, err := s.proofRepo.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return transaction, proofs, nil
+}
+
+// GetReviewQueue lists deposit slips in a status, PENDING by default
+func (s *paymentProofService) GetReviewQueue(ctx context.Context, status string) ([]models.PaymentProof, error) {
+	if status == "" {
+		status = models.PaymentProofPending
+	}
+	return s.proofRepo.GetByStatus(ctx, status)
+}
+
+// Approve accepts a deposit slip: the transaction succeeds and the order is paid.
+// Claiming the proof first stops two admins approving it at once; if the
+// transaction cannot be marked paid the proof goes back to PENDING.
+func (s *paymentProofService) Approve(ctx context.Context, id, adminID uuid.UUID) (*models.PaymentProof, error) {
+	proof, err := s.proofRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("payment proof not found")
+	}
+	if _, err := s.awaitingTransfer(ctx, proof.TransactionID); err != nil {
+		return nil, err
+	}
+
+	proof, err = s.proofRepo.Review(ctx, id, models.PaymentProofApproved, "", adminID)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := s.transactionService.UpdateTransactionStatus(ctx, proof.TransactionID, &models.TransactionUpdateRequest{
+		Status: models.TransactionStatusSuccess,
+	})
+	if err != nil {
+		if reopenErr := s.proofRepo.Reopen(context.WithoutCancel(ctx), id); reopenErr != nil {
+			log.Printf("deposit slip %s left approved for an unpaid transaction: %v", id, reopenErr)
+		}
+		return nil, fmt.Errorf("failed to mark the transaction paid: %v", err)
+	}
+	proof.Transaction = transaction
+	return proof, nil
+}
+
+// Reject turns down a deposit slip. The transaction stays pending so the
+// customer can upload a corrected one.
+func (s *paymentProofService) Reject(ctx context.Context, id, adminID uuid.UUID, reason string) (*models.PaymentProof, error) {
+	proof, err := s.proofRepo.Review(ctx, id, models.PaymentProofRejected, reason, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("payment proof not found")
+	}
+	return proof, err
+}
+
+// OpenFile returns a deposit slip with its stored file; the caller closes the reader
+func (s *paymentProofService) OpenFile(ctx context.Context, id uuid.UUID) (*models.PaymentProof, io.ReadCloser, error) {
+	proof, err := s.proofRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, errors.New("payment proof not found")
+	}
+	file, err := s.store.Open(ctx, proof.FileKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("deposit slip file is unavailable: %v", err)
+	}
+	return proof, file, nil
+}
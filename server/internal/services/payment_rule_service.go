@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/bs"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// offeredMethods are the methods a customer can pick at checkout. Store
+// credit and gift cards are tenders against a balance and are only checked
+// when a rule names them.
+var offeredMethods = []string{
+	models.PaymentMethodEsewa,
+	models.PaymentMethodConnectIPS,
+	models.PaymentMethodBankTransfer,
+	models.PaymentMethodCard,
+	models.PaymentMethodCash,
+}
+
+type PaymentRuleService interface {
+	CreateRule(ctx context.Context, req *models.PaymentMethodRuleRequest) (*models.PaymentMethodRule, error)
+	GetAllRules(ctx context.Context, paymentMethod string) ([]models.PaymentMethodRule, error)
+	GetRuleByID(ctx context.Context, id uuid.UUID) (*models.PaymentMethodRule, error)
+	UpdateRule(ctx context.Context, id uuid.UUID, req *models.PaymentMethodRuleRequest) (*models.PaymentMethodRule, error)
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+	GetOrderPaymentMethods(ctx context.Context, orderID uuid.UUID) (*models.Order, []models.PaymentMethodOption, error)
+	CheckMethods(ctx context.Context, order *models.Order, methods []string) error
+}
+
+type paymentRuleService struct {
+	paymentRuleRepo repositories.PaymentRuleRepository
+	orderRepo       repositories.OrderRepository
+}
+
+func NewPaymentRuleService(paymentRuleRepo repositories.PaymentRuleRepository, orderRepo repositories.OrderRepository) PaymentRuleService {
+	return &paymentRuleService{paymentRuleRepo: paymentRuleRepo, orderRepo: orderRepo}
+}
+
+// parseClock reads an "HH:MM" time of day as minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// paymentRuleFromRequest checks that the rule's conditions are consistent
+func paymentRuleFromRequest(req *models.PaymentMethodRuleRequest) (*models.PaymentMethodRule, error) {
+	if req.MaxAmount > 0 && req.MaxAmount <= req.MinAmount {
+		return nil, errors.New("max_amount must be greater than min_amount")
+	}
+	if (req.StartTime == "") != (req.EndTime == "") {
+		return nil, errors.New("start_time and end_time must be set together")
+	}
+	if req.StartTime != "" {
+		if _, err := parseClock(req.StartTime); err != nil {
+			return nil, err
+		}
+		if _, err := parseClock(req.EndTime); err != nil {
+			return nil, err
+		}
+	}
+
+	var districts []string
+	for _, district := range req.Districts {
+		if district = strings.TrimSpace(district); district != "" {
+			districts = append(districts, district)
+		}
+	}
+
+	rule := &models.PaymentMethodRule{
+		PaymentMethod:   req.PaymentMethod,
+		Name:            req.Name,
+		Message:         req.Message,
+		MinAmount:       req.MinAmount,
+		MaxAmount:       req.MaxAmount,
+		RequireVerified: req.RequireVerified,
+		StartTime:       req.StartTime,
+		EndTime:         req.EndTime,
+		Active:          req.Active == nil || *req.Active,
+	}
+	if len(districts) > 0 {
+		rule.Districts = datatypes.NewJSONSlice(districts)
+	}
+	if len(req.AccountTypes) > 0 {
+		rule.AccountTypes = datatypes.NewJSONSlice(req.AccountTypes)
+	}
+	if len(req.Weekdays) > 0 {
+		rule.Weekdays = datatypes.NewJSONSlice(req.Weekdays)
+	}
+	return rule, nil
+}
+
+// ruleFailure returns why rule rules out the order at the given time, or "" if it passes.
+// The rule's own message is preferred over the failed condition.
+func ruleFailure(rule *models.PaymentMethodRule, order *models.Order, at time.Time) string {
+	reason := ""
+	switch {
+	case order.TotalPrice < rule.MinAmount:
+		reason = fmt.Sprintf("order total must be at least Rs. %.2f", rule.MinAmount)
+	case rule.MaxAmount > 0 && order.TotalPrice >= rule.MaxAmount:
+		reason = fmt.Sprintf("order total must be under Rs. %.2f", rule.MaxAmount)
+	case len(rule.Districts) > 0 && !containsFold(rule.Districts, order.DeliveryDistrict):
+		reason = "not available for delivery to " + districtName(order.DeliveryDistrict)
+	case len(rule.AccountTypes) > 0 && !containsFold(rule.AccountTypes, accountType(&order.User)):
+		reason = "only available to " + strings.ToLower(strings.Join(rule.AccountTypes, " or ")) + " accounts"
+	case rule.RequireVerified && !order.User.Verified:
+		reason = "only available to verified accounts"
+	case !inWindow(rule, at.In(bs.Location())):
+		reason = "not available at this time"
+	default:
+		return ""
+	}
+	if rule.Message != "" {
+		return rule.Message
+	}
+	return reason
+}
+
+// inWindow reports whether a Nepal time falls on the rule's weekdays and daily hours
+func inWindow(rule *models.PaymentMethodRule, at time.Time) bool {
+	if len(rule.Weekdays) > 0 {
+		found := false
+		for _, day := range rule.Weekdays {
+			if time.Weekday(day) == at.Weekday() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if rule.StartTime == "" {
+		return true
+	}
+
+	start, err := parseClock(rule.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(rule.EndTime)
+	if err != nil {
+		return false
+	}
+	now := at.Hour()*60 + at.Minute()
+	if start <= end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func accountType(user *models.User) string {
+	if user.AccountType == "" {
+		return models.AccountTypeIndividual
+	}
+	return user.AccountType
+}
+
+func districtName(district string) string {
+	if strings.TrimSpace(district) == "" {
+		return "an unspecified district"
+	}
+	return district
+}
+
+// evaluatePaymentMethod checks every active rule for method against the order
+func evaluatePaymentMethod(rules []models.PaymentMethodRule, order *models.Order, method string, at time.Time) models.PaymentMethodOption {
+	option := models.PaymentMethodOption{PaymentMethod: method, Available: true}
+	for i := range rules {
+		if rules[i].PaymentMethod != method {
+			continue
+		}
+		if reason := ruleFailure(&rules[i], order, at); reason != "" {
+			option.Available = false
+			option.Reasons = append(option.Reasons, reason)
+		}
+	}
+	return option
+}
+
+func (s *paymentRuleService) CreateRule(ctx context.Context, req *models.PaymentMethodRuleRequest) (*models.PaymentMethodRule, error) {
+	rule, err := paymentRuleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentRuleRepo.Create(ctx, rule)
+}
+
+func (s *paymentRuleService) GetAllRules(ctx context.Context, paymentMethod string) ([]models.PaymentMethodRule, error) {
+	return s.paymentRuleRepo.GetAll(ctx, paymentMethod)
+}
+
+func (s *paymentRuleService) GetRuleByID(ctx context.Context, id uuid.UUID) (*models.PaymentMethodRule, error) {
+	return s.paymentRuleRepo.GetByID(ctx, id)
+}
+
+func (s *paymentRuleService) UpdateRule(ctx context.Context, id uuid.UUID, req *models.PaymentMethodRuleRequest) (*models.PaymentMethodRule, error) {
+	rule, err := paymentRuleFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.paymentRuleRepo.Update(ctx, id, rule)
+}
+
+func (s *paymentRuleService) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	return s.paymentRuleRepo.Delete(ctx, id)
+}
+
+// GetOrderPaymentMethods lists the checkout methods with whether each can pay for the order
+func (s *paymentRuleService) GetOrderPaymentMethods(ctx context.Context, orderID uuid.UUID) (*models.Order, []models.PaymentMethodOption, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, errors.New("order not found")
+	}
+	rules, err := s.paymentRuleRepo.GetActive(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load payment method rules: %v", err)
+	}
+
+	now := time.Now()
+	options := make([]models.PaymentMethodOption, 0, len(offeredMethods))
+	for _, method := range offeredMethods {
+		options = append(options, evaluatePaymentMethod(rules, order, method, now))
+	}
+	return order, options, nil
+}
+
+// CheckMethods returns an error naming the first method the order may not be paid with.
+// The order needs its User loaded.
+func (s *paymentRuleService) CheckMethods(ctx context.Context, order *models.Order, methods []string) error {
+	rules, err := s.paymentRuleRepo.GetActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load payment method rules: %v", err)
+	}
+
+	now := time.Now()
+	for _, method := range methods {
+		if option := evaluatePaymentMethod(rules, order, method, now); !option.Available {
+			return fmt.Errorf("%s is not available for this order: %s", method, strings.Join(option.Reasons, "; "))
+		}
+	}
+	return nil
+}
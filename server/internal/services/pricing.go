@@ -0,0 +1,30 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"context"
+)
+
+// priceOrder reprices an order from its items: promotions first, then the
+// coupon if one is given, then tax and charges. It returns the coupon discount.
+func priceOrder(ctx context.Context, promotions *PromotionEngine, taxCalc *TaxCalculator, order *models.Order, coupon *models.Coupon) (float64, error) {
+	order.Discounts = nil
+	for i := range order.Items {
+		order.Items[i].DiscountAmount = 0
+	}
+
+	if err := promotions.Apply(ctx, order); err != nil {
+		return 0, err
+	}
+
+	var couponDiscount float64
+	if coupon != nil {
+		var err error
+		if couponDiscount, err = applyCouponDiscount(order, coupon); err != nil {
+			return 0, err
+		}
+	}
+
+	taxCalc.Apply(order)
+	return couponDiscount, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/utils"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PromotionEngine applies the best combination of active promotions to an order.
+//
+// Stackable promotions are applied together, in priority order, each on the
+// amounts left by the ones before it. Every subset of them is tried, since a
+// promotion can cost more than it saves: a percentage off can take the order
+// below another promotion's MinOrderValue, such as free delivery's. Every
+// other promotion is exclusive and is tried on its own. The candidate saving
+// the customer the most wins; on a tie the larger stackable set wins, then the
+// exclusive promotion with the highest priority.
+type PromotionEngine struct {
+	promotionRepo  repositories.PromotionRepository
+	deliveryCharge float64
+}
+
+func NewPromotionEngine(promotionRepo repositories.PromotionRepository, taxCfg config.TaxConfig) *PromotionEngine {
+	return &PromotionEngine{promotionRepo: promotionRepo, deliveryCharge: taxCfg.DeliveryCharge}
+}
+
+// promotionEffect is what one promotion takes off an order
+type promotionEffect struct {
+	promotion *models.Promotion
+	items     map[int]float64 // Discount by item index
+	delivery  float64
+	detail    string
+}
+
+func (e *promotionEffect) total() float64 {
+	total := e.delivery
+	for _, amount := range e.items {
+		total += amount
+	}
+	return utils.RoundMoney(total)
+}
+
+// Apply adds item discounts and a discount line for each promotion chosen for
+// the order. Items need their Book loaded; existing discounts are kept and
+// promotions are evaluated on what remains.
+func (e *PromotionEngine) Apply(ctx context.Context, order *models.Order) error {
+	promotions, err := e.promotionRepo.GetActive(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load promotions: %v", err)
+	}
+
+	for _, effect := range e.best(order, promotions) {
+		for i, amount := range effect.items {
+			order.Items[i].DiscountAmount = utils.RoundMoney(order.Items[i].DiscountAmount + amount)
+		}
+
+		promotionID := effect.promotion.ID
+		description := effect.promotion.Name + ": " + effect.detail
+		if amount := utils.RoundMoney(effect.total() - effect.delivery); amount > 0 {
+			order.Discounts = append(order.Discounts, models.OrderDiscount{
+				Type:        models.DiscountTypePromotion,
+				PromotionID: &promotionID,
+				Description: description,
+				Amount:      amount,
+			})
+		}
+		if effect.delivery > 0 {
+			order.Discounts = append(order.Discounts, models.OrderDiscount{
+				Type:        models.DiscountTypeDelivery,
+				PromotionID: &promotionID,
+				Description: description,
+				Amount:      effect.delivery,
+			})
+		}
+	}
+	return nil
+}
+
+// best picks the combination of promotions that saves the most
+func (e *PromotionEngine) best(order *models.Order, promotions []models.Promotion) []*promotionEffect {
+	sort.SliceStable(promotions, func(i, j int) bool {
+		if promotions[i].Priority != promotions[j].Priority {
+			return promotions[i].Priority > promotions[j].Priority
+		}
+		return promotions[i].ID.String() < promotions[j].ID.String()
+	})
+
+	var stackable, exclusive []*models.Promotion
+	for i := range promotions {
+		if promotions[i].Stackable {
+			stackable = append(stackable, &promotions[i])
+		} else {
+			exclusive = append(exclusive, &promotions[i])
+		}
+	}
+	candidates := stackableSubsets(stackable)
+	for _, promotion := range exclusive {
+		candidates = append(candidates, []*models.Promotion{promotion})
+	}
+
+	var best []*promotionEffect
+	var bestSaving float64
+	for _, candidate := range candidates {
+		effects := e.evaluate(order, candidate)
+		var saving float64
+		for _, effect := range effects {
+			saving += effect.total()
+		}
+		if utils.RoundMoney(saving) > bestSaving {
+			best, bestSaving = effects, utils.RoundMoney(saving)
+		}
+	}
+	return best
+}
+
+// maxStackableSearch caps the stackable promotions whose subsets are searched.
+// Beyond it only the highest priority ones are considered.
+const maxStackableSearch = 10
+
+// stackableSubsets lists every non-empty subset of the stackable promotions,
+// each in priority order, larger sets first so they win ties
+func stackableSubsets(stackable []*models.Promotion) [][]*models.Promotion {
+	if len(stackable) > maxStackableSearch {
+		stackable = stackable[:maxStackableSearch]
+	}
+	var subsets [][]*models.Promotion
+	for mask := 1<<len(stackable) - 1; mask > 0; mask-- {
+		var subset []*models.Promotion
+		for i, promotion := range stackable {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, promotion)
+			}
+		}
+		subsets = append(subsets, subset)
+	}
+	sort.SliceStable(subsets, func(i, j int) bool { return len(subsets[i]) > len(subsets[j]) })
+	return subsets
+}
+
+// evaluate applies promotions in order and returns the effect of each that saved anything
+func (e *PromotionEngine) evaluate(order *models.Order, promotions []*models.Promotion) []*promotionEffect {
+	remaining := make([]float64, len(order.Items))
+	for i, item := range order.Items {
+		remaining[i] = utils.RoundMoney(item.Price*float64(item.Quantity) - item.DiscountAmount)
+	}
+	delivery := e.deliveryCharge
+
+	var effects []*promotionEffect
+	for _, promotion := range promotions {
+		var orderValue float64
+		for _, amount := range remaining {
+			orderValue += amount
+		}
+		if orderValue < promotion.MinOrderValue {
+			continue
+		}
+
+		effect := e.evaluateOne(order, promotion, remaining, delivery)
+		if effect == nil || effect.total() <= 0 {
+			continue
+		}
+		for i, amount := range effect.items {
+			remaining[i] = utils.RoundMoney(remaining[i] - amount)
+		}
+		delivery -= effect.delivery
+		effects = append(effects, effect)
+	}
+	return effects
+}
+
+func (e *PromotionEngine) evaluateOne(order *models.Order, promotion *models.Promotion, remaining []float64, delivery float64) *promotionEffect {
+	effect := &promotionEffect{promotion: promotion, items: map[int]float64{}}
+
+	switch promotion.Type {
+	case models.PromotionTypeCategoryPercent, models.PromotionTypeAuthorPercent:
+		var eligible []int
+		var units int
+		for i, item := range order.Items {
+			if promotionCoversItem(promotion, &item) && remaining[i] > 0 {
+				eligible = append(eligible, i)
+				units += item.Quantity
+			}
+		}
+		if len(eligible) == 0 || units < promotion.MinQuantity {
+			return nil
+		}
+		for _, i := range eligible {
+			effect.items[i] = utils.RoundMoney(remaining[i] * promotion.Percentage / 100)
+		}
+		effect.detail = fmt.Sprintf("%g%% off %d eligible book(s)", promotion.Percentage, units)
+
+	case models.PromotionTypeBuyXGetY:
+		group := promotion.BuyQuantity + promotion.FreeQuantity
+		if promotion.BuyQuantity <= 0 || promotion.FreeQuantity <= 0 {
+			return nil
+		}
+
+		// The cheapest units in scope go free
+		type unit struct {
+			item  int
+			price float64
+		}
+		var units []unit
+		for i, item := range order.Items {
+			if !promotionCoversItem(promotion, &item) || remaining[i] <= 0 {
+				continue
+			}
+			for n := 0; n < item.Quantity; n++ {
+				units = append(units, unit{item: i, price: remaining[i] / float64(item.Quantity)})
+			}
+		}
+		free := len(units) / group * promotion.FreeQuantity
+		if free == 0 {
+			return nil
+		}
+		sort.SliceStable(units, func(i, j int) bool { return units[i].price < units[j].price })
+		for _, u := range units[:free] {
+			effect.items[u.item] += u.price
+		}
+		for i, amount := range effect.items {
+			effect.items[i] = min(utils.RoundMoney(amount), remaining[i])
+		}
+		effect.detail = fmt.Sprintf("buy %d get %d free, %d free book(s)", promotion.BuyQuantity, promotion.FreeQuantity, free)
+
+	case models.PromotionTypeFreeDelivery:
+		if delivery <= 0 {
+			return nil
+		}
+		effect.delivery = utils.RoundMoney(delivery)
+		effect.detail = fmt.Sprintf("delivery charge of Rs. %.2f waived", effect.delivery)
+
+	default:
+		return nil
+	}
+	return effect
+}
+
+func promotionCoversItem(promotion *models.Promotion, item *models.OrderItem) bool {
+	if item.Book.ProductType == models.ProductTypeGiftCard {
+		return false
+	}
+	switch promotion.Type {
+	case models.PromotionTypeAuthorPercent:
+		return strings.EqualFold(strings.TrimSpace(item.Book.Author), strings.TrimSpace(promotion.Author))
+	default:
+		return promotion.CategoryID == nil || *promotion.CategoryID == item.Book.CategoryID
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPromotionEngineBest(t *testing.T) {
+	fiction := uuid.New()
+	percent := func(pct float64, priority int) models.Promotion {
+		return models.Promotion{ID: uuid.New(), Name: "Fiction sale", Type: models.PromotionTypeCategoryPercent,
+			CategoryID: &fiction, Percentage: pct, Stackable: true, Priority: priority}
+	}
+	freeDelivery := func(minOrder float64, stackable bool) models.Promotion {
+		return models.Promotion{ID: uuid.New(), Name: "Free delivery", Type: models.PromotionTypeFreeDelivery,
+			MinOrderValue: minOrder, Stackable: stackable}
+	}
+
+	tests := []struct {
+		name       string
+		subtotal   float64
+		promotions []models.Promotion
+		want       []string // promotion types applied, in order
+		wantSaving float64
+	}{
+		{
+			// 5% off 1020 leaves 969, under the free delivery threshold:
+			// delivery alone (100) saves more than the percentage (51)
+			name:       "percent off pushes the order under the free delivery threshold",
+			subtotal:   1020,
+			promotions: []models.Promotion{percent(5, 2), freeDelivery(1000, true)},
+			want:       []string{models.PromotionTypeFreeDelivery},
+			wantSaving: 100,
+		},
+		{
+			name:       "both apply when the order stays over the threshold",
+			subtotal:   1200,
+			promotions: []models.Promotion{percent(5, 2), freeDelivery(1000, true)},
+			want:       []string{models.PromotionTypeCategoryPercent, models.PromotionTypeFreeDelivery},
+			wantSaving: 160,
+		},
+		{
+			name:       "percentage wins when it saves more than delivery",
+			subtotal:   1020,
+			promotions: []models.Promotion{percent(20, 2), freeDelivery(1000, true)},
+			want:       []string{models.PromotionTypeCategoryPercent},
+			wantSaving: 204,
+		},
+		{
+			name:       "exclusive promotion beats a smaller stackable saving",
+			subtotal:   1020,
+			promotions: []models.Promotion{percent(5, 2), freeDelivery(0, false)},
+			want:       []string{models.PromotionTypeFreeDelivery},
+			wantSaving: 100,
+		},
+		{
+			name:       "nothing applies",
+			subtotal:   500,
+			promotions: []models.Promotion{freeDelivery(1000, true)},
+			want:       nil,
+			wantSaving: 0,
+		},
+	}
+
+	engine := &PromotionEngine{deliveryCharge: 100}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.Order{Items: []models.OrderItem{{
+				Quantity: 1,
+				Price:    tt.subtotal,
+				Book:     models.Book{CategoryID: fiction, ProductType: models.ProductTypeBook},
+			}}}
+
+			effects := engine.best(order, tt.promotions)
+
+			var got []string
+			var saving float64
+			for _, effect := range effects {
+				got = append(got, effect.promotion.Type)
+				saving += effect.total()
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("applied %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("applied %v, want %v", got, tt.want)
+				}
+			}
+			if saving != tt.wantSaving {
+				t.Errorf("saving = %.2f, want %.2f", saving, tt.wantSaving)
+			}
+		})
+	}
+}
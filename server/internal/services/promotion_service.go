@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type PromotionService interface {
+	CreatePromotion(ctx context.Context, req *models.PromotionRequest) (*models.Promotion, error)
+	GetAllPromotions(ctx context.Context) ([]models.Promotion, error)
+	GetPromotionByID(ctx context.Context, id uuid.UUID) (*models.Promotion, error)
+	UpdatePromotion(ctx context.Context, id uuid.UUID, req *models.PromotionRequest) (*models.Promotion, error)
+	DeletePromotion(ctx context.Context, id uuid.UUID) error
+}
+
+type promotionService struct {
+	promotionRepo repositories.PromotionRepository
+}
+
+func NewPromotionService(promotionRepo repositories.PromotionRepository) PromotionService {
+	return &promotionService{promotionRepo: promotionRepo}
+}
+
+// promotionFromRequest checks that the rule has the fields its type needs
+func promotionFromRequest(req *models.PromotionRequest) (*models.Promotion, error) {
+	switch req.Type {
+	case models.PromotionTypeCategoryPercent:
+		if req.CategoryID == nil {
+			return nil, errors.New("category_id is required for a category promotion")
+		}
+		if req.Percentage <= 0 {
+			return nil, errors.New("percentage must be greater than zero")
+		}
+	case models.PromotionTypeAuthorPercent:
+		if strings.TrimSpace(req.Author) == "" {
+			return nil, errors.New("author is required for an author promotion")
+		}
+		if req.Percentage <= 0 {
+			return nil, errors.New("percentage must be greater than zero")
+		}
+	case models.PromotionTypeBuyXGetY:
+		if req.BuyQuantity <= 0 || req.FreeQuantity <= 0 {
+			return nil, errors.New("buy_quantity and free_quantity must be greater than zero")
+		}
+	}
+	if req.StartsAt != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.StartsAt) {
+		return nil, errors.New("expires_at must be after starts_at")
+	}
+
+	return &models.Promotion{
+		Name:          req.Name,
+		Description:   req.Description,
+		Type:          req.Type,
+		CategoryID:    req.CategoryID,
+		Author:        strings.TrimSpace(req.Author),
+		Percentage:    req.Percentage,
+		MinQuantity:   req.MinQuantity,
+		BuyQuantity:   req.BuyQuantity,
+		FreeQuantity:  req.FreeQuantity,
+		MinOrderValue: req.MinOrderValue,
+		Stackable:     req.Stackable,
+		Priority:      req.Priority,
+		StartsAt:      req.StartsAt,
+		ExpiresAt:     req.ExpiresAt,
+		Active:        req.Active == nil || *req.Active,
+	}, nil
+}
+
+func (s *promotionService) CreatePromotion(ctx context.Context, req *models.PromotionRequest) (*models.Promotion, error) {
+	promotion, err := promotionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.promotionRepo.Create(ctx, promotion)
+}
+
+func (s *promotionService) GetAllPromotions(ctx context.Context) ([]models.Promotion, error) {
+	return s.promotionRepo.GetAll(ctx)
+}
+
+func (s *promotionService) GetPromotionByID(ctx context.Context, id uuid.UUID) (*models.Promotion, error) {
+	return s.promotionRepo.GetByID(ctx, id)
+}
+
+func (s *promotionService) UpdatePromotion(ctx context.Context, id uuid.UUID, req *models.PromotionRequest) (*models.Promotion, error) {
+	promotion, err := promotionFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.promotionRepo.Update(ctx, id, promotion)
+}
+
+func (s *promotionService) DeletePromotion(ctx context.Context, id uuid.UUID) error {
+	return s.promotionRepo.Delete(ctx, id)
+}
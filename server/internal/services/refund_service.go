@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefundService interface {
+	CreateRefund(ctx context.Context, transactionID uuid.UUID, req *models.CreateRefundRequest, adminID uuid.UUID) (*models.Refund, error)
+	GetTransactionRefunds(ctx context.Context, transactionID uuid.UUID) ([]models.Refund, error)
+	GetAllRefunds(ctx context.Context, status string) ([]models.Refund, error)
+	CompleteRefund(ctx context.Context, id uuid.UUID) (*models.Refund, error)
+}
+
+type refundService struct {
+	refundRepo      repositories.RefundRepository
+	transactionRepo repositories.TransactionRepository
+	ledger          LedgerService
+	walletCfg       config.WalletConfig
+}
+
+func NewRefundService(refundRepo repositories.RefundRepository, transactionRepo repositories.TransactionRepository, ledger LedgerService, walletCfg config.WalletConfig) RefundService {
+	return &refundService{
+		refundRepo:      refundRepo,
+		transactionRepo: transactionRepo,
+		ledger:          ledger,
+		walletCfg:       walletCfg,
+	}
+}
+
+// CreateRefund refunds part or all of a successful transaction. Store credit
+// refunds complete immediately; refunds to the original method stay PENDING
+// until the money has been sent back through the gateway.
+func (s *refundService) CreateRefund(ctx context.Context, transactionID uuid.UUID, req *models.CreateRefundRequest, adminID uuid.UUID) (*models.Refund, error) {
+	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+	if transaction.Status != models.TransactionStatusSuccess {
+		return nil, errors.New("only successful transactions can be refunded")
+	}
+
+	refund := &models.Refund{
+		TransactionID: transaction.ID,
+		OrderID:       transaction.OrderID,
+		UserID:        transaction.UserID,
+		Amount:        req.Amount,
+		Method:        req.Method,
+		Status:        models.RefundStatusPending,
+		Reason:        req.Reason,
+		CreatedBy:     &adminID,
+	}
+
+	var walletCredit *models.WalletEntry
+	if req.Method == models.RefundMethodStoreCredit {
+		now := time.Now()
+		refund.Status = models.RefundStatusCompleted
+		refund.CompletedAt = &now
+
+		walletCredit = &models.WalletEntry{
+			UserID:        transaction.UserID,
+			Source:        models.WalletSourceRefund,
+			Amount:        req.Amount,
+			TransactionID: &transaction.ID,
+			Description:   fmt.Sprintf("Refund for order %s", transaction.OrderID),
+			CreatedBy:     &adminID,
+		}
+		if s.walletCfg.CreditValidity > 0 {
+			expiresAt := now.Add(s.walletCfg.CreditValidity)
+			walletCredit.ExpiresAt = &expiresAt
+		}
+	}
+
+	created, err := s.refundRepo.Create(ctx, refund, walletCredit)
+	if err != nil {
+		return nil, err
+	}
+	if created.Status == models.RefundStatusCompleted {
+		s.postRefund(ctx, created)
+	}
+	return created, nil
+}
+
+// postRefund journals a completed refund. Failures are logged; the ledger
+// check lists refunds left unposted.
+func (s *refundService) postRefund(ctx context.Context, refund *models.Refund) {
+	if _, err := s.ledger.PostRefund(ctx, refund.ID); err != nil {
+		log.Printf("failed to post refund %s to the ledger: %v", refund.ID, err)
+	}
+}
+
+func (s *refundService) GetTransactionRefunds(ctx context.Context, transactionID uuid.UUID) ([]models.Refund, error) {
+	return s.refundRepo.GetByTransactionID(ctx, transactionID)
+}
+
+func (s *refundService) GetAllRefunds(ctx context.Context, status string) ([]models.Refund, error) {
+	return s.refundRepo.GetAll(ctx, status)
+}
+
+// CompleteRefund marks a refund to the original payment method as paid out
+func (s *refundService) CompleteRefund(ctx context.Context, id uuid.UUID) (*models.Refund, error) {
+	refund, err := s.refundRepo.Complete(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("pending refund not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.postRefund(ctx, refund)
+	return refund, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/bs"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ReportService interface {
+	SalesReport(ctx context.Context, from, to time.Time, groupBy, calendar string) ([]models.SalesReportRow, error)
+	SettlementReport(ctx context.Context, from, to time.Time, calendar string) ([]models.SettlementReportRow, error)
+}
+
+type reportService struct {
+	invoiceRepo    repositories.InvoiceRepository
+	settlementRepo repositories.SettlementRepository
+}
+
+func NewReportService(invoiceRepo repositories.InvoiceRepository, settlementRepo repositories.SettlementRepository) ReportService {
+	return &reportService{invoiceRepo: invoiceRepo, settlementRepo: settlementRepo}
+}
+
+// SalesReport totals invoiced sales in [from, to), grouped by month or fiscal year.
+// Credit notes reduce the period in which they were issued.
+func (s *reportService) SalesReport(ctx context.Context, from, to time.Time, groupBy, calendar string) ([]models.SalesReportRow, error) {
+	if groupBy != models.ReportGroupByMonth && groupBy != models.ReportGroupByFiscalYear {
+		return nil, errors.New("group_by must be month or fiscal_year")
+	}
+	if calendar != models.CalendarAD && calendar != models.CalendarBS {
+		return nil, errors.New("calendar must be ad or bs")
+	}
+
+	invoices, err := s.invoiceRepo.GetIssuedBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	creditNotes, err := s.invoiceRepo.GetCreditNotesBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[string]*models.SalesReportRow{}
+	rowFor := func(t time.Time) (*models.SalesReportRow, error) {
+		period, label, fiscalYear, err := reportPeriod(t, groupBy, calendar)
+		if err != nil {
+			return nil, err
+		}
+		row, ok := rows[period]
+		if !ok {
+			row = &models.SalesReportRow{Period: period, PeriodLabel: label, FiscalYear: fiscalYear}
+			rows[period] = row
+		}
+		return row, nil
+	}
+
+	for _, inv := range invoices {
+		row, err := rowFor(inv.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+		row.InvoiceCount++
+		row.TaxableAmount += inv.TaxableAmount
+		row.ExemptAmount += inv.ExemptAmount
+		row.TaxAmount += inv.TaxAmount
+		row.TotalAmount += inv.TotalAmount
+	}
+	for _, cn := range creditNotes {
+		row, err := rowFor(cn.IssuedAt)
+		if err != nil {
+			return nil, err
+		}
+		row.CreditNoteCount++
+		row.TaxableAmount -= cn.TaxableAmount
+		row.ExemptAmount -= cn.ExemptAmount
+		row.TaxAmount -= cn.TaxAmount
+		row.TotalAmount -= cn.TotalAmount
+	}
+
+	report := make([]models.SalesReportRow, 0, len(rows))
+	for _, row := range rows {
+		row.TaxableAmount = utils.RoundMoney(row.TaxableAmount)
+		row.ExemptAmount = utils.RoundMoney(row.ExemptAmount)
+		row.TaxAmount = utils.RoundMoney(row.TaxAmount)
+		row.TotalAmount = utils.RoundMoney(row.TotalAmount)
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Period < report[j].Period })
+	return report, nil
+}
+
+// reportPeriod returns the grouping key, display label and fiscal year for t
+func reportPeriod(t time.Time, groupBy, calendar string) (string, string, string, error) {
+	d, err := bs.FromAD(t)
+	if err != nil {
+		return "", "", "", err
+	}
+	fiscalYear := d.FiscalYear()
+
+	switch {
+	case groupBy == models.ReportGroupByFiscalYear:
+		return fiscalYear, "FY " + fiscalYear, fiscalYear, nil
+	case calendar == models.CalendarBS:
+		return fmt.Sprintf("%04d-%02d", d.Year, d.Month), fmt.Sprintf("%s %d", d.MonthName(), d.Year), fiscalYear, nil
+	default:
+		local := t.In(bs.Location())
+		return local.Format("2006-01"), local.Format("January 2006"), fiscalYear, nil
+	}
+}
+
+// SettlementReport totals gateway takings in [from, to) per day and gateway.
+// A split payment's fee belongs to its single gateway tender.
+func (s *reportService) SettlementReport(ctx context.Context, from, to time.Time, calendar string) ([]models.SettlementReportRow, error) {
+	if calendar != models.CalendarAD && calendar != models.CalendarBS {
+		return nil, errors.New("calendar must be ad or bs")
+	}
+
+	payments, err := s.settlementRepo.GatewayPayments(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.settlementRepo.GatewayRefunds(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[string]*models.SettlementReportRow{}
+	rowFor := func(t time.Time, gateway string) (*models.SettlementReportRow, error) {
+		date, err := reportDate(t, calendar)
+		if err != nil {
+			return nil, err
+		}
+		key := date + "|" + gateway
+		row, ok := rows[key]
+		if !ok {
+			row = &models.SettlementReportRow{Date: date, Gateway: gateway}
+			rows[key] = row
+		}
+		return row, nil
+	}
+
+	for _, payment := range payments {
+		row, err := rowFor(payment.PaidAt, payment.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		row.TransactionCount++
+		row.Gross += payment.Amount
+		row.Fees += payment.Fee
+		if payment.FeeSource != models.FeeSourceSettled {
+			row.UnsettledCount++
+		}
+	}
+	for _, refund := range refunds {
+		row, err := rowFor(refund.CompletedAt, refund.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		row.Refunds += refund.Amount
+	}
+
+	report := make([]models.SettlementReportRow, 0, len(rows))
+	for _, row := range rows {
+		row.Gross = utils.RoundMoney(row.Gross)
+		row.Fees = utils.RoundMoney(row.Fees)
+		row.Refunds = utils.RoundMoney(row.Refunds)
+		row.Net = utils.RoundMoney(row.Gross - row.Fees - row.Refunds)
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Date != report[j].Date {
+			return report[i].Date < report[j].Date
+		}
+		return report[i].Gateway < report[j].Gateway
+	})
+	return report, nil
+}
+
+// reportDate is t's Nepal date in the given calendar
+func reportDate(t time.Time, calendar string) (string, error) {
+	if calendar == models.CalendarBS {
+		d, err := bs.FromAD(t)
+		if err != nil {
+			return "", err
+		}
+		return d.String(), nil
+	}
+	return t.In(bs.Location()).Format("2006-01-02"), nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ErrRiskBlocked is returned when the fraud checks refuse a payment. The
+// signals behind it are kept out of the message and only shown to admins.
+var ErrRiskBlocked = errors.New("this payment could not be processed, please contact support")
+
+// Weights each risk signal adds to the score
+const (
+	riskWeightVelocity      = 40
+	riskWeightFailures      = 30
+	riskWeightUnusualAmount = 30
+	riskWeightNewAccount    = 40
+)
+
+type RiskService interface {
+	// Assess scores a payment attempt for the order, records the decision and returns it
+	Assess(ctx context.Context, stage string, order *models.Order, transactionID *uuid.UUID, amount float64, client models.ClientInfo) (*models.RiskAssessment, error)
+	GetAssessments(ctx context.Context, decision string, userID *uuid.UUID, from, to time.Time) ([]models.RiskAssessment, error)
+}
+
+type riskService struct {
+	riskRepo repositories.RiskRepository
+	cfg      config.RiskConfig
+}
+
+func NewRiskService(riskRepo repositories.RiskRepository, cfg config.RiskConfig) RiskService {
+	return &riskService{riskRepo: riskRepo, cfg: cfg}
+}
+
+// signals evaluates every risk signal for the attempt
+func (s *riskService) signals(ctx context.Context, order *models.Order, amount float64, client models.ClientInfo) ([]models.RiskSignal, error) {
+	var signals []models.RiskSignal
+	now := time.Now()
+
+	since := now.Add(-s.cfg.VelocityWindow)
+	attempts, err := s.riskRepo.CountUserAttempts(ctx, order.UserID, since)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.MaxUserAttempts > 0 && attempts >= int64(s.cfg.MaxUserAttempts) {
+		signals = append(signals, models.RiskSignal{
+			Code:   models.RiskSignalUserVelocity,
+			Score:  riskWeightVelocity,
+			Detail: fmt.Sprintf("%d payment attempts by this user in the last %s", attempts, s.cfg.VelocityWindow),
+		})
+	}
+	if client.IPAddress != "" && s.cfg.MaxIPAttempts > 0 {
+		attempts, err := s.riskRepo.CountIPAttempts(ctx, client.IPAddress, since)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= int64(s.cfg.MaxIPAttempts) {
+			signals = append(signals, models.RiskSignal{
+				Code:   models.RiskSignalIPVelocity,
+				Score:  riskWeightVelocity,
+				Detail: fmt.Sprintf("%d payment attempts from %s in the last %s", attempts, client.IPAddress, s.cfg.VelocityWindow),
+			})
+		}
+	}
+	if client.DeviceID != "" && s.cfg.MaxDeviceAttempts > 0 {
+		attempts, err := s.riskRepo.CountDeviceAttempts(ctx, client.DeviceID, since)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= int64(s.cfg.MaxDeviceAttempts) {
+			signals = append(signals, models.RiskSignal{
+				Code:   models.RiskSignalDeviceVelocity,
+				Score:  riskWeightVelocity,
+				Detail: fmt.Sprintf("%d payment attempts from this device in the last %s", attempts, s.cfg.VelocityWindow),
+			})
+		}
+	}
+
+	if s.cfg.MaxFailures > 0 {
+		failures, err := s.riskRepo.CountFailures(ctx, order.UserID, now.Add(-s.cfg.FailureWindow))
+		if err != nil {
+			return nil, err
+		}
+		if failures >= int64(s.cfg.MaxFailures) {
+			signals = append(signals, models.RiskSignal{
+				Code:   models.RiskSignalFailures,
+				Score:  riskWeightFailures,
+				Detail: fmt.Sprintf("%d failed payments in the last %s", failures, s.cfg.FailureWindow),
+			})
+		}
+	}
+
+	if s.cfg.AmountMultiple > 0 {
+		count, average, err := s.riskRepo.PaymentHistory(ctx, order.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= int64(s.cfg.MinHistory) && average > 0 && amount > average*s.cfg.AmountMultiple {
+			signals = append(signals, models.RiskSignal{
+				Code:   models.RiskSignalUnusualAmount,
+				Score:  riskWeightUnusualAmount,
+				Detail: fmt.Sprintf("Rs. %.2f is %.1f times the average of %d past payments", amount, amount/average, count),
+			})
+		}
+	}
+
+	if s.cfg.HighValueAmount > 0 && amount >= s.cfg.HighValueAmount &&
+		!order.User.CreatedAt.IsZero() && now.Sub(order.User.CreatedAt) < s.cfg.NewAccountAge {
+		signals = append(signals, models.RiskSignal{
+			Code:   models.RiskSignalNewAccount,
+			Score:  riskWeightNewAccount,
+			Detail: fmt.Sprintf("Rs. %.2f order from an account created %s ago", amount, now.Sub(order.User.CreatedAt).Round(time.Minute)),
+		})
+	}
+	return signals, nil
+}
+
+// decide maps a score onto the configured thresholds
+func (s *riskService) decide(score int) string {
+	switch {
+	case score >= s.cfg.BlockScore:
+		return models.RiskBlock
+	case score >= s.cfg.ReviewScore:
+		return models.RiskReview
+	default:
+		return models.RiskAllow
+	}
+}
+
+func (s *riskService) Assess(ctx context.Context, stage string, order *models.Order, transactionID *uuid.UUID, amount float64, client models.ClientInfo) (*models.RiskAssessment, error) {
+	assessment := &models.RiskAssessment{
+		Stage:         stage,
+		UserID:        order.UserID,
+		OrderID:       order.ID,
+		TransactionID: transactionID,
+		Amount:        amount,
+		IPAddress:     client.IPAddress,
+		DeviceID:      client.DeviceID,
+		Decision:      models.RiskAllow,
+	}
+	if !s.cfg.Enabled {
+		return assessment, nil
+	}
+
+	signals, err := s.signals(ctx, order, amount, client)
+	if err != nil {
+		return nil, fmt.Errorf("risk signals: %v", err)
+	}
+	for _, signal := range signals {
+		assessment.Score += signal.Score
+	}
+	assessment.Decision = s.decide(assessment.Score)
+	if len(signals) > 0 {
+		assessment.Signals = datatypes.NewJSONSlice(signals)
+	}
+
+	if _, err := s.riskRepo.Create(ctx, assessment); err != nil {
+		return nil, fmt.Errorf("failed to record risk assessment: %v", err)
+	}
+	if assessment.Decision != models.RiskAllow {
+		log.Printf("risk %s %s for order %s (user %s, ip %s): %s",
+			stage, assessment.Decision, order.ID, order.UserID, client.IPAddress, assessment.Summary())
+	}
+	return assessment, nil
+}
+
+func (s *riskService) GetAssessments(ctx context.Context, decision string, userID *uuid.UUID, from, to time.Time) ([]models.RiskAssessment, error) {
+	return s.riskRepo.GetAll(ctx, decision, userID, from, to)
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/bs"
+	"bookstore/pkg/esewa"
+	"bookstore/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SettlementService interface {
+	ImportEsewaStatement(ctx context.Context, fileName string, r io.Reader, adminID *uuid.UUID) (*models.SettlementImport, error)
+	GetImports(ctx context.Context) ([]models.SettlementImport, error)
+	GetImport(ctx context.Context, id uuid.UUID) (*models.SettlementImport, error)
+	GetReviewQueue(ctx context.Context) ([]models.Transaction, error)
+	ResolveReview(ctx context.Context, transactionID uuid.UUID, note string) error
+}
+
+type settlementService struct {
+	settlementRepo  repositories.SettlementRepository
+	transactionRepo repositories.TransactionRepository
+	fees            FeeService
+}
+
+func NewSettlementService(settlementRepo repositories.SettlementRepository, transactionRepo repositories.TransactionRepository, fees FeeService) SettlementService {
+	return &settlementService{
+		settlementRepo:  settlementRepo,
+		transactionRepo: transactionRepo,
+		fees:            fees,
+	}
+}
+
+// esewaAmount is the part of a transaction collected through eSewa
+func esewaAmount(transaction *models.Transaction) float64 {
+	if len(transaction.Tenders) == 0 {
+		return transaction.Amount
+	}
+	return transaction.TenderAmount(models.PaymentMethodEsewa)
+}
+
+// ImportEsewaStatement reconciles an eSewa settlement statement against our
+// transactions. Rows are matched by ref_id, falling back to the
+// transaction_uuid we sent. Matched rows have their fee recorded as settled;
+// successful eSewa payments from the statement period that eSewa does not
+// list, and every discrepancy with a local transaction, are flagged for review.
+func (s *settlementService) ImportEsewaStatement(ctx context.Context, fileName string, r io.Reader, adminID *uuid.UUID) (*models.SettlementImport, error) {
+	rows, err := esewa.ParseStatement(r, bs.Location())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("statement has no payments")
+	}
+
+	// transaction_uuid is our reference, or our ID for older payments
+	var refIDs, refs []string
+	var ids []uuid.UUID
+	for _, row := range rows {
+		refIDs = append(refIDs, row.RefID)
+		if id, err := uuid.Parse(row.TransactionUUID); err == nil {
+			ids = append(ids, id)
+		} else if row.TransactionUUID != "" {
+			refs = append(refs, row.TransactionUUID)
+		}
+	}
+	transactions, err := s.settlementRepo.FindTransactions(ctx, refIDs, ids, refs)
+	if err != nil {
+		return nil, err
+	}
+	byRef := map[string]*models.Transaction{}
+	byUUID := map[string]*models.Transaction{}
+	for i := range transactions {
+		if transactions[i].TransactionID != "" {
+			byRef[transactions[i].TransactionID] = &transactions[i]
+		}
+		byUUID[transactions[i].ID.String()] = &transactions[i]
+		if transactions[i].Ref != nil {
+			byUUID[*transactions[i].Ref] = &transactions[i]
+		}
+	}
+
+	settlement := &models.SettlementImport{
+		Gateway:    models.PaymentMethodEsewa,
+		FileName:   fileName,
+		ImportedBy: adminID,
+		RowCount:   len(rows),
+	}
+	flags := map[uuid.UUID]string{}
+	seen := map[uuid.UUID]bool{}
+	var fees []models.GatewayFeeRequest
+
+	for _, row := range rows {
+		item := models.SettlementItem{
+			Line:          row.Line,
+			RefID:         row.RefID,
+			GatewayAmount: utils.RoundMoney(row.Amount),
+			Fee:           utils.RoundMoney(row.Fee),
+		}
+		if !row.Date.IsZero() {
+			paidAt := row.Date
+			item.PaidAt = &paidAt
+			if settlement.PeriodFrom == nil || paidAt.Before(*settlement.PeriodFrom) {
+				settlement.PeriodFrom = &paidAt
+			}
+			if settlement.PeriodTo == nil || paidAt.After(*settlement.PeriodTo) {
+				settlement.PeriodTo = &paidAt
+			}
+		}
+
+		if !row.Successful() {
+			item.Status = models.SettlementSkipped
+			item.Note = "Status " + row.Status
+			settlement.Skipped++
+			settlement.Items = append(settlement.Items, item)
+			continue
+		}
+		settlement.GrossAmount += item.GatewayAmount
+		settlement.FeeTotal += item.Fee
+
+		transaction := byRef[row.RefID]
+		if transaction == nil {
+			if id, err := uuid.Parse(row.TransactionUUID); err == nil {
+				transaction = byUUID[id.String()]
+			} else {
+				transaction = byUUID[row.TransactionUUID]
+			}
+		}
+
+		switch {
+		case transaction == nil:
+			item.Status = models.SettlementMissingLocally
+			item.Note = "No transaction with this reference"
+			settlement.MissingLocally++
+		case seen[transaction.ID]:
+			item.TransactionID = &transaction.ID
+			item.Status = models.SettlementDuplicate
+			item.Note = "Transaction already matched on another row"
+			flags[transaction.ID] = fmt.Sprintf("eSewa statement lists ref %s more than once", row.RefID)
+			settlement.Duplicates++
+		case transaction.Status != models.TransactionStatusSuccess:
+			item.TransactionID = &transaction.ID
+			item.LocalAmount = esewaAmount(transaction)
+			item.Status = models.SettlementMissingLocally
+			item.Note = "Local transaction is " + transaction.Status
+			flags[transaction.ID] = fmt.Sprintf("Paid at eSewa (ref %s) but %s locally", row.RefID, transaction.Status)
+			settlement.MissingLocally++
+		case utils.RoundMoney(esewaAmount(transaction)) != item.GatewayAmount:
+			item.TransactionID = &transaction.ID
+			item.LocalAmount = utils.RoundMoney(esewaAmount(transaction))
+			item.Status = models.SettlementAmountMismatch
+			item.Note = fmt.Sprintf("eSewa %.2f, local %.2f", item.GatewayAmount, item.LocalAmount)
+			flags[transaction.ID] = "Amount differs from eSewa statement: " + item.Note
+			settlement.AmountMismatches++
+		default:
+			item.TransactionID = &transaction.ID
+			item.LocalAmount = item.GatewayAmount
+			item.Status = models.SettlementMatched
+			settlement.Matched++
+			if item.Fee > 0 {
+				fees = append(fees, models.GatewayFeeRequest{
+					TransactionID: transaction.ID,
+					Amount:        item.Fee,
+					Reference:     "eSewa ref " + row.RefID,
+					SettledAt:     item.PaidAt,
+				})
+			}
+		}
+		if transaction != nil {
+			seen[transaction.ID] = true
+		}
+		settlement.Items = append(settlement.Items, item)
+	}
+
+	// Successful eSewa payments from the statement's days that no statement lists
+	if settlement.PeriodFrom != nil {
+		from := startOfDay(*settlement.PeriodFrom)
+		to := startOfDay(*settlement.PeriodTo).AddDate(0, 0, 1)
+		unsettled, err := s.settlementRepo.UnsettledTransactions(ctx, models.PaymentMethodEsewa, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for i := range unsettled {
+			transaction := &unsettled[i]
+			if seen[transaction.ID] {
+				continue
+			}
+			settlement.Items = append(settlement.Items, models.SettlementItem{
+				Status:        models.SettlementMissingAtGateway,
+				RefID:         transaction.TransactionID,
+				TransactionID: &transaction.ID,
+				LocalAmount:   utils.RoundMoney(esewaAmount(transaction)),
+				Note:          "Not on the eSewa statement",
+			})
+			flags[transaction.ID] = "Successful locally but missing from the eSewa statement"
+			settlement.MissingAtGateway++
+		}
+	}
+	settlement.GrossAmount = utils.RoundMoney(settlement.GrossAmount)
+	settlement.FeeTotal = utils.RoundMoney(settlement.FeeTotal)
+
+	created, err := s.settlementRepo.Create(ctx, settlement)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fees and review flags follow the saved report; failures are logged so
+	// one bad row does not lose the reconciliation
+	for i := range fees {
+		if _, err := s.fees.RecordSettledFee(ctx, &fees[i]); err != nil {
+			log.Printf("failed to post eSewa fee for transaction %s: %v", fees[i].TransactionID, err)
+		}
+	}
+	for id, reason := range flags {
+		if err := s.transactionRepo.SetReview(ctx, id, true, reason); err != nil {
+			log.Printf("failed to flag transaction %s for review: %v", id, err)
+		}
+	}
+	return created, nil
+}
+
+// startOfDay is midnight Nepal time on t's date
+func startOfDay(t time.Time) time.Time {
+	t = t.In(bs.Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, bs.Location())
+}
+
+func (s *settlementService) GetImports(ctx context.Context) ([]models.SettlementImport, error) {
+	return s.settlementRepo.GetAll(ctx)
+}
+
+func (s *settlementService) GetImport(ctx context.Context, id uuid.UUID) (*models.SettlementImport, error) {
+	return s.settlementRepo.GetByID(ctx, id)
+}
+
+// GetReviewQueue lists transactions flagged by reconciliation
+func (s *settlementService) GetReviewQueue(ctx context.Context) ([]models.Transaction, error) {
+	return s.transactionRepo.GetNeedingReview(ctx)
+}
+
+// ResolveReview clears a transaction's review flag, keeping the note as its reason
+func (s *settlementService) ResolveReview(ctx context.Context, transactionID uuid.UUID, note string) error {
+	err := s.transactionRepo.SetReview(ctx, transactionID, false, note)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("transaction not found")
+	}
+	return err
+}
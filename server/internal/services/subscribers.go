@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bookstore/internal/events"
+	"bookstore/internal/models"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SubscribePaymentOutbox registers the bookkeeping that follows a successful
+// payment: the invoice, gift cards bought with the order, the ledger entry and
+// the gateway fee. It runs from the outbox rather than the bus, so it is not
+// lost if the process stops after the payment is saved, and each consumer is
+// recorded separately so a retry only repeats the work that failed.
+func SubscribePaymentOutbox(relay OutboxRelay, transactions TransactionService, invoices InvoiceService, giftCards GiftCardService, ledger LedgerService, fees FeeService) {
+	succeeded := func(consumer string, fn func(ctx context.Context, transaction *models.Transaction) error) {
+		relay.Subscribe(models.EventTransactionSucceeded, consumer, func(ctx context.Context, event *models.OutboxEvent) error {
+			transaction, err := transactions.GetTransactionByID(ctx, event.AggregateID)
+			if err != nil {
+				return fmt.Errorf("load transaction %s: %v", event.AggregateID, err)
+			}
+			return fn(ctx, transaction)
+		})
+	}
+
+	succeeded("invoice", func(ctx context.Context, transaction *models.Transaction) error {
+		if _, err := invoices.IssueForTransaction(ctx, transaction); err != nil {
+			return fmt.Errorf("issue invoice for transaction %s: %v", transaction.ID, err)
+		}
+		return nil
+	})
+	succeeded("gift-cards", func(ctx context.Context, transaction *models.Transaction) error {
+		if _, err := giftCards.IssueForOrder(ctx, &transaction.Order); err != nil {
+			return fmt.Errorf("issue gift cards for order %s: %v", transaction.OrderID, err)
+		}
+		return nil
+	})
+	succeeded("ledger", func(ctx context.Context, transaction *models.Transaction) error {
+		if _, err := ledger.PostTransaction(ctx, transaction.ID); err != nil {
+			return fmt.Errorf("post transaction %s to the ledger: %v", transaction.ID, err)
+		}
+		return nil
+	})
+	succeeded("fees", func(ctx context.Context, transaction *models.Transaction) error {
+		if err := fees.ApplyComputedFee(ctx, transaction); err != nil {
+			return fmt.Errorf("compute gateway fee for transaction %s: %v", transaction.ID, err)
+		}
+		return nil
+	})
+}
+
+// SubscribePaymentEffects registers the work that follows a failed payment or
+// an order cancellation. Like SubscribePaymentOutbox it runs from the outbox,
+// so balance held by a payment is returned even if the process stops right
+// after the payment fails.
+func SubscribePaymentEffects(relay OutboxRelay, wallets WalletService, giftCards GiftCardService, transactions TransactionService) {
+	// The event is only written when a transaction leaves PENDING, so the
+	// balance it took is returned; a settled payment is only ever reversed
+	// through a refund
+	failed := func(consumer string, fn func(ctx context.Context, transactionID uuid.UUID) error) {
+		for _, eventType := range []string{models.EventTransactionFailed, models.EventTransactionCancelled} {
+			relay.Subscribe(eventType, consumer, func(ctx context.Context, event *models.OutboxEvent) error {
+				return fn(ctx, event.AggregateID)
+			})
+		}
+	}
+
+	failed("store-credit", func(ctx context.Context, transactionID uuid.UUID) error {
+		if err := wallets.ReverseTransactionDebits(ctx, transactionID); err != nil {
+			return fmt.Errorf("return store credit for transaction %s: %v", transactionID, err)
+		}
+		return nil
+	})
+	failed("gift-cards", func(ctx context.Context, transactionID uuid.UUID) error {
+		if err := giftCards.ReverseTransactionRedemptions(ctx, transactionID); err != nil {
+			return fmt.Errorf("return gift card balance for transaction %s: %v", transactionID, err)
+		}
+		return nil
+	})
+
+	// A cancelled order's unpaid transaction is cancelled with it, which in
+	// turn returns any store credit or gift card balance it held
+	relay.Subscribe(models.EventOrderCancelled, "transactions", func(ctx context.Context, event *models.OutboxEvent) error {
+		if err := transactions.CancelPendingPayment(ctx, event.AggregateID); err != nil {
+			return fmt.Errorf("cancel payment for order %s: %v", event.AggregateID, err)
+		}
+		return nil
+	})
+}
+
+// SubscribeStatusFeed forwards order and payment events to the live status
+// streams: the owner's order and transaction streams and the admin feed.
+func SubscribeStatusFeed(bus *events.Bus, feed *events.Broadcaster) {
+	events.SubscribeAsync(bus, "status-feed", func(ctx context.Context, e events.OrderCreated) error {
+		return feed.Publish(e.EventName(), models.OrderStatusUpdate(e.EventName(), e.Order),
+			models.StatusTopicAdmin, models.OrderStatusTopic(e.Order.ID))
+	})
+	events.SubscribeAsync(bus, "status-feed", func(ctx context.Context, e events.OrderCancelled) error {
+		return feed.Publish(e.EventName(), models.OrderStatusUpdate(e.EventName(), e.Order),
+			models.StatusTopicAdmin, models.OrderStatusTopic(e.Order.ID))
+	})
+	events.SubscribeAsync(bus, "status-feed", func(ctx context.Context, e events.PaymentSucceeded) error {
+		return feed.Publish(e.EventName(), models.TransactionStatusUpdate(e.EventName(), e.Transaction),
+			models.StatusTopicAdmin, models.OrderStatusTopic(e.Transaction.OrderID), models.TransactionStatusTopic(e.Transaction.ID))
+	})
+	events.SubscribeAsync(bus, "status-feed", func(ctx context.Context, e events.PaymentFailed) error {
+		return feed.Publish(e.EventName(), models.TransactionStatusUpdate(e.EventName(), e.Transaction),
+			models.StatusTopicAdmin, models.OrderStatusTopic(e.Transaction.OrderID), models.TransactionStatusTopic(e.Transaction.ID))
+	})
+}
+
+// SubscribePaymentLinks closes an order's payment links once it is paid,
+// whether or not the payment came through a link
+func SubscribePaymentLinks(bus *events.Bus, links PaymentLinkService) {
+	events.Subscribe(bus, "payment-links", func(ctx context.Context, e events.PaymentSucceeded) error {
+		if err := links.MarkPaid(ctx, e.Transaction); err != nil {
+			return fmt.Errorf("close payment links for order %s: %v", e.Transaction.OrderID, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/pkg/utils"
+	"strings"
+)
+
+// TaxCalculator computes VAT, service charge and delivery charge for orders
+type TaxCalculator struct {
+	cfg config.TaxConfig
+}
+
+func NewTaxCalculator(cfg config.TaxConfig) *TaxCalculator {
+	return &TaxCalculator{cfg: cfg}
+}
+
+// IsExempt reports whether a book falls in a VAT-exempt category.
+// The book's Category must be loaded for name matching. Gift cards are
+// exempt when sold; VAT is charged on the goods they are spent on.
+func (t *TaxCalculator) IsExempt(book *models.Book) bool {
+	if book.ProductType == models.ProductTypeGiftCard {
+		return true
+	}
+	for _, exempt := range t.cfg.ExemptCategories {
+		if strings.EqualFold(exempt, book.Category.Name) || exempt == book.CategoryID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply fills in item tax and every bill component on the order from
+// item Price, Quantity, DiscountAmount and TaxExempt. Discounts reduce the
+// line amount before tax; DELIVERY discount lines reduce the delivery charge.
+func (t *TaxCalculator) Apply(order *models.Order) {
+	var subTotal, discountTotal, taxable, exempt, tax float64
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		grossAmount := utils.RoundMoney(item.Price * float64(item.Quantity))
+		subTotal += grossAmount
+		discountTotal += item.DiscountAmount
+		lineAmount := utils.RoundMoney(grossAmount - item.DiscountAmount)
+
+		if item.TaxExempt || t.cfg.VATRate <= 0 {
+			item.TaxAmount = 0
+			exempt += lineAmount
+			continue
+		}
+
+		net := lineAmount
+		if t.cfg.PricesIncludeVAT {
+			net = utils.RoundMoney(lineAmount / (1 + t.cfg.VATRate))
+			item.TaxAmount = utils.RoundMoney(lineAmount - net)
+		} else {
+			item.TaxAmount = utils.RoundMoney(net * t.cfg.VATRate)
+		}
+		taxable += net
+		tax += item.TaxAmount
+	}
+
+	order.SubTotal = utils.RoundMoney(subTotal)
+	order.DiscountTotal = utils.RoundMoney(discountTotal)
+	order.TaxableAmount = utils.RoundMoney(taxable)
+	order.ExemptAmount = utils.RoundMoney(exempt)
+	order.TaxAmount = utils.RoundMoney(tax)
+	order.ServiceCharge = utils.RoundMoney((order.TaxableAmount + order.ExemptAmount) * t.cfg.ServiceChargeRate)
+	order.DeliveryCharge = t.cfg.DeliveryCharge
+	for _, discount := range order.Discounts {
+		if discount.Type == models.DiscountTypeDelivery {
+			order.DeliveryCharge -= discount.Amount
+		}
+	}
+	order.DeliveryCharge = utils.RoundMoney(max(order.DeliveryCharge, 0))
+	order.TotalPrice = utils.RoundMoney(order.TaxableAmount + order.ExemptAmount + order.TaxAmount +
+		order.ServiceCharge + order.DeliveryCharge)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// orderTotals are the bill components Apply fills in
+type orderTotals struct {
+	SubTotal, DiscountTotal, TaxableAmount, ExemptAmount, TaxAmount float64
+	ServiceCharge, DeliveryCharge, TotalPrice                       float64
+}
+
+func TestTaxCalculatorApply(t *testing.T) {
+	vat := config.TaxConfig{VATRate: 0.13}
+
+	tests := []struct {
+		name      string
+		cfg       config.TaxConfig
+		items     []models.OrderItem
+		discounts []models.OrderDiscount
+		want      orderTotals
+		wantTax   []float64 // per item
+	}{
+		{
+			name: "VAT added on top, exempt line and charges",
+			cfg:  config.TaxConfig{VATRate: 0.13, ServiceChargeRate: 0.02, DeliveryCharge: 100},
+			items: []models.OrderItem{
+				{Price: 500, Quantity: 2},
+				{Price: 300, Quantity: 1, TaxExempt: true},
+			},
+			want: orderTotals{SubTotal: 1300, TaxableAmount: 1000, ExemptAmount: 300, TaxAmount: 130,
+				ServiceCharge: 26, DeliveryCharge: 100, TotalPrice: 1556},
+			wantTax: []float64{130, 0},
+		},
+		{
+			name:    "prices include VAT",
+			cfg:     config.TaxConfig{VATRate: 0.13, PricesIncludeVAT: true},
+			items:   []models.OrderItem{{Price: 1130, Quantity: 1}},
+			want:    orderTotals{SubTotal: 1130, TaxableAmount: 1000, TaxAmount: 130, TotalPrice: 1130},
+			wantTax: []float64{130},
+		},
+		{
+			name:  "discount reduces the line before tax",
+			cfg:   vat,
+			items: []models.OrderItem{{Price: 1000, Quantity: 1, DiscountAmount: 100}},
+			want: orderTotals{SubTotal: 1000, DiscountTotal: 100, TaxableAmount: 900, TaxAmount: 117,
+				TotalPrice: 1017},
+			wantTax: []float64{117},
+		},
+		{
+			name:      "delivery discount never makes delivery negative",
+			cfg:       config.TaxConfig{VATRate: 0.13, DeliveryCharge: 100},
+			items:     []models.OrderItem{{Price: 200, Quantity: 1}},
+			discounts: []models.OrderDiscount{{Type: models.DiscountTypeDelivery, Amount: 150}},
+			want:      orderTotals{SubTotal: 200, TaxableAmount: 200, TaxAmount: 26, TotalPrice: 226},
+			wantTax:   []float64{26},
+		},
+		{
+			name:    "zero VAT rate treats everything as exempt",
+			cfg:     config.TaxConfig{},
+			items:   []models.OrderItem{{Price: 250, Quantity: 2}},
+			want:    orderTotals{SubTotal: 500, ExemptAmount: 500, TotalPrice: 500},
+			wantTax: []float64{0},
+		},
+		{
+			name:    "item tax is rounded per line",
+			cfg:     vat,
+			items:   []models.OrderItem{{Price: 33.33, Quantity: 3}, {Price: 0.5, Quantity: 1}},
+			want:    orderTotals{SubTotal: 100.49, TaxableAmount: 100.49, TaxAmount: 13.07, TotalPrice: 113.56},
+			wantTax: []float64{13, 0.07},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.Order{Items: tt.items, Discounts: tt.discounts}
+			NewTaxCalculator(tt.cfg).Apply(order)
+
+			got := orderTotals{
+				SubTotal:       order.SubTotal,
+				DiscountTotal:  order.DiscountTotal,
+				TaxableAmount:  order.TaxableAmount,
+				ExemptAmount:   order.ExemptAmount,
+				TaxAmount:      order.TaxAmount,
+				ServiceCharge:  order.ServiceCharge,
+				DeliveryCharge: order.DeliveryCharge,
+				TotalPrice:     order.TotalPrice,
+			}
+			if got != tt.want {
+				t.Errorf("totals = %+v\nwant %+v", got, tt.want)
+			}
+			for i, want := range tt.wantTax {
+				if order.Items[i].TaxAmount != want {
+					t.Errorf("item %d tax = %v, want %v", i, order.Items[i].TaxAmount, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTaxCalculatorIsExempt(t *testing.T) {
+	textbooks := uuid.New()
+	calc := NewTaxCalculator(config.TaxConfig{ExemptCategories: []string{"Educational", textbooks.String()}})
+
+	tests := []struct {
+		name string
+		book models.Book
+		want bool
+	}{
+		{"category name, any case", models.Book{Category: models.Category{Name: "educational"}}, true},
+		{"category ID", models.Book{CategoryID: textbooks}, true},
+		{"gift card", models.Book{ProductType: models.ProductTypeGiftCard}, true},
+		{"taxable category", models.Book{CategoryID: uuid.New(), Category: models.Category{Name: "Fiction"}}, false},
+	}
+	for _, tt := range tests {
+		if got := calc.IsExempt(&tt.book); got != tt.want {
+			t.Errorf("%s: IsExempt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,44 +1,166 @@
 package services
 
 import (
+	"bookstore/config"
+	"bookstore/internal/events"
 	"bookstore/internal/models"
 	"bookstore/internal/repositories"
+	"bookstore/pkg/esewa"
+	"bookstore/pkg/utils"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type TransactionService interface {
-	CreateTransaction(ctx context.Context, req *models.CreateTransactionRequest, userID uuid.UUID) (*models.Transaction, error)
-	GetAllTransactions(ctx context.Context) ([]models.Transaction, error)
+	CreateTransaction(ctx context.Context, req *models.CreateTransactionRequest, userID uuid.UUID, client models.ClientInfo) (*models.Transaction, error)
+	// ListTransactions returns a page of transactions matching q, newest
+	// first unless q.Sort says otherwise, with totals over the whole filter
+	ListTransactions(ctx context.Context, q *models.TransactionListQuery) (*models.TransactionPage, error)
 	GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
 	GetUserTransactions(ctx context.Context, userID uuid.UUID) ([]models.Transaction, error)
 	GetTransactionByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Transaction, error)
 	UpdateTransactionStatus(ctx context.Context, id uuid.UUID, req *models.TransactionUpdateRequest) (*models.Transaction, error)
 	InitiateEsewaPayment(ctx context.Context, transactionID uuid.UUID, esewaReq *models.EsewaPaymentRequest) (*models.Transaction, error)
-	VerifyEsewaPayment(ctx context.Context, esewaResponse *models.EsewaResponseData) (*models.Transaction, error)
+	VerifyEsewaPayment(ctx context.Context, esewaResponse *models.EsewaResponseData, userID uuid.UUID, client models.ClientInfo) (*models.Transaction, error)
 	DeleteTransaction(ctx context.Context, id uuid.UUID) error
+	// CancelPendingPayment cancels the order's transaction if it is still PENDING
+	CancelPendingPayment(ctx context.Context, orderID uuid.UUID) error
+	// CheckPendingEsewa settles pending eSewa payments from eSewa's status API
+	CheckPendingEsewa(ctx context.Context) (int, error)
+	Run(ctx context.Context)
 }
 
 type transactionService struct {
 	transactionRepo repositories.TransactionRepository
-	orderRepo       repositories.OrderRepository
+	orders          *OrderService
+	giftCardService GiftCardService
+	paymentRules    PaymentRuleService
+	risk            RiskService
+	bus             *events.Bus
+	esewa           config.EsewaConfig
+	http            *http.Client
 }
 
-func NewTransactionService(transactionRepo repositories.TransactionRepository, orderRepo repositories.OrderRepository) TransactionService {
+// NewTransactionService builds the payment service. Work that follows a
+// payment is not called from here: invoices, the ledger and fees consume the
+// outbox events written with each status change, and returning store credit
+// subscribes to the bus.
+func NewTransactionService(transactionRepo repositories.TransactionRepository, orders *OrderService, giftCardService GiftCardService, paymentRules PaymentRuleService, risk RiskService, bus *events.Bus, esewaCfg config.EsewaConfig) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
-		orderRepo:       orderRepo,
+		orders:          orders,
+		giftCardService: giftCardService,
+		paymentRules:    paymentRules,
+		risk:            risk,
+		bus:             bus,
+		esewa:           esewaCfg,
+		http:            &http.Client{Timeout: 15 * time.Second},
 	}
 }
 
-func (s *transactionService) CreateTransaction(ctx context.Context, req *models.CreateTransactionRequest, userID uuid.UUID) (*models.Transaction, error) {
+// assessRisk runs the fraud checks. If they cannot run the payment goes
+// ahead but is held for review: a broken check must not stop every payment,
+// nor wave every payment through unseen.
+func (s *transactionService) assessRisk(ctx context.Context, stage string, order *models.Order, transactionID *uuid.UUID, amount float64, client models.ClientInfo) *models.RiskAssessment {
+	assessment, err := s.risk.Assess(ctx, stage, order, transactionID, amount, client)
+	if err != nil {
+		log.Printf("risk check for order %s failed, flagging for review: %v", order.ID, err)
+		return &models.RiskAssessment{
+			Stage:    stage,
+			Decision: models.RiskReview,
+			Signals:  []models.RiskSignal{{Code: models.RiskSignalUnavailable, Detail: err.Error()}},
+		}
+	}
+	return assessment
+}
+
+// onPaymentSucceeded announces a transaction that just moved to SUCCESS.
+// Subscriber failures are logged rather than returned: the payment itself has already been recorded.
+func (s *transactionService) onPaymentSucceeded(ctx context.Context, transaction *models.Transaction) {
+	if err := s.bus.Publish(ctx, events.PaymentSucceeded{Transaction: transaction}); err != nil {
+		log.Printf("follow-up work for transaction %s incomplete: %v", transaction.ID, err)
+	}
+}
+
+// onPaymentFailed announces a transaction that just failed or was cancelled
+func (s *transactionService) onPaymentFailed(ctx context.Context, transaction *models.Transaction, previous string) {
+	if err := s.bus.Publish(ctx, events.PaymentFailed{Transaction: transaction, Previous: previous}); err != nil {
+		log.Printf("follow-up work for transaction %s incomplete: %v", transaction.ID, err)
+	}
+}
+
+// buildTenders turns the request into tenders adding up to the transaction amount.
+// At most one tender may go through an external method; the rest is store
+// credit and gift cards. The returned method is the external one, or
+// STORE_CREDIT/GIFT_CARD when nothing is left to collect.
+func (s *transactionService) buildTenders(ctx context.Context, req *models.CreateTransactionRequest, userID uuid.UUID) ([]models.TransactionTender, string, error) {
+	requested := req.Tenders
+	if len(requested) == 0 {
+		if req.PaymentMethod == models.PaymentMethodGiftCard {
+			return nil, "", errors.New("gift card payments need a tender with gift_card_code")
+		}
+		requested = []models.TenderRequest{{PaymentMethod: req.PaymentMethod, Amount: req.Amount}}
+	}
+
+	primary := ""
+	var tenders []models.TransactionTender
+	var total, storeCredit float64
+	giftCards := map[uuid.UUID]bool{}
+	for _, tender := range requested {
+		total += tender.Amount
+		switch tender.PaymentMethod {
+		case models.PaymentMethodStoreCredit:
+			storeCredit += tender.Amount
+			continue
+		case models.PaymentMethodGiftCard:
+			card, err := s.giftCardService.Resolve(ctx, tender.GiftCardCode, "user:"+userID.String())
+			if err != nil {
+				return nil, "", err
+			}
+			if giftCards[card.ID] {
+				return nil, "", errors.New("the same gift card is used twice")
+			}
+			giftCards[card.ID] = true
+			cardID := card.ID
+			tenders = append(tenders, models.TransactionTender{PaymentMethod: models.PaymentMethodGiftCard, Amount: utils.RoundMoney(tender.Amount), GiftCardID: &cardID})
+			continue
+		}
+		if primary != "" {
+			return nil, "", errors.New("only one payment method besides store credit can be used")
+		}
+		primary = tender.PaymentMethod
+		tenders = append(tenders, models.TransactionTender{PaymentMethod: tender.PaymentMethod, Amount: utils.RoundMoney(tender.Amount)})
+	}
+	if storeCredit > 0 {
+		tenders = append(tenders, models.TransactionTender{PaymentMethod: models.PaymentMethodStoreCredit, Amount: utils.RoundMoney(storeCredit)})
+	}
+	if primary == "" {
+		primary = models.PaymentMethodStoreCredit
+		if len(giftCards) > 0 {
+			primary = models.PaymentMethodGiftCard
+		}
+	}
+
+	if utils.RoundMoney(total) != utils.RoundMoney(req.Amount) {
+		return nil, "", fmt.Errorf("tenders add up to %.2f, not %.2f", total, req.Amount)
+	}
+	return tenders, primary, nil
+}
+
+func (s *transactionService) CreateTransaction(ctx context.Context, req *models.CreateTransactionRequest, userID uuid.UUID, client models.ClientInfo) (*models.Transaction, error) {
 	// Validate order exists and belongs to user
-	order, err := s.orderRepo.GetByID(ctx, req.OrderID)
+	order, err := s.orders.GetOrderByID(ctx, req.OrderID)
 	if err != nil {
 		return nil, errors.New("order not found")
 	}
@@ -47,6 +169,9 @@ func (s *transactionService) CreateTransaction(ctx context.Context, req *models.
 	if order.UserID != userID {
 		return nil, errors.New("order does not belong to user")
 	}
+	if order.Status != models.OrderStatusPending {
+		return nil, repositories.ErrOrderNotPending
+	}
 
 	// Check if transaction already exists for this order
 	existingTransaction, _ := s.transactionRepo.GetByOrderID(ctx, req.OrderID)
@@ -59,21 +184,109 @@ func (s *transactionService) CreateTransaction(ctx context.Context, req *models.
 		return nil, fmt.Errorf("amount %.2f does not match order total %.2f", req.Amount, order.TotalPrice)
 	}
 
-	// Create transaction
+	tenders, paymentMethod, err := s.buildTenders(ctx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every tender's method must be offered for this order
+	methods := []string{paymentMethod}
+	for _, tender := range tenders {
+		methods = append(methods, tender.PaymentMethod)
+	}
+	if err := s.paymentRules.CheckMethods(ctx, order, methods); err != nil {
+		return nil, err
+	}
+
+	// Score the attempt before any store credit or gift card balance is taken
+	assessment := s.assessRisk(ctx, models.RiskStageCreate, order, nil, req.Amount, client)
+	if assessment.Decision == models.RiskBlock {
+		return nil, ErrRiskBlocked
+	}
+
+	// Create transaction; store credit and gift card tenders are taken with it
 	transaction := &models.Transaction{
-		OrderID:       req.OrderID,
-		UserID:        userID,
-		PaymentMethod: req.PaymentMethod,
-		Amount:        req.Amount,
-		Status:        models.TransactionStatusPending,
-		ProductName:   "Book Order",
+		OrderID:        req.OrderID,
+		UserID:         userID,
+		PaymentMethod:  paymentMethod,
+		Amount:         req.Amount,
+		TaxAmount:      order.TaxAmount,
+		ServiceCharge:  order.ServiceCharge,
+		DeliveryCharge: order.DeliveryCharge,
+		Status:         models.TransactionStatusPending,
+		ProductName:    "Book Order",
+		Tenders:        tenders,
+	}
+	if assessment.Decision == models.RiskReview {
+		transaction.NeedsReview = true
+		transaction.ReviewReason = assessment.Summary()
 	}
 
-	return s.transactionRepo.Create(ctx, transaction)
+	created, err := s.transactionRepo.Create(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	// Paid in full from store credit and gift cards: nothing left to collect
+	if paymentMethod == models.PaymentMethodStoreCredit || paymentMethod == models.PaymentMethodGiftCard {
+		return s.UpdateTransactionStatus(ctx, created.ID, &models.TransactionUpdateRequest{Status: models.TransactionStatusSuccess})
+	}
+	return created, nil
 }
 
-func (s *transactionService) GetAllTransactions(ctx context.Context) ([]models.Transaction, error) {
-	return s.transactionRepo.GetAll(ctx)
+// Transaction listing page sizes
+const (
+	DefaultTransactionPageSize = 50
+	MaxTransactionPageSize     = 200
+)
+
+func (s *transactionService) ListTransactions(ctx context.Context, q *models.TransactionListQuery) (*models.TransactionPage, error) {
+	if q.Sort == "" {
+		q.Sort = models.TransactionSortCreatedAtDesc
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultTransactionPageSize
+	}
+	if q.Limit > MaxTransactionPageSize {
+		q.Limit = MaxTransactionPageSize
+	}
+
+	page := &models.TransactionPage{}
+	var last *models.TransactionCursor
+	if q.View == models.TransactionViewFull {
+		transactions, err := s.transactionRepo.ListFull(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if len(transactions) > q.Limit {
+			transactions = transactions[:q.Limit]
+			t := transactions[q.Limit-1]
+			last = &models.TransactionCursor{CreatedAt: t.CreatedAt, Amount: t.Amount, ID: t.ID}
+		}
+		page.Items = transactions
+	} else {
+		summaries, err := s.transactionRepo.ListSummaries(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if len(summaries) > q.Limit {
+			summaries = summaries[:q.Limit]
+			t := summaries[q.Limit-1]
+			last = &models.TransactionCursor{CreatedAt: t.CreatedAt, Amount: t.Amount, ID: t.ID}
+		}
+		page.Items = summaries
+	}
+	if last != nil {
+		last.Sort = q.Sort
+		page.NextCursor = last.Encode()
+	}
+
+	totals, err := s.transactionRepo.Totals(ctx, &q.Filter)
+	if err != nil {
+		return nil, err
+	}
+	page.Totals = *totals
+	return page, nil
 }
 
 func (s *transactionService) GetTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
@@ -116,16 +329,23 @@ func (s *transactionService) UpdateTransactionStatus(ctx context.Context, id uui
 		updateData.TransactionID = req.TransactionID
 	}
 
-	transaction, err := s.transactionRepo.Update(ctx, id, updateData)
+	// A successful transaction marks its order PAID in the same database transaction
+	orderStatus := ""
+	if req.Status == models.TransactionStatusSuccess {
+		orderStatus = models.OrderStatusPaid
+	}
+	transaction, previous, err := s.transactionRepo.ChangeStatus(ctx, id, updateData, orderStatus)
 	if err != nil {
 		return nil, err
 	}
 
-	// If transaction is successful, update order status to PAID
-	if req.Status == models.TransactionStatusSuccess {
-		_, err = s.orderRepo.UpdateStatus(ctx, transaction.OrderID, models.OrderStatusPaid)
-		if err != nil {
-			return nil, fmt.Errorf("failed to update order status: %v", err)
+	// Only a change of status is announced; repeating one is not
+	if transaction.Status != previous {
+		if transaction.Status == models.TransactionStatusSuccess {
+			s.onPaymentSucceeded(ctx, transaction)
+		}
+		if transaction.Status == models.TransactionStatusFailed || transaction.Status == models.TransactionStatusCancelled {
+			s.onPaymentFailed(ctx, transaction, previous)
 		}
 	}
 
@@ -144,15 +364,44 @@ func (s *transactionService) InitiateEsewaPayment(ctx context.Context, transacti
 		return nil, errors.New("transaction is not in pending status")
 	}
 
+	// Bill components always come from the order, never from the client.
+	// When part of the order is paid with store credit, eSewa only collects
+	// the rest as a single amount.
+	esewaTotal := esewaAmountDue(transaction)
+	if esewaTotal <= 0 {
+		return nil, errors.New("transaction has no eSewa amount to pay")
+	}
+	if esewaTotal == transaction.Amount {
+		esewaReq.TaxAmount = transaction.TaxAmount
+		esewaReq.ProductServiceCharge = transaction.ServiceCharge
+		esewaReq.ProductDeliveryCharge = transaction.DeliveryCharge
+		esewaReq.Amount = utils.RoundMoney(transaction.Amount - transaction.TaxAmount -
+			transaction.ServiceCharge - transaction.DeliveryCharge)
+	} else {
+		esewaReq.TaxAmount = 0
+		esewaReq.ProductServiceCharge = 0
+		esewaReq.ProductDeliveryCharge = 0
+		esewaReq.Amount = esewaTotal
+	}
+
 	// Update transaction with eSewa details
-	transaction.MerchantCode = "DUMMY_MERCHANT" // From environment
-	transaction.ProductCode = esewaReq.ProductCode
+	transaction.MerchantCode = s.esewa.ProductCode
+	transaction.ProductCode = s.esewa.ProductCode
 	transaction.ProductName = esewaReq.ProductName
 
-	// Generate payment URL
-	paymentURL := fmt.Sprintf("http://dummy-esewa-url.com/payment?transaction_id=%s&amount=%.2f",
-		transaction.ID, transaction.Amount)
-	transaction.PaymentURL = paymentURL
+	// The ePay v2 form, signed, as a URL the client can open
+	form := esewa.FormRequest{
+		Amount:          esewaReq.Amount,
+		TaxAmount:       esewaReq.TaxAmount,
+		ServiceCharge:   esewaReq.ProductServiceCharge,
+		DeliveryCharge:  esewaReq.ProductDeliveryCharge,
+		TotalAmount:     esewaTotal,
+		TransactionUUID: esewaTransactionUUID(transaction),
+		ProductCode:     s.esewa.ProductCode,
+		SuccessURL:      esewaReq.SuccessURL,
+		FailureURL:      esewaReq.FailureURL,
+	}
+	transaction.PaymentURL = s.esewa.FormURL + "?" + form.Values(s.esewa.SecretKey).Encode()
 
 	updatedTransaction, err := s.transactionRepo.Update(ctx, transaction.ID, transaction)
 	if err != nil {
@@ -162,17 +411,69 @@ func (s *transactionService) InitiateEsewaPayment(ctx context.Context, transacti
 	return updatedTransaction, nil
 }
 
-func (s *transactionService) VerifyEsewaPayment(ctx context.Context, esewaResponse *models.EsewaResponseData) (*models.Transaction, error) {
-	// Find transaction by reference (using TransactionCode as the transaction ID)
-	transactionID, err := uuid.Parse(esewaResponse.TransactionCode)
-	if err != nil {
+func (s *transactionService) VerifyEsewaPayment(ctx context.Context, esewaResponse *models.EsewaResponseData, userID uuid.UUID, client models.ClientInfo) (*models.Transaction, error) {
+	if esewaResponse.Reference() == "" {
 		return nil, errors.New("invalid transaction code")
 	}
 
-	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	// Only a callback signed by eSewa is looked at, and even then it only
+	// says which payment to check: the outcome comes from the status API
+	callback := esewa.Callback{
+		TransactionCode:  esewaResponse.TransactionCode,
+		Status:           esewaResponse.Status,
+		TotalAmount:      esewaResponse.TotalAmount,
+		TransactionUUID:  esewaResponse.TransactionUUID,
+		ProductCode:      esewaResponse.ProductCode,
+		SignedFieldNames: esewaResponse.SignedFieldNames,
+		Signature:        esewaResponse.Signature,
+	}
+	if esewaResponse.Signature == "" || !callback.Verify(s.esewa.SecretKey) {
+		return nil, errors.New("invalid eSewa signature")
+	}
+	if esewaResponse.ProductCode != s.esewa.ProductCode {
+		return nil, errors.New("eSewa callback is for another merchant")
+	}
+	if esewaResponse.TotalAmount == "" {
+		return nil, errors.New("eSewa total amount is missing")
+	}
+	paid, err := strconv.ParseFloat(strings.ReplaceAll(esewaResponse.TotalAmount, ",", ""), 64)
+	if err != nil {
+		return nil, errors.New("invalid eSewa total amount")
+	}
+
+	// Find transaction by the transaction_uuid sent with the form: its
+	// reference, or its ID for transactions started before references
+	transaction, err := s.findByEsewaReference(ctx, esewaResponse.Reference())
 	if err != nil {
 		return nil, errors.New("transaction not found")
 	}
+	if transaction.UserID != userID {
+		return nil, errors.New("transaction does not belong to user")
+	}
+	if transaction.PaymentMethod != models.PaymentMethodEsewa {
+		return nil, errors.New("transaction is not an eSewa payment")
+	}
+
+	// A callback for a different amount than was asked for is held for an admin
+	if due := esewaAmountDue(transaction); utils.RoundMoney(paid) != due {
+		s.flagForReview(ctx, transaction.ID, fmt.Sprintf("eSewa reported %.2f paid, %.2f was due", paid, due))
+		return nil, errors.New("eSewa amount does not match the transaction")
+	}
+
+	// A repeated callback changes nothing
+	if transaction.Status != models.TransactionStatusPending {
+		return transaction, nil
+	}
+
+	// Every confirmation is scored, so repeated calls count towards velocity.
+	// A blocked success is held for an admin to check against eSewa.
+	assessment := s.assessRisk(ctx, models.RiskStageVerify, &transaction.Order, &transaction.ID, transaction.Amount, client)
+	if assessment.Decision == models.RiskBlock {
+		if esewaResponse.Status == esewa.StatusComplete {
+			s.flagForReview(ctx, transaction.ID, "Payment confirmation held: "+assessment.Summary())
+		}
+		return nil, ErrRiskBlocked
+	}
 
 	// Store eSewa response
 	esewaResponseJSON, err := json.Marshal(esewaResponse)
@@ -180,41 +481,174 @@ func (s *transactionService) VerifyEsewaPayment(ctx context.Context, esewaRespon
 		return nil, errors.New("failed to marshal eSewa response")
 	}
 
-	// Update transaction status based on eSewa response
-	var status string
-	var failureReason string
+	return s.settleEsewa(ctx, transaction, datatypes.JSON(esewaResponseJSON), esewaResponse.TransactionCode, assessment)
+}
 
-	switch esewaResponse.Status {
-	case "COMPLETE", "SUCCESS":
-		status = models.TransactionStatusSuccess
-		// Update order status to PAID
-		_, err = s.orderRepo.UpdateStatus(ctx, transaction.OrderID, models.OrderStatusPaid)
+// settleEsewa asks eSewa's status API how a pending payment ended and moves
+// the transaction to match. A payment eSewa reports as pending, or does not
+// know yet, is left for the next check. response is stored with the
+// transaction; when nil, the status answer is stored instead.
+func (s *transactionService) settleEsewa(ctx context.Context, transaction *models.Transaction, response datatypes.JSON, gatewayRef string, assessment *models.RiskAssessment) (*models.Transaction, error) {
+	due := esewaAmountDue(transaction)
+	status, err := esewa.CheckStatus(ctx, s.http, s.esewa.StatusURL, s.esewa.ProductCode, esewaTransactionUUID(transaction), due)
+	if err != nil {
+		return nil, fmt.Errorf("could not confirm the payment with eSewa: %v", err)
+	}
+	if response == nil {
+		statusJSON, err := json.Marshal(status)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update order status: %v", err)
+			return nil, errors.New("failed to marshal eSewa response")
 		}
-	case "FAILED", "ERROR":
-		status = models.TransactionStatusFailed
-		failureReason = esewaResponse.Message
-	default:
-		status = models.TransactionStatusPending
+		response = datatypes.JSON(statusJSON)
 	}
 
-	// Update transaction
-	updateData := &models.Transaction{
-		Status:        status,
-		TransactionID: esewaResponse.RefID,
-		FailureReason: failureReason,
-		EsewaResponse: datatypes.JSON(esewaResponseJSON),
+	updateData := &models.Transaction{EsewaResponse: response}
+	orderStatus := ""
+	switch status.Status {
+	case esewa.StatusComplete:
+		if utils.RoundMoney(status.TotalAmount) != due {
+			s.flagForReview(ctx, transaction.ID, fmt.Sprintf("eSewa reported %.2f paid, %.2f was due", status.TotalAmount, due))
+			return nil, errors.New("eSewa amount does not match the transaction")
+		}
+		updateData.Status = models.TransactionStatusSuccess
+		updateData.TransactionID = gatewayRef
+		if status.RefID != nil && *status.RefID != "" {
+			updateData.TransactionID = *status.RefID
+		}
+		orderStatus = models.OrderStatusPaid
+	case esewa.StatusCanceled:
+		updateData.Status = models.TransactionStatusFailed
+		updateData.FailureReason = "Cancelled on eSewa"
+	case esewa.StatusFullRefund, esewa.StatusPartialRefund:
+		s.flagForReview(ctx, transaction.ID, "eSewa reports the payment as "+status.Status)
+		return transaction, nil
+	default:
+		return transaction, nil
 	}
 
-	updatedTransaction, err := s.transactionRepo.Update(ctx, transaction.ID, updateData)
+	updatedTransaction, previous, err := s.transactionRepo.ChangeStatus(ctx, transaction.ID, updateData, orderStatus)
 	if err != nil {
 		return nil, err
 	}
 
+	if updatedTransaction.Status == previous {
+		return updatedTransaction, nil
+	}
+	if updatedTransaction.Status == models.TransactionStatusSuccess {
+		if assessment != nil && assessment.Decision == models.RiskReview {
+			s.flagForReview(ctx, updatedTransaction.ID, assessment.Summary())
+			updatedTransaction.NeedsReview = true
+			updatedTransaction.ReviewReason = assessment.Summary()
+		}
+		s.onPaymentSucceeded(ctx, updatedTransaction)
+	}
+	if updatedTransaction.Status == models.TransactionStatusFailed {
+		s.onPaymentFailed(ctx, updatedTransaction, previous)
+	}
+
 	return updatedTransaction, nil
 }
 
+// CheckPendingEsewa settles pending eSewa payments from the status API, for
+// customers who never come back from eSewa and payments that complete late.
+// It returns how many were settled.
+func (s *transactionService) CheckPendingEsewa(ctx context.Context) (int, error) {
+	pending, err := s.transactionRepo.GetPendingEsewa(ctx, time.Now().Add(-s.esewa.StatusCheckWindow))
+	if err != nil {
+		return 0, err
+	}
+	settled := 0
+	for i := range pending {
+		transaction, err := s.settleEsewa(ctx, &pending[i], nil, "", nil)
+		if err != nil {
+			log.Printf("eSewa status check for transaction %s: %v", pending[i].ID, err)
+			continue
+		}
+		if transaction.Status != models.TransactionStatusPending {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// Run checks pending eSewa payments every StatusCheckInterval until ctx is cancelled
+func (s *transactionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.esewa.StatusCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.CheckPendingEsewa(ctx); err != nil {
+			log.Printf("eSewa status check: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// flagForReview holds a transaction for an admin. Failing to flag it is
+// logged: the caller has already decided what to do with the payment.
+func (s *transactionService) flagForReview(ctx context.Context, id uuid.UUID, reason string) {
+	if err := s.transactionRepo.SetReview(ctx, id, true, reason); err != nil {
+		log.Printf("failed to flag transaction %s for review: %v", id, err)
+	}
+}
+
+func (s *transactionService) findByEsewaReference(ctx context.Context, reference string) (*models.Transaction, error) {
+	if id, err := uuid.Parse(reference); err == nil {
+		return s.transactionRepo.GetByID(ctx, id)
+	}
+	return s.transactionRepo.GetByRef(ctx, reference)
+}
+
+// matchesEsewaReference reports whether an eSewa transaction_uuid names transaction
+func matchesEsewaReference(transaction *models.Transaction, reference string) bool {
+	return reference == transaction.ID.String() || transaction.Ref != nil && reference == *transaction.Ref
+}
+
+// esewaTransactionUUID is the transaction_uuid a payment is sent to eSewa
+// with: its reference, so it shows on eSewa's side too, unless the configured
+// format uses characters eSewa rejects
+func esewaTransactionUUID(transaction *models.Transaction) string {
+	if ref := transaction.RefOrID(); esewa.ValidTransactionUUID(ref) {
+		return ref
+	}
+	return transaction.ID.String()
+}
+
+// esewaAmountDue is what eSewa collects: the whole amount, or only the eSewa
+// tender when part of the order is paid another way
+func esewaAmountDue(transaction *models.Transaction) float64 {
+	if len(transaction.Tenders) > 0 {
+		return transaction.TenderAmount(models.PaymentMethodEsewa)
+	}
+	return transaction.Amount
+}
+
 func (s *transactionService) DeleteTransaction(ctx context.Context, id uuid.UUID) error {
 	return s.transactionRepo.Delete(ctx, id)
 }
+
+func (s *transactionService) CancelPendingPayment(ctx context.Context, orderID uuid.UUID) error {
+	transaction, err := s.transactionRepo.GetByOrderID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if transaction.Status != models.TransactionStatusPending {
+		return nil
+	}
+	_, err = s.UpdateTransactionStatus(ctx, transaction.ID, &models.TransactionUpdateRequest{
+		Status:        models.TransactionStatusCancelled,
+		FailureReason: "Order cancelled",
+	})
+	// Settled in the meantime: nothing is pending any more
+	if errors.Is(err, repositories.ErrTransactionFinal) {
+		return nil
+	}
+	return err
+}
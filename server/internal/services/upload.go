@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// uploadTypes are the document formats accepted for uploads, by sniffed content type
+var uploadTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// readUpload reads an uploaded document of at most maxSize bytes and returns
+// it with its content type and file extension. The type is taken from the
+// content, not the file name or the type the client declared.
+func readUpload(r io.Reader, maxSize int64) ([]byte, string, string, error) {
+	// Read one byte past the limit to tell a full-size file from an oversized one
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, "", "", err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", "", fmt.Errorf("file must be at most %d KB", maxSize>>10)
+	}
+	if len(data) == 0 {
+		return nil, "", "", errors.New("file is empty")
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := uploadTypes[contentType]
+	if !ok {
+		return nil, "", "", errors.New("file must be a JPEG, PNG or WebP image or a PDF")
+	}
+	return data, contentType, ext, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WalletService interface {
+	GetWallet(ctx context.Context, userID uuid.UUID) (*models.Wallet, error)
+	IssueCredit(ctx context.Context, req *models.WalletCreditRequest, adminID uuid.UUID) (*models.WalletEntry, error)
+	// ReverseTransactionDebits returns store credit taken by a transaction that did not complete
+	ReverseTransactionDebits(ctx context.Context, transactionID uuid.UUID) error
+	ExpireDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+}
+
+type walletService struct {
+	walletRepo repositories.WalletRepository
+	ledger     LedgerService
+	cfg        config.WalletConfig
+}
+
+func NewWalletService(walletRepo repositories.WalletRepository, ledger LedgerService, cfg config.WalletConfig) WalletService {
+	return &walletService{walletRepo: walletRepo, ledger: ledger, cfg: cfg}
+}
+
+func (s *walletService) GetWallet(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	balance, err := s.walletRepo.GetBalance(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.walletRepo.GetEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Wallet{UserID: userID, Balance: balance, Entries: entries}, nil
+}
+
+func (s *walletService) ReverseTransactionDebits(ctx context.Context, transactionID uuid.UUID) error {
+	return s.walletRepo.ReverseTransactionDebits(ctx, transactionID)
+}
+
+// IssueCredit adds goodwill credit to a customer's wallet
+func (s *walletService) IssueCredit(ctx context.Context, req *models.WalletCreditRequest, adminID uuid.UUID) (*models.WalletEntry, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	entry, err := s.walletRepo.Credit(ctx, &models.WalletEntry{
+		UserID:      req.UserID,
+		Source:      models.WalletSourceGoodwill,
+		Amount:      req.Amount,
+		ExpiresAt:   req.ExpiresAt,
+		Description: req.Description,
+		CreatedBy:   &adminID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ledger.PostWalletCredit(ctx, entry); err != nil {
+		log.Printf("failed to post wallet credit %s to the ledger: %v", entry.ID, err)
+	}
+	return entry, nil
+}
+
+// ExpireDue writes off lapsed credit and returns how many lots expired
+func (s *walletService) ExpireDue(ctx context.Context) (int, error) {
+	expired, err := s.walletRepo.ExpireDue(ctx, time.Now())
+	for i := range expired {
+		if _, err := s.ledger.PostWalletExpiry(ctx, &expired[i]); err != nil {
+			log.Printf("failed to post wallet expiry %s to the ledger: %v", expired[i].ID, err)
+		}
+	}
+	return len(expired), err
+}
+
+// Run expires lapsed credit every ExpiryInterval until ctx is cancelled
+func (s *walletService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ExpireDue(ctx); err != nil {
+			log.Printf("wallet expiry: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bookstore/config"
+	"bookstore/internal/models"
+	"bookstore/internal/repositories"
+	"bookstore/pkg/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type WebhookService interface {
+	CreateEndpoint(ctx context.Context, req *models.WebhookEndpointRequest, adminID uuid.UUID) (*models.WebhookSecretResponse, error)
+	GetEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, id uuid.UUID, req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error)
+	RotateSecret(ctx context.Context, id uuid.UUID) (*models.WebhookSecretResponse, error)
+	DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+
+	// Publish queues an outbox event for every active endpoint subscribed to
+	// it. Publishing the same event again queues nothing new.
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+	DeliverDue(ctx context.Context) (int, error)
+	Run(ctx context.Context)
+
+	GetDeliveries(ctx context.Context, status string, endpointID *uuid.UUID) ([]models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, []models.WebhookAttempt, error)
+	Redeliver(ctx context.Context, id uuid.UUID) error
+}
+
+type webhookService struct {
+	webhookRepo repositories.WebhookRepository
+	client      *webhook.Client
+	cfg         config.WebhookConfig
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository, cfg config.WebhookConfig) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		client:      webhook.NewClient(cfg.Timeout),
+		cfg:         cfg,
+	}
+}
+
+const webhookBatchSize = 20
+
+// webhookEndpointFromRequest checks the URL can be posted to and drops repeated events
+func webhookEndpointFromRequest(req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+
+	var events []string
+	seen := map[string]bool{}
+	for _, event := range req.Events {
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+
+	return &models.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      datatypes.NewJSONSlice(events),
+		Active:      req.Active == nil || *req.Active,
+	}, nil
+}
+
+func (s *webhookService) CreateEndpoint(ctx context.Context, req *models.WebhookEndpointRequest, adminID uuid.UUID) (*models.WebhookSecretResponse, error) {
+	endpoint, err := webhookEndpointFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = secret
+	endpoint.CreatedBy = &adminID
+
+	created, err := s.webhookRepo.CreateEndpoint(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebhookSecretResponse{Endpoint: created, Secret: secret}, nil
+}
+
+func (s *webhookService) GetEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	return s.webhookRepo.GetEndpoints(ctx)
+}
+
+func (s *webhookService) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	return s.webhookRepo.GetEndpointByID(ctx, id)
+}
+
+func (s *webhookService) UpdateEndpoint(ctx context.Context, id uuid.UUID, req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	endpoint, err := webhookEndpointFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.UpdateEndpoint(ctx, id, endpoint)
+}
+
+// RotateSecret replaces an endpoint's signing secret. Deliveries sent after
+// this are signed with the new one.
+func (s *webhookService) RotateSecret(ctx context.Context, id uuid.UUID) (*models.WebhookSecretResponse, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.webhookRepo.SetSecret(ctx, id, secret); err != nil {
+		return nil, errors.New("webhook endpoint not found")
+	}
+	endpoint, err := s.webhookRepo.GetEndpointByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebhookSecretResponse{Endpoint: endpoint, Secret: secret}, nil
+}
+
+func (s *webhookService) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return s.webhookRepo.DeleteEndpoint(ctx, id)
+}
+
+func (s *webhookService) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	endpoints, err := s.webhookRepo.GetActiveEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	var deliveries []models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(event.EventType) {
+			continue
+		}
+		if payload == nil {
+			body := models.WebhookEvent{
+				ID:        event.ID,
+				Type:      event.EventType,
+				CreatedAt: event.CreatedAt,
+				Data:      json.RawMessage(event.Payload),
+			}
+			if payload, err = json.Marshal(body); err != nil {
+				return fmt.Errorf("failed to encode %s event: %v", event.EventType, err)
+			}
+		}
+		// The outbox event ID makes a repeated publish a no-op per endpoint
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID: endpoint.ID,
+			EventID:    event.ID,
+			EventType:  event.EventType,
+			Payload:    datatypes.JSON(payload),
+		})
+	}
+	return s.webhookRepo.Enqueue(ctx, deliveries)
+}
+
+// Run sends due deliveries every poll interval until ctx is cancelled
+func (s *webhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DeliverDue(ctx); err != nil {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends every due delivery and returns how many were accepted
+func (s *webhookService) DeliverDue(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		deliveries, err := s.webhookRepo.ClaimDue(ctx, webhookBatchSize, s.cfg.Timeout+time.Minute)
+		if err != nil {
+			return delivered, err
+		}
+		if len(deliveries) == 0 {
+			return delivered, nil
+		}
+
+		for _, delivery := range deliveries {
+			if s.deliver(ctx, &delivery) {
+				delivered++
+			}
+		}
+	}
+}
+
+// deliver makes one attempt at a delivery and records the outcome
+func (s *webhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) bool {
+	endpoint, err := s.webhookRepo.GetEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		s.recordFailure(ctx, delivery, 0, fmt.Errorf("load endpoint: %v", err))
+		return false
+	}
+	if !endpoint.Active {
+		// Held until the endpoint is switched back on or the delivery dead-letters
+		s.recordFailure(ctx, delivery, 0, errors.New("endpoint is disabled"))
+		return false
+	}
+
+	result, sendErr := s.client.Send(ctx, endpoint.URL, endpoint.Secret, delivery.EventType, delivery.ID.String(), delivery.Payload)
+	attempt := &models.WebhookAttempt{DeliveryID: delivery.ID}
+	statusCode := 0
+	if result != nil {
+		statusCode = result.StatusCode
+		attempt.StatusCode = result.StatusCode
+		attempt.ResponseBody = result.Body
+		attempt.DurationMs = result.Duration.Milliseconds()
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.webhookRepo.RecordAttempt(ctx, attempt); err != nil {
+		log.Printf("webhooks: failed to log attempt for delivery %s: %v", delivery.ID, err)
+	}
+
+	if sendErr != nil {
+		s.recordFailure(ctx, delivery, statusCode, sendErr)
+		return false
+	}
+	if err := s.webhookRepo.MarkDelivered(ctx, delivery.ID, statusCode); err != nil {
+		log.Printf("webhooks: failed to mark delivery %s delivered: %v", delivery.ID, err)
+	}
+	return true
+}
+
+func (s *webhookService) recordFailure(ctx context.Context, delivery *models.WebhookDelivery, statusCode int, sendErr error) {
+	attempts := delivery.Attempts + 1
+	status := models.WebhookDeliveryPending
+	if attempts >= s.cfg.MaxAttempts {
+		status = models.WebhookDeliveryDead
+	}
+
+	// Exponential backoff from the retry base, capped
+	backoff := s.cfg.RetryBase << min(attempts-1, 16)
+	if backoff > s.cfg.MaxBackoff {
+		backoff = s.cfg.MaxBackoff
+	}
+
+	log.Printf("webhook %s delivery %s attempt %d failed: %v", delivery.EventType, delivery.ID, attempts, sendErr)
+	if status == models.WebhookDeliveryDead {
+		log.Printf("webhook delivery %s dead-lettered after %d attempts", delivery.ID, attempts)
+	}
+	if err := s.webhookRepo.MarkAttemptFailed(ctx, delivery.ID, status, time.Now().Add(backoff), statusCode, sendErr.Error()); err != nil {
+		log.Printf("webhooks: failed to record attempt for %s: %v", delivery.ID, err)
+	}
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, status string, endpointID *uuid.UUID) ([]models.WebhookDelivery, error) {
+	return s.webhookRepo.GetDeliveries(ctx, status, endpointID)
+}
+
+// GetDelivery returns a delivery with its attempt log
+func (s *webhookService) GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, []models.WebhookAttempt, error) {
+	delivery, err := s.webhookRepo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		return nil, nil, errors.New("webhook delivery not found")
+	}
+	attempts, err := s.webhookRepo.GetAttempts(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return delivery, attempts, nil
+}
+
+// Redeliver queues a delivery to be sent again now, including ones already
+// delivered or dead-lettered. The payload is the one originally queued.
+func (s *webhookService) Redeliver(ctx context.Context, id uuid.UUID) error {
+	requeued, err := s.webhookRepo.Requeue(ctx, id)
+	if err != nil {
+		return err
+	}
+	if requeued == 0 {
+		return errors.New("webhook delivery not found")
+	}
+	return nil
+}
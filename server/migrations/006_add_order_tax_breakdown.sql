@@ -0,0 +1,19 @@
+ALTER TABLE orders
+    ADD COLUMN sub_total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN taxable_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN exempt_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN service_charge DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN delivery_charge DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Existing orders were priced without tax; treat their total as the exempt subtotal
+UPDATE orders SET sub_total = total_price, exempt_amount = total_price;
+
+ALTER TABLE order_items
+    ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE transactions
+    ADD COLUMN tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN service_charge DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN delivery_charge DECIMAL(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,110 @@
+ALTER TABLE orders ADD COLUMN buyer_pan VARCHAR(20);
+
+-- Gapless counters per document type and fiscal year. Rows are locked by the
+-- issuing transaction, so a rolled back issue never burns a number.
+CREATE TABLE document_sequences (
+    document_type VARCHAR(20) NOT NULL,
+    fiscal_year VARCHAR(10) NOT NULL,
+    last_value BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (document_type, fiscal_year)
+);
+
+CREATE TABLE invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_number VARCHAR(30) UNIQUE NOT NULL,
+    fiscal_year VARCHAR(10) NOT NULL,
+    sequence BIGINT NOT NULL,
+    order_id UUID NOT NULL,
+    transaction_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    payment_method VARCHAR(50),
+    seller_name VARCHAR(200) NOT NULL,
+    seller_pan VARCHAR(20) NOT NULL,
+    seller_address VARCHAR(255),
+    buyer_name VARCHAR(100),
+    buyer_email VARCHAR(100),
+    buyer_pan VARCHAR(20),
+    sub_total DECIMAL(10, 2) NOT NULL,
+    taxable_amount DECIMAL(10, 2) NOT NULL,
+    exempt_amount DECIMAL(10, 2) NOT NULL,
+    tax_amount DECIMAL(10, 2) NOT NULL,
+    service_charge DECIMAL(10, 2) NOT NULL,
+    delivery_charge DECIMAL(10, 2) NOT NULL,
+    total_amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'ISSUED',
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (fiscal_year, sequence),
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE RESTRICT,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE RESTRICT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_invoices_order_id ON invoices(order_id);
+CREATE INDEX idx_invoices_user_id ON invoices(user_id);
+CREATE INDEX idx_invoices_issued_at ON invoices(issued_at DESC);
+
+CREATE TABLE invoice_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id UUID NOT NULL,
+    book_id UUID NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    tax_exempt BOOLEAN NOT NULL,
+    tax_amount DECIMAL(10, 2) NOT NULL,
+
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_invoice_items_invoice_id ON invoice_items(invoice_id);
+
+CREATE TABLE credit_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    credit_note_number VARCHAR(30) UNIQUE NOT NULL,
+    fiscal_year VARCHAR(10) NOT NULL,
+    sequence BIGINT NOT NULL,
+    invoice_id UUID UNIQUE NOT NULL,
+    reason TEXT NOT NULL,
+    taxable_amount DECIMAL(10, 2) NOT NULL,
+    exempt_amount DECIMAL(10, 2) NOT NULL,
+    tax_amount DECIMAL(10, 2) NOT NULL,
+    total_amount DECIMAL(10, 2) NOT NULL,
+    issued_by UUID NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (fiscal_year, sequence),
+
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE RESTRICT
+);
+
+-- Tax documents must never be deleted, only cancelled with a credit note
+CREATE OR REPLACE FUNCTION prevent_tax_document_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% rows cannot be deleted', TG_TABLE_NAME;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER prevent_invoices_delete
+    BEFORE DELETE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION prevent_tax_document_delete();
+
+CREATE TRIGGER prevent_invoice_items_delete
+    BEFORE DELETE ON invoice_items
+    FOR EACH ROW EXECUTE FUNCTION prevent_tax_document_delete();
+
+CREATE TRIGGER prevent_credit_notes_delete
+    BEFORE DELETE ON credit_notes
+    FOR EACH ROW EXECUTE FUNCTION prevent_tax_document_delete();
+
+CREATE TRIGGER update_invoices_updated_at
+    BEFORE UPDATE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,26 @@
+CREATE TABLE cbms_sync_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_type VARCHAR(20) NOT NULL,
+    document_id UUID NOT NULL,
+    document_number VARCHAR(30) NOT NULL,
+    invoice_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    synced_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (document_type, document_id),
+
+    FOREIGN KEY (invoice_id) REFERENCES invoices(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_cbms_sync_jobs_due ON cbms_sync_jobs(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_cbms_sync_jobs_status ON cbms_sync_jobs(status);
+CREATE INDEX idx_cbms_sync_jobs_invoice_id ON cbms_sync_jobs(invoice_id);
+
+CREATE TRIGGER update_cbms_sync_jobs_updated_at
+    BEFORE UPDATE ON cbms_sync_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
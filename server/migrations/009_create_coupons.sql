@@ -0,0 +1,69 @@
+ALTER TABLE orders ADD COLUMN discount_total DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN discount_total DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE coupons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) UNIQUE NOT NULL,
+    description VARCHAR(255),
+    discount_type VARCHAR(20) NOT NULL,
+    value DECIMAL(10, 2) NOT NULL,
+    max_discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    usage_limit INTEGER NOT NULL DEFAULT 0,
+    per_user_limit INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (discount_type IN ('PERCENTAGE', 'FIXED')),
+    CHECK (discount_type <> 'PERCENTAGE' OR value <= 100)
+);
+
+CREATE TABLE coupon_categories (
+    coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, category_id)
+);
+
+CREATE TABLE coupon_books (
+    coupon_id UUID NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    book_id UUID NOT NULL REFERENCES books(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_id, book_id)
+);
+
+CREATE TABLE coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    order_id UUID UNIQUE NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE RESTRICT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions(coupon_id, user_id);
+
+CREATE TABLE order_discounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    coupon_id UUID,
+    code VARCHAR(50),
+    description VARCHAR(255),
+    amount DECIMAL(10, 2) NOT NULL,
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (coupon_id) REFERENCES coupons(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_order_discounts_order_id ON order_discounts(order_id);
+
+CREATE TRIGGER update_coupons_updated_at
+    BEFORE UPDATE ON coupons
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
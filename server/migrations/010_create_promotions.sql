@@ -0,0 +1,31 @@
+CREATE TABLE promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    type VARCHAR(30) NOT NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    author VARCHAR(100),
+    percentage DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    min_quantity INTEGER NOT NULL DEFAULT 0,
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    free_quantity INTEGER NOT NULL DEFAULT 0,
+    min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    stackable BOOLEAN NOT NULL DEFAULT FALSE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (type IN ('CATEGORY_PERCENT', 'AUTHOR_PERCENT', 'BUY_X_GET_Y', 'FREE_DELIVERY')),
+    CHECK (percentage BETWEEN 0 AND 100)
+);
+
+CREATE INDEX idx_promotions_active ON promotions(active);
+
+ALTER TABLE order_discounts ADD COLUMN promotion_id UUID REFERENCES promotions(id) ON DELETE SET NULL;
+
+CREATE TRIGGER update_promotions_updated_at
+    BEFORE UPDATE ON promotions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
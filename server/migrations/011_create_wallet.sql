@@ -0,0 +1,75 @@
+CREATE TABLE wallet_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    credit_id UUID REFERENCES wallet_entries(id),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    transaction_id UUID REFERENCES transactions(id),
+    refund_id UUID,
+    description VARCHAR(255),
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (type IN ('CREDIT', 'DEBIT', 'EXPIRY')),
+    CHECK (amount > 0),
+    CHECK ((type = 'CREDIT') = (credit_id IS NULL))
+);
+
+CREATE INDEX idx_wallet_entries_user_id ON wallet_entries(user_id, created_at);
+CREATE INDEX idx_wallet_entries_credit_id ON wallet_entries(credit_id);
+CREATE INDEX idx_wallet_entries_transaction_id ON wallet_entries(transaction_id);
+
+-- The wallet ledger is append-only. Rows referencing a transaction or user
+-- also keep those from being deleted.
+CREATE OR REPLACE FUNCTION prevent_ledger_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% rows are append-only', TG_TABLE_NAME;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER prevent_wallet_entries_change
+    BEFORE UPDATE OR DELETE ON wallet_entries
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_change();
+
+CREATE TABLE transaction_tenders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    payment_method VARCHAR(50) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX idx_transaction_tenders_transaction_id ON transaction_tenders(transaction_id);
+
+-- Existing transactions were paid with a single method
+INSERT INTO transaction_tenders (transaction_id, payment_method, amount)
+SELECT id, payment_method, amount FROM transactions;
+
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE RESTRICT,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    method VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (method IN ('STORE_CREDIT', 'ORIGINAL')),
+    CHECK (status IN ('PENDING', 'COMPLETED'))
+);
+
+CREATE INDEX idx_refunds_transaction_id ON refunds(transaction_id);
+
+ALTER TABLE wallet_entries
+    ADD CONSTRAINT fk_wallet_entries_refund FOREIGN KEY (refund_id) REFERENCES refunds(id);
+
+CREATE TRIGGER update_refunds_updated_at
+    BEFORE UPDATE ON refunds
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,53 @@
+ALTER TABLE books ADD COLUMN product_type VARCHAR(20) NOT NULL DEFAULT 'BOOK'
+    CHECK (product_type IN ('BOOK', 'GIFT_CARD'));
+
+CREATE TABLE gift_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(32) UNIQUE NOT NULL,
+    last4 VARCHAR(4) NOT NULL,
+    initial_balance DECIMAL(10, 2) NOT NULL CHECK (initial_balance > 0),
+    balance DECIMAL(10, 2) NOT NULL CHECK (balance >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    purchaser_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    order_item_id UUID REFERENCES order_items(id) ON DELETE SET NULL,
+    issued_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    note VARCHAR(255),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    voided_at TIMESTAMP WITH TIME ZONE,
+    void_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (status IN ('ACTIVE', 'VOID'))
+);
+
+CREATE INDEX idx_gift_cards_purchaser_id ON gift_cards(purchaser_id);
+CREATE INDEX idx_gift_cards_order_id ON gift_cards(order_id);
+
+CREATE TABLE gift_card_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    gift_card_id UUID NOT NULL REFERENCES gift_cards(id),
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    balance_after DECIMAL(10, 2) NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    description VARCHAR(255),
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (type IN ('ISSUE', 'REDEEM', 'REVERSAL', 'VOID'))
+);
+
+CREATE INDEX idx_gift_card_transactions_gift_card_id ON gift_card_transactions(gift_card_id, created_at);
+CREATE INDEX idx_gift_card_transactions_transaction_id ON gift_card_transactions(transaction_id);
+
+CREATE TRIGGER prevent_gift_card_transactions_change
+    BEFORE UPDATE OR DELETE ON gift_card_transactions
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_change();
+
+ALTER TABLE transaction_tenders ADD COLUMN gift_card_id UUID REFERENCES gift_cards(id);
+
+CREATE TRIGGER update_gift_cards_updated_at
+    BEFORE UPDATE ON gift_cards
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,86 @@
+CREATE TABLE ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (type IN ('ASSET', 'LIABILITY', 'EQUITY', 'REVENUE', 'EXPENSE'))
+);
+
+INSERT INTO ledger_accounts (code, name, type) VALUES
+    ('ESEWA_CLEARING', 'eSewa clearing', 'ASSET'),
+    ('CASH', 'Cash on hand', 'ASSET'),
+    ('CARD_CLEARING', 'Card clearing', 'ASSET'),
+    ('SALES_REVENUE', 'Sales revenue', 'REVENUE'),
+    ('SERVICE_REVENUE', 'Service and delivery charges', 'REVENUE'),
+    ('VAT_PAYABLE', 'VAT payable', 'LIABILITY'),
+    ('STORE_CREDIT_LIABILITY', 'Store credit liability', 'LIABILITY'),
+    ('GIFT_CARD_LIABILITY', 'Gift card liability', 'LIABILITY'),
+    ('SALES_RETURNS', 'Sales returns and refunds', 'EXPENSE'),
+    ('GATEWAY_FEES', 'Payment gateway fees', 'EXPENSE'),
+    ('PROMOTIONAL_EXPENSE', 'Goodwill credit and promotional gift cards', 'EXPENSE'),
+    ('BREAKAGE_INCOME', 'Expired store credit and voided gift cards', 'REVENUE');
+
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_type VARCHAR(30) NOT NULL,
+    source_id VARCHAR(100) NOT NULL,
+    description VARCHAR(255),
+    posted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Each business event is posted once
+    UNIQUE (source_type, source_id)
+);
+
+CREATE INDEX idx_journal_entries_posted_at ON journal_entries(posted_at);
+
+CREATE TABLE journal_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    journal_entry_id UUID NOT NULL REFERENCES journal_entries(id),
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    debit DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    credit DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    description VARCHAR(255),
+
+    CHECK (debit >= 0 AND credit >= 0),
+    CHECK ((debit > 0) <> (credit > 0))
+);
+
+CREATE INDEX idx_journal_lines_journal_entry_id ON journal_lines(journal_entry_id);
+CREATE INDEX idx_journal_lines_account_id ON journal_lines(account_id);
+
+-- Journals are append-only; mistakes are corrected with a new entry
+CREATE TRIGGER prevent_journal_entries_change
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_change();
+
+CREATE TRIGGER prevent_journal_lines_change
+    BEFORE UPDATE OR DELETE ON journal_lines
+    FOR EACH ROW EXECUTE FUNCTION prevent_ledger_change();
+
+-- Every journal must balance by the time its transaction commits
+CREATE OR REPLACE FUNCTION check_journal_balanced()
+RETURNS TRIGGER AS $$
+DECLARE
+    total_debit DECIMAL(12, 2);
+    total_credit DECIMAL(12, 2);
+BEGIN
+    SELECT COALESCE(SUM(debit), 0), COALESCE(SUM(credit), 0)
+    INTO total_debit, total_credit
+    FROM journal_lines
+    WHERE journal_entry_id = NEW.journal_entry_id;
+
+    IF total_debit <> total_credit THEN
+        RAISE EXCEPTION 'journal entry % does not balance: debit %, credit %',
+            NEW.journal_entry_id, total_debit, total_credit;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE CONSTRAINT TRIGGER check_journal_lines_balanced
+    AFTER INSERT ON journal_lines
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_balanced();
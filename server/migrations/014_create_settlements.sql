@@ -0,0 +1,43 @@
+ALTER TABLE transactions
+    ADD COLUMN needs_review BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN review_reason VARCHAR(255);
+
+CREATE INDEX idx_transactions_needs_review ON transactions(needs_review) WHERE needs_review;
+
+CREATE TABLE settlement_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    gateway VARCHAR(20) NOT NULL,
+    file_name VARCHAR(255),
+    period_from TIMESTAMP WITH TIME ZONE,
+    period_to TIMESTAMP WITH TIME ZONE,
+    imported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    matched INTEGER NOT NULL DEFAULT 0,
+    missing_locally INTEGER NOT NULL DEFAULT 0,
+    missing_at_gateway INTEGER NOT NULL DEFAULT 0,
+    amount_mismatches INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    gross_amount DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    fee_total DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE settlement_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    import_id UUID NOT NULL REFERENCES settlement_imports(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    line INTEGER,
+    ref_id VARCHAR(100),
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    gateway_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    local_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    note VARCHAR(255),
+
+    CHECK (status IN ('MATCHED', 'MISSING_LOCALLY', 'MISSING_AT_GATEWAY', 'AMOUNT_MISMATCH', 'SKIPPED'))
+);
+
+CREATE INDEX idx_settlement_items_import_id ON settlement_items(import_id);
+CREATE INDEX idx_settlement_items_transaction_id ON settlement_items(transaction_id);
+CREATE INDEX idx_settlement_items_ref_id ON settlement_items(ref_id);
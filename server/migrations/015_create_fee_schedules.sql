@@ -0,0 +1,43 @@
+CREATE TABLE fee_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_method VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    percentage DECIMAL(6, 3) NOT NULL DEFAULT 0,
+    fixed_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    slabs JSONB,
+    min_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    max_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (type IN ('PERCENTAGE', 'FIXED', 'SLAB')),
+    CHECK (percentage >= 0 AND fixed_amount >= 0 AND min_fee >= 0 AND max_fee >= 0)
+);
+
+CREATE INDEX idx_fee_schedules_payment_method ON fee_schedules(payment_method, effective_from);
+
+CREATE TRIGGER update_fee_schedules_updated_at
+    BEFORE UPDATE ON fee_schedules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE transactions
+    ADD COLUMN paid_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN gateway_fee DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN net_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN fee_source VARCHAR(20);
+
+-- Existing successful payments were paid when last updated, with no fee known
+UPDATE transactions SET paid_at = updated_at, net_amount = amount WHERE status = 'SUCCESS';
+
+CREATE INDEX idx_transactions_paid_at ON transactions(paid_at);
+
+-- Fees already posted to the ledger from settlement statements
+UPDATE transactions t
+SET gateway_fee = l.debit, net_amount = t.amount - l.debit, fee_source = 'SETTLED'
+FROM journal_entries e
+JOIN journal_lines l ON l.journal_entry_id = e.id
+JOIN ledger_accounts a ON a.id = l.account_id AND a.code = 'GATEWAY_FEES'
+WHERE e.source_type = 'GATEWAY_FEE' AND e.source_id = t.id::text;
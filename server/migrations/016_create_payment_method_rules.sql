@@ -0,0 +1,46 @@
+CREATE TABLE payment_method_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_method VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    message VARCHAR(255),
+    min_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    max_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    districts JSONB,
+    account_types JSONB,
+    require_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    weekdays JSONB,
+    start_time VARCHAR(5),
+    end_time VARCHAR(5),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (min_amount >= 0 AND max_amount >= 0)
+);
+
+CREATE INDEX idx_payment_method_rules_payment_method ON payment_method_rules(payment_method);
+
+CREATE TRIGGER update_payment_method_rules_updated_at
+    BEFORE UPDATE ON payment_method_rules
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Starting rules; amounts and districts can be changed from the admin API
+INSERT INTO payment_method_rules (payment_method, name, message, max_amount, districts) VALUES
+    ('CASH', 'Cash on delivery inside the valley', 'Cash on delivery is only available inside Kathmandu valley for orders under Rs. 10,000',
+     10000, '["Kathmandu", "Lalitpur", "Bhaktapur"]');
+
+INSERT INTO payment_method_rules (payment_method, name, message, min_amount) VALUES
+    ('CARD', 'Card minimum', 'Card payments need an order of at least Rs. 500', 500);
+
+INSERT INTO payment_method_rules (payment_method, name, message, account_types, require_verified) VALUES
+    ('CONNECTIPS', 'Verified institutions only', 'connectIPS is available to verified institutional accounts',
+     '["INSTITUTIONAL"]', TRUE);
+
+INSERT INTO ledger_accounts (code, name, type) VALUES
+    ('CONNECTIPS_CLEARING', 'connectIPS clearing', 'ASSET');
+
+ALTER TABLE users
+    ADD COLUMN account_type VARCHAR(20) NOT NULL DEFAULT 'INDIVIDUAL',
+    ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE orders ADD COLUMN delivery_district VARCHAR(50);
@@ -0,0 +1,69 @@
+CREATE TABLE delivery_agents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(20),
+    courier VARCHAR(100),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_delivery_agents_updated_at
+    BEFORE UPDATE ON delivery_agents
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE cod_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    agent_id UUID NOT NULL REFERENCES delivery_agents(id),
+    status VARCHAR(30) NOT NULL,
+    expected_amount DECIMAL(10, 2) NOT NULL,
+    collected_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    refusal_reason VARCHAR(255),
+    assigned_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (status IN ('ASSIGNED', 'PARTIALLY_COLLECTED', 'COLLECTED', 'REFUSED')),
+    CHECK (collected_amount >= 0 AND collected_amount <= expected_amount)
+);
+
+CREATE INDEX idx_cod_deliveries_agent_status ON cod_deliveries(agent_id, status);
+
+CREATE TRIGGER update_cod_deliveries_updated_at
+    BEFORE UPDATE ON cod_deliveries
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE cod_remittances (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_id UUID NOT NULL REFERENCES delivery_agents(id),
+    remittance_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount >= 0),
+    expected_amount DECIMAL(10, 2) NOT NULL,
+    difference DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    note VARCHAR(255),
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (status IN ('BALANCED', 'SHORT', 'OVER'))
+);
+
+CREATE INDEX idx_cod_remittances_agent_date ON cod_remittances(agent_id, remittance_date);
+
+CREATE TABLE cod_collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL REFERENCES cod_deliveries(id) ON DELETE CASCADE,
+    agent_id UUID NOT NULL REFERENCES delivery_agents(id),
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    collected_by VARCHAR(100) NOT NULL,
+    collected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    note VARCHAR(255),
+    remittance_id UUID REFERENCES cod_remittances(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_cod_collections_delivery_id ON cod_collections(delivery_id);
+CREATE INDEX idx_cod_collections_unremitted ON cod_collections(agent_id, collected_at) WHERE remittance_id IS NULL;
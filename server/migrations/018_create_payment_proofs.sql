@@ -0,0 +1,33 @@
+CREATE TABLE payment_proofs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    file_key VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    bank_name VARCHAR(100),
+    deposit_reference VARCHAR(100),
+    deposited_at TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    rejection_reason VARCHAR(255),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED'))
+);
+
+CREATE INDEX idx_payment_proofs_transaction_id ON payment_proofs(transaction_id);
+CREATE INDEX idx_payment_proofs_status ON payment_proofs(status, created_at);
+
+-- One slip under review per transaction at a time
+CREATE UNIQUE INDEX idx_payment_proofs_one_pending ON payment_proofs(transaction_id) WHERE status = 'PENDING';
+
+CREATE TRIGGER update_payment_proofs_updated_at
+    BEFORE UPDATE ON payment_proofs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO ledger_accounts (code, name, type) VALUES
+    ('BANK', 'Bank account', 'ASSET');
@@ -0,0 +1,65 @@
+CREATE TABLE disputes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    case_reference VARCHAR(100),
+    reason_code VARCHAR(50) NOT NULL,
+    description TEXT,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    evidence_due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    evidence_submitted_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    resolution_note TEXT,
+    reversal_id UUID REFERENCES refunds(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (status IN ('OPEN', 'EVIDENCE_SUBMITTED', 'WON', 'LOST'))
+);
+
+CREATE INDEX idx_disputes_transaction_id ON disputes(transaction_id);
+CREATE INDEX idx_disputes_status ON disputes(status, evidence_due_at);
+
+CREATE TRIGGER update_disputes_updated_at
+    BEFORE UPDATE ON disputes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE dispute_evidence (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_id UUID NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+    file_key VARCHAR(255) NOT NULL,
+    file_name VARCHAR(255),
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    description VARCHAR(255),
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_dispute_evidence_dispute_id ON dispute_evidence(dispute_id);
+
+CREATE TABLE dispute_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dispute_id UUID NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    message VARCHAR(255) NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (kind IN ('DEADLINE_APPROACHING', 'DEADLINE_PASSED')),
+    UNIQUE (dispute_id, kind)
+);
+
+CREATE INDEX idx_dispute_notifications_unread ON dispute_notifications(created_at) WHERE read_at IS NULL;
+
+-- Lost disputes are reversed as chargeback refunds
+ALTER TABLE refunds DROP CONSTRAINT refunds_method_check;
+ALTER TABLE refunds ADD CONSTRAINT refunds_method_check
+    CHECK (method IN ('STORE_CREDIT', 'ORIGINAL', 'CHARGEBACK'));
+
+INSERT INTO ledger_accounts (code, name, type) VALUES
+    ('CHARGEBACKS', 'Chargebacks lost', 'EXPENSE');
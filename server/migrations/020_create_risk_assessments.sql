@@ -0,0 +1,23 @@
+CREATE TABLE risk_assessments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    stage VARCHAR(20) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    ip_address VARCHAR(45),
+    device_id VARCHAR(100),
+    score INTEGER NOT NULL,
+    decision VARCHAR(10) NOT NULL,
+    signals JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (stage IN ('CREATE', 'VERIFY')),
+    CHECK (decision IN ('ALLOW', 'REVIEW', 'BLOCK'))
+);
+
+-- Velocity signals count recent attempts per user, IP and device
+CREATE INDEX idx_risk_assessments_user ON risk_assessments(user_id, created_at);
+CREATE INDEX idx_risk_assessments_ip ON risk_assessments(ip_address, created_at);
+CREATE INDEX idx_risk_assessments_device ON risk_assessments(device_id, created_at) WHERE device_id IS NOT NULL;
+CREATE INDEX idx_risk_assessments_decision ON risk_assessments(decision, created_at);
@@ -0,0 +1,29 @@
+CREATE TABLE payment_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    note VARCHAR(255),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    transaction_id UUID,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by UUID,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE SET NULL,
+    FOREIGN KEY (revoked_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_payment_links_order_id ON payment_links(order_id);
+CREATE INDEX idx_payment_links_status ON payment_links(status);
+
+CREATE TRIGGER update_payment_links_updated_at
+    BEFORE UPDATE ON payment_links
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,12 @@
+-- Human-readable references. Sequences never hand out a number twice, even
+-- across concurrent inserts; a rolled back insert leaves a gap, which is fine
+-- for references (unlike bill numbers, see document_sequences).
+CREATE SEQUENCE order_ref_seq;
+CREATE SEQUENCE transaction_ref_seq;
+
+-- Rows created before this migration have no reference
+ALTER TABLE orders ADD COLUMN ref VARCHAR(40);
+ALTER TABLE transactions ADD COLUMN ref VARCHAR(40);
+
+CREATE UNIQUE INDEX idx_orders_ref ON orders(ref);
+CREATE UNIQUE INDEX idx_transactions_ref ON transactions(ref);
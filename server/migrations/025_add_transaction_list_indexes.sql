@@ -0,0 +1,5 @@
+-- Keyset pagination of GET /transactions sorts on (column, id)
+DROP INDEX IF EXISTS idx_transactions_created_at;
+CREATE INDEX idx_transactions_created_at_id ON transactions(created_at, id);
+CREATE INDEX idx_transactions_amount_id ON transactions(amount, id);
+CREATE INDEX idx_transaction_tenders_method ON transaction_tenders(payment_method, transaction_id);
@@ -0,0 +1,30 @@
+CREATE TABLE export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    params JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    row_count INTEGER NOT NULL DEFAULT 0,
+    file_key VARCHAR(255),
+    file_name VARCHAR(255),
+    file_size BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    requested_by UUID NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (requested_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_export_jobs_status ON export_jobs(status, created_at);
+CREATE INDEX idx_export_jobs_expires_at ON export_jobs(expires_at) WHERE status = 'DONE';
+
+-- Order exports are read newest first
+CREATE INDEX idx_orders_created_at_id ON orders(created_at, id);
+
+CREATE TRIGGER update_export_jobs_updated_at
+    BEFORE UPDATE ON export_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- One issued invoice per transaction. Concurrent issues for the same payment
+-- conflict on this instead of taking two bill numbers.
+CREATE UNIQUE INDEX idx_invoices_transaction_issued ON invoices(transaction_id) WHERE status = 'ISSUED';
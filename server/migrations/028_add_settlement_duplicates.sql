@@ -0,0 +1,8 @@
+-- Statement rows for a transaction already matched on another row get a
+-- status of their own instead of being counted as missing locally
+ALTER TABLE settlement_imports
+    ADD COLUMN duplicates INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE settlement_items DROP CONSTRAINT settlement_items_status_check;
+ALTER TABLE settlement_items ADD CONSTRAINT settlement_items_status_check
+    CHECK (status IN ('MATCHED', 'MISSING_LOCALLY', 'MISSING_AT_GATEWAY', 'AMOUNT_MISMATCH', 'DUPLICATE', 'SKIPPED'));
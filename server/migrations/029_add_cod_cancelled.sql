@@ -0,0 +1,4 @@
+-- Open deliveries are closed when their transaction fails or is cancelled
+ALTER TABLE cod_deliveries DROP CONSTRAINT cod_deliveries_status_check;
+ALTER TABLE cod_deliveries ADD CONSTRAINT cod_deliveries_status_check
+    CHECK (status IN ('ASSIGNED', 'PARTIALLY_COLLECTED', 'COLLECTED', 'REFUSED', 'CANCELLED'));
@@ -0,0 +1,3 @@
+-- Invoice lines show their discount; amount is the line total net of it
+ALTER TABLE invoice_items
+    ADD COLUMN discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
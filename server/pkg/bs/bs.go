@@ -0,0 +1,196 @@
+// Package bs converts between the Gregorian (AD) calendar and the Nepali
+// Bikram Sambat (BS) calendar, and provides Nepali fiscal year helpers.
+//
+// BS month lengths are not computable and come from the published calendar
+// table below; conversion is supported for BS years MinYear to MaxYear.
+package bs
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	MinYear = 2070
+	MaxYear = 2090
+)
+
+// ErrOutOfRange is returned for dates outside the supported calendar table
+var ErrOutOfRange = errors.New("date outside supported Bikram Sambat range")
+
+// monthDays[y-MinYear][m-1] is the number of days in BS month m of year y
+var monthDays = [][12]int{
+	{31, 31, 31, 32, 31, 31, 29, 30, 30, 29, 30, 30}, // 2070
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30}, // 2071
+	{31, 32, 31, 32, 31, 30, 30, 29, 30, 29, 30, 30}, // 2072
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 31}, // 2073
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30}, // 2074
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30}, // 2075
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30}, // 2076
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 30, 29, 31}, // 2077
+	{31, 31, 31, 32, 31, 31, 30, 29, 30, 29, 30, 30}, // 2078
+	{31, 31, 32, 31, 31, 31, 30, 29, 30, 29, 30, 30}, // 2079
+	{31, 32, 31, 32, 31, 30, 30, 30, 29, 29, 30, 30}, // 2080
+	{31, 31, 32, 32, 31, 30, 30, 30, 29, 30, 30, 30}, // 2081
+	{31, 31, 32, 31, 31, 30, 30, 30, 29, 30, 30, 30}, // 2082
+	{31, 31, 32, 31, 31, 30, 30, 30, 29, 30, 30, 30}, // 2083
+	{31, 31, 32, 31, 31, 30, 30, 30, 29, 30, 30, 30}, // 2084
+	{31, 32, 31, 32, 30, 31, 30, 30, 29, 30, 30, 30}, // 2085
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 30, 30}, // 2086
+	{31, 31, 32, 31, 31, 30, 30, 30, 29, 30, 30, 30}, // 2087
+	{30, 31, 32, 32, 30, 31, 30, 30, 29, 30, 30, 30}, // 2088
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 30, 30}, // 2089
+	{30, 32, 31, 32, 31, 30, 30, 30, 29, 30, 30, 30}, // 2090
+}
+
+// MonthNames are the BS month names, Baisakh first
+var MonthNames = [12]string{
+	"Baisakh", "Jestha", "Ashadh", "Shrawan", "Bhadra", "Ashwin",
+	"Kartik", "Mangsir", "Poush", "Magh", "Falgun", "Chaitra",
+}
+
+// Shrawan is the first month of the Nepali fiscal year
+const Shrawan = 4
+
+// nepalTime is Nepal Standard Time (UTC+05:45)
+var nepalTime = time.FixedZone("NPT", 5*3600+45*60)
+
+// epoch is 1 Baisakh MinYear in AD
+var epoch = time.Date(2013, time.April, 14, 0, 0, 0, 0, nepalTime)
+
+// Location returns Nepal Standard Time, the zone BS dates are reckoned in
+func Location() *time.Location {
+	return nepalTime
+}
+
+// Date is a Bikram Sambat calendar date
+type Date struct {
+	Year  int
+	Month int // 1 = Baisakh ... 12 = Chaitra
+	Day   int
+}
+
+// String formats the date as YYYY-MM-DD
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// MonthName returns the BS month name, e.g. "Shrawan"
+func (d Date) MonthName() string {
+	if d.Month < 1 || d.Month > 12 {
+		return ""
+	}
+	return MonthNames[d.Month-1]
+}
+
+// DaysInMonth returns the number of days in a BS month
+func DaysInMonth(year, month int) (int, error) {
+	if year < MinYear || year > MaxYear || month < 1 || month > 12 {
+		return 0, ErrOutOfRange
+	}
+	return monthDays[year-MinYear][month-1], nil
+}
+
+// FromAD converts the Nepal-local calendar day of t to a BS date
+func FromAD(t time.Time) (Date, error) {
+	t = t.In(nepalTime)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, nepalTime)
+	if day.Before(epoch) {
+		return Date{}, ErrOutOfRange
+	}
+
+	// Days elapsed since the epoch; NPT has no DST, so every day is 24h
+	days := int(day.Sub(epoch).Hours() / 24)
+	for y := 0; y < len(monthDays); y++ {
+		for m := 0; m < 12; m++ {
+			if days < monthDays[y][m] {
+				return Date{Year: MinYear + y, Month: m + 1, Day: days + 1}, nil
+			}
+			days -= monthDays[y][m]
+		}
+	}
+	return Date{}, ErrOutOfRange
+}
+
+// ToAD returns midnight NPT at the start of the BS date
+func (d Date) ToAD() (time.Time, error) {
+	if d.Year < MinYear || d.Year > MaxYear || d.Month < 1 || d.Month > 12 ||
+		d.Day < 1 || d.Day > monthDays[d.Year-MinYear][d.Month-1] {
+		return time.Time{}, ErrOutOfRange
+	}
+
+	days := d.Day - 1
+	for y := MinYear; y < d.Year; y++ {
+		for _, n := range monthDays[y-MinYear] {
+			days += n
+		}
+	}
+	for m := 1; m < d.Month; m++ {
+		days += monthDays[d.Year-MinYear][m-1]
+	}
+	return epoch.AddDate(0, 0, days), nil
+}
+
+// Parse reads a BS date in YYYY-MM-DD form
+func Parse(s string) (Date, error) {
+	var d Date
+	if _, err := fmt.Sscanf(s, "%d-%d-%d", &d.Year, &d.Month, &d.Day); err != nil {
+		return Date{}, fmt.Errorf("invalid BS date %q: expected YYYY-MM-DD", s)
+	}
+	if _, err := d.ToAD(); err != nil {
+		return Date{}, err
+	}
+	return d, nil
+}
+
+// FiscalYear returns the fiscal year label containing d, e.g. "2082/83".
+// The Nepali fiscal year runs from 1 Shrawan to the end of Ashadh.
+func (d Date) FiscalYear() string {
+	start := d.Year
+	if d.Month < Shrawan {
+		start--
+	}
+	return FiscalYearLabel(start)
+}
+
+// FiscalYearLabel formats the fiscal year starting in BS year start, e.g. 2082 -> "2082/83"
+func FiscalYearLabel(start int) string {
+	return fmt.Sprintf("%d/%02d", start, (start+1)%100)
+}
+
+// FiscalYearOf returns the fiscal year label for an instant
+func FiscalYearOf(t time.Time) (string, error) {
+	d, err := FromAD(t)
+	if err != nil {
+		return "", err
+	}
+	return d.FiscalYear(), nil
+}
+
+// FiscalYearBounds returns the AD instants [start, end) of a fiscal year label
+func FiscalYearBounds(label string) (time.Time, time.Time, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(label, "%d/%d", &start, &end); err != nil || (start+1)%100 != end {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid fiscal year %q: expected e.g. 2082/83", label)
+	}
+	from, err := Date{Year: start, Month: Shrawan, Day: 1}.ToAD()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := Date{Year: start + 1, Month: Shrawan, Day: 1}.ToAD()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// MonthBounds returns the AD instants [start, end) of a BS month
+func MonthBounds(year, month int) (time.Time, time.Time, error) {
+	from, err := Date{Year: year, Month: month, Day: 1}.ToAD()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	days, _ := DaysInMonth(year, month)
+	return from, from.AddDate(0, 0, days), nil
+}
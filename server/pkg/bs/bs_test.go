@@ -0,0 +1,152 @@
+package bs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func npt(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, Location())
+}
+
+func TestAnchors(t *testing.T) {
+	tests := []struct {
+		bs Date
+		ad time.Time
+	}{
+		{Date{2070, 1, 1}, npt(2013, time.April, 14)},
+		{Date{2077, 1, 1}, npt(2020, time.April, 13)},
+		{Date{2080, 1, 1}, npt(2023, time.April, 14)},
+		{Date{2080, 4, 1}, npt(2023, time.July, 17)},
+		{Date{2081, 1, 1}, npt(2024, time.April, 13)},
+		{Date{2081, 4, 1}, npt(2024, time.July, 16)},
+		{Date{2082, 1, 1}, npt(2025, time.April, 14)},
+		{Date{2082, 3, 32}, npt(2025, time.July, 16)},
+		{Date{2082, 4, 1}, npt(2025, time.July, 17)},
+		{Date{2083, 1, 1}, npt(2026, time.April, 14)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bs.String(), func(t *testing.T) {
+			ad, err := tt.bs.ToAD()
+			if err != nil {
+				t.Fatalf("ToAD: %v", err)
+			}
+			if !ad.Equal(tt.ad) {
+				t.Errorf("ToAD = %s, want %s", ad.Format("2006-01-02"), tt.ad.Format("2006-01-02"))
+			}
+			bs, err := FromAD(tt.ad)
+			if err != nil {
+				t.Fatalf("FromAD: %v", err)
+			}
+			if bs != tt.bs {
+				t.Errorf("FromAD = %s, want %s", bs, tt.bs)
+			}
+		})
+	}
+}
+
+func TestFromADUsesNepalDay(t *testing.T) {
+	// 19:00 UTC on 16 July is already 17 July in Kathmandu
+	got, err := FromAD(time.Date(2025, time.July, 16, 19, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Date{2082, 4, 1}); got != want {
+		t.Errorf("FromAD = %s, want %s", got, want)
+	}
+}
+
+func TestYearLengths(t *testing.T) {
+	tests := []struct {
+		year int
+		days int
+	}{
+		{2073, 366},
+		{2077, 366},
+		{2079, 365},
+		{2080, 365},
+		{2081, 366},
+		{2082, 365},
+	}
+	for _, tt := range tests {
+		start, err := Date{tt.year, 1, 1}.ToAD()
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, err := Date{tt.year + 1, 1, 1}.ToAD()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if days := int(next.Sub(start).Hours() / 24); days != tt.days {
+			t.Errorf("%d has %d days, want %d", tt.year, days, tt.days)
+		}
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	if _, err := FromAD(npt(2013, time.April, 13)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("FromAD before 2070: err = %v, want ErrOutOfRange", err)
+	}
+	if _, err := FromAD(npt(2040, time.January, 1)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("FromAD after 2090: err = %v, want ErrOutOfRange", err)
+	}
+
+	for _, d := range []Date{
+		{MinYear - 1, 12, 30},
+		{MaxYear + 1, 1, 1},
+		{2082, 0, 1},
+		{2082, 13, 1},
+		{2082, 1, 0},
+		{2082, 9, 30}, // Poush 2082 has 29 days
+	} {
+		if _, err := d.ToAD(); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("%s.ToAD(): err = %v, want ErrOutOfRange", d, err)
+		}
+	}
+
+	if _, err := DaysInMonth(MaxYear+1, 1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("DaysInMonth(%d, 1): err = %v, want ErrOutOfRange", MaxYear+1, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse("2082-04-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Date{2082, 4, 1}); got != want {
+		t.Errorf("Parse = %s, want %s", got, want)
+	}
+	for _, s := range []string{"", "2082/04/01", "2082-04-33", "2095-01-01"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+}
+
+func TestFiscalYear(t *testing.T) {
+	if got := (Date{2082, 3, 32}).FiscalYear(); got != "2081/82" {
+		t.Errorf("last day of Ashadh 2082 is in %s, want 2081/82", got)
+	}
+	if got := (Date{2082, 4, 1}).FiscalYear(); got != "2082/83" {
+		t.Errorf("1 Shrawan 2082 is in %s, want 2082/83", got)
+	}
+	if got := FiscalYearLabel(2099); got != "2099/00" {
+		t.Errorf("FiscalYearLabel(2099) = %s, want 2099/00", got)
+	}
+
+	from, to, err := FiscalYearBounds("2082/83")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !from.Equal(npt(2025, time.July, 17)) {
+		t.Errorf("2082/83 starts %s, want 2025-07-17", from.Format("2006-01-02"))
+	}
+	if next, _ := (Date{2083, 4, 1}).ToAD(); !to.Equal(next) {
+		t.Errorf("2082/83 ends %s, want 1 Shrawan 2083 (%s)", to.Format("2006-01-02"), next.Format("2006-01-02"))
+	}
+	if _, _, err := FiscalYearBounds("2082/84"); err == nil {
+		t.Error("FiscalYearBounds(2082/84) succeeded")
+	}
+}
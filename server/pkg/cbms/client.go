@@ -0,0 +1,153 @@
+// Package cbms is a client for the IRD Central Billing Monitoring System (CBMS)
+// real-time sales bill and sales return API.
+package cbms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CBMS response codes
+const (
+	CodeSuccess           = 200
+	CodeInvalidCredential = 100
+	CodeAlreadyExists     = 101
+	CodeSaveException     = 102
+	CodeUnknownException  = 103
+	CodeInvalidModel      = 104
+)
+
+// Bill is a sales bill posted to /api/bill
+type Bill struct {
+	Username         string  `json:"username"`
+	Password         string  `json:"password"`
+	SellerPAN        string  `json:"seller_pan"`
+	BuyerPAN         string  `json:"buyer_pan"`
+	BuyerName        string  `json:"buyer_name"`
+	FiscalYear       string  `json:"fiscal_year"` // e.g. 2082.083
+	InvoiceNumber    string  `json:"invoice_number"`
+	InvoiceDate      string  `json:"invoice_date"` // BS, e.g. 2082.04.01
+	TotalSales       float64 `json:"total_sales"`
+	TaxableSalesVAT  float64 `json:"taxable_sales_vat"`
+	VAT              float64 `json:"vat"`
+	ExcisableAmount  float64 `json:"excisable_amount"`
+	Excise           float64 `json:"excise"`
+	TaxableSalesHST  float64 `json:"taxable_sales_hst"`
+	HST              float64 `json:"hst"`
+	AmountForESF     float64 `json:"amount_for_esf"`
+	ESF              float64 `json:"esf"`
+	ExportSales      float64 `json:"export_sales"`
+	TaxExemptedSales float64 `json:"tax_exempted_sales"`
+	IsRealtime       bool    `json:"isrealtime"`
+	DatetimeClient   string  `json:"datetimeClient"`
+}
+
+// BillReturn is a sales return (credit note) posted to /api/billreturn
+type BillReturn struct {
+	Bill
+	RefInvoiceNumber string `json:"ref_invoice_number"`
+	CreditNoteNumber string `json:"credit_note_number"`
+	CreditNoteDate   string `json:"credit_note_date"` // BS, e.g. 2082.04.01
+	ReasonForReturn  string `json:"reason_for_return"`
+}
+
+// ResponseError is a non-success CBMS response code
+type ResponseError struct {
+	Code int
+}
+
+func (e *ResponseError) Error() string {
+	switch e.Code {
+	case CodeInvalidCredential:
+		return "cbms: API credentials do not match"
+	case CodeSaveException:
+		return "cbms: exception while saving bill details"
+	case CodeUnknownException:
+		return "cbms: unknown exception"
+	case CodeInvalidModel:
+		return "cbms: model invalid"
+	default:
+		return fmt.Sprintf("cbms: unexpected response code %d", e.Code)
+	}
+}
+
+// Client posts bills to a CBMS endpoint
+type Client struct {
+	BaseURL  string
+	Username string
+	Password string
+	HTTP     *http.Client
+}
+
+func NewClient(baseURL, username, password string) *Client {
+	return &Client{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Username: username,
+		Password: password,
+		HTTP:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// PostBill reports a sales bill. A bill CBMS already has counts as success.
+func (c *Client) PostBill(ctx context.Context, bill Bill) error {
+	bill.Username, bill.Password = c.Username, c.Password
+	return c.post(ctx, "/api/bill", bill)
+}
+
+// PostBillReturn reports a sales return. A return CBMS already has counts as success.
+func (c *Client) PostBillReturn(ctx context.Context, ret BillReturn) error {
+	ret.Username, ret.Password = c.Username, c.Password
+	return c.post(ctx, "/api/billreturn", ret)
+}
+
+func (c *Client) post(ctx context.Context, path string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cbms: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	code, err := strconv.Atoi(strings.Trim(strings.TrimSpace(string(raw)), `"`))
+	if err != nil {
+		return fmt.Errorf("cbms: unreadable response %q", string(raw))
+	}
+	if code == CodeSuccess || code == CodeAlreadyExists {
+		return nil
+	}
+	return &ResponseError{Code: code}
+}
+
+// FiscalYear converts a fiscal year label like 2082/83 to the CBMS form 2082.083
+func FiscalYear(label string) string {
+	var start, end int
+	if _, err := fmt.Sscanf(label, "%d/%d", &start, &end); err != nil {
+		return label
+	}
+	return fmt.Sprintf("%d.%03d", start, (start+1)%1000)
+}
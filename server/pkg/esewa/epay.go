@@ -0,0 +1,225 @@
+package esewa
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// eSewa's public UAT merchant. Real credentials come from the merchant agreement.
+const (
+	UATFormURL     = "https://rc-epay.esewa.com.np/api/epay/main/v2/form"
+	UATStatusURL   = "https://rc.esewa.com.np/api/epay/transaction/status/"
+	UATSecretKey   = "8gBm/:&EnhH.1/q"
+	UATProductCode = "EPAYTEST"
+)
+
+// Paths of the ePay v2 endpoints, relative to the gateway's host
+const (
+	FormPath   = "/api/epay/main/v2/form"
+	StatusPath = "/api/epay/transaction/status/"
+)
+
+// Payment statuses reported in callbacks and by the status check
+const (
+	StatusComplete      = "COMPLETE"
+	StatusPending       = "PENDING"
+	StatusFullRefund    = "FULL_REFUND"
+	StatusPartialRefund = "PARTIAL_REFUND"
+	StatusAmbiguous     = "AMBIGUOUS"
+	StatusNotFound      = "NOT_FOUND"
+	StatusCanceled      = "CANCELED"
+)
+
+// requestSignedFields are the form fields eSewa expects the merchant to sign
+const requestSignedFields = "total_amount,transaction_uuid,product_code"
+
+// Sign returns the base64 HMAC-SHA256 of the named fields, written as
+// "name=value" pairs joined by commas in the order given
+func Sign(secret, signedFieldNames string, field func(name string) string) string {
+	var message []string
+	for _, name := range strings.Split(signedFieldNames, ",") {
+		name = strings.TrimSpace(name)
+		message = append(message, name+"="+field(name))
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(message, ",")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// FormatAmount writes an amount the way it is sent and signed, e.g. "110" or "110.5"
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// ValidTransactionUUID reports whether s can be sent as transaction_uuid,
+// which eSewa limits to letters, digits and hyphens
+func ValidTransactionUUID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// FormRequest is the payment form posted to eSewa. TotalAmount must equal
+// Amount + TaxAmount + ServiceCharge + DeliveryCharge.
+type FormRequest struct {
+	Amount          float64
+	TaxAmount       float64
+	ServiceCharge   float64
+	DeliveryCharge  float64
+	TotalAmount     float64
+	TransactionUUID string
+	ProductCode     string
+	SuccessURL      string
+	FailureURL      string
+}
+
+// Values returns the signed form fields
+func (r *FormRequest) Values(secret string) url.Values {
+	values := url.Values{
+		"amount":                  {FormatAmount(r.Amount)},
+		"tax_amount":              {FormatAmount(r.TaxAmount)},
+		"product_service_charge":  {FormatAmount(r.ServiceCharge)},
+		"product_delivery_charge": {FormatAmount(r.DeliveryCharge)},
+		"total_amount":            {FormatAmount(r.TotalAmount)},
+		"transaction_uuid":        {r.TransactionUUID},
+		"product_code":            {r.ProductCode},
+		"success_url":             {r.SuccessURL},
+		"failure_url":             {r.FailureURL},
+		"signed_field_names":      {requestSignedFields},
+	}
+	values.Set("signature", Sign(secret, requestSignedFields, values.Get))
+	return values
+}
+
+// VerifyForm checks the signature on posted form fields
+func VerifyForm(secret string, values url.Values) bool {
+	names := values.Get("signed_field_names")
+	if names == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, names, values.Get)), []byte(values.Get("signature")))
+}
+
+// Callback is the JSON eSewa sends back, base64 encoded in the "data" query
+// parameter of the success URL
+type Callback struct {
+	TransactionCode  string `json:"transaction_code"` // eSewa's reference for the payment
+	Status           string `json:"status"`
+	TotalAmount      string `json:"total_amount"`
+	TransactionUUID  string `json:"transaction_uuid"`
+	ProductCode      string `json:"product_code"`
+	SignedFieldNames string `json:"signed_field_names"`
+	Signature        string `json:"signature"`
+}
+
+// callbackSignedFields are the fields eSewa signs in a callback
+const callbackSignedFields = "transaction_code,status,total_amount,transaction_uuid,product_code,signed_field_names"
+
+func (c *Callback) field(name string) string {
+	switch name {
+	case "transaction_code":
+		return c.TransactionCode
+	case "status":
+		return c.Status
+	case "total_amount":
+		return c.TotalAmount
+	case "transaction_uuid":
+		return c.TransactionUUID
+	case "product_code":
+		return c.ProductCode
+	case "signed_field_names":
+		return c.SignedFieldNames
+	}
+	return ""
+}
+
+// Sign fills in the signature the way eSewa does
+func (c *Callback) Sign(secret string) {
+	c.SignedFieldNames = callbackSignedFields
+	c.Signature = Sign(secret, c.SignedFieldNames, c.field)
+}
+
+// Verify checks the callback was signed with secret, over at least the
+// fields eSewa always signs
+func (c *Callback) Verify(secret string) bool {
+	signed := map[string]bool{}
+	for _, name := range strings.Split(c.SignedFieldNames, ",") {
+		signed[name] = true
+	}
+	for _, name := range strings.Split(callbackSignedFields, ",") {
+		if !signed[name] {
+			return false
+		}
+	}
+	return hmac.Equal([]byte(Sign(secret, c.SignedFieldNames, c.field)), []byte(c.Signature))
+}
+
+// Encode returns the callback as it appears in the "data" query parameter
+func (c *Callback) Encode() string {
+	body, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(body)
+}
+
+// DecodeCallback reads the "data" query parameter of a success redirect
+func DecodeCallback(data string) (*Callback, error) {
+	body, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, errors.New("callback data is not base64")
+	}
+	var callback Callback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("callback data is not JSON: %v", err)
+	}
+	return &callback, nil
+}
+
+// StatusResponse is the answer to a status check
+type StatusResponse struct {
+	ProductCode     string  `json:"product_code"`
+	TransactionUUID string  `json:"transaction_uuid"`
+	TotalAmount     float64 `json:"total_amount"`
+	Status          string  `json:"status"`
+	RefID           *string `json:"ref_id"`
+}
+
+// CheckStatus asks eSewa for the state of a payment
+func CheckStatus(ctx context.Context, client *http.Client, statusURL, productCode, transactionUUID string, totalAmount float64) (*StatusResponse, error) {
+	query := url.Values{
+		"product_code":     {productCode},
+		"total_amount":     {FormatAmount(totalAmount)},
+		"transaction_uuid": {transactionUUID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status check returned %s", resp.Status)
+	}
+
+	var status StatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,402 @@
+// Package esewamock is a stand-in for the eSewa ePay v2 gateway, for local
+// development and tests that cannot reach eSewa's UAT.
+//
+// It serves the payment form, redirects back to the merchant with a signed
+// base64 "data" parameter the way eSewa does, and answers status checks. How
+// each payment ends is chosen per transaction_uuid with SetScenario, with
+// Default used otherwise; with no Default a page asks. Embed it in a test with
+// NewServer:
+//
+//	mock := esewamock.NewServer(esewa.UATSecretKey)
+//	defer mock.Close()
+//	mock.SetScenario(transactionUUID, esewamock.AmountMismatch)
+//	// point the form and status URLs at mock.FormURL() and mock.StatusURL()
+package esewamock
+
+import (
+	"bookstore/pkg/esewa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scenario decides how a payment ends
+type Scenario string
+
+const (
+	// Success completes the payment and redirects to the success URL
+	Success Scenario = "success"
+	// Failure cancels the payment and redirects to the failure URL
+	Failure Scenario = "failure"
+	// Pending redirects to the success URL with status PENDING and leaves
+	// the payment pending
+	Pending Scenario = "pending"
+	// Delayed redirects to the success URL with status PENDING at once and
+	// completes the payment after Delay, seen only through status checks
+	Delayed Scenario = "delayed"
+	// TamperedSignature completes the payment but sends a callback whose
+	// signature does not match
+	TamperedSignature Scenario = "tampered_signature"
+	// AmountMismatch completes the payment for less than was asked and
+	// signs the callback correctly
+	AmountMismatch Scenario = "amount_mismatch"
+)
+
+// Scenarios lists every scenario, in the order the chooser page shows them
+var Scenarios = []Scenario{Success, Failure, Pending, Delayed, TamperedSignature, AmountMismatch}
+
+// Valid reports whether s is one of the known scenarios
+func (s Scenario) Valid() bool {
+	for _, scenario := range Scenarios {
+		if s == scenario {
+			return true
+		}
+	}
+	return false
+}
+
+// Payment is what the mock remembers about one transaction_uuid
+type Payment struct {
+	TransactionUUID string    `json:"transaction_uuid"`
+	ProductCode     string    `json:"product_code"`
+	TotalAmount     float64   `json:"total_amount"` // Amount asked for
+	PaidAmount      float64   `json:"paid_amount"`  // Amount reported back
+	Status          string    `json:"status"`
+	RefID           string    `json:"ref_id,omitempty"`
+	Scenario        Scenario  `json:"scenario"`
+	CompleteAt      time.Time `json:"complete_at,omitempty"` // Delayed payments turn COMPLETE at this time
+}
+
+// Mock is an http.Handler serving the ePay v2 endpoints and a small control API:
+//
+//	GET|POST /api/epay/main/v2/form       payment form
+//	GET      /api/epay/transaction/status/ status check
+//	POST     /mock/scenarios              {"transaction_uuid": "...", "scenario": "failure"}
+//	GET      /mock/payments               payments seen so far
+type Mock struct {
+	SecretKey string
+	// Default is used for transactions without a scenario. When empty, a
+	// page asks which scenario to use, as eSewa's login page would ask for
+	// credentials.
+	Default Scenario
+	// Delay is how long a Delayed payment stays PENDING
+	Delay time.Duration
+	// Now is the clock; tests may replace it
+	Now func() time.Time
+
+	mu        sync.Mutex
+	scenarios map[string]Scenario
+	payments  map[string]*Payment
+	mux       *http.ServeMux
+}
+
+func New(secretKey string) *Mock {
+	m := &Mock{
+		SecretKey: secretKey,
+		Delay:     5 * time.Second,
+		Now:       time.Now,
+		scenarios: map[string]Scenario{},
+		payments:  map[string]*Payment{},
+		mux:       http.NewServeMux(),
+	}
+	m.mux.HandleFunc(esewa.FormPath, m.handleForm)
+	m.mux.HandleFunc(esewa.StatusPath, m.handleStatus)
+	m.mux.HandleFunc("/mock/scenarios", m.handleScenarios)
+	m.mux.HandleFunc("/mock/payments", m.handlePayments)
+	return m
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// SetScenario chooses how the payment with this transaction_uuid ends
+func (m *Mock) SetScenario(transactionUUID string, scenario Scenario) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scenarios[transactionUUID] = scenario
+}
+
+// Payment returns what the mock recorded for a transaction_uuid
+func (m *Mock) Payment(transactionUUID string) (Payment, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	payment, ok := m.payments[transactionUUID]
+	if !ok {
+		return Payment{}, false
+	}
+	return m.current(payment), true
+}
+
+// current applies a delayed payment's completion; m.mu must be held
+func (m *Mock) current(payment *Payment) Payment {
+	if payment.Scenario == Delayed && payment.Status == esewa.StatusPending && !m.Now().Before(payment.CompleteAt) {
+		payment.Status = esewa.StatusComplete
+	}
+	return *payment
+}
+
+func (m *Mock) handleForm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	form := r.Form
+
+	total, err := m.checkForm(form)
+	if err != nil {
+		log.Printf("esewa mock: rejected form: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	transactionUUID := form.Get("transaction_uuid")
+
+	scenario := Scenario(form.Get("mock_scenario"))
+	if !scenario.Valid() {
+		m.mu.Lock()
+		scenario = m.scenarios[transactionUUID]
+		m.mu.Unlock()
+	}
+	if scenario == "" {
+		scenario = m.Default
+	}
+	if scenario == "" {
+		renderChooser(w, form)
+		return
+	}
+
+	payment := m.pay(transactionUUID, form.Get("product_code"), total, scenario)
+	log.Printf("esewa mock: %s %s %s -> %s", transactionUUID, form.Get("total_amount"), scenario, payment.Status)
+
+	if scenario == Failure {
+		http.Redirect(w, r, form.Get("failure_url"), http.StatusFound)
+		return
+	}
+
+	callback := &esewa.Callback{
+		TransactionCode: payment.RefID,
+		Status:          payment.Status,
+		TotalAmount:     esewa.FormatAmount(payment.PaidAmount),
+		TransactionUUID: transactionUUID,
+		ProductCode:     payment.ProductCode,
+	}
+	callback.Sign(m.SecretKey)
+	if scenario == TamperedSignature {
+		callback.Signature = tamper(callback.Signature)
+	}
+
+	target, err := withQuery(form.Get("success_url"), "data", callback.Encode())
+	if err != nil {
+		http.Error(w, "invalid success_url", http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// checkForm validates the posted fields the way eSewa does and returns the total
+func (m *Mock) checkForm(form url.Values) (float64, error) {
+	for _, name := range []string{"amount", "tax_amount", "total_amount", "transaction_uuid", "product_code",
+		"product_service_charge", "product_delivery_charge", "success_url", "failure_url", "signed_field_names", "signature"} {
+		if form.Get(name) == "" {
+			return 0, fmt.Errorf("%s is required", name)
+		}
+	}
+	if !esewa.ValidTransactionUUID(form.Get("transaction_uuid")) {
+		return 0, fmt.Errorf("transaction_uuid may only contain letters, digits and hyphens")
+	}
+	if !esewa.VerifyForm(m.SecretKey, form) {
+		return 0, fmt.Errorf("invalid signature")
+	}
+
+	var parts [5]float64
+	for i, name := range []string{"amount", "tax_amount", "product_service_charge", "product_delivery_charge", "total_amount"} {
+		value, err := strconv.ParseFloat(form.Get(name), 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("%s is not a valid amount", name)
+		}
+		parts[i] = value
+	}
+	sum := parts[0] + parts[1] + parts[2] + parts[3]
+	if fmt.Sprintf("%.2f", sum) != fmt.Sprintf("%.2f", parts[4]) {
+		return 0, fmt.Errorf("total_amount %.2f does not match the sum of its parts %.2f", parts[4], sum)
+	}
+	return parts[4], nil
+}
+
+// pay records the outcome of a payment attempt
+func (m *Mock) pay(transactionUUID, productCode string, total float64, scenario Scenario) Payment {
+	payment := &Payment{
+		TransactionUUID: transactionUUID,
+		ProductCode:     productCode,
+		TotalAmount:     total,
+		PaidAmount:      total,
+		Status:          esewa.StatusComplete,
+		RefID:           newRefID(),
+		Scenario:        scenario,
+	}
+	switch scenario {
+	case Failure:
+		payment.Status = esewa.StatusCanceled
+		payment.RefID = ""
+	case Pending:
+		payment.Status = esewa.StatusPending
+	case Delayed:
+		payment.Status = esewa.StatusPending
+		payment.CompleteAt = m.Now().Add(m.Delay)
+	case AmountMismatch:
+		payment.PaidAmount = total / 2
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payments[transactionUUID] = payment
+	return *payment
+}
+
+func (m *Mock) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	transactionUUID := query.Get("transaction_uuid")
+	productCode := query.Get("product_code")
+	total, _ := strconv.ParseFloat(query.Get("total_amount"), 64)
+
+	response := esewa.StatusResponse{
+		ProductCode:     productCode,
+		TransactionUUID: transactionUUID,
+		TotalAmount:     total,
+		Status:          esewa.StatusNotFound,
+	}
+
+	m.mu.Lock()
+	if stored, ok := m.payments[transactionUUID]; ok && stored.ProductCode == productCode &&
+		fmt.Sprintf("%.2f", stored.TotalAmount) == fmt.Sprintf("%.2f", total) {
+		payment := m.current(stored)
+		response.Status = payment.Status
+		response.TotalAmount = payment.PaidAmount
+		if payment.Status == esewa.StatusComplete {
+			refID := payment.RefID
+			response.RefID = &refID
+		}
+	}
+	m.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (m *Mock) handleScenarios(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		TransactionUUID string   `json:"transaction_uuid"`
+		Scenario        Scenario `json:"scenario"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TransactionUUID == "" || !body.Scenario.Valid() {
+		http.Error(w, "transaction_uuid and a valid scenario are required", http.StatusBadRequest)
+		return
+	}
+	m.SetScenario(body.TransactionUUID, body.Scenario)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (m *Mock) handlePayments(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	payments := make([]Payment, 0, len(m.payments))
+	for _, payment := range m.payments {
+		payments = append(payments, m.current(payment))
+	}
+	m.mu.Unlock()
+	writeJSON(w, http.StatusOK, payments)
+}
+
+// Server is a Mock running on an httptest server
+type Server struct {
+	*Mock
+	HTTP *httptest.Server
+}
+
+// NewServer starts a mock on a local port. Close it when done.
+func NewServer(secretKey string) *Server {
+	mock := New(secretKey)
+	return &Server{Mock: mock, HTTP: httptest.NewServer(mock)}
+}
+
+func (s *Server) URL() string       { return s.HTTP.URL }
+func (s *Server) FormURL() string   { return s.HTTP.URL + esewa.FormPath }
+func (s *Server) StatusURL() string { return s.HTTP.URL + esewa.StatusPath }
+func (s *Server) Close()            { s.HTTP.Close() }
+
+var chooser = template.Must(template.New("chooser").Parse(`<!DOCTYPE html>
+<html><head><title>eSewa mock</title></head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 40px auto">
+<h2>eSewa mock payment</h2>
+<p>Rs. {{.Total}} for {{.UUID}}</p>
+<form method="post">
+{{range $name, $values := .Form}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">
+{{end}}{{end}}
+{{range .Scenarios}}<p><button type="submit" name="mock_scenario" value="{{.}}">{{.}}</button></p>
+{{end}}
+</form>
+</body></html>`))
+
+// renderChooser asks the developer which scenario to play out
+func renderChooser(w http.ResponseWriter, form url.Values) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	chooser.Execute(w, map[string]interface{}{
+		"Total":     form.Get("total_amount"),
+		"UUID":      form.Get("transaction_uuid"),
+		"Form":      form,
+		"Scenarios": Scenarios,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// withQuery adds a query parameter to a URL that may already have some
+func withQuery(raw, name, value string) (string, error) {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	query := target.Query()
+	query.Set(name, value)
+	target.RawQuery = query.Encode()
+	return target.String(), nil
+}
+
+// tamper changes a signature so it no longer verifies
+func tamper(signature string) string {
+	if strings.HasPrefix(signature, "A") {
+		return "B" + signature[1:]
+	}
+	return "A" + signature[1:]
+}
+
+// newRefID makes an eSewa style reference code such as 000AB12
+func newRefID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return strings.ToUpper("000" + hex.EncodeToString(b)[:4])
+}
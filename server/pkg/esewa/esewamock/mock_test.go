@@ -0,0 +1,208 @@
+package esewamock
+
+import (
+	"bookstore/pkg/esewa"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	successURL = "http://merchant.test/payment/success?order=1"
+	failureURL = "http://merchant.test/payment/failure"
+)
+
+// startPayment signs a payment form, posts it to the mock and returns where
+// the mock redirected the browser
+func startPayment(t *testing.T, server *Server, transactionUUID string) *url.URL {
+	t.Helper()
+	form := (&esewa.FormRequest{
+		Amount:          100,
+		TaxAmount:       13,
+		DeliveryCharge:  10,
+		TotalAmount:     123,
+		TransactionUUID: transactionUUID,
+		ProductCode:     esewa.UATProductCode,
+		SuccessURL:      successURL,
+		FailureURL:      failureURL,
+	}).Values(esewa.UATSecretKey)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.PostForm(server.FormURL(), form)
+	if err != nil {
+		t.Fatalf("post form: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("form answered %s, want a redirect", resp.Status)
+	}
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatalf("redirect location: %v", err)
+	}
+	return location
+}
+
+// callback decodes the data parameter of a success redirect
+func callback(t *testing.T, location *url.URL) *esewa.Callback {
+	t.Helper()
+	if !strings.HasPrefix(location.String(), "http://merchant.test/payment/success") {
+		t.Fatalf("redirected to %s, want the success URL", location)
+	}
+	if location.Query().Get("order") != "1" {
+		t.Errorf("success URL lost its query: %s", location)
+	}
+	cb, err := esewa.DecodeCallback(location.Query().Get("data"))
+	if err != nil {
+		t.Fatalf("decode callback: %v", err)
+	}
+	return cb
+}
+
+func checkStatus(t *testing.T, server *Server, transactionUUID string) *esewa.StatusResponse {
+	t.Helper()
+	status, err := esewa.CheckStatus(context.Background(), http.DefaultClient, server.StatusURL(),
+		esewa.UATProductCode, transactionUUID, 123)
+	if err != nil {
+		t.Fatalf("status check: %v", err)
+	}
+	return status
+}
+
+func TestMockScenarios(t *testing.T) {
+	tests := []struct {
+		scenario     Scenario
+		wantFailure  bool   // redirected to the failure URL
+		wantVerified bool   // callback signature checks out
+		wantCallback string // callback status
+		wantAmount   string // callback total_amount
+		wantStatus   string // status check answer
+	}{
+		{Success, false, true, esewa.StatusComplete, "123", esewa.StatusComplete},
+		{Failure, true, false, "", "", esewa.StatusCanceled},
+		{Pending, false, true, esewa.StatusPending, "123", esewa.StatusPending},
+		{Delayed, false, true, esewa.StatusPending, "123", esewa.StatusPending},
+		{TamperedSignature, false, false, esewa.StatusComplete, "123", esewa.StatusComplete},
+		{AmountMismatch, false, true, esewa.StatusComplete, "61.5", esewa.StatusComplete},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.scenario), func(t *testing.T) {
+			server := NewServer(esewa.UATSecretKey)
+			defer server.Close()
+			transactionUUID := "TX-" + strings.ReplaceAll(string(tt.scenario), "_", "-")
+			server.SetScenario(transactionUUID, tt.scenario)
+
+			location := startPayment(t, server, transactionUUID)
+			if tt.wantFailure {
+				if location.String() != failureURL {
+					t.Fatalf("redirected to %s, want %s", location, failureURL)
+				}
+			} else {
+				cb := callback(t, location)
+				if got := cb.Verify(esewa.UATSecretKey); got != tt.wantVerified {
+					t.Errorf("Verify() = %v, want %v", got, tt.wantVerified)
+				}
+				if cb.Status != tt.wantCallback {
+					t.Errorf("callback status = %s, want %s", cb.Status, tt.wantCallback)
+				}
+				if cb.TotalAmount != tt.wantAmount {
+					t.Errorf("callback total_amount = %s, want %s", cb.TotalAmount, tt.wantAmount)
+				}
+				if cb.TransactionUUID != transactionUUID || cb.ProductCode != esewa.UATProductCode {
+					t.Errorf("callback is for %s/%s", cb.TransactionUUID, cb.ProductCode)
+				}
+			}
+
+			status := checkStatus(t, server, transactionUUID)
+			if status.Status != tt.wantStatus {
+				t.Errorf("status check = %s, want %s", status.Status, tt.wantStatus)
+			}
+			if (status.RefID != nil) != (tt.wantStatus == esewa.StatusComplete) {
+				t.Errorf("status check ref_id = %v with status %s", status.RefID, status.Status)
+			}
+		})
+	}
+}
+
+func TestMockDelayedCompletesThroughStatusCheck(t *testing.T) {
+	server := NewServer(esewa.UATSecretKey)
+	defer server.Close()
+	now := time.Date(2025, 7, 17, 10, 0, 0, 0, time.UTC)
+	server.Now = func() time.Time { return now }
+	server.Delay = time.Minute
+	server.SetScenario("TX-delayed", Delayed)
+
+	// The redirect is not held back
+	if cb := callback(t, startPayment(t, server, "TX-delayed")); cb.Status != esewa.StatusPending {
+		t.Fatalf("callback status = %s, want %s", cb.Status, esewa.StatusPending)
+	}
+
+	now = now.Add(59 * time.Second)
+	if status := checkStatus(t, server, "TX-delayed"); status.Status != esewa.StatusPending {
+		t.Fatalf("before the delay: status = %s, want %s", status.Status, esewa.StatusPending)
+	}
+
+	now = now.Add(time.Second)
+	status := checkStatus(t, server, "TX-delayed")
+	if status.Status != esewa.StatusComplete || status.RefID == nil || *status.RefID == "" {
+		t.Fatalf("after the delay: status = %s, ref_id = %v, want COMPLETE with a ref_id", status.Status, status.RefID)
+	}
+	if status.TotalAmount != 123 {
+		t.Errorf("total_amount = %v, want 123", status.TotalAmount)
+	}
+}
+
+func TestMockChooserWithoutDefault(t *testing.T) {
+	server := NewServer(esewa.UATSecretKey)
+	defer server.Close()
+	if server.Default != "" {
+		t.Fatalf("Default = %q, want empty so the chooser is shown", server.Default)
+	}
+
+	form := (&esewa.FormRequest{
+		Amount:          100,
+		TotalAmount:     100,
+		TransactionUUID: "TX-chooser",
+		ProductCode:     esewa.UATProductCode,
+		SuccessURL:      successURL,
+		FailureURL:      failureURL,
+	}).Values(esewa.UATSecretKey)
+	resp, err := http.PostForm(server.FormURL(), form)
+	if err != nil {
+		t.Fatalf("post form: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("form answered %s %s, want the chooser page", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	if _, ok := server.Payment("TX-chooser"); ok {
+		t.Error("payment recorded before a scenario was chosen")
+	}
+}
+
+func TestMockRejectsBadSignature(t *testing.T) {
+	server := NewServer(esewa.UATSecretKey)
+	defer server.Close()
+
+	form := (&esewa.FormRequest{
+		Amount:          100,
+		TotalAmount:     100,
+		TransactionUUID: "TX-forged",
+		ProductCode:     esewa.UATProductCode,
+		SuccessURL:      successURL,
+		FailureURL:      failureURL,
+	}).Values("not-the-merchant-secret")
+	resp, err := http.PostForm(server.FormURL(), form)
+	if err != nil {
+		t.Fatalf("post form: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("form answered %s, want 400", resp.Status)
+	}
+}
@@ -0,0 +1,180 @@
+// Package esewa reads files exported from the eSewa merchant portal and
+// speaks the ePay v2 payment protocol.
+package esewa
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// StatementRow is one payment on an eSewa settlement statement
+type StatementRow struct {
+	Line            int       // 1-based line in the file
+	RefID           string    // eSewa reference ID, our Transaction.TransactionID
+	TransactionUUID string    // transaction_uuid we sent with the payment, if present
+	Amount          float64   // gross amount paid by the customer
+	Fee             float64   // eSewa commission kept from the amount
+	Status          string    // as printed, e.g. COMPLETE; empty if the file has no status column
+	Date            time.Time // zero if the file has no date column
+}
+
+// Successful reports whether the row is a completed payment
+func (r *StatementRow) Successful() bool {
+	switch strings.ToUpper(r.Status) {
+	case "", "COMPLETE", "COMPLETED", "SUCCESS", "SETTLED":
+		return true
+	}
+	return false
+}
+
+// Header names seen in portal exports, normalised to lower case letters and digits
+var columnAliases = map[string][]string{
+	"ref":    {"refid", "referenceid", "referencecode", "esewarefid", "transactioncode", "esewaid"},
+	"uuid":   {"transactionuuid", "productid", "pid", "merchanttransactionid"},
+	"amount": {"amount", "totalamount", "grossamount", "txnamount"},
+	"fee":    {"fee", "servicecharge", "commission", "esewacharge", "charge", "mdr"},
+	"net":    {"netamount", "settlementamount", "settledamount", "payableamount"},
+	"status": {"status", "transactionstatus"},
+	"date":   {"date", "transactiondate", "txndate", "datetime", "createddate", "settlementdate"},
+}
+
+var dateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"02/01/2006 15:04:05",
+	"02/01/2006",
+}
+
+// ParseStatement reads a settlement statement CSV. Columns are found by
+// header name; a reference ID and amount are required. Dates without a
+// zone are read in loc.
+func ParseStatement(r io.Reader, loc *time.Location) ([]StatementRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("statement is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := mapColumns(header)
+	if _, ok := columns["ref"]; !ok {
+		return nil, errors.New("statement has no reference ID column")
+	}
+	if _, ok := columns["amount"]; !ok {
+		return nil, errors.New("statement has no amount column")
+	}
+
+	var rows []StatementRow
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		// Portal exports end with a totals row that has no reference
+		row := StatementRow{Line: line, RefID: field("ref"), TransactionUUID: field("uuid"), Status: field("status")}
+		if row.RefID == "" {
+			continue
+		}
+		if row.Amount, err = parseAmount(field("amount")); err != nil {
+			return nil, fmt.Errorf("line %d: amount: %v", line, err)
+		}
+		if s := field("fee"); s != "" {
+			if row.Fee, err = parseAmount(s); err != nil {
+				return nil, fmt.Errorf("line %d: fee: %v", line, err)
+			}
+		} else if s := field("net"); s != "" {
+			net, err := parseAmount(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: net amount: %v", line, err)
+			}
+			row.Fee = row.Amount - net
+		}
+		if s := field("date"); s != "" {
+			if row.Date, err = parseDate(s, loc); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func mapColumns(header []string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range header {
+		key := normaliseHeader(name)
+		for column, aliases := range columnAliases {
+			if _, done := columns[column]; done {
+				continue
+			}
+			for _, alias := range aliases {
+				if key == alias {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	return columns
+}
+
+func normaliseHeader(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// parseAmount accepts amounts like "1,250.00" or "Rs. 1250"
+func parseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"NPR", "Rs.", "Rs"} {
+		s = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+	}
+	s = strings.ReplaceAll(s, ",", "")
+	if s == "" {
+		return 0, errors.New("missing value")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", s)
+}
@@ -0,0 +1,89 @@
+// Package limiter provides in-memory protection against repeated failed attempts.
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// FailureLimiter locks a key out after too many failures inside a window.
+// State is per process, so each server instance counts on its own.
+type FailureLimiter struct {
+	maxFailures int
+	window      time.Duration
+	lockout     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*failureEntry
+	now     func() time.Time
+}
+
+type failureEntry struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// NewFailureLimiter allows maxFailures failures per window before locking a key out for lockout
+func NewFailureLimiter(maxFailures int, window, lockout time.Duration) *FailureLimiter {
+	return &FailureLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		lockout:     lockout,
+		entries:     make(map[string]*failureEntry),
+		now:         time.Now,
+	}
+}
+
+// Allowed reports whether key may try again, and if not, how long until it may
+func (l *FailureLimiter) Allowed(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[key]
+	if !ok {
+		return true, 0
+	}
+	now := l.now()
+	if now.Before(entry.lockedUntil) {
+		return false, entry.lockedUntil.Sub(now)
+	}
+	return true, 0
+}
+
+// Fail records a failed attempt for key
+func (l *FailureLimiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.windowStart) > l.window {
+		entry = &failureEntry{windowStart: now}
+		l.entries[key] = entry
+	}
+	entry.failures++
+	if entry.failures >= l.maxFailures {
+		entry.lockedUntil = now.Add(l.lockout)
+		entry.failures = 0
+		entry.windowStart = now
+	}
+}
+
+// Reset clears the failures recorded for key
+func (l *FailureLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// prune drops entries that are neither locked nor inside their window
+func (l *FailureLimiter) prune(now time.Time) {
+	for key, entry := range l.entries {
+		if now.After(entry.lockedUntil) && now.Sub(entry.windowStart) > l.window {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -12,6 +12,21 @@ import (
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && isStreamRequest(c) {
+			// EventSource cannot set headers, so streams pass a short-lived
+			// ticket from POST /streams/ticket in the query instead
+			if ticket := c.Query("ticket"); ticket != "" {
+				claims, err := utils.ValidateStreamTicket(ticket)
+				if err != nil {
+					utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired stream ticket")
+					c.Abort()
+					return
+				}
+				setClaims(c, claims)
+				c.Next()
+				return
+			}
+		}
 		if authHeader == "" {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Authorization header required")
 			c.Abort()
@@ -32,18 +47,31 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// ✅ store user info in context
-		if userID, ok := claims["user_id"].(string); ok {
-			c.Set("user_id", userID)
-		}
-		if role, ok := claims["role"].(string); ok {
-			c.Set("role", role)
-		}
-
+		setClaims(c, claims)
 		c.Next()
 	}
 }
 
+// setClaims stores the user info from a token in the context
+func setClaims(c *gin.Context, claims map[string]interface{}) {
+	if userID, ok := claims["user_id"].(string); ok {
+		c.Set("user_id", userID)
+	}
+	if role, ok := claims["role"].(string); ok {
+		c.Set("role", role)
+	}
+}
+
+// isStreamRequest reports whether the request opens one of the
+// Server-Sent Events routes: .../stream or /streams/...
+func isStreamRequest(c *gin.Context) bool {
+	if c.Request.Method != http.MethodGet || !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		return false
+	}
+	route := c.FullPath()
+	return strings.HasSuffix(route, "/stream") || strings.Contains(route, "/streams/")
+}
+
 // RequireRole restricts access to specific roles
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,67 @@
+// Package paylink signs and checks the tokens in shareable payment links.
+//
+// A token is "<payload>.<signature>", both base64url without padding. The
+// payload is the link's 16-byte ID followed by its expiry as big-endian Unix
+// seconds; the signature is HMAC-SHA256 over the payload. A token that checks
+// out only proves the link was issued and has not expired: whether it has
+// been revoked or paid is kept with the link.
+package paylink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalid = errors.New("invalid payment link")
+	ErrExpired = errors.New("payment link has expired")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Sign returns the token for a link that expires at expiresAt
+func Sign(secret string, id uuid.UUID, expiresAt time.Time) string {
+	payload := make([]byte, 24)
+	copy(payload, id[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(sign(secret, payload))
+}
+
+// Parse checks a token's signature and expiry and returns the link ID
+func Parse(secret, token string, now time.Time) (uuid.UUID, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalid
+	}
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != 24 {
+		return uuid.Nil, ErrInvalid
+	}
+	signature, err := encoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, sign(secret, payload)) {
+		return uuid.Nil, ErrInvalid
+	}
+
+	id, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, ErrInvalid
+	}
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	if !now.Before(expiresAt) {
+		return id, ErrExpired
+	}
+	return id, nil
+}
+
+func sign(secret string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
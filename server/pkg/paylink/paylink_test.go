@@ -0,0 +1,87 @@
+package paylink
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParse(t *testing.T) {
+	id := uuid.New()
+	now := time.Date(2025, time.July, 17, 10, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(72 * time.Hour)
+	token := Sign("link-secret", id, expiresAt)
+
+	got, err := Parse("link-secret", token, now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got != id {
+		t.Errorf("Parse = %s, want %s", got, id)
+	}
+	if strings.ContainsAny(token, "+/=") {
+		t.Errorf("token %q is not URL safe", token)
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	id := uuid.New()
+	expiresAt := time.Date(2025, time.July, 20, 10, 0, 0, 0, time.UTC)
+	token := Sign("link-secret", id, expiresAt)
+
+	if _, err := Parse("link-secret", token, expiresAt.Add(-time.Second)); err != nil {
+		t.Errorf("a second before expiry: %v", err)
+	}
+	for _, now := range []time.Time{expiresAt, expiresAt.Add(time.Hour)} {
+		got, err := Parse("link-secret", token, now)
+		if !errors.Is(err, ErrExpired) {
+			t.Errorf("at %s: err = %v, want ErrExpired", now, err)
+		}
+		// The ID still comes back so the caller can say which link expired
+		if got != id {
+			t.Errorf("at %s: id = %s, want %s", now, got, id)
+		}
+	}
+}
+
+func TestParseTampered(t *testing.T) {
+	id := uuid.New()
+	now := time.Date(2025, time.July, 17, 10, 0, 0, 0, time.UTC)
+	token := Sign("link-secret", id, now.Add(time.Hour))
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// Same signature over a payload with the expiry pushed back a year
+	raw, _ := encoding.DecodeString(payload)
+	binary.BigEndian.PutUint64(raw[16:], uint64(now.AddDate(1, 0, 0).Unix()))
+	extended := encoding.EncodeToString(raw) + "." + signature
+
+	// Same signature over another link's ID
+	other := uuid.New()
+	copy(raw, other[:])
+	swapped := encoding.EncodeToString(raw) + "." + signature
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+	}{
+		{"wrong secret", "other-secret", token},
+		{"expiry extended", "link-secret", extended},
+		{"ID swapped", "link-secret", swapped},
+		{"signature changed", "link-secret", payload + "." + encoding.EncodeToString(make([]byte, 32))},
+		{"no signature", "link-secret", payload},
+		{"empty signature", "link-secret", payload + "."},
+		{"short payload", "link-secret", encoding.EncodeToString(raw[:16]) + "." + signature},
+		{"not base64", "link-secret", "!!!." + signature},
+		{"empty", "link-secret", ""},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.secret, tt.token, now); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package pdf writes simple text-only PDF documents (A4, Courier)
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	PageWidth  = 595.0 // A4 width in points
+	PageHeight = 842.0 // A4 height in points
+)
+
+type textItem struct {
+	x, y float64
+	size float64
+	bold bool
+	text string
+}
+
+// Document is an in-memory PDF made of pages of positioned text
+type Document struct {
+	pages [][]textItem
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage starts a new page; later Text calls draw on it
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, nil)
+}
+
+// Text draws s at (x, y) points from the top-left corner of the current page
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	last := len(d.pages) - 1
+	d.pages[last] = append(d.pages[last], textItem{x: x, y: y, size: size, bold: bold, text: s})
+}
+
+// WriteTo renders the document
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Object layout: 1 catalog, 2 pages, 3 regular font, 4 bold font,
+	// then a page and a content stream object per page.
+	buf.WriteString("%PDF-1.4\n")
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, items := range d.pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 6+2*i))
+
+		var content bytes.Buffer
+		for _, item := range items {
+			font := "F1"
+			if item.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+				font, item.size, item.x, PageHeight-item.y, escape(item.text))
+		}
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// escape quotes PDF string delimiters and replaces characters outside Latin-1
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r > 255:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+// Package refno formats the human-readable reference numbers given to orders
+// and transactions, e.g. BK-2082-000457.
+//
+// A pattern is plain text with placeholders:
+//
+//	{prefix}   the scheme's prefix, e.g. BK
+//	{year}     the Bikram Sambat year the record was created in (Nepal time)
+//	{ad_year}  the Gregorian year
+//	{seq}      the sequence number, zero-padded to the scheme's width
+//
+// The sequence alone keeps references unique, so every pattern must use {seq}.
+package refno
+
+import (
+	"bookstore/pkg/bs"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPattern gives references like BK-2082-000457
+const DefaultPattern = "{prefix}-{year}-{seq}"
+
+// MaxLength is the longest reference the database columns hold
+const MaxLength = 40
+
+var placeholder = regexp.MustCompile(`\{[a-z_]*\}`)
+
+// Scheme is how one kind of record is numbered
+type Scheme struct {
+	Prefix  string
+	Pattern string
+	Width   int // minimum digits in {seq}
+}
+
+// Validate checks the pattern only uses known placeholders and includes {seq}
+func (s Scheme) Validate() error {
+	if !strings.Contains(s.Pattern, "{seq}") {
+		return errors.New("reference pattern must include {seq}")
+	}
+	for _, name := range placeholder.FindAllString(s.Pattern, -1) {
+		switch name {
+		case "{prefix}", "{year}", "{ad_year}", "{seq}":
+		default:
+			return fmt.Errorf("unknown placeholder %s in reference pattern", name)
+		}
+	}
+	if s.Width < 0 || s.Width > 18 {
+		return errors.New("reference width must be between 0 and 18")
+	}
+	if len(s.Format(time.Now(), math.MaxInt64)) > MaxLength {
+		return fmt.Errorf("references could be longer than %d characters", MaxLength)
+	}
+	return nil
+}
+
+// Format returns the reference for sequence number seq of a record created at t.
+// Outside the supported BS calendar range {year} falls back to the AD year.
+func (s Scheme) Format(t time.Time, seq int64) string {
+	year := t.In(bs.Location()).Year()
+	if date, err := bs.FromAD(t); err == nil {
+		year = date.Year
+	}
+	number := strconv.FormatInt(seq, 10)
+	if pad := s.Width - len(number); pad > 0 {
+		number = strings.Repeat("0", pad) + number
+	}
+	return strings.NewReplacer(
+		"{prefix}", s.Prefix,
+		"{year}", strconv.Itoa(year),
+		"{ad_year}", strconv.Itoa(t.In(bs.Location()).Year()),
+		"{seq}", number,
+	).Replace(s.Pattern)
+}
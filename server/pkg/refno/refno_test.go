@@ -0,0 +1,69 @@
+package refno
+
+import (
+	"bookstore/pkg/bs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	// 17 July 2025 in Kathmandu is 1 Shrawan 2082
+	july := time.Date(2025, time.July, 17, 10, 0, 0, 0, bs.Location())
+	// 19:00 UTC on 31 December is already 1 January in Kathmandu
+	newYear := time.Date(2025, time.December, 31, 19, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		scheme Scheme
+		at     time.Time
+		seq    int64
+		want   string
+	}{
+		{"default pattern", Scheme{Prefix: "BK", Pattern: DefaultPattern, Width: 6}, july, 457, "BK-2082-000457"},
+		{"sequence wider than width", Scheme{Prefix: "TX", Pattern: DefaultPattern, Width: 3}, july, 123456, "TX-2082-123456"},
+		{"no padding", Scheme{Prefix: "TX", Pattern: DefaultPattern}, july, 7, "TX-2082-7"},
+		{"AD year", Scheme{Prefix: "BK", Pattern: "{prefix}/{ad_year}/{seq}", Width: 4}, july, 12, "BK/2025/0012"},
+		{"both years", Scheme{Prefix: "BK", Pattern: "{year}-{ad_year}-{seq}", Width: 2}, july, 1, "2082-2025-01"},
+		{"AD year in Nepal time", Scheme{Pattern: "{ad_year}-{seq}"}, newYear, 1, "2026-1"},
+		{"sequence only", Scheme{Pattern: "{seq}", Width: 5}, july, 42, "00042"},
+		{"before the BS table", Scheme{Prefix: "BK", Pattern: DefaultPattern, Width: 2}, time.Date(2010, time.June, 1, 0, 0, 0, 0, bs.Location()), 5, "BK-2010-05"},
+		{"after the BS table", Scheme{Prefix: "BK", Pattern: DefaultPattern, Width: 2}, time.Date(2040, time.June, 1, 0, 0, 0, 0, bs.Location()), 5, "BK-2040-05"},
+	}
+	for _, tt := range tests {
+		if got := tt.scheme.Format(tt.at, tt.seq); got != tt.want {
+			t.Errorf("%s: Format = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []Scheme{
+		{Prefix: "BK", Pattern: DefaultPattern, Width: 6},
+		{Prefix: "TX", Pattern: "{prefix}{ad_year}{seq}", Width: 0},
+		{Pattern: "{seq}", Width: 18},
+	}
+	for _, scheme := range valid {
+		if err := scheme.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", scheme, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		scheme Scheme
+	}{
+		{"no sequence", Scheme{Prefix: "BK", Pattern: "{prefix}-{year}", Width: 6}},
+		{"unknown placeholder", Scheme{Prefix: "BK", Pattern: "{prefix}-{month}-{seq}", Width: 6}},
+		{"empty placeholder", Scheme{Prefix: "BK", Pattern: "{prefix}-{}-{seq}", Width: 6}},
+		{"negative width", Scheme{Prefix: "BK", Pattern: DefaultPattern, Width: -1}},
+		{"width too large", Scheme{Prefix: "BK", Pattern: DefaultPattern, Width: 19}},
+		{"too long", Scheme{Prefix: strings.Repeat("B", 20), Pattern: DefaultPattern, Width: 6}},
+		{"empty pattern", Scheme{Prefix: "BK"}},
+	}
+	for _, tt := range tests {
+		if err := tt.scheme.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted %+v", tt.name, tt.scheme)
+		}
+	}
+}
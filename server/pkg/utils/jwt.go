@@ -20,6 +20,28 @@ func GenerateJWT(userID string, role string) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// StreamTicketTTL is how long a stream ticket can be used to open a stream
+const StreamTicketTTL = time.Minute
+
+// streamScope marks a token that may only open a status stream
+const streamScope = "stream"
+
+// GenerateStreamTicket issues a short-lived token that only opens Server-Sent
+// Events streams. EventSource cannot send headers, so it goes in the query
+// string, where it must not be worth stealing.
+func GenerateStreamTicket(userID string, role string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(StreamTicketTTL)
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"role":    role,
+		"scope":   streamScope,
+		"exp":     expiresAt.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(jwtSecret)
+	return signed, expiresAt, err
+}
+
 func getEnv(key, fallback string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		return val
@@ -27,10 +49,34 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// ValidateJWT checks a login token. Stream tickets are refused.
 func ValidateJWT(tokenString string) (map[string]interface{}, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if _, scoped := claims["scope"]; scoped {
+		return nil, errors.New("token cannot be used here")
+	}
+	return claims, nil
+}
+
+// ValidateStreamTicket checks a ticket from GenerateStreamTicket
+func ValidateStreamTicket(ticket string) (map[string]interface{}, error) {
+	claims, err := parseToken(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if claims["scope"] != streamScope {
+		return nil, errors.New("not a stream ticket")
+	}
+	return claims, nil
+}
+
+func parseToken(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return jwtSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil || !token.Valid {
 		return nil, err
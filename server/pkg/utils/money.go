@@ -0,0 +1,8 @@
+package utils
+
+import "math"
+
+// RoundMoney rounds an amount to the nearest paisa (2 decimal places)
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
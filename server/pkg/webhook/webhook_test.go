@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"payment.succeeded"}`)
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1752710400." + string(body)))
+	want := "v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("whsec_test", 1752710400, body); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now().Unix()
+	signature := Sign("whsec_test", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		signature string
+		tolerance time.Duration
+		want      bool
+	}{
+		{"valid", "whsec_test", now, body, signature, 5 * time.Minute, true},
+		{"wrong secret", "whsec_other", now, body, signature, 5 * time.Minute, false},
+		{"tampered body", "whsec_test", now, []byte(`{"id":"2"}`), signature, 5 * time.Minute, false},
+		{"timestamp changed", "whsec_test", now + 1, body, signature, 5 * time.Minute, false},
+		{"missing signature", "whsec_test", now, body, "", 5 * time.Minute, false},
+		{"too old", "whsec_test", now - 600, body, Sign("whsec_test", now-600, body), 5 * time.Minute, false},
+		{"too far ahead", "whsec_test", now + 600, body, Sign("whsec_test", now+600, body), 5 * time.Minute, false},
+		{"no tolerance accepts any age", "whsec_test", now - 600, body, Sign("whsec_test", now-600, body), 0, true},
+	}
+	for _, tt := range tests {
+		if got := Verify(tt.secret, tt.timestamp, tt.body, tt.signature, tt.tolerance); got != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSecret()
+	if !strings.HasPrefix(a, "whsec_") || len(a) != len("whsec_")+64 {
+		t.Errorf("NewSecret = %q, want whsec_ and 64 hex digits", a)
+	}
+	if a == b {
+		t.Error("NewSecret returned the same secret twice")
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	var verified bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified = Verify("whsec_test", timestamp, got, r.Header.Get(HeaderSignature), time.Minute) &&
+			r.Header.Get(HeaderEvent) == "payment.succeeded" && r.Header.Get(HeaderDelivery) == "d-1"
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	result, err := NewClient(time.Second).Send(context.Background(), receiver.URL, "whsec_test", "payment.succeeded", "d-1", body)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !verified {
+		t.Error("receiver could not verify the request")
+	}
+	if result.StatusCode != http.StatusOK || result.Body != "ok" {
+		t.Errorf("result = %d %q, want 200 ok", result.StatusCode, result.Body)
+	}
+}
+
+func TestClientSendRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}, http.StatusInternalServerError},
+		{"redirect is not followed", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://elsewhere.test/hook", http.StatusTemporaryRedirect)
+		}, http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		receiver := httptest.NewServer(tt.handler)
+		result, err := NewClient(time.Second).Send(context.Background(), receiver.URL, "whsec_test", "payment.succeeded", "d-1", []byte("{}"))
+		receiver.Close()
+		if err == nil {
+			t.Errorf("%s: Send succeeded", tt.name)
+			continue
+		}
+		if result == nil || result.StatusCode != tt.status {
+			t.Errorf("%s: result = %+v, want status %d", tt.name, result, tt.status)
+		}
+	}
+}
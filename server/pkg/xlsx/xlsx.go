@@ -0,0 +1,160 @@
+// Package xlsx writes single-sheet Office Open XML spreadsheets a row at a
+// time, so a large export never has to be held in memory. Cells are text or
+// numbers; there are no styles, formulas or shared strings.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the files Writer produces
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// MaxRows is the most rows a worksheet can hold
+const MaxRows = 1048576
+
+var ErrTooManyRows = errors.New("xlsx: worksheet is full")
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+const workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetEnd = `</sheetData></worksheet>`
+
+// Writer streams rows into the only worksheet of a workbook
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+// NewWriter writes the workbook parts that come before the rows. The sheet
+// name is cut to the 31 characters spreadsheet programs allow.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, escape(sheetTitle(sheetName)))},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	// The worksheet is the last part, so it can stay open while rows arrive
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow adds a row. Numbers (int, int64, float64) become numeric cells;
+// anything else is written as text. A nil cell is left empty.
+func (w *Writer) WriteRow(cells []interface{}) error {
+	if w.rows >= MaxRows {
+		return ErrTooManyRows
+	}
+	w.rows++
+
+	var b strings.Builder
+	b.WriteString("<row>")
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case nil:
+			b.WriteString("<c/>")
+		case int:
+			b.WriteString(`<c><v>` + strconv.Itoa(v) + `</v></c>`)
+		case int64:
+			b.WriteString(`<c><v>` + strconv.FormatInt(v, 10) + `</v></c>`)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				b.WriteString("<c/>")
+				continue
+			}
+			b.WriteString(`<c><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+		default:
+			b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escape(fmt.Sprint(v)) + `</t></is></c>`)
+		}
+	}
+	b.WriteString("</row>")
+	_, err := w.sheet.WriteString(b.String())
+	return err
+}
+
+// Close finishes the worksheet and the zip archive. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// escape makes s safe as XML text. Characters XML cannot hold at all become U+FFFD.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// sheetTitle drops the characters sheet names may not contain
+func sheetTitle(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
@@ -0,0 +1,145 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// readPart returns one file of the workbook archive
+func readPart(t *testing.T, file []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	body, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(body)
+}
+
+type sheet struct {
+	Rows []struct {
+		Cells []struct {
+			Type  string `xml:"t,attr"`
+			Value string `xml:"v"`
+			Text  string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "transactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]interface{}{
+		{"ref", "amount", "count", "note"},
+		{"TX-2082-000001", 1556.75, 3, `<b>"Ram" & Sita</b>`},
+		{"  leading space", math.NaN(), int64(7), nil},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/workbook.xml"} {
+		readPart(t, file, part)
+	}
+	if book := readPart(t, file, "xl/workbook.xml"); !strings.Contains(book, `name="transactions"`) {
+		t.Errorf("workbook does not name the sheet: %s", book)
+	}
+
+	var got sheet
+	if err := xml.Unmarshal([]byte(readPart(t, file, "xl/worksheets/sheet1.xml")), &got); err != nil {
+		t.Fatalf("worksheet is not valid XML: %v", err)
+	}
+	if len(got.Rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(got.Rows))
+	}
+
+	type cell struct{ typ, value string }
+	want := [][]cell{
+		{{"inlineStr", "ref"}, {"inlineStr", "amount"}, {"inlineStr", "count"}, {"inlineStr", "note"}},
+		{{"inlineStr", "TX-2082-000001"}, {"", "1556.75"}, {"", "3"}, {"inlineStr", `<b>"Ram" & Sita</b>`}},
+		{{"inlineStr", "  leading space"}, {"", ""}, {"", "7"}, {"", ""}},
+	}
+	for i, row := range want {
+		if len(got.Rows[i].Cells) != len(row) {
+			t.Fatalf("row %d has %d cells, want %d", i, len(got.Rows[i].Cells), len(row))
+		}
+		for j, c := range row {
+			gotCell := got.Rows[i].Cells[j]
+			value := gotCell.Value
+			if gotCell.Type == "inlineStr" {
+				value = gotCell.Text
+			}
+			if gotCell.Type != c.typ || value != c.value {
+				t.Errorf("row %d cell %d = %s %q, want %s %q", i, j, gotCell.Type, value, c.typ, c.value)
+			}
+		}
+	}
+}
+
+func TestWriterInvalidXMLCharacters(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, "orders")
+	if err := w.WriteRow([]interface{}{"bad\x00byte"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got sheet
+	if err := xml.Unmarshal([]byte(readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")), &got); err != nil {
+		t.Fatalf("worksheet is not valid XML: %v", err)
+	}
+	if text := got.Rows[0].Cells[0].Text; text != "bad\uFFFDbyte" {
+		t.Errorf("cell = %q, want the NUL replaced", text)
+	}
+}
+
+func TestWriterTooManyRows(t *testing.T) {
+	w, _ := NewWriter(io.Discard, "orders")
+	w.rows = MaxRows - 1
+	if err := w.WriteRow([]interface{}{1}); err != nil {
+		t.Fatalf("last row: %v", err)
+	}
+	if err := w.WriteRow([]interface{}{1}); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("row past the limit: err = %v, want ErrTooManyRows", err)
+	}
+}
+
+func TestSheetTitle(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"transactions", "transactions"},
+		{"2082/83 [Q1]: sales?", "208283 Q1 sales"},
+		{strings.Repeat("a", 40), strings.Repeat("a", 31)},
+		{strings.Repeat("क", 40), strings.Repeat("क", 31)},
+		{"/*?", "Sheet1"},
+		{"", "Sheet1"},
+	}
+	for _, tt := range tests {
+		if got := sheetTitle(tt.name); got != tt.want {
+			t.Errorf("sheetTitle(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}